@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/backtest"
+	"github.com/binance_cyan/indicators/internal/config"
+	"github.com/binance_cyan/indicators/internal/database"
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+func main() {
+	var (
+		configPath     = flag.String("config", "configs/config.yaml", "配置文件路径（用于Binance和MySQL）")
+		csvPath        = flag.String("csv", "", "K线CSV文件（time,open,high,low,close[,volume]），为空时从Binance拉取")
+		symbol         = flag.String("symbol", "BTCUSDT", "交易对")
+		interval       = flag.String("interval", "1h", "K线周期")
+		start          = flag.String("start", "", "开始时间（2006-01-02 或 RFC3339），从Binance拉取时必填")
+		end            = flag.String("end", "", "结束时间（默认当前时间）")
+		indicatorsPath = flag.String("indicators", "", "指标配置JSON文件（默认从数据库读取该symbol的配置，再回退到默认配置）")
+		strategyName   = flag.String("strategy", "cci_cross", "策略名称")
+		strategyParams = flag.String("params", "", "策略参数，如 key=48,level=100")
+		capital        = flag.Float64("capital", 10000, "初始资金")
+		fee            = flag.Float64("fee", 0.001, "手续费率")
+		slippage       = flag.Float64("slippage", 0, "滑点（基点）")
+		closeAtEnd     = flag.Bool("close-at-end", true, "回测结束时平掉剩余仓位")
+		outDir         = flag.String("out", "backtest_output", "输出目录")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "用法: backtest [选项]\n可用策略: %s\n", strings.Join(backtest.StrategyNames(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	*symbol = strings.ToUpper(*symbol)
+
+	params, err := backtest.ParseStrategyParams(*strategyParams)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	strategy, err := backtest.NewStrategy(*strategyName, params)
+	if err != nil {
+		log.Fatalf("创建策略失败: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Printf("加载配置失败（将不使用数据库和API密钥）: %v", err)
+	}
+
+	indicatorConfig, err := loadIndicatorConfig(cfg, *indicatorsPath, types.Symbol(*symbol))
+	if err != nil {
+		log.Fatalf("加载指标配置失败: %v", err)
+	}
+
+	klines, err := loadKlines(cfg, *csvPath, *symbol, *interval, *start, *end)
+	if err != nil {
+		log.Fatalf("加载K线失败: %v", err)
+	}
+	log.Printf("已加载 %d 根K线", len(klines))
+
+	report, err := backtest.Run(backtest.Config{
+		Symbol:         *symbol,
+		Interval:       *interval,
+		Indicators:     indicatorConfig,
+		InitialCapital: *capital,
+		FeeRate:        *fee,
+		SlippageBps:    *slippage,
+		CloseAtEnd:     *closeAtEnd,
+	}, klines, strategy)
+	if err != nil {
+		log.Fatalf("回测失败: %v", err)
+	}
+
+	if err := report.WriteFiles(*outDir); err != nil {
+		log.Fatalf("写入回测报告失败: %v", err)
+	}
+
+	log.Printf("策略 %s  %s@%s  %s ~ %s", report.Strategy, report.Symbol, report.Interval,
+		report.Start.UTC().Format("2006-01-02 15:04"), report.End.UTC().Format("2006-01-02 15:04"))
+	log.Printf("最终权益: %.2f  总收益: %.2f%%  最大回撤: %.2f%%  夏普: %.2f",
+		report.FinalEquity, report.TotalReturn*100, report.MaxDrawdown*100, report.SharpeRatio)
+	log.Printf("交易次数: %d  胜率: %.2f%%  手续费: %.2f", report.TradeCount, report.WinRate*100, report.TotalFees)
+	log.Printf("报告已写入 %s", *outDir)
+}
+
+// loadIndicatorConfig 加载指标配置：JSON文件 > 数据库 > 默认配置
+func loadIndicatorConfig(cfg *config.Config, path string, symbol types.Symbol) (types.IndicatorConfig, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return types.IndicatorConfig{}, fmt.Errorf("读取指标配置文件失败: %w", err)
+		}
+		indicatorConfig := types.GetDefaultConfig()
+		if err := json.Unmarshal(data, &indicatorConfig); err != nil {
+			return types.IndicatorConfig{}, fmt.Errorf("解析指标配置文件失败: %w", err)
+		}
+		return indicatorConfig, nil
+	}
+
+	if cfg != nil && cfg.Database.MySQL.Host != "" {
+		if err := database.InitMySQL(database.MySQLConfig{
+			Host:     cfg.Database.MySQL.Host,
+			Port:     cfg.Database.MySQL.Port,
+			User:     cfg.Database.MySQL.User,
+			Password: cfg.Database.MySQL.Password,
+			Database: cfg.Database.MySQL.Database,
+		}); err != nil {
+			log.Printf("MySQL初始化失败，使用默认指标配置: %v", err)
+		} else if repo, err := database.NewConfigRepository(); err != nil {
+			log.Printf("创建配置仓库失败，使用默认指标配置: %v", err)
+		} else if dbConfig, err := repo.GetConfig(context.Background(), symbol); err != nil {
+			log.Printf("读取 %s 的指标配置失败，使用默认配置: %v", symbol, err)
+		} else {
+			log.Printf("使用数据库中 %s 的指标配置", symbol)
+			return *dbConfig, nil
+		}
+	}
+
+	return types.GetDefaultConfig(), nil
+}
+
+// loadKlines 加载K线：指定CSV时从文件读取，否则按时间范围从本地K线存储/Binance获取
+func loadKlines(cfg *config.Config, csvPath, symbol, interval, start, end string) ([]types.Kline, error) {
+	if csvPath != "" {
+		return backtest.LoadKlinesCSV(csvPath, symbol)
+	}
+
+	if start == "" {
+		return nil, fmt.Errorf("未指定CSV文件时必须指定 -start")
+	}
+	startTime, err := parseTime(start)
+	if err != nil {
+		return nil, err
+	}
+	endTime := time.Now()
+	if end != "" {
+		if endTime, err = parseTime(end); err != nil {
+			return nil, err
+		}
+	}
+
+	var apiKey, apiSecret, baseURL string
+	if cfg != nil {
+		apiKey, apiSecret, baseURL = cfg.Exchange.APIKey, cfg.Exchange.APISecret, cfg.Exchange.BaseURL
+	}
+	client := binance.NewClient(apiKey, apiSecret, baseURL)
+	if cfg != nil {
+		defaultMarket, markets, err := cfg.Exchange.MarketSelection()
+		if err != nil {
+			return nil, err
+		}
+		client.SetMarkets(defaultMarket, markets)
+	}
+
+	// MySQL可用时优先从本地K线存储读取，只向Binance请求缺失的部分
+	var provider exchange.MarketDataProvider = client
+	if database.DB != nil {
+		if repo, err := database.NewKlineRepository(); err != nil {
+			log.Printf("创建K线仓库失败，直接从Binance拉取: %v", err)
+		} else {
+			provider = exchange.NewStoredProvider(client, repo)
+		}
+	}
+
+	klines, err := provider.GetKlinesRange(types.Symbol(symbol), interval, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最后一根K线可能尚未收盘，回测只使用已收盘K线
+	if duration, err := types.IntervalDuration(interval); err == nil && len(klines) > 0 {
+		if last := klines[len(klines)-1]; last.Timestamp.Add(duration).After(time.Now()) {
+			klines = klines[:len(klines)-1]
+		}
+	}
+	return klines, nil
+}
+
+// parseTime 解析命令行时间参数（UTC）
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", s)
+}
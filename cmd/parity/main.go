@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/binance_cyan/indicators/internal/parity"
+)
+
+// timeLayout 报告中的时间格式
+const timeLayout = "2006-01-02 15:04"
+
+func main() {
+	var (
+		profile    = flag.String("profile", "default", "默认参数集（"+strings.Join(parity.ProfileNames(), ", ")+"）")
+		configPath = flag.String("indicators", "", "指标配置JSON文件，优先于 -profile（周期按K线根数，不做缩放）")
+		tolerance  = flag.Float64("tol", parity.DefaultTolerance, "默认绝对误差容差")
+		skip       = flag.Int("skip", 0, "在预热期之外额外跳过的最早K线数量")
+		jsonOut    = flag.String("json", "", "将完整报告写入JSON文件")
+		verbose    = flag.Bool("v", false, "打印超差K线明细")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "用法: parity [选项] <导出CSV或目录>...\n"+
+			"每个CSV旁可放同名 .json 设置文件（profile/config/tolerance/tolerances/skip），覆盖命令行选项\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	base := parity.Options{Profile: *profile, Tolerance: *tolerance, Skip: *skip}
+	if *configPath != "" {
+		data, err := os.ReadFile(*configPath)
+		if err != nil {
+			log.Fatalf("读取指标配置失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &base.Config); err != nil {
+			log.Fatalf("解析指标配置失败: %v", err)
+		}
+		base.Profile = filepath.Base(*configPath)
+	} else {
+		config, err := parity.Profile(*profile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		base.Config = config
+	}
+
+	files, err := collectFiles(flag.Args())
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("没有找到CSV文件")
+	}
+
+	var reports []*parity.Report
+	pass := true
+	for _, path := range files {
+		report, err := runCase(path, base)
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			pass = false
+			continue
+		}
+		printReport(report, *verbose)
+		reports = append(reports, report)
+		if !report.Pass {
+			pass = false
+		}
+	}
+
+	if *jsonOut != "" {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			log.Fatalf("序列化报告失败: %v", err)
+		}
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			log.Fatalf("写入报告失败: %v", err)
+		}
+	}
+
+	if !pass {
+		fmt.Println("结果: 失败")
+		os.Exit(1)
+	}
+	fmt.Println("结果: 通过")
+}
+
+// collectFiles 展开参数中的目录，返回所有CSV文件
+func collectFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("读取路径失败: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.csv"))
+		if err != nil {
+			return nil, fmt.Errorf("查找CSV文件失败: %w", err)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// runCase 加载一份导出数据及其设置文件并对比
+func runCase(path string, base parity.Options) (*parity.Report, error) {
+	ds, err := parity.LoadCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parity.LoadSpec(strings.TrimSuffix(path, filepath.Ext(path)) + ".json")
+	if err != nil {
+		return nil, err
+	}
+	opts, err := spec.Apply(base)
+	if err != nil {
+		return nil, err
+	}
+	return parity.Compare(ds, opts), nil
+}
+
+// printReport 以表格形式打印报告
+func printReport(report *parity.Report, verbose bool) {
+	status := "通过"
+	if !report.Pass {
+		status = "失败"
+	}
+	fmt.Printf("== %s（参数集: %s，K线: %d）%s\n", report.Name, report.Profile, report.Bars, status)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "列\t对比数\t最大误差\t平均误差\t容差\t超差\t最大误差时间\t结果")
+	for _, c := range report.Columns {
+		result := "OK"
+		if !c.Pass {
+			result = "FAIL"
+		}
+		if c.Note != "" {
+			fmt.Fprintf(w, "%s\t%d\t-\t-\t%g\t-\t-\t%s（%s）\n", c.Column, c.Compared, c.Tolerance, result, c.Note)
+			continue
+		}
+		worstTime := "-"
+		if c.Worst != nil {
+			worstTime = c.Worst.Time.Format(timeLayout)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.3e\t%.3e\t%g\t%d\t%s\t%s\n",
+			c.Column, c.Compared, c.MaxAbsError, c.MeanAbsError, c.Tolerance, c.Failed, worstTime, result)
+	}
+	w.Flush()
+
+	if !verbose {
+		return
+	}
+	for _, c := range report.Columns {
+		for _, f := range c.Failures {
+			fmt.Printf("  %s %s 期望=%.10f 实际=%.10f 误差=%.3e\n",
+				c.Column, f.Time.Format(timeLayout), f.Expected, f.Actual, f.AbsError)
+		}
+		if c.Failed > len(c.Failures) {
+			fmt.Printf("  %s ... 另有 %d 根超差K线\n", c.Column, c.Failed-len(c.Failures))
+		}
+	}
+}
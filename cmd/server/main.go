@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/binance_cyan/indicators/internal/api"
+	"github.com/binance_cyan/indicators/internal/backtest"
 	"github.com/binance_cyan/indicators/internal/config"
 	"github.com/binance_cyan/indicators/internal/database"
+	"github.com/binance_cyan/indicators/internal/exchange"
 	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/internal/execution"
+	"github.com/binance_cyan/indicators/internal/notify"
 	"github.com/binance_cyan/indicators/internal/service"
 	"github.com/binance_cyan/indicators/pkg/types"
 )
@@ -45,16 +50,43 @@ func main() {
 		log.Println("Redis连接成功")
 	}
 
-	// 创建Binance客户端
+	// 创建Binance客户端（作为行情数据源 exchange.MarketDataProvider 注入各服务）
 	binanceClient := binance.NewClient(
 		cfg.Exchange.APIKey,
 		cfg.Exchange.APISecret,
 		cfg.Exchange.BaseURL,
 	)
+	defaultMarket, markets, err := cfg.Exchange.MarketSelection()
+	if err != nil {
+		log.Fatalf("加载市场配置失败: %v", err)
+	}
+	binanceClient.SetMarkets(defaultMarket, markets)
+
+	ctx := context.Background()
+
+	// MySQL可用时使用本地K线存储：历史K线优先从本地读取，只向Binance请求缺失的部分
+	var provider exchange.MarketDataProvider = binanceClient
+	if database.DB != nil {
+		klineRepo, err := database.NewKlineRepository()
+		if err != nil {
+			log.Printf("创建K线仓库失败（K线将不会持久化）: %v", err)
+		} else {
+			storedProvider := exchange.NewStoredProvider(binanceClient, klineRepo)
+			provider = storedProvider
+			log.Println("K线仓库初始化成功，已收盘K线将持久化到数据库")
+
+			// 启动时在后台修复本地K线缺口
+			go func() {
+				if err := storedProvider.RepairGaps(ctx); err != nil {
+					log.Printf("修复本地K线缺口失败: %v", err)
+				}
+			}()
+		}
+	}
 
 	// 创建指标服务
 	cacheTTL := time.Duration(cfg.Cache.TTL) * time.Second
-	indicatorService := service.NewIndicatorService(binanceClient, cacheTTL)
+	indicatorService := service.NewIndicatorService(provider, cacheTTL)
 
 	// 创建配置仓库（如果MySQL可用）
 	var configRepo service.ConfigRepository
@@ -68,26 +100,108 @@ func main() {
 		}
 	}
 
-	// 创建实时服务（默认BTCUSDT，1h周期）
-	realtimeService := service.NewRealtimeService(
-		binanceClient,
-		indicatorService,
-		types.Symbol("BTCUSDT"),
-		"1h",
-		configRepo,
-	)
+	// 创建实时数据中心（按symbol+interval按需启动实时流，最后一个订阅者离开时停止）
+	realtimeHub := service.NewRealtimeHub(ctx, provider, indicatorService, configRepo)
+	defer realtimeHub.Close()
+	indicatorService.SetConfigSource(realtimeHub)
 
-	// 启动实时服务（失败不阻塞HTTP服务器）
-	ctx := context.Background()
-	if err := realtimeService.Start(ctx); err != nil {
-		log.Printf("警告: 实时服务启动失败（HTTP服务器将继续运行）: %v", err)
-		log.Println("提示: 实时数据功能将不可用，但可以通过HTTP API获取历史数据")
-	} else {
-		log.Println("实时服务启动成功")
+	// 创建告警服务（MySQL不可用时规则和历史只保存在内存中）
+	var alertRepo service.AlertRepository
+	if database.DB != nil {
+		repo, err := database.NewAlertRepository()
+		if err != nil {
+			log.Printf("创建告警仓库失败（告警规则将不会持久化）: %v", err)
+		} else {
+			alertRepo = repo
+		}
+	}
+	alertService := service.NewAlertService(realtimeHub, alertRepo)
+	if err := alertService.Start(ctx); err != nil {
+		log.Printf("告警服务启动失败: %v", err)
+	}
+
+	// 创建市场扫描服务（可选）
+	var scanner *service.ScannerService
+	if cfg.Scanner.Enabled {
+		market, err := types.ParseMarket(cfg.Scanner.Market)
+		if err != nil {
+			log.Fatalf("加载扫描配置失败: scanner.market 无效: %v", err)
+		}
+		scanner = service.NewScannerService(provider, realtimeHub, service.ScannerOptions{
+			Interval:       cfg.Scanner.Interval,
+			Refresh:        time.Duration(cfg.Scanner.Refresh) * time.Second,
+			Symbols:        cfg.Scanner.Symbols,
+			Market:         market,
+			QuoteAsset:     cfg.Scanner.QuoteAsset,
+			MinQuoteVolume: cfg.Scanner.MinQuoteVolume,
+			MaxSymbols:     cfg.Scanner.MaxSymbols,
+			Concurrency:    cfg.Scanner.Concurrency,
+		})
+		if err := scanner.Start(ctx); err != nil {
+			log.Printf("市场扫描服务启动失败: %v", err)
+			scanner = nil
+		}
+	}
+
+	// 创建webhook通知服务（可选）
+	var webhooks *service.WebhookNotifier
+	if len(cfg.Webhooks.Endpoints) > 0 {
+		webhooks, err = newWebhookNotifier(cfg.Webhooks, realtimeHub, alertService)
+		if err != nil {
+			log.Fatalf("加载webhook配置失败: %v", err)
+		}
+		webhooks.Start(ctx)
+	}
+
+	// 创建Telegram/邮件通知服务（可选）
+	if len(cfg.Notifications.Users) > 0 {
+		dispatcher, err := newNotifyDispatcher(cfg.Notifications, realtimeHub, alertService)
+		if err != nil {
+			log.Fatalf("加载通知配置失败: %v", err)
+		}
+		dispatcher.Start(ctx)
+	}
+
+	// 创建模拟交易服务（可选，MySQL不可用时状态只保存在内存中）
+	var paper *service.PaperTradingService
+	if cfg.Paper.Enabled {
+		var paperRepo service.PaperRepository
+		if database.DB != nil {
+			repo, err := database.NewPaperRepository()
+			if err != nil {
+				log.Printf("创建模拟交易仓库失败（模拟账户将不会持久化）: %v", err)
+			} else {
+				paperRepo = repo
+			}
+		}
+		paper, err = service.NewPaperTradingService(realtimeHub, paperRepo, service.PaperOptions{
+			Interval:       cfg.Paper.Interval,
+			InitialBalance: cfg.Paper.InitialBalance,
+			MakerFee:       cfg.Paper.MakerFee,
+			TakerFee:       cfg.Paper.TakerFee,
+			Leverage:       cfg.Paper.Leverage,
+		})
+		if err != nil {
+			log.Fatalf("加载模拟交易配置失败: %v", err)
+		}
+		if err := paper.Start(ctx); err != nil {
+			log.Printf("模拟交易服务启动失败: %v", err)
+			paper = nil
+		}
+	}
+
+	// 创建策略执行引擎（可选）
+	var engine *execution.Engine
+	if cfg.Executor.Enabled {
+		engine, err = newExecutionEngine(cfg.Executor, realtimeHub, alertService, paper, binanceClient, cfg.Exchange.APIKey != "")
+		if err != nil {
+			log.Fatalf("加载策略执行配置失败: %v", err)
+		}
+		engine.Start(ctx)
 	}
 
 	// 创建HTTP服务器
-	server := api.NewServer(cfg, indicatorService, realtimeService)
+	server := api.NewServer(cfg, indicatorService, realtimeHub, alertService, scanner, webhooks, paper, engine)
 
 	// 启动服务器
 	log.Printf("服务器启动在 http://%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -95,3 +209,151 @@ func main() {
 		log.Fatalf("服务器启动失败: %v", err)
 	}
 }
+
+// newWebhookNotifier 根据配置创建webhook通知服务（MySQL不可用时投递记录只保存在内存中）
+func newWebhookNotifier(cfg config.WebhookConfig, hub *service.RealtimeHub, alerts *service.AlertService) (*service.WebhookNotifier, error) {
+	opts := service.WebhookOptions{QueueSize: cfg.QueueSize}
+	for _, s := range cfg.Streams {
+		key, err := service.ParseStreamKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("webhooks.streams 无效: %w", err)
+		}
+		opts.Streams = append(opts.Streams, key)
+	}
+	for _, e := range cfg.Endpoints {
+		opts.Endpoints = append(opts.Endpoints, service.WebhookEndpoint{
+			Name:       e.Name,
+			URL:        e.URL,
+			Secret:     e.Secret,
+			Events:     e.Events,
+			Symbols:    e.Symbols,
+			MaxRetries: e.MaxRetries,
+			Backoff:    time.Duration(e.Backoff * float64(time.Second)),
+			Timeout:    time.Duration(e.Timeout * float64(time.Second)),
+		})
+	}
+
+	var repo service.WebhookDeliveryRepository
+	if database.DB != nil {
+		r, err := database.NewWebhookRepository()
+		if err != nil {
+			log.Printf("创建webhook投递记录仓库失败（投递记录将不会持久化）: %v", err)
+		} else {
+			repo = r
+		}
+	}
+	return service.NewWebhookNotifier(hub, alerts, repo, opts)
+}
+
+// newNotifyDispatcher 根据配置创建Telegram/邮件通知服务
+func newNotifyDispatcher(cfg config.NotificationConfig, hub *service.RealtimeHub, alerts *service.AlertService) (*notify.Dispatcher, error) {
+	opts := notify.Options{Templates: cfg.Templates}
+	for _, s := range cfg.Streams {
+		key, err := service.ParseStreamKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("notifications.streams 无效: %w", err)
+		}
+		opts.Streams = append(opts.Streams, key)
+	}
+
+	var notifiers []notify.Notifier
+	if cfg.Telegram.Token != "" {
+		telegram, err := notify.NewTelegramNotifier(cfg.Telegram.APIURL, cfg.Telegram.Token)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, telegram)
+	}
+	if cfg.SMTP.Host != "" {
+		smtp, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, smtp)
+	}
+
+	for _, u := range cfg.Users {
+		user := notify.User{
+			Name:       u.Name,
+			Recipients: make(map[string]string),
+			Events:     u.Events,
+			Symbols:    u.Symbols,
+		}
+		if u.Telegram != "" {
+			user.Recipients[notify.ChannelTelegram] = u.Telegram
+		}
+		if u.Email != "" {
+			user.Recipients[notify.ChannelSMTP] = u.Email
+		}
+		if u.QuietHours.Start != "" {
+			quiet, err := notify.ParseQuietHours(u.QuietHours.Start, u.QuietHours.End, u.QuietHours.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("通知用户 %s 的免打扰时段无效: %w", u.Name, err)
+			}
+			user.Quiet = quiet
+		}
+		opts.Users = append(opts.Users, user)
+	}
+	return notify.NewDispatcher(hub, alerts, notifiers, opts)
+}
+
+// newExecutionEngine 根据配置创建策略执行引擎（MySQL不可用时审计记录只保存在内存中）
+func newExecutionEngine(cfg config.ExecutorConfig, hub *service.RealtimeHub, alerts *service.AlertService, paper *service.PaperTradingService, client *binance.Client, hasAPIKey bool) (*execution.Engine, error) {
+	var executor execution.Executor
+	switch cfg.Mode {
+	case "", execution.ExecutorPaper:
+		if paper == nil {
+			return nil, fmt.Errorf("executor.mode=paper 需要启用模拟交易（paper.enabled）")
+		}
+		executor = execution.NewPaperExecutor(paper)
+	case execution.ExecutorBinance:
+		if !hasAPIKey {
+			return nil, fmt.Errorf("executor.mode=binance 需要配置API Key和Secret")
+		}
+		executor = execution.NewBinanceExecutor(client, cfg.QuoteAsset)
+	default:
+		return nil, fmt.Errorf("不支持的执行器: %s（可选 paper、binance）", cfg.Mode)
+	}
+
+	opts := execution.Options{
+		RiskPerTrade:       cfg.RiskPerTrade,
+		VolatilityMultiple: cfg.VolatilityMultiple,
+		MaxPosition:        cfg.MaxPosition,
+		MaxDailyLoss:       cfg.MaxDailyLoss,
+		QuantitySteps:      cfg.QuantitySteps,
+		KillSwitch:         cfg.KillSwitch,
+	}
+	for _, s := range cfg.Strategies {
+		params, err := backtest.ParseStrategyParams(s.Params)
+		if err != nil {
+			return nil, fmt.Errorf("策略 %s 的参数无效: %w", s.Name, err)
+		}
+		opts.Strategies = append(opts.Strategies, execution.StrategyConfig{
+			Name:     s.Name,
+			Strategy: s.Strategy,
+			Params:   params,
+			Symbol:   s.Symbol,
+			Interval: s.Interval,
+		})
+	}
+	for _, r := range cfg.Rules {
+		opts.Rules = append(opts.Rules, execution.RuleBinding{RuleID: r.RuleID, Action: backtest.Action(r.Action)})
+	}
+
+	var repo execution.AuditRepository
+	if database.DB != nil {
+		r, err := database.NewExecutionAuditRepository()
+		if err != nil {
+			log.Printf("创建策略执行审计仓库失败（审计记录将不会持久化）: %v", err)
+		} else {
+			repo = r
+		}
+	}
+	return execution.NewEngine(hub, alerts, executor, repo, opts)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHandler 告警API处理器
+type AlertHandler struct {
+	alertService *service.AlertService
+}
+
+// NewAlertHandler 创建告警API处理器
+func NewAlertHandler(alertService *service.AlertService) *AlertHandler {
+	return &AlertHandler{alertService: alertService}
+}
+
+// ListRules 获取告警规则
+// GET /api/alerts/rules?symbol=BTCUSDT
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	c.JSON(http.StatusOK, gin.H{"rules": h.alertService.ListRules(types.Symbol(symbol))})
+}
+
+// CreateRule 新增告警规则
+// POST /api/alerts/rules
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var rule types.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "规则格式错误: " + err.Error()})
+		return
+	}
+	rule.ID = 0
+
+	saved, err := h.alertService.SaveRule(c.Request.Context(), rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "告警规则已创建", "rule": saved})
+}
+
+// UpdateRule 更新告警规则
+// PUT /api/alerts/rules/:id
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则ID"})
+		return
+	}
+
+	var rule types.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "规则格式错误: " + err.Error()})
+		return
+	}
+	rule.ID = id
+
+	saved, err := h.alertService.SaveRule(c.Request.Context(), rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "告警规则已更新", "rule": saved})
+}
+
+// DeleteRule 删除告警规则
+// DELETE /api/alerts/rules/:id
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则ID"})
+		return
+	}
+
+	if err := h.alertService.DeleteRule(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "告警规则已删除", "id": id})
+}
+
+// GetHistory 获取告警历史
+// GET /api/alerts/history?symbol=BTCUSDT&limit=100
+func (h *AlertHandler) GetHistory(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	events, err := h.alertService.History(c.Request.Context(), types.Symbol(symbol), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": events})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfigHistory 获取指定symbol的配置历史
+// GET /api/config/history?symbol=BTCUSDT&limit=50
+func (h *Handler) GetConfigHistory(c *gin.Context) {
+	if h.realtimeHub == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
+		return
+	}
+
+	symbol := c.DefaultQuery("symbol", "BTCUSDT")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	versions, err := h.realtimeHub.ListConfigVersions(c.Request.Context(), types.Symbol(symbol), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "versions": versions})
+}
+
+// DiffConfigVersions 比较两个配置版本，省略 to 时与当前生效的配置比较
+// GET /api/config/diff?symbol=BTCUSDT&from=3&to=5
+func (h *Handler) DiffConfigVersions(c *gin.Context) {
+	if h.realtimeHub == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
+		return
+	}
+
+	symbol := c.DefaultQuery("symbol", "BTCUSDT")
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的from版本号"})
+		return
+	}
+	to := 0
+	if s := c.Query("to"); s != "" {
+		if to, err = strconv.Atoi(s); err != nil || to <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的to版本号"})
+			return
+		}
+	}
+
+	diff, err := h.realtimeHub.DiffConfigVersions(c.Request.Context(), types.Symbol(symbol), from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// RollbackConfig 将配置回滚到指定历史版本，实时流立即按恢复的配置推送
+// POST /api/config/rollback?symbol=BTCUSDT
+// Body: {"version": 3, "author": "alice", "note": "恢复周末前的参数"}
+func (h *Handler) RollbackConfig(c *gin.Context) {
+	if h.realtimeHub == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
+		return
+	}
+
+	symbol := c.DefaultQuery("symbol", "BTCUSDT")
+	var req struct {
+		Version int    `json:"version"`
+		Author  string `json:"author"`
+		Note    string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误: " + err.Error()})
+		return
+	}
+	if req.Version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本号"})
+		return
+	}
+
+	saved, err := h.realtimeHub.RollbackConfig(c.Request.Context(), types.Symbol(symbol), req.Version, req.Author, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "配置已回滚",
+		"symbol":  symbol,
+		"version": saved.Version,
+		"config":  saved.Config,
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/binance_cyan/indicators/internal/execution"
+	"github.com/gin-gonic/gin"
+)
+
+// ExecutorHandler 策略执行API处理器
+type ExecutorHandler struct {
+	engine *execution.Engine
+}
+
+// NewExecutorHandler 创建策略执行API处理器，engine为nil表示未启用
+func NewExecutorHandler(engine *execution.Engine) *ExecutorHandler {
+	return &ExecutorHandler{engine: engine}
+}
+
+// available 检查策略执行是否已启用
+func (h *ExecutorHandler) available(c *gin.Context) bool {
+	if h.engine == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "策略执行未启用（配置 executor.enabled）"})
+		return false
+	}
+	return true
+}
+
+// GetStatus 获取执行状态（熔断开关、策略、规则动作和当日权益）
+// GET /api/executor/status
+func (h *ExecutorHandler) GetStatus(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.engine.Status())
+}
+
+// Kill 开启熔断开关，flatten为true时同时平掉已知交易对的持仓
+// POST /api/executor/kill {"reason":"...","flatten":true}
+func (h *ExecutorHandler) Kill(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	var req struct {
+		Reason  string `json:"reason"`
+		Flatten bool   `json:"flatten"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误: " + err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, h.engine.Kill(c.Request.Context(), req.Reason, req.Flatten))
+}
+
+// Resume 关闭熔断开关
+// POST /api/executor/resume
+func (h *ExecutorHandler) Resume(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.engine.Resume(c.Request.Context()))
+}
+
+// GetAudit 获取审计记录（按时间倒序）
+// GET /api/executor/audit?symbol=BTCUSDT&limit=100
+func (h *ExecutorHandler) GetAudit(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	audits, err := h.engine.Audits(c.Request.Context(), c.Query("symbol"), queryLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"audits": audits})
+}
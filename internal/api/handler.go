@@ -3,8 +3,10 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/indicators"
 	"github.com/binance_cyan/indicators/pkg/types"
 	"github.com/gin-gonic/gin"
 )
@@ -12,14 +14,14 @@ import (
 // Handler API处理器
 type Handler struct {
 	indicatorService *service.IndicatorService
-	realtimeService *service.RealtimeService
+	realtimeHub      *service.RealtimeHub
 }
 
 // NewHandler 创建API处理器
-func NewHandler(indicatorService *service.IndicatorService, realtimeService *service.RealtimeService) *Handler {
+func NewHandler(indicatorService *service.IndicatorService, realtimeHub *service.RealtimeHub) *Handler {
 	return &Handler{
 		indicatorService: indicatorService,
-		realtimeService:  realtimeService,
+		realtimeHub:      realtimeHub,
 	}
 }
 
@@ -49,23 +51,34 @@ func (h *Handler) GetIndicators(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetIndicatorRegistry 获取所有已注册的指标及其参数定义
+// GET /api/indicators/registry
+func (h *Handler) GetIndicatorRegistry(c *gin.Context) {
+	list := indicators.List()
+	infos := make([]indicators.IndicatorInfo, 0, len(list))
+	for _, ind := range list {
+		infos = append(infos, indicators.Describe(ind))
+	}
+	c.JSON(http.StatusOK, gin.H{"indicators": infos})
+}
+
 // GetConfig 获取指定symbol的配置
 // GET /api/config?symbol=BTCUSDT
 func (h *Handler) GetConfig(c *gin.Context) {
-	if h.realtimeService == nil {
+	if h.realtimeHub == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
 		return
 	}
 	
 	symbol := c.DefaultQuery("symbol", "BTCUSDT")
-	config := h.realtimeService.GetConfig(types.Symbol(symbol))
+	config := h.realtimeHub.GetConfig(types.Symbol(symbol))
 	c.JSON(http.StatusOK, config)
 }
 
-// UpdateConfig 更新指定symbol的配置
-// POST /api/config?symbol=BTCUSDT
+// UpdateConfig 更新指定symbol的配置，author、note 记录到配置历史
+// POST /api/config?symbol=BTCUSDT&author=alice&note=调整CCI周期
 func (h *Handler) UpdateConfig(c *gin.Context) {
-	if h.realtimeService == nil {
+	if h.realtimeHub == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
 		return
 	}
@@ -81,34 +94,84 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "配置格式错误: " + err.Error()})
 		return
 	}
+	// 请求中没有 indicators 字段（如只修改背离检测参数）时保留当前的指标实例
+	if config.Indicators == nil {
+		config.Indicators = h.realtimeHub.GetConfig(types.Symbol(symbol)).Clone().Indicators
+	}
 
-	// 验证配置参数
-	if config.CCI_Period1 <= 0 || config.CCI_Period2 <= 0 || config.CCI_Period3 <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "CCI周期必须大于0"})
+	// 验证配置参数（指标类型、参数范围、key是否重复），移动平均方法统一为小写保存
+	for i := range config.Indicators {
+		if config.Indicators[i].MA == "" {
+			continue
+		}
+		method, err := indicators.ParseMAMethod(config.Indicators[i].MA)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		config.Indicators[i].MA = string(method)
+	}
+	if err := indicators.ValidateConfig(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置无效: " + err.Error()})
 		return
 	}
-	if config.MACD_Fast1 <= 0 || config.MACD_Slow1 <= 0 || config.MACD_Signal1 <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "MACD参数必须大于0"})
+
+	meta := types.ConfigChangeMeta{Author: c.Query("author"), Note: c.Query("note")}
+	saved, err := h.realtimeHub.UpdateConfigWithMeta(types.Symbol(symbol), config, meta)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败: " + err.Error()})
 		return
 	}
-	if config.RSI_Period1 <= 0 || config.RSI_Period2 <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "RSI周期必须大于0"})
+
+	response := gin.H{"message": "配置已更新", "symbol": symbol, "config": config}
+	if saved != nil {
+		response["version"] = saved.Version
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+
+// GetStreams 获取当前活跃的实时流
+// GET /api/streams
+func (h *Handler) GetStreams(c *gin.Context) {
+	if h.realtimeHub == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
 		return
 	}
-	if config.Boll_Period <= 0 || config.Boll_Deviation <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "布林线参数必须大于0"})
+
+	c.JSON(http.StatusOK, gin.H{"streams": h.realtimeHub.Streams()})
+}
+
+// GetConfluence 获取多周期共振概要：由基础周期的K线重采样出更高周期，按symbol的配置计算指标
+// GET /api/confluence?symbol=BTCUSDT&interval=15m&timeframes=15m,1h,4h
+func (h *Handler) GetConfluence(c *gin.Context) {
+	if h.realtimeHub == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实时服务未初始化"})
+		return
+	}
+
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol参数必填"})
 		return
 	}
-	if config.Env_Period <= 0 || config.Env_Deviation <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "包络线参数必须大于0"})
+	interval := c.DefaultQuery("interval", "15m")
+	if _, err := types.IntervalToMinutes(interval); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	timeframes := service.DefaultTimeframes(interval)
+	if s := c.Query("timeframes"); s != "" {
+		timeframes = strings.Split(s, ",")
+		for i := range timeframes {
+			timeframes[i] = strings.TrimSpace(timeframes[i])
+		}
+	}
 
-	if err := h.realtimeService.UpdateConfig(types.Symbol(symbol), config); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败: " + err.Error()})
+	summary, err := h.realtimeHub.Confluence(types.Symbol(symbol), interval, timeframes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"message": "配置已更新", "symbol": symbol, "config": config})
+	c.JSON(http.StatusOK, summary)
 }
-
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// PaperHandler 模拟交易API处理器
+type PaperHandler struct {
+	paper *service.PaperTradingService
+}
+
+// NewPaperHandler 创建模拟交易API处理器，paper为nil表示未启用
+func NewPaperHandler(paper *service.PaperTradingService) *PaperHandler {
+	return &PaperHandler{paper: paper}
+}
+
+// available 检查模拟交易是否已启用
+func (h *PaperHandler) available(c *gin.Context) bool {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "模拟交易未启用（配置 paper.enabled）"})
+		return false
+	}
+	return true
+}
+
+// queryLimit 读取limit查询参数，默认100
+func queryLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	return limit
+}
+
+// GetAccount 获取账户快照（余额、权益、保证金、持仓和挂单）
+// GET /api/paper/account
+func (h *PaperHandler) GetAccount(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.paper.Account())
+}
+
+// PlaceOrder 下单
+// POST /api/paper/orders
+func (h *PaperHandler) PlaceOrder(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	var req types.OrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "订单格式错误: " + err.Error()})
+		return
+	}
+
+	order, err := h.paper.PlaceOrder(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"order": order})
+}
+
+// ListOrders 获取订单
+// GET /api/paper/orders?symbol=BTCUSDT&status=open&limit=100
+func (h *PaperHandler) ListOrders(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	symbol := strings.ToUpper(c.Query("symbol"))
+	openOnly := c.Query("status") == "open"
+	orders, err := h.paper.Orders(c.Request.Context(), symbol, openOnly, queryLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+// CancelOrder 撤销未成交订单
+// DELETE /api/paper/orders/:id
+func (h *PaperHandler) CancelOrder(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的订单ID"})
+		return
+	}
+
+	order, err := h.paper.CancelOrder(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"order": order})
+}
+
+// ListTrades 获取成交记录
+// GET /api/paper/trades?symbol=BTCUSDT&limit=100
+func (h *PaperHandler) ListTrades(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	symbol := strings.ToUpper(c.Query("symbol"))
+	trades, err := h.paper.Trades(c.Request.Context(), symbol, queryLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trades": trades})
+}
+
+// Reset 重置账户
+// POST /api/paper/reset
+func (h *PaperHandler) Reset(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	account, err := h.paper.Reset(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
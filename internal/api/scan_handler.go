@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ScanHandler 市场扫描API处理器
+type ScanHandler struct {
+	scanner *service.ScannerService
+}
+
+// NewScanHandler 创建市场扫描API处理器，scanner为nil表示未启用
+func NewScanHandler(scanner *service.ScannerService) *ScanHandler {
+	return &ScanHandler{scanner: scanner}
+}
+
+// Scan 按过滤和排序表达式查询扫描结果
+// GET /api/scan?filter=boll_zone>=8 and rsi(48)<30&sort=-volatility&limit=20
+func (h *ScanHandler) Scan(c *gin.Context) {
+	if h.scanner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "市场扫描未启用（配置 scanner.enabled）"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		limit = 0
+	}
+
+	response, err := h.scanner.Scan(service.ScanQuery{
+		Filter: c.Query("filter"),
+		Sort:   c.Query("sort"),
+		Limit:  limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
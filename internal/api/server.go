@@ -2,27 +2,44 @@ package api
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/binance_cyan/indicators/internal/config"
+	"github.com/binance_cyan/indicators/internal/execution"
 	"github.com/binance_cyan/indicators/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 // Server HTTP服务器
 type Server struct {
-	config          *config.Config
-	handler         *Handler
-	wsHandler       *WebSocketHandler
-	realtimeService *service.RealtimeService
+	config         *config.Config
+	handler        *Handler
+	wsHandler      *WebSocketHandler
+	alertHandler   *AlertHandler
+	scanHandler    *ScanHandler
+	webhookHandler *WebhookHandler
+	paperHandler   *PaperHandler
+	execHandler    *ExecutorHandler
+	realtimeHub    *service.RealtimeHub
 }
 
 // NewServer 创建HTTP服务器
-func NewServer(cfg *config.Config, indicatorService *service.IndicatorService, realtimeService *service.RealtimeService) *Server {
+func NewServer(cfg *config.Config, indicatorService *service.IndicatorService, realtimeHub *service.RealtimeHub, alertService *service.AlertService, scanner *service.ScannerService, webhooks *service.WebhookNotifier, paper *service.PaperTradingService, executor *execution.Engine) *Server {
+	policy, err := service.ParseSlowConsumerPolicy(cfg.Server.SlowConsumerPolicy)
+	if err != nil {
+		log.Printf("%v，使用 %s", err, service.PolicyDropOldest)
+		policy = service.PolicyDropOldest
+	}
 	return &Server{
-		config:          cfg,
-		handler:         NewHandler(indicatorService, realtimeService),
-		realtimeService: realtimeService,
-		wsHandler:       NewWebSocketHandler(realtimeService),
+		config:         cfg,
+		handler:        NewHandler(indicatorService, realtimeHub),
+		realtimeHub:    realtimeHub,
+		wsHandler:      NewWebSocketHandler(realtimeHub, alertService, paper, policy),
+		alertHandler:   NewAlertHandler(alertService),
+		scanHandler:    NewScanHandler(scanner),
+		webhookHandler: NewWebhookHandler(webhooks),
+		paperHandler:   NewPaperHandler(paper),
+		execHandler:    NewExecutorHandler(executor),
 	}
 }
 
@@ -41,9 +58,40 @@ func (s *Server) Start() error {
 	api := router.Group("/api")
 	{
 		api.GET("/indicators", s.handler.GetIndicators)
+		api.GET("/indicators/registry", s.handler.GetIndicatorRegistry)
 		api.GET("/config", s.handler.GetConfig)
 		api.POST("/config", s.handler.UpdateConfig)
+		api.GET("/config/history", s.handler.GetConfigHistory)
+		api.GET("/config/diff", s.handler.DiffConfigVersions)
+		api.POST("/config/rollback", s.handler.RollbackConfig)
+		api.GET("/streams", s.handler.GetStreams)
+		api.GET("/confluence", s.handler.GetConfluence)
+		api.GET("/scan", s.scanHandler.Scan)
 		api.GET("/ws", s.wsHandler.HandleWebSocket)
+
+		// 告警
+		api.GET("/alerts/rules", s.alertHandler.ListRules)
+		api.POST("/alerts/rules", s.alertHandler.CreateRule)
+		api.PUT("/alerts/rules/:id", s.alertHandler.UpdateRule)
+		api.DELETE("/alerts/rules/:id", s.alertHandler.DeleteRule)
+		api.GET("/alerts/history", s.alertHandler.GetHistory)
+
+		// webhook通知
+		api.GET("/webhooks", s.webhookHandler.ListEndpoints)
+		api.GET("/webhooks/deliveries", s.webhookHandler.GetDeliveries)
+		api.POST("/webhooks/:name/test", s.webhookHandler.TestSend)
+
+		// 模拟交易
+		api.GET("/paper/account", s.paperHandler.GetAccount)
+		api.POST("/paper/orders", s.paperHandler.PlaceOrder)
+		api.GET("/paper/orders", s.paperHandler.ListOrders)
+		api.DELETE("/paper/orders/:id", s.paperHandler.CancelOrder)
+		api.GET("/paper/trades", s.paperHandler.ListTrades)
+		api.POST("/paper/reset", s.paperHandler.Reset)
+		api.GET("/executor/status", s.execHandler.GetStatus)
+		api.POST("/executor/kill", s.execHandler.Kill)
+		api.POST("/executor/resume", s.execHandler.Resume)
+		api.GET("/executor/audit", s.execHandler.GetAudit)
 	}
 
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
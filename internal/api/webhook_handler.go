@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler webhook通知API处理器
+type WebhookHandler struct {
+	notifier *service.WebhookNotifier
+}
+
+// NewWebhookHandler 创建webhook通知API处理器，notifier为nil表示未配置
+func NewWebhookHandler(notifier *service.WebhookNotifier) *WebhookHandler {
+	return &WebhookHandler{notifier: notifier}
+}
+
+// available 检查webhook通知是否已配置
+func (h *WebhookHandler) available(c *gin.Context) bool {
+	if h.notifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "webhook通知未配置（配置 webhooks.endpoints）"})
+		return false
+	}
+	return true
+}
+
+// ListEndpoints 获取webhook端点
+// GET /api/webhooks
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"endpoints": h.notifier.Endpoints()})
+}
+
+// GetDeliveries 获取投递记录
+// GET /api/webhooks/deliveries?endpoint=ops&limit=100
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	deliveries, err := h.notifier.Deliveries(c.Request.Context(), c.Query("endpoint"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// TestSend 向端点发送一条测试事件
+// POST /api/webhooks/:name/test
+func (h *WebhookHandler) TestSend(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	delivery, err := h.notifier.TestSend(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delivery": delivery})
+}
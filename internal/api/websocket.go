@@ -3,6 +3,7 @@ package api
 import (
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/binance_cyan/indicators/internal/service"
@@ -19,35 +20,37 @@ var upgrader = websocket.Upgrader{
 
 // WebSocketHandler WebSocket处理器
 type WebSocketHandler struct {
-	realtimeService *service.RealtimeService
-	clients         map[*websocket.Conn]bool
-	clientsMu       sync.RWMutex
+	realtimeHub  *service.RealtimeHub
+	alertService *service.AlertService
+	paper        *service.PaperTradingService
+	policy       service.SlowConsumerPolicy // 默认慢消费者策略
+	clients      map[*websocket.Conn]bool
+	clientsMu    sync.RWMutex
 }
 
 // NewWebSocketHandler 创建WebSocket处理器
-func NewWebSocketHandler(realtimeService *service.RealtimeService) *WebSocketHandler {
+// policy 为订阅未指定策略时使用的慢消费者策略，paper 为nil时不支持订阅模拟交易账户
+func NewWebSocketHandler(realtimeHub *service.RealtimeHub, alertService *service.AlertService, paper *service.PaperTradingService, policy service.SlowConsumerPolicy) *WebSocketHandler {
 	return &WebSocketHandler{
-		realtimeService: realtimeService,
-		clients:         make(map[*websocket.Conn]bool),
+		realtimeHub:  realtimeHub,
+		alertService: alertService,
+		paper:        paper,
+		policy:       policy,
+		clients:      make(map[*websocket.Conn]bool),
 	}
 }
 
 // HandleWebSocket 处理WebSocket连接
+// 带 symbol 查询参数时为旧版连接：自动订阅该实时流并直接推送 RealtimeData；
+// 否则由客户端通过 subscribe/unsubscribe 消息管理订阅，推送包装为 snapshot/update 消息
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	// 获取参数
-	symbol := c.Query("symbol")
+	symbol := strings.ToUpper(c.Query("symbol"))
 	interval := c.Query("interval")
-	
-	if symbol == "" {
-		symbol = "BTCUSDT"
-	}
-	if interval == "" {
+	if symbol != "" && interval == "" {
 		interval = "1h"
 	}
-	
-	// 更新实时服务的symbol和interval
-	h.realtimeService.UpdateSymbolAndInterval(types.Symbol(symbol), interval)
-	
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket升级失败: %v", err)
@@ -67,19 +70,84 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		h.clientsMu.Unlock()
 	}()
 
-	// 订阅实时数据
-	dataChan := h.realtimeService.Subscribe()
-	defer h.realtimeService.Unsubscribe(dataChan)
+	session := newWSSession(conn, h.realtimeHub, h.paper, h.policy)
+	writerDone := make(chan struct{})
+	go func() {
+		session.writeLoop()
+		close(writerDone)
+	}()
+	defer func() {
+		session.close()
+		<-writerDone
+	}()
+	defer session.unsubscribeAllStreams()
+
+	// 旧版连接：订阅查询参数指定的实时流（每个symbol+interval独立的实时流，不影响其他连接）
+	if symbol != "" {
+		req := []wsStreamRequest{{Symbol: symbol, Interval: interval}}
+		subs, err := session.subscribeAll(req, true)
+		if err != nil {
+			log.Printf("订阅实时流 %s@%s 失败: %v", symbol, interval, err)
+			session.enqueue(gin.H{"error": "订阅实时数据失败: " + err.Error()})
+			return
+		}
+		session.start(subs)
+	}
+
+	// 订阅告警事件（只推送已订阅symbol的告警）
+	if h.alertService != nil {
+		alertChan := h.alertService.Subscribe()
+		defer h.alertService.Unsubscribe(alertChan)
+		go h.forwardAlerts(session, alertChan)
+	}
+
+	// 订阅模拟交易账户快照（只推送给发送了 subscribe_account 的连接）
+	if h.paper != nil {
+		accountChan := h.paper.Subscribe()
+		defer h.paper.Unsubscribe(accountChan)
+		go h.forwardAccount(session, accountChan)
+	}
+
+	// 读循环：处理客户端消息，连接断开时返回并释放所有订阅
+	session.readLoop()
+}
 
-	// 发送数据循环
+// forwardAlerts 转发已订阅symbol的告警事件
+func (h *WebSocketHandler) forwardAlerts(session *wsSession, alertChan <-chan *types.AlertEvent) {
 	for {
 		select {
-		case data := <-dataChan:
-			if err := conn.WriteJSON(data); err != nil {
-				log.Printf("WebSocket写入失败: %v", err)
+		case <-session.done:
+			return
+		case event, ok := <-alertChan:
+			if !ok {
+				return
+			}
+			if !session.subscribedSymbol(event.Symbol) {
+				continue
+			}
+			if !session.enqueue(wsMessage{Type: wsTypeAlert, Alert: event}) {
 				return
 			}
 		}
 	}
 }
 
+// forwardAccount 转发模拟交易账户快照
+func (h *WebSocketHandler) forwardAccount(session *wsSession, accountChan <-chan *types.PaperAccountSnapshot) {
+	for {
+		select {
+		case <-session.done:
+			return
+		case snapshot, ok := <-accountChan:
+			if !ok {
+				return
+			}
+			if !session.account.Load() {
+				continue
+			}
+			if !session.enqueue(wsMessage{Type: wsTypeAccount, Account: snapshot}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,702 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait       = 10 * time.Second    // 单次写入超时
+	wsPongWait        = 60 * time.Second    // 等待客户端pong（或任意消息）的超时
+	wsPingPeriod      = wsPongWait * 9 / 10 // 服务端ping间隔
+	wsMaxMessageSize  = 64 * 1024           // 客户端消息大小上限
+	wsMaxSubscription = 20                  // 单个连接的订阅上限
+	wsSendBuffer      = 64                  // 待写出消息缓冲
+)
+
+// 客户端动作
+const (
+	wsActionSubscribe   = "subscribe"
+	wsActionUnsubscribe = "unsubscribe"
+	wsActionResync      = "resync"
+	wsActionList        = "list"
+	wsActionPing        = "ping"
+	// 模拟交易账户快照（需启用模拟交易）
+	wsActionSubscribeAccount   = "subscribe_account"
+	wsActionUnsubscribeAccount = "unsubscribe_account"
+)
+
+// 服务端消息类型
+const (
+	wsTypeAck           = "ack"
+	wsTypeError         = "error"
+	wsTypeSnapshot      = "snapshot"
+	wsTypeUpdate        = "update"
+	wsTypeDelta         = "delta"
+	wsTypeAlert         = "alert"
+	wsTypePong          = "pong"
+	wsTypeSubscriptions = "subscriptions"
+	wsTypeAccount       = "account"
+)
+
+// 推送模式
+const (
+	wsModeFull  = "full"
+	wsModeDelta = "delta"
+)
+
+// wsIndicatorFields 可按需订阅的指标字段（对应 RealtimeData 的JSON字段名）
+var wsIndicatorFields = map[string]bool{
+	"cci":         true,
+	"macd":        true,
+	"rsi":         true,
+	"bollinger":   true,
+	"envelope":    true,
+	"volatility":  true,
+	"custom":      true,
+	"divergences": true,
+}
+
+// wsStreamRequest 单个订阅项
+type wsStreamRequest struct {
+	Symbol     string   `json:"symbol"`
+	Interval   string   `json:"interval"`
+	Indicators []string `json:"indicators,omitempty"` // 为空时推送全部指标
+	Mode       string   `json:"mode,omitempty"`       // full（默认，每次推送完整数据）或 delta（快照之后只推送变化的尾部）
+	Policy     string   `json:"policy,omitempty"`     // 慢消费者策略，仅在首次订阅时生效，为空时使用服务端默认值
+	Timeframes []string `json:"timeframes,omitempty"` // 多周期共振：由该实时流的K线重采样出的周期，为空时不推送
+}
+
+// wsRequest 客户端消息
+// 订阅项可以直接写在消息上（symbol/interval/indicators），也可以通过 streams 一次提交多个
+type wsRequest struct {
+	Action  string            `json:"action"`
+	ID      string            `json:"id,omitempty"` // 客户端请求ID，原样出现在ack/error中
+	Streams []wsStreamRequest `json:"streams,omitempty"`
+	wsStreamRequest
+}
+
+// wsSubscriptionInfo 订阅状态
+type wsSubscriptionInfo struct {
+	Stream     string                     `json:"stream"`
+	Symbol     string                     `json:"symbol"`
+	Interval   string                     `json:"interval"`
+	Mode       string                     `json:"mode"`
+	Indicators []string                   `json:"indicators,omitempty"`
+	Timeframes []string                   `json:"timeframes,omitempty"`
+	Stats      *service.SubscriptionStats `json:"stats,omitempty"`
+}
+
+// wsMessage 服务端消息
+type wsMessage struct {
+	Type          string               `json:"type"`
+	ID            string               `json:"id,omitempty"`
+	Action        string               `json:"action,omitempty"`
+	Stream        string               `json:"stream,omitempty"`
+	Seq           uint64               `json:"seq,omitempty"`    // 每个订阅从1开始连续递增，不连续时客户端应发送resync
+	Length        int                  `json:"length,omitempty"` // delta模式：服务端完整窗口的K线数量
+	Streams       []string             `json:"streams,omitempty"`
+	Subscriptions []wsSubscriptionInfo `json:"subscriptions,omitempty"`
+	Error         string               `json:"error,omitempty"`
+	Data          interface{}          `json:"data,omitempty"`
+	Alert         *types.AlertEvent    `json:"alert,omitempty"`
+	// Confluence 多周期共振概要（订阅时指定了timeframes才有），始终按完整K线窗口计算，在某个周期的K线收盘时更新
+	Confluence *service.ConfluenceSummary `json:"confluence,omitempty"`
+	// Account 模拟交易账户快照（订阅了 subscribe_account 才推送）
+	Account *types.PaperAccountSnapshot `json:"account,omitempty"`
+}
+
+// wsSubscription 连接上的一个实时流订阅
+type wsSubscription struct {
+	key        service.StreamKey
+	indicators map[string]bool // 为空表示全部
+	legacy     bool            // 旧版连接：直接推送 RealtimeData
+	mode       string          // full 或 delta
+	timeframes []string        // 多周期共振的周期，为空表示不推送
+	handle     *service.Subscription
+	stop       chan struct{}
+	resync     chan struct{} // 请求重新发送快照
+	started    bool          // 转发是否已启动（只在读循环中访问）
+}
+
+// requestResync 请求转发goroutine重新发送快照（已有未处理的请求时忽略）
+func (s *wsSubscription) requestResync() {
+	select {
+	case s.resync <- struct{}{}:
+	default:
+	}
+}
+
+// info 订阅状态
+func (s *wsSubscription) info() wsSubscriptionInfo {
+	info := wsSubscriptionInfo{
+		Stream:     s.key.String(),
+		Symbol:     string(s.key.Symbol),
+		Interval:   s.key.Interval,
+		Mode:       s.mode,
+		Timeframes: s.timeframes,
+	}
+	stats := s.handle.Stats()
+	info.Stats = &stats
+	for name := range s.indicators {
+		info.Indicators = append(info.Indicators, name)
+	}
+	sort.Strings(info.Indicators)
+	return info
+}
+
+// wsSession 一个WebSocket连接的会话
+// 读循环处理客户端消息，写循环是连接唯一的写入者，每个订阅由独立的goroutine转发数据
+type wsSession struct {
+	conn   *websocket.Conn
+	hub    *service.RealtimeHub
+	policy service.SlowConsumerPolicy // 默认慢消费者策略
+	send   chan interface{}
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	subs   map[service.StreamKey]*wsSubscription
+	wg     sync.WaitGroup
+	// paper 模拟交易服务（未启用时为nil），account 表示是否推送账户快照
+	paper   *service.PaperTradingService
+	account atomic.Bool
+}
+
+// newWSSession 创建会话，paper为nil时不支持订阅模拟交易账户
+func newWSSession(conn *websocket.Conn, hub *service.RealtimeHub, paper *service.PaperTradingService, policy service.SlowConsumerPolicy) *wsSession {
+	return &wsSession{
+		conn:   conn,
+		hub:    hub,
+		paper:  paper,
+		policy: policy,
+		send:   make(chan interface{}, wsSendBuffer),
+		done:   make(chan struct{}),
+		subs:   make(map[service.StreamKey]*wsSubscription),
+	}
+}
+
+// close 结束会话（可重复调用）
+func (s *wsSession) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// closed 会话是否已结束
+func (s *wsSession) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueue 将消息交给写循环，会话结束时返回false
+func (s *wsSession) enqueue(msg interface{}) bool {
+	select {
+	case s.send <- msg:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// reply 回复ack或error
+func (s *wsSession) reply(req *wsRequest, streams []string, err error) {
+	if err != nil {
+		s.enqueue(wsMessage{Type: wsTypeError, ID: req.ID, Action: req.Action, Error: err.Error()})
+		return
+	}
+	s.enqueue(wsMessage{Type: wsTypeAck, ID: req.ID, Action: req.Action, Streams: streams})
+}
+
+// writeLoop 写循环：写出消息并定时发送ping
+func (s *wsSession) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer s.close()
+
+	for {
+		select {
+		case <-s.done:
+			// 写出已排队的消息（如订阅失败的错误）后再关闭
+			for {
+				select {
+				case msg := <-s.send:
+					s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+					if err := s.conn.WriteJSON(msg); err != nil {
+						return
+					}
+					continue
+				default:
+				}
+				break
+			}
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				log.Printf("WebSocket写入失败: %v", err)
+				return
+			}
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLoop 读循环：处理客户端消息，连接断开时返回
+func (s *wsSession) readLoop() {
+	defer s.close()
+
+	s.conn.SetReadLimit(wsMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket读取失败: %v", err)
+			}
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req wsRequest
+		if err := json.Unmarshal(message, &req); err != nil {
+			s.enqueue(wsMessage{Type: wsTypeError, Error: "消息格式无效: " + err.Error()})
+			continue
+		}
+		s.handle(&req)
+	}
+}
+
+// handle 处理一条客户端消息
+func (s *wsSession) handle(req *wsRequest) {
+	switch strings.ToLower(req.Action) {
+	case wsActionSubscribe:
+		subs, err := s.subscribeAll(req.requests(), false)
+		// 先回复ack，再开始推送快照
+		s.reply(req, streamNames(subs), err)
+		s.start(subs)
+	case wsActionUnsubscribe:
+		streams, err := s.unsubscribeAll(req.requests())
+		s.reply(req, streams, err)
+	case wsActionResync:
+		subs, err := s.lookupAll(req.requests())
+		s.reply(req, streamNames(subs), err)
+		for _, sub := range subs {
+			sub.requestResync()
+		}
+	case wsActionList:
+		s.enqueue(wsMessage{Type: wsTypeSubscriptions, ID: req.ID, Subscriptions: s.subscriptions()})
+	case wsActionPing:
+		s.enqueue(wsMessage{Type: wsTypePong, ID: req.ID})
+	case wsActionSubscribeAccount:
+		if s.paper == nil {
+			s.reply(req, nil, fmt.Errorf("模拟交易未启用"))
+			return
+		}
+		s.account.Store(true)
+		// 先回复ack，再发送当前快照
+		s.reply(req, nil, nil)
+		s.enqueue(wsMessage{Type: wsTypeAccount, Account: s.paper.Account()})
+	case wsActionUnsubscribeAccount:
+		s.account.Store(false)
+		s.reply(req, nil, nil)
+	default:
+		s.reply(req, nil, fmt.Errorf("未知的action: %s", req.Action))
+	}
+}
+
+// requests 返回消息中的所有订阅项
+func (r *wsRequest) requests() []wsStreamRequest {
+	reqs := r.Streams
+	if r.Symbol != "" || r.Interval != "" {
+		reqs = append([]wsStreamRequest{r.wsStreamRequest}, reqs...)
+	}
+	return reqs
+}
+
+// key 校验订阅项并返回实时流标识
+func (r wsStreamRequest) key() (service.StreamKey, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.Symbol))
+	if symbol == "" {
+		return service.StreamKey{}, fmt.Errorf("symbol不能为空")
+	}
+	if _, err := types.IntervalToMinutes(r.Interval); err != nil {
+		return service.StreamKey{}, err
+	}
+	return service.StreamKey{Symbol: types.Symbol(symbol), Interval: r.Interval}, nil
+}
+
+// mode 校验并返回推送模式
+func (r wsStreamRequest) mode() (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(r.Mode)); mode {
+	case "", wsModeFull:
+		return wsModeFull, nil
+	case wsModeDelta:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("未知的推送模式: %s", r.Mode)
+	}
+}
+
+// indicators 校验并返回指标集合，为空表示全部
+func (r wsStreamRequest) indicators() (map[string]bool, error) {
+	var indicators map[string]bool
+	for _, name := range r.Indicators {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !wsIndicatorFields[name] {
+			return nil, fmt.Errorf("未知的指标: %s", name)
+		}
+		if indicators == nil {
+			indicators = make(map[string]bool)
+		}
+		indicators[name] = true
+	}
+	return indicators, nil
+}
+
+// subscribeAll 订阅多个实时流，遇到错误时停止并返回已成功的部分
+// 返回的订阅需调用 start 开始推送
+func (s *wsSession) subscribeAll(reqs []wsStreamRequest, legacy bool) ([]*wsSubscription, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("缺少订阅项")
+	}
+
+	var subs []*wsSubscription
+	for _, r := range reqs {
+		key, err := r.key()
+		if err != nil {
+			return subs, err
+		}
+		indicators, err := r.indicators()
+		if err != nil {
+			return subs, err
+		}
+		mode, err := r.mode()
+		if err != nil {
+			return subs, err
+		}
+		var timeframes []string
+		if len(r.Timeframes) > 0 {
+			if timeframes, err = service.ParseTimeframes(key.Interval, r.Timeframes); err != nil {
+				return subs, err
+			}
+		}
+		policy := s.policy
+		if r.Policy != "" {
+			if policy, err = service.ParseSlowConsumerPolicy(r.Policy); err != nil {
+				return subs, err
+			}
+		}
+		sub, err := s.subscribe(key, indicators, mode, timeframes, policy, legacy)
+		if err != nil {
+			return subs, fmt.Errorf("订阅 %s 失败: %w", key, err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// subscribe 订阅单个实时流
+// 已订阅的实时流只更新指标集合、推送模式和共振周期（start 时重新发送快照）
+func (s *wsSession) subscribe(key service.StreamKey, indicators map[string]bool, mode string, timeframes []string, policy service.SlowConsumerPolicy, legacy bool) (*wsSubscription, error) {
+	s.mu.Lock()
+	if existing, ok := s.subs[key]; ok {
+		existing.indicators = indicators
+		existing.mode = mode
+		existing.timeframes = timeframes
+		s.mu.Unlock()
+		existing.handle.SetTimeframes(timeframes)
+		return existing, nil
+	}
+	if len(s.subs) >= wsMaxSubscription {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("超过单个连接的订阅上限 %d", wsMaxSubscription)
+	}
+	s.mu.Unlock()
+
+	// 启动实时流可能涉及网络请求，在锁外进行
+	handle, err := s.hub.Subscribe(key.Symbol, key.Interval, policy)
+	if err != nil {
+		return nil, err
+	}
+	handle.SetTimeframes(timeframes)
+
+	sub := &wsSubscription{
+		key:        key,
+		indicators: indicators,
+		mode:       mode,
+		timeframes: timeframes,
+		legacy:     legacy,
+		handle:     handle,
+		stop:       make(chan struct{}),
+		resync:     make(chan struct{}, 1),
+	}
+	s.mu.Lock()
+	if s.closed() {
+		s.mu.Unlock()
+		s.hub.Unsubscribe(handle)
+		return nil, fmt.Errorf("连接已关闭")
+	}
+	s.subs[key] = sub
+	s.mu.Unlock()
+	return sub, nil
+}
+
+// start 开始推送：新订阅启动转发，已在推送的订阅重新发送快照
+func (s *wsSession) start(subs []*wsSubscription) {
+	for _, sub := range subs {
+		if sub.started {
+			sub.requestResync()
+			continue
+		}
+		sub.started = true
+		s.wg.Add(1)
+		go s.forward(sub)
+	}
+}
+
+// unsubscribeAll 取消多个订阅
+func (s *wsSession) unsubscribeAll(reqs []wsStreamRequest) ([]string, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("缺少订阅项")
+	}
+
+	var streams []string
+	for _, r := range reqs {
+		key, err := r.key()
+		if err != nil {
+			return streams, err
+		}
+		if !s.unsubscribe(key) {
+			return streams, fmt.Errorf("未订阅 %s", key)
+		}
+		streams = append(streams, key.String())
+	}
+	return streams, nil
+}
+
+// lookupAll 查找已有的订阅，遇到未订阅的实时流时停止并返回已找到的部分
+func (s *wsSession) lookupAll(reqs []wsStreamRequest) ([]*wsSubscription, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("缺少订阅项")
+	}
+
+	var subs []*wsSubscription
+	for _, r := range reqs {
+		key, err := r.key()
+		if err != nil {
+			return subs, err
+		}
+		s.mu.Lock()
+		sub, ok := s.subs[key]
+		s.mu.Unlock()
+		if !ok {
+			return subs, fmt.Errorf("未订阅 %s", key)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// streamNames 返回订阅对应的实时流标识
+func streamNames(subs []*wsSubscription) []string {
+	names := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		names = append(names, sub.key.String())
+	}
+	return names
+}
+
+// unsubscribe 取消单个订阅，未订阅时返回false
+func (s *wsSession) unsubscribe(key service.StreamKey) bool {
+	s.mu.Lock()
+	sub, ok := s.subs[key]
+	delete(s.subs, key)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	close(sub.stop)
+	s.hub.Unsubscribe(sub.handle)
+	return true
+}
+
+// unsubscribeAllStreams 连接关闭时释放所有订阅
+func (s *wsSession) unsubscribeAllStreams() {
+	s.mu.Lock()
+	keys := make([]service.StreamKey, 0, len(s.subs))
+	for key := range s.subs {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	for _, key := range keys {
+		s.unsubscribe(key)
+	}
+	s.wg.Wait()
+}
+
+// subscriptions 当前所有订阅
+func (s *wsSession) subscriptions() []wsSubscriptionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]wsSubscriptionInfo, 0, len(s.subs))
+	for _, sub := range s.subs {
+		infos = append(infos, sub.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Stream < infos[j].Stream })
+	return infos
+}
+
+// subscribedSymbol 是否订阅了指定symbol的任一实时流
+func (s *wsSession) subscribedSymbol(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.subs {
+		if string(key.Symbol) == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// wsStreamState 单个订阅的推送状态（只在转发goroutine中访问）
+type wsStreamState struct {
+	seq  uint64                // 最近一次推送的序号
+	last *service.RealtimeData // 最近一次推送的数据，nil表示下一次推送快照
+}
+
+// forward 将实时流数据转发给写循环：先发送快照，之后发送更新（delta模式下只发送变化的尾部）
+func (s *wsSession) forward(sub *wsSubscription) {
+	defer s.wg.Done()
+
+	state := &wsStreamState{}
+	snapshot := func() bool {
+		// 实时流尚未推送过时，由首次推送作为快照
+		data := s.hub.Latest(sub.key.Symbol, sub.key.Interval)
+		if data == nil {
+			state.last = nil
+			return true
+		}
+		return s.enqueue(s.frame(sub, state, data, true))
+	}
+
+	if !snapshot() {
+		return
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-sub.stop:
+			return
+		case <-sub.resync:
+			if !snapshot() {
+				return
+			}
+		case data, ok := <-sub.handle.C():
+			if !ok {
+				if sub.handle.Disconnected() {
+					// 慢消费者策略为disconnect：通知客户端后关闭整个连接
+					log.Printf("WebSocket订阅 %s 读取过慢，断开连接", sub.key)
+					s.enqueue(wsMessage{Type: wsTypeError, Stream: sub.key.String(), Error: "读取过慢，连接已断开"})
+					s.close()
+				}
+				return
+			}
+			if !s.enqueue(s.frame(sub, state, data, false)) {
+				return
+			}
+		}
+	}
+}
+
+// frame 构造推送消息：旧版连接直接推送 RealtimeData，否则包装为带序号的 snapshot/update/delta 消息
+// delta模式下，与上次推送属于同一Epoch且上次的最新K线仍在窗口内时只发送变化的尾部，否则发送快照
+func (s *wsSession) frame(sub *wsSubscription, state *wsStreamState, data *service.RealtimeData, snapshot bool) interface{} {
+	if sub.legacy {
+		return data
+	}
+
+	s.mu.Lock()
+	indicators, mode, timeframes := sub.indicators, sub.mode, sub.timeframes
+	s.mu.Unlock()
+
+	state.seq++
+	msg := wsMessage{Type: wsTypeUpdate, Stream: sub.key.String(), Seq: state.seq}
+	payload := data
+	switch {
+	case snapshot || state.last == nil:
+		msg.Type = wsTypeSnapshot
+	case mode == wsModeDelta:
+		msg.Type = wsTypeSnapshot
+		if data.Epoch == state.last.Epoch && len(state.last.Klines) > 0 {
+			if n, ok := data.ChangedSince(state.last.Klines[0].Time); ok {
+				msg.Type = wsTypeDelta
+				payload = data.Tail(n)
+			}
+		}
+	}
+	if mode == wsModeDelta {
+		msg.Length = len(data.Klines)
+	}
+	state.last = data
+
+	// 多周期共振由实时流在K线收盘时计算并缓存，这里只选取订阅的周期
+	if len(timeframes) > 0 && data.Confluence != nil {
+		msg.Confluence = data.Confluence.Select(timeframes)
+	}
+
+	msg.Data = payload
+	if len(indicators) > 0 {
+		filtered, err := filterIndicators(payload, indicators)
+		if err != nil {
+			log.Printf("过滤 %s 推送字段失败: %v", sub.key, err)
+		} else {
+			msg.Data = filtered
+		}
+	}
+	return msg
+}
+
+// filterIndicators 只保留订阅的指标字段，其他基础字段（K线、价格、连接状态等）始终保留
+func filterIndicators(data *service.RealtimeData, indicators map[string]bool) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for name := range wsIndicatorFields {
+		if !indicators[name] {
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
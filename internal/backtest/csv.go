@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// csvTimeLayouts 支持的时间格式（另支持毫秒/秒级Unix时间戳）
+var csvTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006.01.02 15:04:05", // MT5导出格式
+	"2006.01.02 15:04",
+	"2006-01-02",
+}
+
+// LoadKlinesCSV 从CSV文件加载K线
+// 列顺序：time,open,high,low,close[,volume]，首行为表头时自动跳过
+// 返回的K线按时间从旧到新排序
+func LoadKlinesCSV(path, symbol string) ([]types.Kline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开K线文件失败: %w", err)
+	}
+	defer f.Close()
+	return ReadKlinesCSV(f, symbol)
+}
+
+// ReadKlinesCSV 从CSV数据读取K线，格式同 LoadKlinesCSV
+func ReadKlinesCSV(r io.Reader, symbol string) ([]types.Kline, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var klines []types.Kline
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取CSV第%d行失败: %w", line, err)
+		}
+		if len(record) < 5 {
+			return nil, fmt.Errorf("CSV第%d行列数不足: %d", line, len(record))
+		}
+
+		values := make([]float64, 5)
+		var parseErr error
+		for i := 1; i < len(record) && i <= 5; i++ {
+			if values[i-1], parseErr = strconv.ParseFloat(strings.TrimSpace(record[i]), 64); parseErr != nil {
+				break
+			}
+		}
+		if parseErr != nil {
+			if line == 1 {
+				continue // 表头
+			}
+			return nil, fmt.Errorf("CSV第%d行数值无效: %w", line, parseErr)
+		}
+
+		ts, err := ParseCSVTime(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("CSV第%d行: %w", line, err)
+		}
+
+		kline := types.Kline{
+			Symbol:    symbol,
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Timestamp: ts,
+		}
+		if len(record) >= 6 {
+			kline.Volume = values[4]
+		}
+		klines = append(klines, kline)
+	}
+
+	sort.SliceStable(klines, func(i, j int) bool {
+		return klines[i].Timestamp.Before(klines[j].Timestamp)
+	})
+	return klines, nil
+}
+
+// ParseCSVTime 解析CSV时间列（毫秒/秒级Unix时间戳或 csvTimeLayouts 中的格式）
+func ParseCSVTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// 大于1e12视为毫秒时间戳
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", s)
+}
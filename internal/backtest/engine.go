@@ -0,0 +1,244 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// Config 回测配置
+type Config struct {
+	Symbol         string
+	Interval       string
+	Indicators     types.IndicatorConfig // 该symbol的指标配置（周期基于小时，按interval缩放）
+	InitialCapital float64               // 初始资金
+	FeeRate        float64               // 手续费率（按成交额收取，如0.001表示0.1%）
+	SlippageBps    float64               // 滑点（基点，1bp=0.01%），买入价上浮、卖出价下浮
+	CloseAtEnd     bool                  // 回测结束时是否按最后收盘价平掉剩余仓位
+}
+
+// Run 执行回测
+// klines 为从旧到新的已收盘K线；策略在每根K线收盘后产生信号，于下一根K线开盘价成交
+func Run(cfg Config, klines []types.Kline, strategy Strategy) (*Report, error) {
+	if len(klines) < 2 {
+		return nil, fmt.Errorf("K线数量不足: %d", len(klines))
+	}
+	if cfg.InitialCapital <= 0 {
+		return nil, fmt.Errorf("初始资金必须大于0")
+	}
+	if cfg.FeeRate < 0 || cfg.SlippageBps < 0 {
+		return nil, fmt.Errorf("手续费率和滑点不能为负数")
+	}
+	if _, err := types.IntervalToMinutes(cfg.Interval); err != nil {
+		return nil, err
+	}
+
+	b := &broker{cfg: cfg, cash: cfg.InitialCapital}
+	builder := newSnapshotBuilder(cfg.Indicators, cfg.Interval)
+	history := make([]*Snapshot, 0, len(klines))
+	equity := make([]EquityPoint, 0, len(klines))
+	var pending *Signal
+
+	for i, k := range klines {
+		// 上一根K线的信号在当前K线开盘价成交
+		if pending != nil {
+			b.execute(*pending, k, i)
+			pending = nil
+		}
+
+		snap := builder.next(k)
+		history = append(history, snap)
+		equity = append(equity, EquityPoint{Time: k.Timestamp, Equity: b.equity(k.Close)})
+
+		if i == len(klines)-1 {
+			break
+		}
+		signal := strategy.OnBar(history, b.position)
+		if signal.Action != "" && signal.Action != ActionHold {
+			pending = &signal
+		}
+	}
+
+	last := klines[len(klines)-1]
+	if cfg.CloseAtEnd && !b.position.Flat() {
+		b.closePosition(last.Close, last.Timestamp, len(klines)-1, "回测结束")
+		equity[len(equity)-1].Equity = b.cash
+	}
+
+	return buildReport(cfg, strategy.Name(), klines, equity, b), nil
+}
+
+// broker 模拟成交（单一仓位，全部以现金结算）
+type broker struct {
+	cfg      Config
+	cash     float64
+	position Position
+	entryAt  time.Time
+	entryFee float64
+	reason   string
+	trades   []Trade
+	fees     float64
+}
+
+// equity 按指定价格计算当前权益
+func (b *broker) equity(price float64) float64 {
+	switch b.position.Side {
+	case ActionLong:
+		return b.cash + b.position.Quantity*price
+	case ActionShort:
+		return b.cash - b.position.Quantity*price
+	}
+	return b.cash
+}
+
+// fillPrice 计算含滑点的成交价
+func (b *broker) fillPrice(price float64, buy bool) float64 {
+	slip := b.cfg.SlippageBps / 10000
+	if buy {
+		return price * (1 + slip)
+	}
+	return price * (1 - slip)
+}
+
+// execute 在K线k的开盘价执行信号
+func (b *broker) execute(signal Signal, k types.Kline, index int) {
+	switch signal.Action {
+	case ActionClose:
+		if !b.position.Flat() {
+			b.closePosition(k.Open, k.Timestamp, index, signal.Reason)
+		}
+	case ActionLong, ActionShort:
+		if b.position.Side == signal.Action {
+			return
+		}
+		if !b.position.Flat() {
+			b.closePosition(k.Open, k.Timestamp, index, signal.Reason)
+		}
+		b.openPosition(signal, k.Open, k.Timestamp, index)
+	}
+}
+
+// openPosition 开仓，仓位按当前权益的比例计算（手续费从现金中扣除）
+func (b *broker) openPosition(signal Signal, price float64, at time.Time, index int) {
+	size := signal.Size
+	if size <= 0 || size > 1 {
+		size = 1
+	}
+	buy := signal.Action == ActionLong
+	fill := b.fillPrice(price, buy)
+	notional := b.cash * size / (1 + b.cfg.FeeRate)
+	if notional <= 0 || fill <= 0 {
+		return
+	}
+
+	qty := notional / fill
+	fee := notional * b.cfg.FeeRate
+	if buy {
+		b.cash -= notional + fee
+	} else {
+		b.cash += notional - fee
+	}
+	b.fees += fee
+	b.entryFee = fee
+	b.entryAt = at
+	b.reason = signal.Reason
+	b.position = Position{Side: signal.Action, Quantity: qty, EntryPrice: fill, EntryIndex: index}
+}
+
+// closePosition 平仓并记录交易
+func (b *broker) closePosition(price float64, at time.Time, index int, reason string) {
+	pos := b.position
+	long := pos.Side == ActionLong
+	fill := b.fillPrice(price, !long)
+	notional := pos.Quantity * fill
+	fee := notional * b.cfg.FeeRate
+
+	var gross float64
+	if long {
+		b.cash += notional - fee
+		gross = (fill - pos.EntryPrice) * pos.Quantity
+	} else {
+		b.cash -= notional + fee
+		gross = (pos.EntryPrice - fill) * pos.Quantity
+	}
+	b.fees += fee
+
+	pnl := gross - b.entryFee - fee
+	cost := pos.EntryPrice * pos.Quantity
+	ret := 0.0
+	if cost > 0 {
+		ret = pnl / cost
+	}
+	b.trades = append(b.trades, Trade{
+		Side:        string(pos.Side),
+		EntryTime:   b.entryAt,
+		EntryPrice:  pos.EntryPrice,
+		ExitTime:    at,
+		ExitPrice:   fill,
+		Quantity:    pos.Quantity,
+		Bars:        index - pos.EntryIndex,
+		Fee:         b.entryFee + fee,
+		PnL:         pnl,
+		Return:      ret,
+		EntryReason: b.reason,
+		ExitReason:  reason,
+	})
+	b.position = Position{}
+	b.entryFee = 0
+	b.reason = ""
+}
+
+// maxDrawdown 计算最大回撤（比例）
+func maxDrawdown(equity []EquityPoint) float64 {
+	peak, maxDD := 0.0, 0.0
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - p.Equity) / peak; dd > maxDD {
+				maxDD = dd
+			}
+		}
+	}
+	return maxDD
+}
+
+// sharpeRatio 根据逐根K线收益率计算年化夏普比率（无风险利率按0）
+func sharpeRatio(equity []EquityPoint, interval string) float64 {
+	minutes, err := types.IntervalToMinutes(interval)
+	if err != nil || minutes <= 0 || len(equity) < 3 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity <= 0 {
+			continue
+		}
+		returns = append(returns, equity[i].Equity/equity[i-1].Equity-1)
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	barsPerYear := 365 * 24 * 60 / float64(minutes)
+	return mean / std * math.Sqrt(barsPerYear)
+}
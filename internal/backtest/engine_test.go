@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// scriptStrategy 在指定K线（从0开始）收盘后产生固定信号的策略
+type scriptStrategy struct {
+	signals map[int]Action
+}
+
+func (s *scriptStrategy) Name() string {
+	return "script"
+}
+
+func (s *scriptStrategy) OnBar(history []*Snapshot, position Position) Signal {
+	if action, ok := s.signals[len(history)-1]; ok {
+		return Signal{Action: action, Reason: string(action)}
+	}
+	return Hold
+}
+
+// testKlines 生成开盘价与收盘价不同的1小时K线，第i根开盘价为 100+10i，收盘价为开盘价+5
+func testKlines(n int) []types.Kline {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]types.Kline, n)
+	for i := range klines {
+		open := 100 + 10*float64(i)
+		klines[i] = types.Kline{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Open:      open,
+			High:      open + 8,
+			Low:       open - 3,
+			Close:     open + 5,
+		}
+	}
+	return klines
+}
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+		t.Fatalf("%s %.10f，期望 %.10f", name, got, want)
+	}
+}
+
+func TestRunFillsAtNextOpen(t *testing.T) {
+	const fee, slip = 0.001, 0.001 // 手续费0.1%，滑点10个基点
+	cfg := Config{Symbol: "BTCUSDT", Interval: "1h", InitialCapital: 1000, FeeRate: fee, SlippageBps: 10}
+	klines := testKlines(6)
+
+	// 第1根K线收盘后开多，第3根K线收盘后平仓：分别在第2、4根K线的开盘价成交
+	report, err := Run(cfg, klines, &scriptStrategy{signals: map[int]Action{1: ActionLong, 3: ActionClose}})
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+	if len(report.Trades) != 1 {
+		t.Fatalf("交易数量 %d，期望1", len(report.Trades))
+	}
+
+	entry := klines[2].Open * (1 + slip)
+	notional := 1000 / (1 + fee)
+	qty := notional / entry
+	entryFee := notional * fee
+	exit := klines[4].Open * (1 - slip)
+	exitFee := qty * exit * fee
+
+	trade := report.Trades[0]
+	if trade.Side != string(ActionLong) || !trade.EntryTime.Equal(klines[2].Timestamp) || !trade.ExitTime.Equal(klines[4].Timestamp) || trade.Bars != 2 {
+		t.Fatalf("交易明细不正确: %+v", trade)
+	}
+	assertClose(t, "开仓价", trade.EntryPrice, entry)
+	assertClose(t, "平仓价", trade.ExitPrice, exit)
+	assertClose(t, "数量", trade.Quantity, qty)
+	assertClose(t, "手续费", trade.Fee, entryFee+exitFee)
+	assertClose(t, "盈亏", trade.PnL, (exit-entry)*qty-entryFee-exitFee)
+	assertClose(t, "总手续费", report.TotalFees, entryFee+exitFee)
+
+	// 开仓前权益不变，持仓期间按收盘价计算，平仓后为现金
+	cash := qty*exit - exitFee
+	wantEquity := []float64{1000, 1000, qty * klines[2].Close, qty * klines[3].Close, cash, cash}
+	for i, p := range report.EquityCurve {
+		assertClose(t, klines[i].Timestamp.Format("15:04")+" 权益", p.Equity, wantEquity[i])
+	}
+	assertClose(t, "最终权益", report.FinalEquity, cash)
+	assertClose(t, "总收益", report.TotalReturn, cash/1000-1)
+	if report.WinRate != 1 || report.OpenPosition != nil {
+		t.Fatalf("胜率 %v，未平仓位 %+v", report.WinRate, report.OpenPosition)
+	}
+}
+
+func TestRunShortAndReverse(t *testing.T) {
+	const slip = 0.0005
+	cfg := Config{Interval: "1h", InitialCapital: 1000, SlippageBps: 5}
+	klines := testKlines(6)
+
+	// 第0根K线收盘后开空，第2根收盘后反手开多：空单在第3根开盘价买入平仓，随即开多
+	report, err := Run(cfg, klines, &scriptStrategy{signals: map[int]Action{0: ActionShort, 2: ActionLong}})
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+	if len(report.Trades) != 1 || report.OpenPosition == nil || report.OpenPosition.Side != ActionLong {
+		t.Fatalf("交易 %+v，未平仓位 %+v", report.Trades, report.OpenPosition)
+	}
+
+	short := report.Trades[0]
+	entry, exit := klines[1].Open*(1-slip), klines[3].Open*(1+slip)
+	assertClose(t, "空单开仓价", short.EntryPrice, entry)
+	assertClose(t, "空单平仓价", short.ExitPrice, exit)
+	assertClose(t, "空单盈亏", short.PnL, (entry-exit)*short.Quantity)
+	if short.PnL >= 0 || report.WinRate != 0 {
+		t.Fatalf("价格上涨时空单应亏损: %+v", short)
+	}
+
+	long := report.OpenPosition
+	assertClose(t, "多单开仓价", long.EntryPrice, klines[3].Open*(1+slip))
+	if long.EntryIndex != 3 {
+		t.Fatalf("多单开仓K线 %d，期望3", long.EntryIndex)
+	}
+}
+
+func TestRunCloseAtEnd(t *testing.T) {
+	klines := testKlines(4)
+	// 最后一根K线收盘后的信号没有下一根K线成交，不会执行
+	strategy := &scriptStrategy{signals: map[int]Action{0: ActionLong, 3: ActionClose}}
+
+	report, err := Run(Config{Interval: "1h", InitialCapital: 1000}, klines, strategy)
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+	if len(report.Trades) != 0 || report.OpenPosition == nil {
+		t.Fatalf("不平仓时应保留仓位: 交易 %+v，仓位 %+v", report.Trades, report.OpenPosition)
+	}
+
+	report, err = Run(Config{Interval: "1h", InitialCapital: 1000, CloseAtEnd: true}, klines, strategy)
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+	if len(report.Trades) != 1 || report.OpenPosition != nil {
+		t.Fatalf("回测结束时应平仓: 交易 %+v，仓位 %+v", report.Trades, report.OpenPosition)
+	}
+	trade := report.Trades[0]
+	if trade.ExitPrice != klines[3].Close || trade.ExitReason != "回测结束" {
+		t.Fatalf("应按最后收盘价平仓: %+v", trade)
+	}
+	assertClose(t, "最终权益", report.FinalEquity, 1000/klines[1].Open*klines[3].Close)
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	klines := testKlines(3)
+	strategy := &scriptStrategy{}
+	tests := []struct {
+		name   string
+		cfg    Config
+		klines []types.Kline
+	}{
+		{name: "K线不足", cfg: Config{Interval: "1h", InitialCapital: 1000}, klines: klines[:1]},
+		{name: "初始资金为0", cfg: Config{Interval: "1h"}, klines: klines},
+		{name: "手续费为负", cfg: Config{Interval: "1h", InitialCapital: 1000, FeeRate: -0.1}, klines: klines},
+		{name: "滑点为负", cfg: Config{Interval: "1h", InitialCapital: 1000, SlippageBps: -1}, klines: klines},
+		{name: "周期无效", cfg: Config{Interval: "7x", InitialCapital: 1000}, klines: klines},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(tt.cfg, tt.klines, strategy); err == nil {
+				t.Fatalf("应返回错误")
+			}
+		})
+	}
+}
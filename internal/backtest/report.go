@@ -0,0 +1,157 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// EquityPoint 权益曲线上的一个点（K线收盘时的权益）
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Trade 一笔已平仓交易
+type Trade struct {
+	Side        string    `json:"side"`
+	EntryTime   time.Time `json:"entry_time"`
+	EntryPrice  float64   `json:"entry_price"`
+	ExitTime    time.Time `json:"exit_time"`
+	ExitPrice   float64   `json:"exit_price"`
+	Quantity    float64   `json:"quantity"`
+	Bars        int       `json:"bars"` // 持仓K线数
+	Fee         float64   `json:"fee"`  // 开平仓手续费合计
+	PnL         float64   `json:"pnl"`  // 扣除手续费后的盈亏
+	Return      float64   `json:"return"`
+	EntryReason string    `json:"entry_reason,omitempty"`
+	ExitReason  string    `json:"exit_reason,omitempty"`
+}
+
+// Report 回测报告
+type Report struct {
+	Symbol         string        `json:"symbol"`
+	Interval       string        `json:"interval"`
+	Strategy       string        `json:"strategy"`
+	Start          time.Time     `json:"start"`
+	End            time.Time     `json:"end"`
+	Bars           int           `json:"bars"`
+	InitialCapital float64       `json:"initial_capital"`
+	FinalEquity    float64       `json:"final_equity"`
+	TotalReturn    float64       `json:"total_return"`
+	MaxDrawdown    float64       `json:"max_drawdown"`
+	SharpeRatio    float64       `json:"sharpe_ratio"`
+	WinRate        float64       `json:"win_rate"`
+	TradeCount     int           `json:"trade_count"`
+	TotalFees      float64       `json:"total_fees"`
+	OpenPosition   *Position     `json:"open_position,omitempty"` // 回测结束时未平的仓位
+	Trades         []Trade       `json:"trades"`
+	EquityCurve    []EquityPoint `json:"equity_curve"`
+}
+
+// buildReport 汇总回测结果
+func buildReport(cfg Config, strategy string, klines []types.Kline, equity []EquityPoint, b *broker) *Report {
+	final := equity[len(equity)-1].Equity
+	report := &Report{
+		Symbol:         cfg.Symbol,
+		Interval:       cfg.Interval,
+		Strategy:       strategy,
+		Start:          klines[0].Timestamp,
+		End:            klines[len(klines)-1].Timestamp,
+		Bars:           len(klines),
+		InitialCapital: cfg.InitialCapital,
+		FinalEquity:    final,
+		TotalReturn:    final/cfg.InitialCapital - 1,
+		MaxDrawdown:    maxDrawdown(equity),
+		SharpeRatio:    sharpeRatio(equity, cfg.Interval),
+		TradeCount:     len(b.trades),
+		TotalFees:      b.fees,
+		Trades:         b.trades,
+		EquityCurve:    equity,
+	}
+	if report.Trades == nil {
+		report.Trades = []Trade{}
+	}
+	if !b.position.Flat() {
+		pos := b.position
+		report.OpenPosition = &pos
+	}
+
+	wins := 0
+	for _, t := range b.trades {
+		if t.PnL > 0 {
+			wins++
+		}
+	}
+	if len(b.trades) > 0 {
+		report.WinRate = float64(wins) / float64(len(b.trades))
+	}
+	return report
+}
+
+// WriteFiles 将报告写入目录：report.json、trades.csv、equity.csv
+func (r *Report) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化报告失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.json"), data, 0644); err != nil {
+		return fmt.Errorf("写入报告失败: %w", err)
+	}
+
+	trades := [][]string{{"side", "entry_time", "entry_price", "exit_time", "exit_price", "quantity", "bars", "fee", "pnl", "return", "entry_reason", "exit_reason"}}
+	for _, t := range r.Trades {
+		trades = append(trades, []string{
+			t.Side,
+			t.EntryTime.UTC().Format(time.RFC3339),
+			formatFloat(t.EntryPrice),
+			t.ExitTime.UTC().Format(time.RFC3339),
+			formatFloat(t.ExitPrice),
+			formatFloat(t.Quantity),
+			strconv.Itoa(t.Bars),
+			formatFloat(t.Fee),
+			formatFloat(t.PnL),
+			formatFloat(t.Return),
+			t.EntryReason,
+			t.ExitReason,
+		})
+	}
+	if err := writeCSV(filepath.Join(dir, "trades.csv"), trades); err != nil {
+		return err
+	}
+
+	curve := [][]string{{"time", "equity"}}
+	for _, p := range r.EquityCurve {
+		curve = append(curve, []string{p.Time.UTC().Format(time.RFC3339), formatFloat(p.Equity)})
+	}
+	return writeCSV(filepath.Join(dir, "equity.csv"), curve)
+}
+
+// writeCSV 写入CSV文件
+func writeCSV(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
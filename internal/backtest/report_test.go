@@ -0,0 +1,93 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// equityCurve 由权益值生成1小时间隔的权益曲线
+func equityCurve(values ...float64) []EquityPoint {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	curve := make([]EquityPoint, len(values))
+	for i, v := range values {
+		curve[i] = EquityPoint{Time: start.Add(time.Duration(i) * time.Hour), Equity: v}
+	}
+	return curve
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		equity []float64
+		want   float64
+	}{
+		{name: "空曲线", want: 0},
+		{name: "单调上涨", equity: []float64{100, 110, 120}, want: 0},
+		// 120 -> 90 回撤25%，之后 130 -> 104 回撤20%，取最大值
+		{name: "多次回撤取最大", equity: []float64{100, 120, 90, 130, 104}, want: 0.25},
+		{name: "从初始值开始下跌", equity: []float64{100, 80, 60, 70}, want: 0.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertClose(t, "最大回撤", maxDrawdown(equityCurve(tt.equity...)), tt.want)
+		})
+	}
+}
+
+func TestSharpeRatio(t *testing.T) {
+	// 收益率 +10%、-10%、+10%：均值 1/30，样本标准差 1/sqrt(75)
+	curve := equityCurve(100, 110, 99, 108.9)
+	perBar := math.Sqrt(75) / 30
+	tests := []struct {
+		name     string
+		equity   []EquityPoint
+		interval string
+		want     float64
+	}{
+		{name: "1小时按每年8760根年化", equity: curve, interval: "1h", want: perBar * math.Sqrt(365*24)},
+		{name: "4小时按每年2190根年化", equity: curve, interval: "4h", want: perBar * math.Sqrt(365*6)},
+		{name: "权益不变", equity: equityCurve(100, 100, 100, 100), interval: "1h", want: 0},
+		{name: "数据点不足", equity: equityCurve(100, 110), interval: "1h", want: 0},
+		{name: "周期无效", equity: curve, interval: "7x", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertClose(t, "夏普比率", sharpeRatio(tt.equity, tt.interval), tt.want)
+		})
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	klines := testKlines(5)
+	b := &broker{
+		fees: 3,
+		trades: []Trade{
+			{PnL: 10},
+			{PnL: -4},
+			{PnL: 0}, // 盈亏为0不算盈利
+			{PnL: 2},
+		},
+		position: Position{Side: ActionShort, Quantity: 1, EntryPrice: 140, EntryIndex: 4},
+	}
+	cfg := Config{Symbol: "BTCUSDT", Interval: "1h", InitialCapital: 100}
+	report := buildReport(cfg, "script", klines, equityCurve(100, 120, 90, 130, 104), b)
+
+	if report.Bars != 5 || !report.Start.Equal(klines[0].Timestamp) || !report.End.Equal(klines[4].Timestamp) {
+		t.Fatalf("K线范围不正确: %+v", report)
+	}
+	if report.TradeCount != 4 || report.WinRate != 0.5 || report.TotalFees != 3 {
+		t.Fatalf("交易统计不正确: 数量 %d，胜率 %v，手续费 %v", report.TradeCount, report.WinRate, report.TotalFees)
+	}
+	assertClose(t, "总收益", report.TotalReturn, 0.04)
+	assertClose(t, "最大回撤", report.MaxDrawdown, 0.25)
+	if report.OpenPosition == nil || *report.OpenPosition != b.position {
+		t.Fatalf("未平仓位 %+v", report.OpenPosition)
+	}
+
+	// 没有交易时交易明细为空数组（JSON输出[]而不是null）
+	empty := buildReport(cfg, "script", klines, equityCurve(100, 100), &broker{})
+	if empty.Trades == nil || empty.WinRate != 0 || empty.OpenPosition != nil {
+		t.Fatalf("空报告不正确: %+v", empty)
+	}
+}
@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"log"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// MACDPoint 单根K线的MACD值
+type MACDPoint struct {
+	Line      float64 `json:"line"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
+}
+
+// BandPoint 单根K线的通道值（布林线、包络线）
+type BandPoint struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+	Zone   int     `json:"zone"` // 收盘价所在的分区号（-10到+10，0为中轨）
+}
+
+// Snapshot 某根已收盘K线的指标快照（key规则与实时数据一致）
+type Snapshot struct {
+	Index     int                  `json:"index"`
+	Kline     types.Kline          `json:"kline"`
+	Price     float64              `json:"price"` // (H+L+C)/3
+	CCI       map[string]float64   `json:"cci"`
+	RSI       map[string]float64   `json:"rsi"`
+	MACD      map[string]MACDPoint `json:"macd"`
+	Bollinger BandPoint            `json:"bollinger"`
+	Envelope  BandPoint            `json:"envelope"`
+	// Custom 内置字段之外的指标（key 为 "指标名称_实例key"，值为 输出序列名称 -> 值）
+	// 包括其他注册表指标，以及布林线、包络线除第一个之外的实例
+	Custom     map[string]map[string]float64 `json:"custom,omitempty"`
+	Volatility float64                       `json:"volatility"` // 5天平均波动价格值（不包括当前日）
+	Ready      bool                          `json:"ready"`      // 所有指标是否都已有足够数据
+}
+
+// snapshotSlot 快照计算器中的一个指标实例
+type snapshotSlot struct {
+	instance *indicators.Instance
+	params   indicators.Params // 按K线周期缩放后的参数
+	stream   indicators.Stream // 不支持增量计算的指标为nil，每根K线批量计算
+}
+
+// snapshotBuilder 逐根K线计算指标快照
+// 与实时服务的指标引擎一样按注册表创建指标实例，支持增量计算的指标使用其增量流
+type snapshotBuilder struct {
+	slots      []*snapshotSlot
+	first      map[string]string // 指标名称 -> 配置中第一个实例的key
+	klines     []types.Kline     // 已输入的K线（从旧到新），仅在有不支持增量计算的指标时保存
+	volatility *indicators.VolatilityTracker
+	count      int
+}
+
+// newSnapshotBuilder 根据配置创建快照计算器（周期参数按K线周期缩放，与实时服务一致）
+func newSnapshotBuilder(config types.IndicatorConfig, interval string) *snapshotBuilder {
+	b := &snapshotBuilder{
+		first:      make(map[string]string),
+		volatility: indicators.NewVolatilityTracker(5),
+	}
+
+	seen := make(map[string]bool, len(config.Indicators))
+	for _, cfg := range config.Indicators {
+		instance, err := indicators.ResolveInstance(cfg)
+		if err != nil {
+			log.Printf("指标 %s 配置无效，已跳过: %v", cfg.Type, err)
+			continue
+		}
+		id := cfg.Type + "_" + instance.Key
+		if seen[id] {
+			log.Printf("指标 %s 的key %s 重复，已跳过", cfg.Type, instance.Key)
+			continue
+		}
+		seen[id] = true
+
+		params, err := indicators.ScaleParams(instance.Indicator, instance.Params, interval)
+		if err != nil {
+			log.Printf("指标 %s %v, 使用原值", cfg.Type, err)
+		}
+		slot := &snapshotSlot{instance: instance, params: params}
+		if streamer, ok := instance.Indicator.(indicators.Streamer); ok {
+			slot.stream = streamer.NewStream(params)
+		}
+		b.slots = append(b.slots, slot)
+
+		if _, ok := b.first[instance.Indicator.Name()]; !ok {
+			b.first[instance.Indicator.Name()] = instance.Key
+		}
+	}
+	return b
+}
+
+// next 输入下一根已收盘K线，返回其指标快照
+func (b *snapshotBuilder) next(k types.Kline) *Snapshot {
+	price := (k.High + k.Low + k.Close) * (1.0 / 3.0)
+	snap := &Snapshot{
+		Index: b.count,
+		Kline: k,
+		Price: price,
+		CCI:   make(map[string]float64),
+		RSI:   make(map[string]float64),
+		MACD:  make(map[string]MACDPoint),
+		Ready: true,
+	}
+	b.count++
+
+	var input *indicators.Input
+	for _, slot := range b.slots {
+		var values map[string]float64
+		if slot.stream != nil {
+			slot.stream.Append(price)
+			values = indicators.LastValues(slot.stream)
+		} else {
+			if input == nil {
+				b.klines = append(b.klines, k)
+				in := indicators.NewInputFromKlines(b.klines)
+				input = &in
+			}
+			if series, err := slot.instance.Indicator.Compute(*input, slot.params); err == nil {
+				values = indicators.Latest(series)
+			}
+		}
+		// 数据不足时输出为nil，内置字段取0
+		snap.Ready = snap.Ready && values != nil
+
+		kind, key := slot.instance.Indicator.Name(), slot.instance.Key
+		switch {
+		case kind == indicators.NameCCI:
+			snap.CCI[key] = values["cci"]
+		case kind == indicators.NameRSI:
+			snap.RSI[key] = values["rsi"]
+		case kind == indicators.NameMACD:
+			snap.MACD[key] = MACDPoint{Line: values["macd_line"], Signal: values["signal_line"], Histogram: values["histogram"]}
+		case kind == indicators.NameBollinger && key == b.first[kind]:
+			snap.Bollinger = bandPoint(k.Close, values)
+		case kind == indicators.NameEnvelope && key == b.first[kind]:
+			snap.Envelope = bandPoint(k.Close, values)
+		case values != nil:
+			if snap.Custom == nil {
+				snap.Custom = make(map[string]map[string]float64)
+			}
+			snap.Custom[kind+"_"+key] = values
+		}
+	}
+
+	snap.Volatility = b.volatility.Add(k)
+	return snap
+}
+
+// bandPoint 由通道类指标的输出计算通道值和收盘价所在的分区号
+func bandPoint(close float64, values map[string]float64) BandPoint {
+	upper, middle, lower := values["upper"], values["middle"], values["lower"]
+	return BandPoint{Upper: upper, Middle: middle, Lower: lower, Zone: indicators.Zone(close, middle, upper, lower)}
+}
@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// rangeSumIndicator 不支持增量计算的测试指标：最近period根K线的 最高价-最低价 之和
+type rangeSumIndicator struct{}
+
+func (rangeSumIndicator) Name() string        { return "test_range_sum" }
+func (rangeSumIndicator) Description() string { return "测试指标" }
+func (rangeSumIndicator) Outputs() []string   { return []string{"sum"} }
+func (rangeSumIndicator) Params() []indicators.ParamSpec {
+	return []indicators.ParamSpec{{Name: "period", Type: indicators.ParamInt, Default: 2, Min: 1, Scalable: true}}
+}
+func (rangeSumIndicator) WarmUp(p indicators.Params) int { return p.Int("period") }
+
+func (i rangeSumIndicator) Compute(in indicators.Input, p indicators.Params) (map[string][]float64, error) {
+	period := p.Int("period")
+	if len(in.High) < period {
+		return nil, fmt.Errorf("数据不足")
+	}
+	sum := make([]float64, len(in.High)-period+1)
+	for k := range sum {
+		for j := 0; j < period; j++ {
+			sum[k] += in.High[k+j] - in.Low[k+j]
+		}
+	}
+	return map[string][]float64{"sum": sum}, nil
+}
+
+// waveKlines 生成价格上下波动的30分钟K线
+func waveKlines(n int) []types.Kline {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]types.Kline, n)
+	price := 100.0
+	for i := range klines {
+		open := price
+		price += math.Sin(float64(i)*0.3)*2 + math.Cos(float64(i)*0.07)
+		klines[i] = types.Kline{
+			Timestamp: start.Add(time.Duration(i) * 30 * time.Minute),
+			Open:      open,
+			High:      math.Max(open, price) + 0.5 + 0.3*math.Sin(float64(i)),
+			Low:       math.Min(open, price) - 0.5,
+			Close:     price,
+		}
+	}
+	return klines
+}
+
+// computeLatest 用批量计算得到最新一根K线的输出
+func computeLatest(t *testing.T, name string, params indicators.Params, klines []types.Kline) map[string]float64 {
+	t.Helper()
+	ind, ok := indicators.Get(name)
+	if !ok {
+		t.Fatalf("未注册的指标: %s", name)
+	}
+	values, err := ind.Compute(indicators.NewInputFromKlines(klines), params)
+	if err != nil {
+		return nil
+	}
+	return indicators.Latest(values)
+}
+
+func TestSnapshotBuilder(t *testing.T) {
+	if _, ok := indicators.Get("test_range_sum"); !ok {
+		if err := indicators.Register(rangeSumIndicator{}); err != nil {
+			t.Fatalf("注册测试指标失败: %v", err)
+		}
+	}
+
+	// 配置周期基于小时，30分钟K线上周期翻倍
+	config := types.IndicatorConfig{Indicators: []types.IndicatorInstance{
+		{Type: "cci", Params: map[string]float64{"period": 7}},
+		{Type: "rsi", Params: map[string]float64{"period": 5}, MA: "sma"},
+		{Type: "macd", Params: map[string]float64{"fast": 3, "slow": 6, "signal": 2}},
+		{Type: "bollinger", Params: map[string]float64{"period": 10, "deviation": 2}},
+		{Type: "bollinger", Params: map[string]float64{"period": 4, "deviation": 1}},
+		{Type: "envelope", Params: map[string]float64{"period": 8, "deviation": 0.5}},
+		{Type: "test_range_sum"},
+		{Type: "cci", Params: map[string]float64{"period": 7}}, // 重复的key被跳过
+		{Type: "unknown"},                                      // 未注册的指标被跳过
+	}}
+	scaled := map[string]indicators.Params{
+		"cci":            {"period": 14, "ma": 0},
+		"rsi":            {"period": 10, "ma": 1},
+		"macd":           {"fast": 6, "slow": 12, "signal": 4, "ma": 0},
+		"bollinger":      {"period": 20, "deviation": 2, "ma": 0},
+		"bollinger_4":    {"period": 8, "deviation": 1, "ma": 0},
+		"envelope":       {"period": 16, "deviation": 0.5, "ma": 0},
+		"test_range_sum": {"period": 4},
+	}
+
+	klines := waveKlines(80)
+	builder := newSnapshotBuilder(config, "30m")
+	if len(builder.slots) != 7 {
+		t.Fatalf("指标实例数量 %d，期望7", len(builder.slots))
+	}
+
+	readyAt := -1
+	for i, k := range klines {
+		snap := builder.next(k)
+		history := klines[:i+1]
+		if snap.Index != i || snap.Kline != k {
+			t.Fatalf("第%d根K线: 快照序号或K线不正确", i)
+		}
+
+		cci := computeLatest(t, "cci", scaled["cci"], history)
+		rsi := computeLatest(t, "rsi", scaled["rsi"], history)
+		macd := computeLatest(t, "macd", scaled["macd"], history)
+		boll := computeLatest(t, "bollinger", scaled["bollinger"], history)
+		boll4 := computeLatest(t, "bollinger", scaled["bollinger_4"], history)
+		env := computeLatest(t, "envelope", scaled["envelope"], history)
+		sum := computeLatest(t, "test_range_sum", scaled["test_range_sum"], history)
+
+		// 所有指标都有值时才就绪
+		ready := cci != nil && rsi != nil && macd != nil && boll != nil && boll4 != nil && env != nil && sum != nil
+		if snap.Ready != ready {
+			t.Fatalf("第%d根K线: 就绪 %v，期望 %v", i, snap.Ready, ready)
+		}
+		if ready && readyAt < 0 {
+			readyAt = i
+		}
+		if !ready {
+			continue
+		}
+
+		name := func(s string) string { return fmt.Sprintf("第%d根K线 %s", i, s) }
+		assertClose(t, name("CCI"), snap.CCI["7"], cci["cci"])
+		assertClose(t, name("RSI"), snap.RSI["5"], rsi["rsi"])
+		m := snap.MACD["3_6"]
+		assertClose(t, name("MACD线"), m.Line, macd["macd_line"])
+		assertClose(t, name("信号线"), m.Signal, macd["signal_line"])
+		assertClose(t, name("柱状图"), m.Histogram, macd["histogram"])
+
+		// 通道类指标只有第一个实例放在内置字段，分区号按收盘价计算
+		assertClose(t, name("布林线中轨"), snap.Bollinger.Middle, boll["middle"])
+		assertClose(t, name("布林线上轨"), snap.Bollinger.Upper, boll["upper"])
+		if want := indicators.Zone(k.Close, boll["middle"], boll["upper"], boll["lower"]); snap.Bollinger.Zone != want {
+			t.Fatalf("%s: %d，期望 %d", name("布林线分区"), snap.Bollinger.Zone, want)
+		}
+		assertClose(t, name("包络线下轨"), snap.Envelope.Lower, env["lower"])
+		if want := indicators.Zone(k.Close, env["middle"], env["upper"], env["lower"]); snap.Envelope.Zone != want {
+			t.Fatalf("%s: %d，期望 %d", name("包络线分区"), snap.Envelope.Zone, want)
+		}
+
+		// 其他实例和注册表指标放在 Custom 中，key 与实时数据一致
+		if len(snap.Custom) != 2 {
+			t.Fatalf("%s: %v", name("自定义指标"), snap.Custom)
+		}
+		assertClose(t, name("第二个布林线"), snap.Custom["bollinger_4"]["middle"], boll4["middle"])
+		assertClose(t, name("自定义指标"), snap.Custom["test_range_sum_2"]["sum"], sum["sum"])
+	}
+	if readyAt < 0 {
+		t.Fatalf("指标始终未就绪")
+	}
+}
+
+func TestSnapshotBuilderVolatility(t *testing.T) {
+	// 每小时一根K线，共8天：波动值与对截至当前K线的数据批量计算一致
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]types.Kline, 8*24)
+	for i := range klines {
+		day := float64(i / 24)
+		klines[i] = types.Kline{Timestamp: start.Add(time.Duration(i) * time.Hour), Open: 100, Close: 100, High: 101 + day, Low: 99}
+	}
+
+	builder := newSnapshotBuilder(types.IndicatorConfig{}, "1h")
+	for i, k := range klines {
+		snap := builder.next(k)
+		if want := indicators.CalculateVolatility5Days(klines[:i+1]); snap.Volatility != want {
+			t.Fatalf("第%d根K线: 波动值 %v，期望 %v", i, snap.Volatility, want)
+		}
+		if !snap.Ready {
+			t.Fatalf("没有配置指标时应始终就绪")
+		}
+	}
+	// 第9天：前5天（第3到第7天，从0开始）的波动值为 5、6、7、8、9
+	if last := builder.next(types.Kline{Timestamp: start.Add(8 * 24 * time.Hour), High: 100, Low: 100}); last.Volatility != 7 {
+		t.Fatalf("波动值 %v，期望7", last.Volatility)
+	}
+}
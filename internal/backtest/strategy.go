@@ -0,0 +1,240 @@
+package backtest
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Action 策略信号动作
+type Action string
+
+const (
+	ActionHold  Action = "hold"  // 保持当前仓位
+	ActionLong  Action = "long"  // 开多（持有空仓时先平仓再反手）
+	ActionShort Action = "short" // 开空（持有多仓时先平仓再反手）
+	ActionClose Action = "close" // 平仓
+)
+
+// Signal 策略信号
+// 信号在当前K线收盘后产生，于下一根K线开盘价成交
+type Signal struct {
+	Action Action
+	Size   float64 // 仓位占权益的比例（0到1），0表示使用1
+	Reason string  // 信号原因（记录到交易明细）
+}
+
+// Hold 不操作的信号
+var Hold = Signal{Action: ActionHold}
+
+// Position 当前持仓
+type Position struct {
+	Side       Action  `json:"side"` // ActionLong / ActionShort，空仓为空字符串
+	Quantity   float64 `json:"quantity"`
+	EntryPrice float64 `json:"entry_price"`
+	EntryIndex int     `json:"entry_index"`
+}
+
+// Flat 是否空仓
+func (p Position) Flat() bool {
+	return p.Side == "" || p.Quantity == 0
+}
+
+// Strategy 回测策略接口
+// OnBar 在每根K线收盘后调用，history 为截至当前K线的全部快照（从旧到新，最后一个即当前K线）
+type Strategy interface {
+	Name() string
+	OnBar(history []*Snapshot, position Position) Signal
+}
+
+// StrategyParams 策略参数
+type StrategyParams map[string]string
+
+// String 获取字符串参数
+func (p StrategyParams) String(name, def string) string {
+	if v, ok := p[name]; ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// Float 获取数值参数，解析失败时返回错误
+func (p StrategyParams) Float(name string, def float64) (float64, error) {
+	v, ok := p[name]
+	if !ok || v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("参数 %s 无效: %w", name, err)
+	}
+	return f, nil
+}
+
+// ParseStrategyParams 解析 "k1=v1,k2=v2" 形式的策略参数
+func ParseStrategyParams(s string) (StrategyParams, error) {
+	params := make(StrategyParams)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("策略参数格式无效: %s（应为 key=value）", part)
+		}
+		params[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return params, nil
+}
+
+// StrategyFactory 策略构造函数
+type StrategyFactory func(params StrategyParams) (Strategy, error)
+
+var strategies = map[string]StrategyFactory{}
+
+// RegisterStrategy 注册策略（重复注册会覆盖）
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategies[name] = factory
+}
+
+// NewStrategy 按名称创建策略
+func NewStrategy(name string, params StrategyParams) (Strategy, error) {
+	factory, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的策略: %s（可用: %s）", name, strings.Join(StrategyNames(), ", "))
+	}
+	return factory(params)
+}
+
+// StrategyNames 获取所有已注册的策略名称
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterStrategy("cci_cross", newCCICrossStrategy)
+	RegisterStrategy("boll_zone", newBollZoneStrategy)
+}
+
+// cciCrossStrategy CCI穿越策略
+// CCI上穿 -level 开多，下穿 +level 开空；short=false 时只做多，下穿 +level 平仓
+type cciCrossStrategy struct {
+	key    string
+	level  float64
+	short  bool
+	warned bool
+}
+
+func newCCICrossStrategy(params StrategyParams) (Strategy, error) {
+	level, err := params.Float("level", 100)
+	if err != nil {
+		return nil, err
+	}
+	return &cciCrossStrategy{
+		key:   params.String("key", ""),
+		level: level,
+		short: params.String("short", "false") == "true",
+	}, nil
+}
+
+func (s *cciCrossStrategy) Name() string {
+	return "cci_cross"
+}
+
+func (s *cciCrossStrategy) OnBar(history []*Snapshot, position Position) Signal {
+	if len(history) < 2 {
+		return Hold
+	}
+	cur, prev := history[len(history)-1], history[len(history)-2]
+	if !prev.Ready {
+		return Hold
+	}
+
+	key := s.key
+	if key == "" {
+		key = firstKey(cur.CCI)
+	}
+	now, ok1 := cur.CCI[key]
+	before, ok2 := prev.CCI[key]
+	if !ok1 || !ok2 {
+		if !s.warned {
+			s.warned = true
+			log.Printf("策略 cci_cross: 指标配置中没有 CCI(%s)，不会产生信号", key)
+		}
+		return Hold
+	}
+
+	switch {
+	case before <= -s.level && now > -s.level && position.Side != ActionLong:
+		return Signal{Action: ActionLong, Reason: fmt.Sprintf("CCI(%s)上穿%.0f", key, -s.level)}
+	case before >= s.level && now < s.level:
+		if s.short && position.Side != ActionShort {
+			return Signal{Action: ActionShort, Reason: fmt.Sprintf("CCI(%s)下穿%.0f", key, s.level)}
+		}
+		if !s.short && position.Side == ActionLong {
+			return Signal{Action: ActionClose, Reason: fmt.Sprintf("CCI(%s)下穿%.0f", key, s.level)}
+		}
+	}
+	return Hold
+}
+
+// bollZoneStrategy 布林线分区均值回归策略
+// 收盘价跌到下轨分区 entry（如-8）以下开多，回到分区 exit（如0）以上平仓
+type bollZoneStrategy struct {
+	entry int
+	exit  int
+}
+
+func newBollZoneStrategy(params StrategyParams) (Strategy, error) {
+	entry, err := params.Float("entry", -8)
+	if err != nil {
+		return nil, err
+	}
+	exit, err := params.Float("exit", 0)
+	if err != nil {
+		return nil, err
+	}
+	if entry >= exit {
+		return nil, fmt.Errorf("entry 必须小于 exit")
+	}
+	return &bollZoneStrategy{entry: int(entry), exit: int(exit)}, nil
+}
+
+func (s *bollZoneStrategy) Name() string {
+	return "boll_zone"
+}
+
+func (s *bollZoneStrategy) OnBar(history []*Snapshot, position Position) Signal {
+	cur := history[len(history)-1]
+	if !cur.Ready {
+		return Hold
+	}
+	switch {
+	case position.Flat() && cur.Bollinger.Zone <= s.entry:
+		return Signal{Action: ActionLong, Reason: fmt.Sprintf("布林分区%d", cur.Bollinger.Zone)}
+	case position.Side == ActionLong && cur.Bollinger.Zone >= s.exit:
+		return Signal{Action: ActionClose, Reason: fmt.Sprintf("布林分区%d", cur.Bollinger.Zone)}
+	}
+	return Hold
+}
+
+// firstKey 获取map中排序后的第一个key（保证结果稳定）
+func firstKey(m map[string]float64) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
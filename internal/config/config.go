@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
 
+	"github.com/binance_cyan/indicators/pkg/types"
 	"github.com/spf13/viper"
 )
 
@@ -15,6 +17,12 @@ type Config struct {
 	Logging     LoggingConfig  `mapstructure:"logging"`
 	Server      ServerConfig   `mapstructure:"server"`
 	Cache       CacheConfig    `mapstructure:"cache"`
+	Scanner     ScannerConfig  `mapstructure:"scanner"`
+	Webhooks    WebhookConfig  `mapstructure:"webhooks"`
+	// Notifications Telegram/邮件通知
+	Notifications NotificationConfig `mapstructure:"notifications"`
+	Paper         PaperConfig        `mapstructure:"paper"`
+	Executor      ExecutorConfig     `mapstructure:"executor"`
 }
 
 // DatabaseConfig 数据库配置
@@ -50,6 +58,29 @@ type ExchangeConfig struct {
 	APIKey        string // 根据environment自动选择
 	APISecret     string // 根据environment自动选择
 	BaseURL       string
+	// DefaultMarket 默认市场类型：spot（现货）、usdm（U本位合约）、coinm（币本位合约）
+	DefaultMarket string `mapstructure:"default_market"`
+	// Markets 每个交易对的市场类型，覆盖 DefaultMarket（如 BTCUSDT: usdm）
+	Markets map[string]string `mapstructure:"markets"`
+}
+
+// MarketSelection 解析市场选择配置：默认市场和每个交易对的市场（交易对为大写）
+func (e ExchangeConfig) MarketSelection() (types.Market, map[types.Symbol]types.Market, error) {
+	defaultMarket, err := types.ParseMarket(e.DefaultMarket)
+	if err != nil {
+		return "", nil, fmt.Errorf("default_market 配置无效: %w", err)
+	}
+
+	markets := make(map[types.Symbol]types.Market, len(e.Markets))
+	for symbol, value := range e.Markets {
+		market, err := types.ParseMarket(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("交易对 %s 的市场配置无效: %w", symbol, err)
+		}
+		// viper会把map的key转为小写，这里统一转为大写
+		markets[types.Symbol(strings.ToUpper(symbol))] = market
+	}
+	return defaultMarket, markets, nil
 }
 
 // LoggingConfig 日志配置
@@ -62,6 +93,8 @@ type LoggingConfig struct {
 type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
+	// SlowConsumerPolicy WebSocket客户端读取过慢时的默认处理策略：drop_oldest、coalesce、disconnect
+	SlowConsumerPolicy string `mapstructure:"slow_consumer_policy"`
 }
 
 // CacheConfig 缓存配置
@@ -69,6 +102,123 @@ type CacheConfig struct {
 	TTL int `mapstructure:"ttl"` // 缓存过期时间（秒）
 }
 
+// ScannerConfig 市场扫描配置
+type ScannerConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Interval string `mapstructure:"interval"` // K线周期，默认1h
+	Refresh  int    `mapstructure:"refresh"`  // 刷新间隔（秒），默认300
+	// Symbols 固定的交易对范围，为空时从exchangeInfo按报价资产和成交额筛选
+	Symbols        []string `mapstructure:"symbols"`
+	Market         string   `mapstructure:"market"`           // 筛选交易对的市场，默认与 exchange.default_market 相同
+	QuoteAsset     string   `mapstructure:"quote_asset"`      // 报价资产，默认USDT
+	MinQuoteVolume float64  `mapstructure:"min_quote_volume"` // 24小时成交额下限
+	MaxSymbols     int      `mapstructure:"max_symbols"`      // 按成交额从高到低最多扫描的交易对数量，默认50
+	Concurrency    int      `mapstructure:"concurrency"`      // 同时计算的交易对数量，默认4
+}
+
+// WebhookConfig webhook通知配置
+type WebhookConfig struct {
+	// Streams 推送K线收盘快照（bar_close）和分区变化（zone_change）的实时流，格式 "BTCUSDT@1h"
+	Streams   []string                `mapstructure:"streams"`
+	QueueSize int                     `mapstructure:"queue_size"` // 每个端点的发送队列长度，默认256
+	Endpoints []WebhookEndpointConfig `mapstructure:"endpoints"`
+}
+
+// WebhookEndpointConfig webhook端点配置
+type WebhookEndpointConfig struct {
+	Name       string   `mapstructure:"name"`
+	URL        string   `mapstructure:"url"`
+	Secret     string   `mapstructure:"secret"`      // HMAC-SHA256签名密钥，为空时不签名
+	Events     []string `mapstructure:"events"`      // bar_close、zone_change、alert，为空表示全部
+	Symbols    []string `mapstructure:"symbols"`     // 只推送这些交易对的事件，为空表示全部
+	MaxRetries int      `mapstructure:"max_retries"` // 最大重试次数，默认3，-1表示不重试
+	Backoff    float64  `mapstructure:"backoff"`     // 首次重试前的等待时间（秒），之后每次翻倍，默认1
+	Timeout    float64  `mapstructure:"timeout"`     // 单次请求超时（秒），默认10
+}
+
+// NotificationConfig Telegram/邮件通知配置
+type NotificationConfig struct {
+	// Streams 发送K线收盘（bar_close）和分区变化（zone_change）通知的实时流，格式 "BTCUSDT@1h"
+	Streams  []string       `mapstructure:"streams"`
+	Telegram TelegramConfig `mapstructure:"telegram"`
+	SMTP     SMTPConfig     `mapstructure:"smtp"`
+	// Templates 按事件类型（bar_close、zone_change、alert）覆盖默认消息模板（text/template语法）
+	Templates map[string]string  `mapstructure:"templates"`
+	Users     []NotifyUserConfig `mapstructure:"users"`
+}
+
+// TelegramConfig Telegram Bot配置
+type TelegramConfig struct {
+	APIURL string `mapstructure:"api_url"` // Bot API地址，默认 https://api.telegram.org
+	Token  string `mapstructure:"token"`
+}
+
+// SMTPConfig 邮件服务器配置
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"` // 默认25
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// NotifyUserConfig 通知接收人
+type NotifyUserConfig struct {
+	Name       string           `mapstructure:"name"`
+	Telegram   string           `mapstructure:"telegram"` // Telegram chat_id
+	Email      string           `mapstructure:"email"`
+	Events     []string         `mapstructure:"events"`  // 为空表示全部
+	Symbols    []string         `mapstructure:"symbols"` // 为空表示全部
+	QuietHours QuietHoursConfig `mapstructure:"quiet_hours"`
+}
+
+// QuietHoursConfig 免打扰时段（如 23:00 到 07:00），start为空表示不设置
+type QuietHoursConfig struct {
+	Start    string `mapstructure:"start"`
+	End      string `mapstructure:"end"`
+	Timezone string `mapstructure:"timezone"` // IANA时区，如 Asia/Shanghai，默认UTC
+}
+
+// PaperConfig 模拟交易配置
+type PaperConfig struct {
+	Enabled        bool    `mapstructure:"enabled"`
+	Interval       string  `mapstructure:"interval"`        // 订阅的实时流周期（只使用最新价格），默认1m
+	InitialBalance float64 `mapstructure:"initial_balance"` // 初始资金（USDT），默认10000
+	MakerFee       float64 `mapstructure:"maker_fee"`       // 挂单手续费率，默认0.0002
+	TakerFee       float64 `mapstructure:"taker_fee"`       // 吃单手续费率，默认0.0004
+	Leverage       float64 `mapstructure:"leverage"`        // 杠杆倍数，默认1
+}
+
+// ExecutorConfig 策略执行配置
+type ExecutorConfig struct {
+	Enabled            bool                     `mapstructure:"enabled"`
+	Mode               string                   `mapstructure:"mode"`                // 执行器: paper（需启用paper）、binance（需配置API Key）
+	QuoteAsset         string                   `mapstructure:"quote_asset"`         // 计价资产，默认USDT（binance模式计算权益）
+	KillSwitch         bool                     `mapstructure:"kill_switch"`         // 启动时开启熔断开关
+	RiskPerTrade       float64                  `mapstructure:"risk_per_trade"`      // 每笔风险占权益的比例，默认0.01
+	VolatilityMultiple float64                  `mapstructure:"volatility_multiple"` // 止损距离 = 5天平均波动价格值 × 倍数，默认1
+	MaxPosition        float64                  `mapstructure:"max_position"`        // 单个交易对最大持仓名义价值，0不限制
+	MaxDailyLoss       float64                  `mapstructure:"max_daily_loss"`      // 当日亏损比例上限，0不限制
+	QuantitySteps      map[string]float64       `mapstructure:"quantity_steps"`      // 各交易对的下单数量步长
+	Strategies         []ExecutorStrategyConfig `mapstructure:"strategies"`
+	Rules              []ExecutorRuleConfig     `mapstructure:"rules"`
+}
+
+// ExecutorStrategyConfig 在实时流上运行的策略
+type ExecutorStrategyConfig struct {
+	Name     string `mapstructure:"name"`
+	Strategy string `mapstructure:"strategy"` // 回测策略名称，如 cci_cross、boll_zone
+	Symbol   string `mapstructure:"symbol"`
+	Interval string `mapstructure:"interval"`
+	Params   string `mapstructure:"params"` // 策略参数，格式同回测 -params（k1=v1,k2=v2）
+}
+
+// ExecutorRuleConfig 告警规则触发时执行的动作
+type ExecutorRuleConfig struct {
+	RuleID int64  `mapstructure:"rule_id"`
+	Action string `mapstructure:"action"` // long、short、close
+}
+
 var globalConfig *Config
 
 // Load 加载配置文件
@@ -136,6 +286,10 @@ func setDefaults(config *Config) {
 		config.Exchange.BaseURL = "https://api.binance.com"
 	}
 
+	if config.Exchange.DefaultMarket == "" {
+		config.Exchange.DefaultMarket = "spot"
+	}
+
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -149,10 +303,32 @@ func setDefaults(config *Config) {
 	if config.Server.Port == 0 {
 		config.Server.Port = 8080
 	}
+	if config.Server.SlowConsumerPolicy == "" {
+		config.Server.SlowConsumerPolicy = "drop_oldest"
+	}
 
 	if config.Cache.TTL == 0 {
 		config.Cache.TTL = 300 // 默认5分钟
 	}
+
+	if config.Scanner.Interval == "" {
+		config.Scanner.Interval = "1h"
+	}
+	if config.Scanner.Refresh <= 0 {
+		config.Scanner.Refresh = 300
+	}
+	if config.Scanner.Market == "" {
+		config.Scanner.Market = config.Exchange.DefaultMarket
+	}
+	if config.Scanner.QuoteAsset == "" {
+		config.Scanner.QuoteAsset = "USDT"
+	}
+	if config.Scanner.MaxSymbols <= 0 {
+		config.Scanner.MaxSymbols = 50
+	}
+	if config.Scanner.Concurrency <= 0 {
+		config.Scanner.Concurrency = 4
+	}
 }
 
 // selectAPIKeys 根据environment选择对应的API密钥
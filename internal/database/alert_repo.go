@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// AlertRepository 告警规则和告警历史仓库
+type AlertRepository struct {
+	db *sql.DB
+}
+
+// NewAlertRepository 创建告警仓库
+func NewAlertRepository() (*AlertRepository, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	repo := &AlertRepository{db: DB}
+
+	// 创建表（如果不存在）
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("创建告警表失败: %w", err)
+	}
+
+	return repo, nil
+}
+
+// createTables 创建告警规则表和告警历史表
+func (r *AlertRepository) createTables() error {
+	queries := []string{`
+	CREATE TABLE IF NOT EXISTS alert_rules (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		symbol VARCHAR(20) NOT NULL,
+		` + "`interval`" + ` VARCHAR(10) NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		source VARCHAR(32) NOT NULL,
+		` + "`key`" + ` VARCHAR(32) NOT NULL DEFAULT '',
+		` + "`condition`" + ` VARCHAR(16) NOT NULL,
+		threshold DOUBLE NOT NULL,
+		bars INT NOT NULL DEFAULT 1,
+		on_close TINYINT(1) NOT NULL DEFAULT 0,
+		cooldown INT NOT NULL DEFAULT 0,
+		enabled TINYINT(1) NOT NULL DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX idx_symbol (symbol)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`, `
+	CREATE TABLE IF NOT EXISTS alert_events (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		rule_id BIGINT NOT NULL,
+		rule_name VARCHAR(100) NOT NULL,
+		symbol VARCHAR(20) NOT NULL,
+		` + "`interval`" + ` VARCHAR(10) NOT NULL,
+		source VARCHAR(32) NOT NULL,
+		` + "`key`" + ` VARCHAR(32) NOT NULL DEFAULT '',
+		` + "`condition`" + ` VARCHAR(16) NOT NULL,
+		threshold DOUBLE NOT NULL,
+		value DOUBLE NOT NULL,
+		price DOUBLE NOT NULL,
+		bar_time TIMESTAMP NULL,
+		message VARCHAR(255) NOT NULL,
+		triggered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX idx_symbol_time (symbol, triggered_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`}
+
+	for _, query := range queries {
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("创建表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+const alertRuleColumns = "id, symbol, `interval`, name, source, `key`, `condition`, threshold, bars, on_close, cooldown, enabled, created_at, updated_at"
+
+// ListRules 获取所有告警规则
+func (r *AlertRepository) ListRules(ctx context.Context) ([]types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询告警规则失败: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []types.AlertRule{}
+	for rows.Next() {
+		var rule types.AlertRule
+		if err := rows.Scan(&rule.ID, &rule.Symbol, &rule.Interval, &rule.Name, &rule.Source, &rule.Key,
+			&rule.Condition, &rule.Threshold, &rule.Bars, &rule.OnClose, &rule.Cooldown, &rule.Enabled,
+			&rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描告警规则失败: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// SaveRule 保存告警规则（ID为0时新增并回填ID）
+func (r *AlertRepository) SaveRule(ctx context.Context, rule *types.AlertRule) error {
+	if rule.ID == 0 {
+		query := "INSERT INTO alert_rules (symbol, `interval`, name, source, `key`, `condition`, threshold, bars, on_close, cooldown, enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		result, err := r.db.ExecContext(ctx, query, rule.Symbol, rule.Interval, rule.Name, rule.Source, rule.Key,
+			rule.Condition, rule.Threshold, rule.Bars, rule.OnClose, rule.Cooldown, rule.Enabled)
+		if err != nil {
+			return fmt.Errorf("新增告警规则失败: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取告警规则ID失败: %w", err)
+		}
+		rule.ID = id
+		return nil
+	}
+
+	query := "UPDATE alert_rules SET symbol = ?, `interval` = ?, name = ?, source = ?, `key` = ?, `condition` = ?, threshold = ?, bars = ?, on_close = ?, cooldown = ?, enabled = ? WHERE id = ?"
+	result, err := r.db.ExecContext(ctx, query, rule.Symbol, rule.Interval, rule.Name, rule.Source, rule.Key,
+		rule.Condition, rule.Threshold, rule.Bars, rule.OnClose, rule.Cooldown, rule.Enabled, rule.ID)
+	if err != nil {
+		return fmt.Errorf("更新告警规则失败: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// MySQL在值未变化时也返回0，这里再确认一次规则是否存在
+		var exists int
+		if err := r.db.QueryRowContext(ctx, `SELECT 1 FROM alert_rules WHERE id = ?`, rule.ID).Scan(&exists); err != nil {
+			return fmt.Errorf("告警规则 %d 不存在", rule.ID)
+		}
+	}
+	return nil
+}
+
+// DeleteRule 删除告警规则
+func (r *AlertRepository) DeleteRule(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("删除告警规则失败: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("告警规则 %d 不存在", id)
+	}
+	return nil
+}
+
+// SaveEvent 保存告警事件
+func (r *AlertRepository) SaveEvent(ctx context.Context, event *types.AlertEvent) error {
+	query := "INSERT INTO alert_events (rule_id, rule_name, symbol, `interval`, source, `key`, `condition`, threshold, value, price, bar_time, message, triggered_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, event.RuleID, event.RuleName, event.Symbol, event.Interval,
+		event.Source, event.Key, event.Condition, event.Threshold, event.Value, event.Price, event.BarTime,
+		event.Message, event.TriggeredAt)
+	if err != nil {
+		return fmt.Errorf("保存告警事件失败: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		event.ID = id
+	}
+	return nil
+}
+
+// ListEvents 获取告警历史（按触发时间倒序），symbol为空时返回所有symbol
+func (r *AlertRepository) ListEvents(ctx context.Context, symbol types.Symbol, limit int) ([]types.AlertEvent, error) {
+	query := "SELECT id, rule_id, rule_name, symbol, `interval`, source, `key`, `condition`, threshold, value, price, bar_time, message, triggered_at FROM alert_events"
+	args := []interface{}{}
+	if symbol != "" {
+		query += " WHERE symbol = ?"
+		args = append(args, string(symbol))
+	}
+	query += " ORDER BY triggered_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+	defer rows.Close()
+
+	events := []types.AlertEvent{}
+	for rows.Next() {
+		var event types.AlertEvent
+		var barTime sql.NullTime
+		if err := rows.Scan(&event.ID, &event.RuleID, &event.RuleName, &event.Symbol, &event.Interval,
+			&event.Source, &event.Key, &event.Condition, &event.Threshold, &event.Value, &event.Price,
+			&barTime, &event.Message, &event.TriggeredAt); err != nil {
+			return nil, fmt.Errorf("扫描告警历史失败: %w", err)
+		}
+		event.BarTime = barTime.Time
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
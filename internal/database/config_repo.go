@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/binance_cyan/indicators/pkg/types"
 )
@@ -27,13 +28,18 @@ func NewConfigRepository() (*ConfigRepository, error) {
 	if err := repo.createTable(); err != nil {
 		return nil, fmt.Errorf("创建配置表失败: %w", err)
 	}
+
+	// 将旧版固定字段格式的配置转换为指标实例列表
+	if err := repo.migrateLegacyConfigs(context.Background()); err != nil {
+		return nil, fmt.Errorf("迁移旧版配置失败: %w", err)
+	}
 	
 	return repo, nil
 }
 
-// createTable 创建配置表
+// createTable 创建配置表和配置历史表
 func (r *ConfigRepository) createTable() error {
-	query := `
+	queries := []string{`
 	CREATE TABLE IF NOT EXISTS indicator_configs (
 		symbol VARCHAR(20) NOT NULL PRIMARY KEY,
 		config JSON NOT NULL,
@@ -41,13 +47,65 @@ func (r *ConfigRepository) createTable() error {
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 		INDEX idx_symbol (symbol)
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-	`
-	
-	_, err := r.db.Exec(query)
+	`, `
+	CREATE TABLE IF NOT EXISTS indicator_config_history (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		symbol VARCHAR(20) NOT NULL,
+		version INT NOT NULL,
+		config JSON NOT NULL,
+		author VARCHAR(64) NOT NULL DEFAULT '',
+		note VARCHAR(255) NOT NULL DEFAULT '',
+		action VARCHAR(16) NOT NULL DEFAULT 'update',
+		rollback_from INT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY uk_symbol_version (symbol, version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`}
+
+	for _, query := range queries {
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("创建表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateLegacyConfigs 将旧版固定字段格式（cci_period1、macd_fast1 等）的配置行改写为指标实例列表
+// 读取时 types.IndicatorConfig 也兼容旧格式，迁移只是让表中数据统一为新格式
+func (r *ConfigRepository) migrateLegacyConfigs(ctx context.Context) error {
+	rows, err := r.db.QueryContext(ctx, `SELECT symbol, config FROM indicator_configs`)
 	if err != nil {
-		return fmt.Errorf("创建表失败: %w", err)
+		return fmt.Errorf("查询配置失败: %w", err)
+	}
+
+	migrated := make(map[string]string)
+	for rows.Next() {
+		var symbol, configJSON string
+		if err := rows.Scan(&symbol, &configJSON); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描配置失败: %w", err)
+		}
+		data, ok, err := types.MigrateIndicatorConfigJSON([]byte(configJSON))
+		if err != nil {
+			log.Printf("迁移 %s 的配置失败，保留原数据: %v", symbol, err)
+			continue
+		}
+		if ok {
+			migrated[symbol] = string(data)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+	rows.Close()
+
+	for symbol, configJSON := range migrated {
+		if _, err := r.db.ExecContext(ctx, `UPDATE indicator_configs SET config = ? WHERE symbol = ?`, configJSON, symbol); err != nil {
+			return fmt.Errorf("更新 %s 的配置失败: %w", symbol, err)
+		}
+		log.Printf("已将 %s 的配置迁移为指标实例列表格式", symbol)
 	}
-	
 	return nil
 }
 
@@ -74,26 +132,119 @@ func (r *ConfigRepository) GetConfig(ctx context.Context, symbol types.Symbol) (
 	return &config, nil
 }
 
-// SaveConfig 保存指定symbol的配置
+// SaveConfig 保存指定symbol的配置（同时记录一条没有作者信息的历史版本）
 func (r *ConfigRepository) SaveConfig(ctx context.Context, symbol types.Symbol, config types.IndicatorConfig) error {
+	_, err := r.SaveConfigVersion(ctx, symbol, config, types.ConfigChangeMeta{})
+	return err
+}
+
+// SaveConfigVersion 在同一事务中保存当前配置并追加一条历史版本，返回新版本
+func (r *ConfigRepository) SaveConfigVersion(ctx context.Context, symbol types.Symbol, config types.IndicatorConfig, meta types.ConfigChangeMeta) (*types.ConfigVersion, error) {
 	configJSON, err := json.Marshal(config)
 	if err != nil {
-		return fmt.Errorf("序列化配置失败: %w", err)
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
 	}
-	
+	if meta.Action == "" {
+		meta.Action = types.ConfigActionUpdate
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 	INSERT INTO indicator_configs (symbol, config) 
 	VALUES (?, ?)
 	ON DUPLICATE KEY UPDATE config = VALUES(config), updated_at = CURRENT_TIMESTAMP
 	`
-	
-	_, err = r.db.ExecContext(ctx, query, string(symbol), string(configJSON))
+	if _, err := tx.ExecContext(ctx, query, string(symbol), string(configJSON)); err != nil {
+		return nil, fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	// indicator_configs 行上的锁保证同一symbol的版本号串行分配
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) + 1 FROM indicator_config_history WHERE symbol = ?`, string(symbol)).Scan(&version); err != nil {
+		return nil, fmt.Errorf("查询配置版本失败: %w", err)
+	}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO indicator_config_history (symbol, version, config, author, note, action, rollback_from) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		string(symbol), version, string(configJSON), meta.Author, meta.Note, meta.Action, meta.RollbackFrom)
 	if err != nil {
-		return fmt.Errorf("保存配置失败: %w", err)
+		return nil, fmt.Errorf("保存配置历史失败: %w", err)
 	}
-	
-	log.Printf("已保存 %s 的配置", symbol)
-	return nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交配置失败: %w", err)
+	}
+
+	saved := &types.ConfigVersion{
+		Symbol:       string(symbol),
+		Version:      version,
+		Config:       config,
+		Author:       meta.Author,
+		Note:         meta.Note,
+		Action:       meta.Action,
+		RollbackFrom: meta.RollbackFrom,
+		CreatedAt:    time.Now(),
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		saved.ID = id
+	}
+
+	log.Printf("已保存 %s 的配置（版本 %d）", symbol, version)
+	return saved, nil
+}
+
+const configVersionColumns = "id, symbol, version, config, author, note, action, rollback_from, created_at"
+
+// ListConfigVersions 获取指定symbol的配置历史（按版本倒序）
+func (r *ConfigRepository) ListConfigVersions(ctx context.Context, symbol types.Symbol, limit int) ([]types.ConfigVersion, error) {
+	query := `SELECT ` + configVersionColumns + ` FROM indicator_config_history WHERE symbol = ? ORDER BY version DESC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, string(symbol), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询配置历史失败: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []types.ConfigVersion{}
+	for rows.Next() {
+		version, err := scanConfigVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+	return versions, rows.Err()
+}
+
+// GetConfigVersion 获取指定symbol的某个历史版本，不存在时返回错误
+func (r *ConfigRepository) GetConfigVersion(ctx context.Context, symbol types.Symbol, version int) (*types.ConfigVersion, error) {
+	query := `SELECT ` + configVersionColumns + ` FROM indicator_config_history WHERE symbol = ? AND version = ?`
+
+	v, err := scanConfigVersion(r.db.QueryRowContext(ctx, query, string(symbol), version))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s 的配置版本 %d 不存在", symbol, version)
+	}
+	return v, err
+}
+
+// scanConfigVersion 扫描一行配置历史
+func scanConfigVersion(row interface{ Scan(dest ...interface{}) error }) (*types.ConfigVersion, error) {
+	var v types.ConfigVersion
+	var configJSON string
+	if err := row.Scan(&v.ID, &v.Symbol, &v.Version, &configJSON, &v.Author, &v.Note, &v.Action,
+		&v.RollbackFrom, &v.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描配置历史失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(configJSON), &v.Config); err != nil {
+		return nil, fmt.Errorf("解析配置版本 %d 失败: %w", v.Version, err)
+	}
+	return &v, nil
 }
 
 // GetAllConfigs 获取所有symbol的配置
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// ExecutionAuditRepository 策略执行审计记录仓库
+type ExecutionAuditRepository struct {
+	db *sql.DB
+}
+
+// NewExecutionAuditRepository 创建策略执行审计记录仓库
+func NewExecutionAuditRepository() (*ExecutionAuditRepository, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	repo := &ExecutionAuditRepository{db: DB}
+
+	// 创建表（如果不存在）
+	if err := repo.createTable(); err != nil {
+		return nil, fmt.Errorf("创建策略执行审计表失败: %w", err)
+	}
+
+	return repo, nil
+}
+
+// createTable 创建策略执行审计表
+func (r *ExecutionAuditRepository) createTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS execution_audit (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		time TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+		kind VARCHAR(16) NOT NULL,
+		source VARCHAR(128) NOT NULL DEFAULT '',
+		executor VARCHAR(16) NOT NULL DEFAULT '',
+		symbol VARCHAR(20) NOT NULL DEFAULT '',
+		` + "`interval`" + ` VARCHAR(10) NOT NULL DEFAULT '',
+		action VARCHAR(16) NOT NULL DEFAULT '',
+		reason VARCHAR(512) NOT NULL DEFAULT '',
+		price DOUBLE NOT NULL DEFAULT 0,
+		volatility DOUBLE NOT NULL DEFAULT 0,
+		equity DOUBLE NOT NULL DEFAULT 0,
+		position DOUBLE NOT NULL DEFAULT 0,
+		side VARCHAR(8) NOT NULL DEFAULT '',
+		quantity DOUBLE NOT NULL DEFAULT 0,
+		reduce_only TINYINT(1) NOT NULL DEFAULT 0,
+		order_id VARCHAR(64) NOT NULL DEFAULT '',
+		status VARCHAR(32) NOT NULL DEFAULT '',
+		filled_qty DOUBLE NOT NULL DEFAULT 0,
+		avg_price DOUBLE NOT NULL DEFAULT 0,
+		message VARCHAR(1024) NOT NULL DEFAULT '',
+		INDEX idx_time (time),
+		INDEX idx_symbol_time (symbol, time),
+		INDEX idx_executor_kind (executor, kind, time)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// SaveAudit 保存一条审计记录
+func (r *ExecutionAuditRepository) SaveAudit(ctx context.Context, a *types.ExecutionAudit) error {
+	query := "INSERT INTO execution_audit (time, kind, source, executor, symbol, `interval`, action, reason, price, volatility, equity, position, side, quantity, reduce_only, order_id, status, filled_qty, avg_price, message) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, a.Time, a.Kind, truncate(a.Source, 128), a.Executor, a.Symbol, a.Interval, a.Action,
+		truncate(a.Reason, 512), a.Price, a.Volatility, a.Equity, a.Position, a.Side, a.Quantity, a.ReduceOnly,
+		a.OrderID, a.Status, a.FilledQty, a.AvgPrice, truncate(a.Message, 1024))
+	if err != nil {
+		return fmt.Errorf("保存策略执行审计记录失败: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		a.ID = id
+	}
+	return nil
+}
+
+// auditColumns 查询审计记录的列（与 scanAudits 的顺序一致）
+const auditColumns = "id, time, kind, source, executor, symbol, `interval`, action, reason, price, volatility, equity, position, side, quantity, reduce_only, order_id, status, filled_qty, avg_price, message"
+
+// ListAudits 获取审计记录（按时间倒序），symbol为空时返回所有交易对
+func (r *ExecutionAuditRepository) ListAudits(ctx context.Context, symbol string, limit int) ([]types.ExecutionAudit, error) {
+	query := "SELECT " + auditColumns + " FROM execution_audit"
+	args := []interface{}{}
+	if symbol != "" {
+		query += " WHERE symbol = ?"
+		args = append(args, symbol)
+	}
+	query += " ORDER BY time DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询策略执行审计记录失败: %w", err)
+	}
+	defer rows.Close()
+	return scanAudits(rows)
+}
+
+// ListExecutorAudits 获取执行器指定类型的所有审计记录（按时间正序）
+func (r *ExecutionAuditRepository) ListExecutorAudits(ctx context.Context, executor string, kinds ...string) ([]types.ExecutionAudit, error) {
+	if len(kinds) == 0 {
+		return []types.ExecutionAudit{}, nil
+	}
+	query := "SELECT " + auditColumns + " FROM execution_audit WHERE executor = ? AND kind IN (?" + strings.Repeat(", ?", len(kinds)-1) + ") ORDER BY time, id"
+	args := []interface{}{executor}
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询策略执行审计记录失败: %w", err)
+	}
+	defer rows.Close()
+	return scanAudits(rows)
+}
+
+// scanAudits 读取查询到的审计记录
+func scanAudits(rows *sql.Rows) ([]types.ExecutionAudit, error) {
+	audits := []types.ExecutionAudit{}
+	for rows.Next() {
+		var a types.ExecutionAudit
+		if err := rows.Scan(&a.ID, &a.Time, &a.Kind, &a.Source, &a.Executor, &a.Symbol, &a.Interval, &a.Action, &a.Reason,
+			&a.Price, &a.Volatility, &a.Equity, &a.Position, &a.Side, &a.Quantity, &a.ReduceOnly,
+			&a.OrderID, &a.Status, &a.FilledQty, &a.AvgPrice, &a.Message); err != nil {
+			return nil, fmt.Errorf("扫描策略执行审计记录失败: %w", err)
+		}
+		audits = append(audits, a)
+	}
+	return audits, rows.Err()
+}
+
+// truncate 按字节截断字符串（不截断半个UTF-8字符）
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
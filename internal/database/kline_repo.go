@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// klineBatchSize 批量写入K线时每条SQL的行数
+const klineBatchSize = 500
+
+// KlineRepository 已收盘K线仓库（实现 exchange.KlineStore）
+type KlineRepository struct {
+	db *sql.DB
+}
+
+// 确保 KlineRepository 实现 KlineStore、EmptyRangeStore 接口
+var (
+	_ exchange.KlineStore      = (*KlineRepository)(nil)
+	_ exchange.EmptyRangeStore = (*KlineRepository)(nil)
+)
+
+// NewKlineRepository 创建K线仓库
+func NewKlineRepository() (*KlineRepository, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	repo := &KlineRepository{db: DB}
+
+	// 创建表（如果不存在）
+	if err := repo.createTable(); err != nil {
+		return nil, fmt.Errorf("创建K线表失败: %w", err)
+	}
+
+	return repo, nil
+}
+
+// createTable 创建K线表和空区间表（开盘时间为毫秒时间戳）
+func (r *KlineRepository) createTable() error {
+	klines := `
+	CREATE TABLE IF NOT EXISTS klines (
+		market VARCHAR(10) NOT NULL,
+		symbol VARCHAR(20) NOT NULL,
+		` + "`interval`" + ` VARCHAR(10) NOT NULL,
+		open_time BIGINT NOT NULL,
+		open DOUBLE NOT NULL,
+		high DOUBLE NOT NULL,
+		low DOUBLE NOT NULL,
+		close DOUBLE NOT NULL,
+		volume DOUBLE NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		PRIMARY KEY (market, symbol, ` + "`interval`" + `, open_time)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	// 上游确认没有数据的已收盘区间（按开盘时间，闭区间）
+	emptyRanges := `
+	CREATE TABLE IF NOT EXISTS kline_empty_ranges (
+		market VARCHAR(10) NOT NULL,
+		symbol VARCHAR(20) NOT NULL,
+		` + "`interval`" + ` VARCHAR(10) NOT NULL,
+		start_time BIGINT NOT NULL,
+		end_time BIGINT NOT NULL,
+		PRIMARY KEY (market, symbol, ` + "`interval`" + `, start_time)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	for _, query := range []string{klines, emptyRanges} {
+		if _, err := r.db.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveKlines 批量保存K线，相同开盘时间的K线覆盖
+func (r *KlineRepository) SaveKlines(ctx context.Context, key exchange.KlineKey, klines []types.Kline) error {
+	for start := 0; start < len(klines); start += klineBatchSize {
+		end := start + klineBatchSize
+		if end > len(klines) {
+			end = len(klines)
+		}
+		batch := klines[start:end]
+
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*9)
+		for i, k := range batch {
+			placeholders[i] = "(?, ?, ?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, string(key.Market), string(key.Symbol), key.Interval, k.Timestamp.UnixMilli(),
+				k.Open, k.High, k.Low, k.Close, k.Volume)
+		}
+
+		query := "INSERT INTO klines (market, symbol, `interval`, open_time, open, high, low, close, volume) VALUES " +
+			strings.Join(placeholders, ", ") +
+			" ON DUPLICATE KEY UPDATE open = VALUES(open), high = VALUES(high), low = VALUES(low), close = VALUES(close), volume = VALUES(volume)"
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("保存K线失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadKlines 加载开盘时间在[start, end]内的K线（从旧到新）
+func (r *KlineRepository) LoadKlines(ctx context.Context, key exchange.KlineKey, start, end time.Time) ([]types.Kline, error) {
+	query := "SELECT open_time, open, high, low, close, volume FROM klines " +
+		"WHERE market = ? AND symbol = ? AND `interval` = ? AND open_time BETWEEN ? AND ? ORDER BY open_time"
+
+	rows, err := r.db.QueryContext(ctx, query, string(key.Market), string(key.Symbol), key.Interval,
+		start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("查询K线失败: %w", err)
+	}
+	defer rows.Close()
+
+	klines := []types.Kline{}
+	for rows.Next() {
+		var openTime int64
+		k := types.Kline{Symbol: string(key.Symbol)}
+		if err := rows.Scan(&openTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume); err != nil {
+			return nil, fmt.Errorf("扫描K线失败: %w", err)
+		}
+		k.Timestamp = time.UnixMilli(openTime)
+		klines = append(klines, k)
+	}
+	return klines, rows.Err()
+}
+
+// ListSeries 列出已存储的K线序列及其最早开盘时间
+func (r *KlineRepository) ListSeries(ctx context.Context) (map[exchange.KlineKey]time.Time, error) {
+	query := "SELECT market, symbol, `interval`, MIN(open_time) FROM klines GROUP BY market, symbol, `interval`"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询K线序列失败: %w", err)
+	}
+	defer rows.Close()
+
+	series := make(map[exchange.KlineKey]time.Time)
+	for rows.Next() {
+		var market, symbol, interval string
+		var earliest int64
+		if err := rows.Scan(&market, &symbol, &interval, &earliest); err != nil {
+			return nil, fmt.Errorf("扫描K线序列失败: %w", err)
+		}
+		key := exchange.KlineKey{Market: types.Market(market), Symbol: types.Symbol(symbol), Interval: interval}
+		series[key] = time.UnixMilli(earliest)
+	}
+	return series, rows.Err()
+}
+
+// SaveEmptyRange 保存上游确认没有数据的区间，起始时间相同的区间取较大的结束时间
+func (r *KlineRepository) SaveEmptyRange(ctx context.Context, key exchange.KlineKey, start, end time.Time) error {
+	query := "INSERT INTO kline_empty_ranges (market, symbol, `interval`, start_time, end_time) VALUES (?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE end_time = GREATEST(end_time, VALUES(end_time))"
+	if _, err := r.db.ExecContext(ctx, query, string(key.Market), string(key.Symbol), key.Interval,
+		start.UnixMilli(), end.UnixMilli()); err != nil {
+		return fmt.Errorf("保存K线空区间失败: %w", err)
+	}
+	return nil
+}
+
+// LoadEmptyRanges 加载K线序列已保存的空区间
+func (r *KlineRepository) LoadEmptyRanges(ctx context.Context, key exchange.KlineKey) ([][2]time.Time, error) {
+	query := "SELECT start_time, end_time FROM kline_empty_ranges WHERE market = ? AND symbol = ? AND `interval` = ? ORDER BY start_time"
+
+	rows, err := r.db.QueryContext(ctx, query, string(key.Market), string(key.Symbol), key.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("查询K线空区间失败: %w", err)
+	}
+	defer rows.Close()
+
+	var ranges [][2]time.Time
+	for rows.Next() {
+		var start, end int64
+		if err := rows.Scan(&start, &end); err != nil {
+			return nil, fmt.Errorf("扫描K线空区间失败: %w", err)
+		}
+		ranges = append(ranges, [2]time.Time{time.UnixMilli(start), time.UnixMilli(end)})
+	}
+	return ranges, rows.Err()
+}
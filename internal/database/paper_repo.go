@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// PaperRepository 模拟交易仓库（单账户）
+type PaperRepository struct {
+	db *sql.DB
+}
+
+// NewPaperRepository 创建模拟交易仓库
+func NewPaperRepository() (*PaperRepository, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	repo := &PaperRepository{db: DB}
+
+	// 创建表（如果不存在）
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("创建模拟交易表失败: %w", err)
+	}
+
+	return repo, nil
+}
+
+// createTables 创建账户、持仓、订单和成交表
+func (r *PaperRepository) createTables() error {
+	queries := []string{`
+	CREATE TABLE IF NOT EXISTS paper_account (
+		id TINYINT NOT NULL PRIMARY KEY,
+		initial_balance DOUBLE NOT NULL,
+		balance DOUBLE NOT NULL,
+		realized_pnl DOUBLE NOT NULL DEFAULT 0,
+		fees DOUBLE NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`, `
+	CREATE TABLE IF NOT EXISTS paper_positions (
+		symbol VARCHAR(20) NOT NULL PRIMARY KEY,
+		quantity DOUBLE NOT NULL,
+		entry_price DOUBLE NOT NULL,
+		realized_pnl DOUBLE NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`, `
+	CREATE TABLE IF NOT EXISTS paper_orders (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		symbol VARCHAR(20) NOT NULL,
+		side VARCHAR(8) NOT NULL,
+		` + "`type`" + ` VARCHAR(16) NOT NULL,
+		quantity DOUBLE NOT NULL,
+		price DOUBLE NOT NULL DEFAULT 0,
+		stop_price DOUBLE NOT NULL DEFAULT 0,
+		reduce_only TINYINT(1) NOT NULL DEFAULT 0,
+		status VARCHAR(16) NOT NULL,
+		fill_price DOUBLE NOT NULL DEFAULT 0,
+		filled_qty DOUBLE NOT NULL DEFAULT 0,
+		fee DOUBLE NOT NULL DEFAULT 0,
+		reason VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+		updated_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+		INDEX idx_status (status),
+		INDEX idx_symbol (symbol, id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`, `
+	CREATE TABLE IF NOT EXISTS paper_trades (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		order_id BIGINT NOT NULL,
+		symbol VARCHAR(20) NOT NULL,
+		side VARCHAR(8) NOT NULL,
+		quantity DOUBLE NOT NULL,
+		price DOUBLE NOT NULL,
+		fee DOUBLE NOT NULL,
+		liquidity VARCHAR(8) NOT NULL,
+		realized_pnl DOUBLE NOT NULL DEFAULT 0,
+		time TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+		INDEX idx_symbol_time (symbol, time)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`}
+
+	for _, query := range queries {
+		if _, err := r.db.Exec(query); err != nil {
+			return fmt.Errorf("创建表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadAccount 读取账户，不存在时返回nil
+func (r *PaperRepository) LoadAccount(ctx context.Context) (*types.PaperAccount, error) {
+	var a types.PaperAccount
+	err := r.db.QueryRowContext(ctx,
+		`SELECT initial_balance, balance, realized_pnl, fees, updated_at FROM paper_account WHERE id = 1`,
+	).Scan(&a.InitialBalance, &a.Balance, &a.RealizedPnL, &a.Fees, &a.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟账户失败: %w", err)
+	}
+	return &a, nil
+}
+
+// SaveAccount 保存账户
+func (r *PaperRepository) SaveAccount(ctx context.Context, a *types.PaperAccount) error {
+	query := `
+	INSERT INTO paper_account (id, initial_balance, balance, realized_pnl, fees, updated_at)
+	VALUES (1, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		initial_balance = VALUES(initial_balance),
+		balance = VALUES(balance),
+		realized_pnl = VALUES(realized_pnl),
+		fees = VALUES(fees),
+		updated_at = VALUES(updated_at)
+	`
+	if _, err := r.db.ExecContext(ctx, query, a.InitialBalance, a.Balance, a.RealizedPnL, a.Fees, a.UpdatedAt); err != nil {
+		return fmt.Errorf("保存模拟账户失败: %w", err)
+	}
+	return nil
+}
+
+// ListPositions 获取所有持仓
+func (r *PaperRepository) ListPositions(ctx context.Context) ([]types.PaperPosition, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT symbol, quantity, entry_price, realized_pnl, updated_at FROM paper_positions ORDER BY symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟持仓失败: %w", err)
+	}
+	defer rows.Close()
+
+	positions := []types.PaperPosition{}
+	for rows.Next() {
+		var p types.PaperPosition
+		if err := rows.Scan(&p.Symbol, &p.Quantity, &p.EntryPrice, &p.RealizedPnL, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描模拟持仓失败: %w", err)
+		}
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// SavePosition 保存持仓，数量为0时删除
+func (r *PaperRepository) SavePosition(ctx context.Context, p *types.PaperPosition) error {
+	if p.Quantity == 0 {
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM paper_positions WHERE symbol = ?`, p.Symbol); err != nil {
+			return fmt.Errorf("删除模拟持仓失败: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+	INSERT INTO paper_positions (symbol, quantity, entry_price, realized_pnl, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		quantity = VALUES(quantity),
+		entry_price = VALUES(entry_price),
+		realized_pnl = VALUES(realized_pnl),
+		updated_at = VALUES(updated_at)
+	`
+	if _, err := r.db.ExecContext(ctx, query, p.Symbol, p.Quantity, p.EntryPrice, p.RealizedPnL, p.UpdatedAt); err != nil {
+		return fmt.Errorf("保存模拟持仓失败: %w", err)
+	}
+	return nil
+}
+
+const paperOrderColumns = "id, symbol, side, `type`, quantity, price, stop_price, reduce_only, status, fill_price, filled_qty, fee, reason, created_at, updated_at"
+
+// SaveOrder 保存订单（ID为0时新增并回填ID）
+func (r *PaperRepository) SaveOrder(ctx context.Context, o *types.PaperOrder) error {
+	if o.ID == 0 {
+		query := "INSERT INTO paper_orders (symbol, side, `type`, quantity, price, stop_price, reduce_only, status, fill_price, filled_qty, fee, reason, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		result, err := r.db.ExecContext(ctx, query, o.Symbol, o.Side, o.Type, o.Quantity, o.Price, o.StopPrice,
+			o.ReduceOnly, o.Status, o.FillPrice, o.FilledQty, o.Fee, o.Reason, o.CreatedAt, o.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("新增模拟订单失败: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取模拟订单ID失败: %w", err)
+		}
+		o.ID = id
+		return nil
+	}
+
+	query := `UPDATE paper_orders SET status = ?, fill_price = ?, filled_qty = ?, fee = ?, reason = ?, updated_at = ? WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, query, o.Status, o.FillPrice, o.FilledQty, o.Fee, o.Reason, o.UpdatedAt, o.ID); err != nil {
+		return fmt.Errorf("更新模拟订单失败: %w", err)
+	}
+	return nil
+}
+
+// ListOrders 获取订单（按ID倒序），symbol为空时返回全部，openOnly 时只返回未成交订单，limit为0表示不限制
+func (r *PaperRepository) ListOrders(ctx context.Context, symbol string, openOnly bool, limit int) ([]types.PaperOrder, error) {
+	query := `SELECT ` + paperOrderColumns + ` FROM paper_orders WHERE 1 = 1`
+	args := []interface{}{}
+	if symbol != "" {
+		query += " AND symbol = ?"
+		args = append(args, symbol)
+	}
+	if openOnly {
+		query += " AND status = ?"
+		args = append(args, types.OrderStatusNew)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟订单失败: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []types.PaperOrder{}
+	for rows.Next() {
+		var o types.PaperOrder
+		if err := rows.Scan(&o.ID, &o.Symbol, &o.Side, &o.Type, &o.Quantity, &o.Price, &o.StopPrice, &o.ReduceOnly,
+			&o.Status, &o.FillPrice, &o.FilledQty, &o.Fee, &o.Reason, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描模拟订单失败: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// SaveTrade 保存成交记录
+func (r *PaperRepository) SaveTrade(ctx context.Context, t *types.PaperTrade) error {
+	query := `INSERT INTO paper_trades (order_id, symbol, side, quantity, price, fee, liquidity, realized_pnl, time) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, t.OrderID, t.Symbol, t.Side, t.Quantity, t.Price, t.Fee,
+		t.Liquidity, t.RealizedPnL, t.Time)
+	if err != nil {
+		return fmt.Errorf("保存模拟成交失败: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		t.ID = id
+	}
+	return nil
+}
+
+// ListTrades 获取成交记录（按时间倒序），symbol为空时返回全部
+func (r *PaperRepository) ListTrades(ctx context.Context, symbol string, limit int) ([]types.PaperTrade, error) {
+	query := `SELECT id, order_id, symbol, side, quantity, price, fee, liquidity, realized_pnl, time FROM paper_trades`
+	args := []interface{}{}
+	if symbol != "" {
+		query += " WHERE symbol = ?"
+		args = append(args, symbol)
+	}
+	query += " ORDER BY time DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟成交失败: %w", err)
+	}
+	defer rows.Close()
+
+	trades := []types.PaperTrade{}
+	for rows.Next() {
+		var t types.PaperTrade
+		if err := rows.Scan(&t.ID, &t.OrderID, &t.Symbol, &t.Side, &t.Quantity, &t.Price, &t.Fee,
+			&t.Liquidity, &t.RealizedPnL, &t.Time); err != nil {
+			return nil, fmt.Errorf("扫描模拟成交失败: %w", err)
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+// Reset 清空账户、持仓、订单和成交
+func (r *PaperRepository) Reset(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"paper_account", "paper_positions", "paper_orders", "paper_trades"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("清空 %s 失败: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// WebhookRepository webhook投递记录仓库
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository 创建webhook投递记录仓库
+func NewWebhookRepository() (*WebhookRepository, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	repo := &WebhookRepository{db: DB}
+
+	// 创建表（如果不存在）
+	if err := repo.createTable(); err != nil {
+		return nil, fmt.Errorf("创建webhook投递记录表失败: %w", err)
+	}
+
+	return repo, nil
+}
+
+// createTable 创建webhook投递记录表
+func (r *WebhookRepository) createTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		endpoint VARCHAR(64) NOT NULL,
+		event_id VARCHAR(64) NOT NULL,
+		event_type VARCHAR(32) NOT NULL,
+		symbol VARCHAR(20) NOT NULL DEFAULT '',
+		url VARCHAR(512) NOT NULL,
+		attempt INT NOT NULL,
+		status_code INT NOT NULL DEFAULT 0,
+		success TINYINT(1) NOT NULL DEFAULT 0,
+		error VARCHAR(512) NOT NULL DEFAULT '',
+		duration_ms BIGINT NOT NULL DEFAULT 0,
+		payload MEDIUMTEXT NOT NULL,
+		response TEXT NOT NULL,
+		created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+		INDEX idx_endpoint_time (endpoint, created_at),
+		INDEX idx_event (event_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// SaveDelivery 保存一次投递尝试
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, d *types.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (endpoint, event_id, event_type, symbol, url, attempt, status_code, success, error, duration_ms, payload, response, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	errText := d.Error
+	if len(errText) > 512 {
+		errText = errText[:512]
+	}
+	result, err := r.db.ExecContext(ctx, query, d.Endpoint, d.EventID, d.EventType, d.Symbol, d.URL, d.Attempt,
+		d.StatusCode, d.Success, errText, d.DurationMs, d.Payload, d.Response, d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存webhook投递记录失败: %w", err)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		d.ID = id
+	}
+	return nil
+}
+
+// ListDeliveries 获取投递记录（按时间倒序），endpoint为空时返回所有端点
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpoint string, limit int) ([]types.WebhookDelivery, error) {
+	query := `SELECT id, endpoint, event_id, event_type, symbol, url, attempt, status_code, success, error, duration_ms, payload, response, created_at FROM webhook_deliveries`
+	args := []interface{}{}
+	if endpoint != "" {
+		query += " WHERE endpoint = ?"
+		args = append(args, endpoint)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询webhook投递记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		var d types.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.Endpoint, &d.EventID, &d.EventType, &d.Symbol, &d.URL, &d.Attempt,
+			&d.StatusCode, &d.Success, &d.Error, &d.DurationMs, &d.Payload, &d.Response, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描webhook投递记录失败: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
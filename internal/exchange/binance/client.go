@@ -5,23 +5,88 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/binance_cyan/indicators/pkg/types"
 )
 
+// MaxKlinesPerRequest 单次K线请求的最大数量（现货限制，合约为1500，统一按1000分页）
+const MaxKlinesPerRequest = 1000
+
+// weightSafetyRatio 已用权重超过上限的该比例时等待到下一分钟
+const weightSafetyRatio = 0.8
+
+// defaultRecvWindow 签名请求的默认有效时间窗口（recvWindow）
+const defaultRecvWindow = 5 * time.Second
+
+// errCodeTimestamp 时间戳超出recvWindow或超前于服务器时间
+const errCodeTimestamp = -1021
+
+// weightState 某个市场的请求权重使用情况
+type weightState struct {
+	used      int       // 最近一次响应头中的已用权重（X-MBX-USED-WEIGHT-1M）
+	updatedAt time.Time // used 的更新时间
+}
+
 // Client Binance 客户端
 type Client struct {
 	apiKey    string
 	apiSecret string
 	baseURL   string
 	client    *http.Client
+
+	weightMu sync.Mutex
+	weights  map[types.Market]weightState // 各市场分别计算权重
+
+	markets marketSelector // 每个交易对的市场选择（现货/U本位/币本位）
+
+	baseMu    sync.RWMutex
+	restBases map[types.Market]string // 覆盖的REST根地址（测试网或本地模拟服务）
+
+	timeMu      sync.Mutex
+	timeOffsets map[types.Market]time.Duration // 服务器时间 - 本地时间
+	recvWindow  time.Duration
+}
+
+// APIError Binance返回的错误（响应体为 {"code":-2010,"msg":"..."}）
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("API请求失败: status=%d, body=%s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API请求失败: status=%d, code=%d, msg=%s", e.StatusCode, e.Code, e.Message)
+}
+
+// retryable 限流和服务端错误可以重试，其他错误（参数、签名、余额不足等）重试无意义
+func (e *APIError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// decodeAPIError 解析错误响应，无法解析时保留原始响应体
+func decodeAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{}
+	if err := json.Unmarshal(body, apiErr); err != nil || (apiErr.Code == 0 && apiErr.Message == "") {
+		apiErr = &APIError{Message: string(body)}
+	}
+	apiErr.StatusCode = statusCode
+	return apiErr
 }
 
 // NewClient 创建新的 Binance 客户端
@@ -31,7 +96,13 @@ func NewClient(apiKey, apiSecret, baseURL string) *Client {
 	var transport *http.Transport
 	if err == nil {
 		transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				// 本地地址（模拟服务）不走代理
+				if ip := net.ParseIP(req.URL.Hostname()); (ip != nil && ip.IsLoopback()) || req.URL.Hostname() == "localhost" {
+					return nil, nil
+				}
+				return proxyURL, nil
+			},
 		}
 		log.Printf("已设置HTTP代理: %s", proxyURL.String())
 	} else {
@@ -47,17 +118,200 @@ func NewClient(apiKey, apiSecret, baseURL string) *Client {
 			Transport: transport,
 			Timeout:   60 * time.Second, // 增加超时时间到60秒
 		},
+		weights:     make(map[types.Market]weightState),
+		restBases:   make(map[types.Market]string),
+		timeOffsets: make(map[types.Market]time.Duration),
+		recvWindow:  defaultRecvWindow,
+	}
+}
+
+// SetRESTBase 覆盖某个市场的REST根地址（如测试网或本地模拟服务），为空时恢复正式环境地址
+func (c *Client) SetRESTBase(market types.Market, baseURL string) {
+	c.baseMu.Lock()
+	defer c.baseMu.Unlock()
+	if baseURL == "" {
+		delete(c.restBases, market)
+		return
+	}
+	c.restBases[market] = strings.TrimRight(baseURL, "/")
+}
+
+// SetRecvWindow 设置签名请求的recvWindow（最大60秒）
+func (c *Client) SetRecvWindow(window time.Duration) {
+	if window <= 0 {
+		window = defaultRecvWindow
+	}
+	if window > time.Minute {
+		window = time.Minute
+	}
+	c.timeMu.Lock()
+	c.recvWindow = window
+	c.timeMu.Unlock()
+}
+
+// restBaseFor 获取市场的REST根地址
+func (c *Client) restBaseFor(market types.Market) string {
+	c.baseMu.RLock()
+	base, ok := c.restBases[market]
+	c.baseMu.RUnlock()
+	if ok {
+		return base
+	}
+
+	// 确保使用正式环境（合约接口使用对应的合约域名）
+	if market.IsFutures() {
+		return endpointsFor(market).restBase
+	}
+	if c.baseURL != "https://api.binance.com" {
+		log.Printf("警告: BaseURL不是正式环境，强制使用 https://api.binance.com (当前: %s)", c.baseURL)
+	}
+	return "https://api.binance.com"
+}
+
+// SyncTime 同步指定市场的服务器时间，签名请求的时间戳按偏移量校正
+func (c *Client) SyncTime(market types.Market) error {
+	sent := time.Now()
+	body, err := c.getRaw(endpointsFor(market).apiPrefix+"/time", nil, false)
+	if err != nil {
+		return fmt.Errorf("获取服务器时间失败: %w", err)
+	}
+	received := time.Now()
+
+	var data struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil || data.ServerTime == 0 {
+		return fmt.Errorf("解析服务器时间失败: %s", string(body))
+	}
+
+	// 按请求往返的中点估计本地时间
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := time.UnixMilli(data.ServerTime).Sub(local)
+
+	c.timeMu.Lock()
+	c.timeOffsets[market] = offset
+	c.timeMu.Unlock()
+	log.Printf("%s 服务器时间偏移: %v", market, offset)
+	return nil
+}
+
+// TimeOffset 获取指定市场的服务器时间偏移（服务器时间 - 本地时间）
+func (c *Client) TimeOffset(market types.Market) time.Duration {
+	c.timeMu.Lock()
+	defer c.timeMu.Unlock()
+	return c.timeOffsets[market]
+}
+
+// ensureTimeSynced 首次签名请求前同步服务器时间，失败时按本地时间签名
+func (c *Client) ensureTimeSynced(market types.Market) {
+	c.timeMu.Lock()
+	_, synced := c.timeOffsets[market]
+	c.timeMu.Unlock()
+	if synced {
+		return
+	}
+	if err := c.SyncTime(market); err != nil {
+		log.Printf("同步 %s 服务器时间失败，使用本地时间签名: %v", market, err)
 	}
 }
 
-// GetKlines 获取 K 线数据（现货）
+// GetKlines 获取 K 线数据（按交易对所属市场请求现货或合约接口）
 func (c *Client) GetKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
-	endpoint := "/api/v3/klines"
 	params := url.Values{}
 	params.Set("symbol", string(symbol))
 	params.Set("interval", interval)
 	params.Set("limit", strconv.Itoa(limit))
 
+	return c.fetchKlines(symbol, params)
+}
+
+// GetKlinesRange 获取指定时间范围内的K线（按开盘时间，包含start和end）
+// 使用startTime/endTime自动分页，突破单次1000根的限制，结果按时间排序并去重
+func (c *Client) GetKlinesRange(symbol types.Symbol, interval string, start, end time.Time) ([]types.Kline, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("无效的时间范围: %s - %s", start, end)
+	}
+
+	seen := make(map[int64]bool)
+	klines := []types.Kline{}
+	cursor := start
+
+	market := c.MarketOf(symbol)
+	for !cursor.After(end) {
+		c.waitForWeight(market)
+
+		params := url.Values{}
+		params.Set("symbol", string(symbol))
+		params.Set("interval", interval)
+		params.Set("startTime", strconv.FormatInt(cursor.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(MaxKlinesPerRequest))
+
+		page, err := c.fetchKlines(symbol, params)
+		if err != nil {
+			return nil, fmt.Errorf("分页获取K线失败（已获取%d根）: %w", len(klines), err)
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		for _, k := range page {
+			ts := k.Timestamp.Unix()
+			if seen[ts] {
+				continue
+			}
+			seen[ts] = true
+			klines = append(klines, k)
+		}
+
+		if len(page) < MaxKlinesPerRequest {
+			break
+		}
+		// 下一页从最后一根K线之后开始（startTime包含边界）
+		next := page[len(page)-1].Timestamp.Add(time.Millisecond)
+		if !next.After(cursor) {
+			break
+		}
+		cursor = next
+	}
+
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].Timestamp.Before(klines[j].Timestamp)
+	})
+
+	return klines, nil
+}
+
+// GetRecentKlines 获取最近limit根K线
+// 不超过单次请求上限时直接请求，否则按时间范围分页获取
+func (c *Client) GetRecentKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
+	if limit <= MaxKlinesPerRequest {
+		return c.GetKlines(symbol, interval, limit)
+	}
+
+	step, err := types.IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	start := end.Add(-step * time.Duration(limit))
+	klines, err := c.GetKlinesRange(symbol, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// fetchKlines 请求K线接口并解析结果
+// 现货: /api/v3/klines，U本位: /fapi/v1/klines，币本位: /dapi/v1/klines（返回格式相同）
+func (c *Client) fetchKlines(symbol types.Symbol, params url.Values) ([]types.Kline, error) {
+	endpoint := endpointsFor(c.MarketOf(symbol)).apiPrefix + "/klines"
+
 	body, err := c.getRaw(endpoint, params, false)
 	if err != nil {
 		return nil, err
@@ -91,67 +345,150 @@ func (c *Client) GetKlines(symbol types.Symbol, interval string, limit int) ([]t
 	return klines, nil
 }
 
-// getRaw 发送HTTP请求（带重试机制）
-func (c *Client) getRaw(endpoint string, params url.Values, signed bool) ([]byte, error) {
-	// 确保使用正式环境
-	baseURL := c.baseURL
-	if baseURL != "https://api.binance.com" {
-		log.Printf("警告: BaseURL不是正式环境，强制使用 https://api.binance.com (当前: %s)", baseURL)
-		baseURL = "https://api.binance.com"
+// waitForWeight 指定市场的已用请求权重接近上限时，等待到下一分钟权重重置
+func (c *Client) waitForWeight(market types.Market) {
+	c.weightMu.Lock()
+	state := c.weights[market]
+	c.weightMu.Unlock()
+
+	limit := endpointsFor(market).weightLimit
+	if float64(state.used) < float64(limit)*weightSafetyRatio {
+		return
 	}
 
-	reqURL := baseURL + endpoint
-	if len(params) > 0 {
-		reqURL += "?" + params.Encode()
+	// 权重按自然分钟统计
+	reset := state.updatedAt.Truncate(time.Minute).Add(time.Minute)
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return
 	}
+	log.Printf("%s 请求权重已用 %d/%d，等待 %v 后继续", market, state.used, limit, wait.Round(time.Second))
+	time.Sleep(wait)
+}
 
-	if signed {
-		// 添加时间戳
-		params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
-		// 生成签名
-		queryString := params.Encode()
-		signature := c.sign(queryString)
-		reqURL = baseURL + endpoint + "?" + queryString + "&signature=" + signature
+// recordWeight 记录响应头中的已用权重
+func (c *Client) recordWeight(market types.Market, resp *http.Response) {
+	value := resp.Header.Get("X-MBX-USED-WEIGHT-1M")
+	if value == "" {
+		return
+	}
+	used, err := strconv.Atoi(value)
+	if err != nil {
+		return
 	}
 
-	// 重试机制：最多重试3次
-	var resp *http.Response
-	var err error
-	for i := 0; i < 3; i++ {
-		req, err := http.NewRequest("GET", reqURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
-		}
+	c.weightMu.Lock()
+	c.weights[market] = weightState{used: used, updatedAt: time.Now()}
+	c.weightMu.Unlock()
+}
 
-		if c.apiKey != "" {
-			req.Header.Set("X-MBX-APIKEY", c.apiKey)
-		}
+// UsedWeight 获取指定市场最近一次记录的已用请求权重
+func (c *Client) UsedWeight(market types.Market) int {
+	c.weightMu.Lock()
+	defer c.weightMu.Unlock()
+	return c.weights[market].used
+}
 
-		resp, err = c.client.Do(req)
-		if err == nil && resp != nil && resp.StatusCode == http.StatusOK {
-			break
+// getRaw 发送GET请求（带重试机制）
+func (c *Client) getRaw(endpoint string, params url.Values, signed bool) ([]byte, error) {
+	return c.request(http.MethodGet, endpoint, params, signed)
+}
+
+// request 发送HTTP请求
+// GET请求在网络错误、429和5xx时最多重试3次；下单、撤单等非GET请求不重试，避免重复下单。
+// 签名请求的时间戳超出recvWindow（-1021）时同步服务器时间后重新签名发送一次
+func (c *Client) request(method, endpoint string, params url.Values, signed bool) ([]byte, error) {
+	market := endpointMarket(endpoint)
+	if signed {
+		if c.apiKey == "" || c.apiSecret == "" {
+			return nil, fmt.Errorf("签名请求需要配置API Key和Secret")
 		}
+		c.ensureTimeSynced(market)
+	}
 
-		if resp != nil {
-			resp.Body.Close()
-			resp = nil
+	body, err := c.requestWithRetry(method, endpoint, params, signed)
+	var apiErr *APIError
+	if signed && errors.As(err, &apiErr) && apiErr.Code == errCodeTimestamp {
+		log.Printf("%s 签名时间戳被拒绝，重新同步服务器时间: %v", market, err)
+		if syncErr := c.SyncTime(market); syncErr != nil {
+			return nil, err
 		}
+		body, err = c.requestWithRetry(method, endpoint, params, signed)
+	}
+	return body, err
+}
 
-		if i < 2 {
-			log.Printf("HTTP请求失败，1秒后重试 (第%d次): %v", i+1, err)
+// requestWithRetry 发送请求，GET请求失败时重试
+func (c *Client) requestWithRetry(method, endpoint string, params url.Values, signed bool) ([]byte, error) {
+	attempts := 1
+	if method == http.MethodGet {
+		attempts = 3
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			log.Printf("HTTP请求失败，1秒后重试 (第%d次): %v", i, err)
 			time.Sleep(1 * time.Second)
 		}
+
+		var body []byte
+		body, err = c.send(method, endpoint, params, signed)
+		if err == nil {
+			return body, nil
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !apiErr.retryable() {
+			return nil, err
+		}
+	}
+
+	if attempts > 1 {
+		return nil, fmt.Errorf("请求失败（已重试%d次）: %w", attempts, err)
+	}
+	return nil, err
+}
+
+// send 发送一次HTTP请求，签名请求每次发送时重新生成时间戳和签名
+// 参数统一放在查询字符串中（Binance的POST/DELETE接口同样支持）
+func (c *Client) send(method, endpoint string, params url.Values, signed bool) ([]byte, error) {
+	market := endpointMarket(endpoint)
+	reqURL := c.restBaseFor(market) + endpoint
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	queryString := query.Encode()
+	if signed {
+		c.timeMu.Lock()
+		now := time.Now().Add(c.timeOffsets[market])
+		recvWindow := c.recvWindow
+		c.timeMu.Unlock()
+
+		query.Set("timestamp", strconv.FormatInt(now.UnixMilli(), 10))
+		query.Set("recvWindow", strconv.FormatInt(recvWindow.Milliseconds(), 10))
+		queryString = query.Encode()
+		queryString += "&signature=" + c.sign(queryString)
+	}
+	if queryString != "" {
+		reqURL += "?" + queryString
 	}
 
+	req, err := http.NewRequest(method, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败（已重试3次）: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
-	
-	if resp == nil {
-		return nil, fmt.Errorf("请求失败：响应为空")
+	if c.apiKey != "" {
+		req.Header.Set("X-MBX-APIKEY", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
-	
 	defer resp.Body.Close()
+	c.recordWeight(market, resp)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -159,7 +496,7 @@ func (c *Client) getRaw(endpoint string, params url.Values, signed bool) ([]byte
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API请求失败: status=%d, body=%s", resp.StatusCode, string(body))
+		return nil, decodeAPIError(resp.StatusCode, body)
 	}
 
 	return body, nil
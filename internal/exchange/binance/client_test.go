@@ -0,0 +1,295 @@
+package binance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+const (
+	testAPIKey    = "test-key"
+	testAPISecret = "test-secret"
+)
+
+// fakeResponse 模拟服务的一次响应
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+// fakeRequest 模拟服务收到的一次请求（/time 除外）
+type fakeRequest struct {
+	method    string
+	path      string
+	query     url.Values
+	apiKey    string
+	signed    bool // 带有签名
+	signValid bool // 签名与查询字符串一致
+}
+
+// fakeBinance 本地Binance REST模拟服务
+// /api/v3/time 返回本地时间加 offset；其他路径按 "METHOD path" 依次返回预设的响应（用完后重复最后一个，未设置时返回 {}）
+type fakeBinance struct {
+	*httptest.Server
+	mu        sync.Mutex
+	offset    time.Duration
+	timeCalls int
+	responses map[string][]fakeResponse
+	requests  []fakeRequest
+}
+
+func newFakeBinance(t *testing.T) (*fakeBinance, *Client) {
+	f := &fakeBinance{responses: make(map[string][]fakeResponse)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+
+	client := NewClient(testAPIKey, testAPISecret, "https://api.binance.com")
+	client.SetRESTBase(types.MarketSpot, f.URL)
+	return f, client
+}
+
+func (f *fakeBinance) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/api/v3/time" {
+		f.timeCalls++
+		fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().Add(f.offset).UnixMilli())
+		return
+	}
+
+	req := fakeRequest{method: r.Method, path: r.URL.Path, query: r.URL.Query(), apiKey: r.Header.Get("X-MBX-APIKEY")}
+	if payload, signature, ok := strings.Cut(r.URL.RawQuery, "&signature="); ok {
+		mac := hmac.New(sha256.New, []byte(testAPISecret))
+		mac.Write([]byte(payload))
+		req.signed = true
+		req.signValid = hex.EncodeToString(mac.Sum(nil)) == signature
+	}
+	f.requests = append(f.requests, req)
+
+	key := r.Method + " " + r.URL.Path
+	resp := fakeResponse{status: http.StatusOK, body: "{}"}
+	if queue := f.responses[key]; len(queue) > 0 {
+		resp = queue[0]
+		if len(queue) > 1 {
+			f.responses[key] = queue[1:]
+		}
+	}
+	w.WriteHeader(resp.status)
+	w.Write([]byte(resp.body))
+}
+
+// respond 预设某个接口的响应序列
+func (f *fakeBinance) respond(method, path string, responses ...fakeResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[method+" "+path] = responses
+}
+
+// setOffset 设置服务器时间相对本地时间的偏移
+func (f *fakeBinance) setOffset(offset time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offset = offset
+}
+
+// received 收到的请求和 /time 调用次数
+func (f *fakeBinance) received() ([]fakeRequest, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...), f.timeCalls
+}
+
+// requestTime 请求中的timestamp参数
+func requestTime(t *testing.T, req fakeRequest) time.Time {
+	t.Helper()
+	ms, err := strconv.ParseInt(req.query.Get("timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp参数无效: %q", req.query.Get("timestamp"))
+	}
+	return time.UnixMilli(ms)
+}
+
+func TestSignedRequest(t *testing.T) {
+	f, client := newFakeBinance(t)
+	f.setOffset(time.Hour)
+	f.respond(http.MethodGet, "/api/v3/account", fakeResponse{http.StatusOK, `{"balances":[{"asset":"USDT","free":"100.5","locked":"1.5"},{"asset":"BTC","free":"0","locked":"0"}]}`})
+
+	balances, err := client.GetBalances(types.MarketSpot)
+	if err != nil {
+		t.Fatalf("获取余额失败: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Asset != "USDT" || balances[0].Total != 102 {
+		t.Fatalf("余额解析不正确: %+v", balances)
+	}
+
+	requests, timeCalls := f.received()
+	if timeCalls != 1 || len(requests) != 1 {
+		t.Fatalf("首次签名请求前应同步一次时间: time=%d, requests=%d", timeCalls, len(requests))
+	}
+	req := requests[0]
+	if !req.signed || !req.signValid || req.apiKey != testAPIKey {
+		t.Fatalf("签名或API Key不正确: %+v", req)
+	}
+	if req.query.Get("recvWindow") != "5000" {
+		t.Fatalf("recvWindow %q，期望 5000", req.query.Get("recvWindow"))
+	}
+	// 时间戳按服务器时间偏移校正
+	if skew := time.Until(requestTime(t, req)) - time.Hour; skew < -5*time.Second || skew > 5*time.Second {
+		t.Fatalf("时间戳未按服务器时间校正，偏差 %v", skew)
+	}
+
+	client.SetRecvWindow(10 * time.Second)
+	if _, err := client.GetBalances(types.MarketSpot); err != nil {
+		t.Fatalf("获取余额失败: %v", err)
+	}
+	requests, timeCalls = f.received()
+	if timeCalls != 1 || requests[1].query.Get("recvWindow") != "10000" || !requests[1].signValid {
+		t.Fatalf("第二次请求不应重新同步时间，且按新recvWindow签名: time=%d, %+v", timeCalls, requests[1])
+	}
+
+	// 未签名请求不带签名参数
+	if _, err := client.request(http.MethodGet, "/api/v3/exchangeInfo", nil, false); err != nil {
+		t.Fatalf("未签名请求失败: %v", err)
+	}
+	requests, _ = f.received()
+	if last := requests[len(requests)-1]; last.signed || last.query.Has("timestamp") {
+		t.Fatalf("未签名请求不应带时间戳和签名: %+v", last)
+	}
+}
+
+func TestRequestRetryPolicy(t *testing.T) {
+	f, client := newFakeBinance(t)
+	unavailable := fakeResponse{http.StatusServiceUnavailable, `{"code":-1001,"msg":"Internal error"}`}
+	f.respond(http.MethodPost, "/api/v3/order", unavailable)
+	f.respond(http.MethodDelete, "/api/v3/order", unavailable)
+	f.respond(http.MethodGet, "/api/v3/order", unavailable, fakeResponse{http.StatusOK, `{"symbol":"BTCUSDT","orderId":7,"status":"FILLED","origQty":"0.5","executedQty":"0.5","cummulativeQuoteQty":"50"}`})
+
+	count := func(method string) int {
+		requests, _ := f.received()
+		n := 0
+		for _, req := range requests {
+			if req.method == method && req.path == "/api/v3/order" {
+				n++
+			}
+		}
+		return n
+	}
+
+	// 下单和撤单失败时不重试，避免重复下单
+	_, err := client.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.5}, "cid-1")
+	if err == nil || count(http.MethodPost) != 1 {
+		t.Fatalf("下单失败时不应重试: err=%v, 请求%d次", err, count(http.MethodPost))
+	}
+	if _, err := client.CancelOrder("BTCUSDT", 7); err == nil || count(http.MethodDelete) != 1 {
+		t.Fatalf("撤单失败时不应重试: err=%v, 请求%d次", err, count(http.MethodDelete))
+	}
+
+	// 查询在5xx后重试，并重新生成时间戳和签名
+	order, err := client.GetOrder("BTCUSDT", 7)
+	if err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if order.OrderID != 7 || order.Status != OrderStatusFilled || order.AvgPrice != 100 {
+		t.Fatalf("订单解析不正确: %+v", order)
+	}
+	if count(http.MethodGet) != 2 {
+		t.Fatalf("查询应重试一次后成功，实际请求%d次", count(http.MethodGet))
+	}
+	requests, _ := f.received()
+	for _, req := range requests {
+		if !req.signValid {
+			t.Fatalf("签名无效: %+v", req)
+		}
+	}
+
+	// 参数错误等4xx不重试
+	f.respond(http.MethodGet, "/api/v3/openOrders", fakeResponse{http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`})
+	if _, err := client.GetOpenOrders("BTCUSDT"); err == nil {
+		t.Fatalf("查询挂单应失败")
+	}
+	requests, _ = f.received()
+	n := 0
+	for _, req := range requests {
+		if req.path == "/api/v3/openOrders" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Fatalf("4xx不应重试，实际请求%d次", n)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	f, client := newFakeBinance(t)
+	f.respond(http.MethodPost, "/api/v3/order", fakeResponse{http.StatusBadRequest, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`})
+	f.respond(http.MethodDelete, "/api/v3/order", fakeResponse{http.StatusForbidden, `<html>WAF</html>`})
+
+	_, err := client.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1}, "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("应返回 *APIError: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != -2010 || !strings.Contains(apiErr.Message, "insufficient balance") {
+		t.Fatalf("错误解析不正确: %+v", apiErr)
+	}
+
+	// 无法解析的响应体原样保留
+	_, err = client.CancelOrder("BTCUSDT", 1)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Code != 0 || apiErr.Message != "<html>WAF</html>" {
+		t.Fatalf("错误解析不正确: %v", err)
+	}
+}
+
+func TestTimestampRejectedResyncs(t *testing.T) {
+	f, client := newFakeBinance(t)
+	rejected := fakeResponse{http.StatusBadRequest, `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`}
+	f.respond(http.MethodPost, "/api/v3/order", rejected, fakeResponse{http.StatusOK, `{"symbol":"BTCUSDT","orderId":9,"status":"NEW","origQty":"1"}`})
+
+	// 首次同步后服务器时间跳变，第一次下单被拒绝，重新同步后按新的偏移重新签名发送一次
+	client.SyncTime(types.MarketSpot)
+	f.setOffset(30 * time.Second)
+	order, err := client.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1}, "")
+	if err != nil {
+		t.Fatalf("重新同步后下单应成功: %v", err)
+	}
+	if order.OrderID != 9 {
+		t.Fatalf("订单解析不正确: %+v", order)
+	}
+
+	requests, timeCalls := f.received()
+	if timeCalls != 2 || len(requests) != 2 {
+		t.Fatalf("应重新同步一次时间并重发一次: time=%d, requests=%d", timeCalls, len(requests))
+	}
+	first, second := requestTime(t, requests[0]), requestTime(t, requests[1])
+	if !requests[1].signValid || second.Sub(first) < 25*time.Second {
+		t.Fatalf("重发的请求应按新的时间偏移重新签名: %v -> %v", first, second)
+	}
+	if offset := client.TimeOffset(types.MarketSpot); offset < 25*time.Second {
+		t.Fatalf("时间偏移未更新: %v", offset)
+	}
+
+	// 重新签名后仍被拒绝时只重发一次就返回错误
+	f.respond(http.MethodPost, "/api/v3/order", rejected)
+	_, err = client.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 1}, "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != errCodeTimestamp {
+		t.Fatalf("应返回-1021错误: %v", err)
+	}
+	requests, timeCalls = f.received()
+	if timeCalls != 3 || len(requests) != 4 {
+		t.Fatalf("只应重新签名一次: time=%d, requests=%d", timeCalls, len(requests))
+	}
+}
@@ -0,0 +1,159 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// 确保 Client 实现 FuturesDataProvider 接口
+var _ exchange.FuturesDataProvider = (*Client)(nil)
+
+// marketEndpoints 各市场的REST和WebSocket地址
+type marketEndpoints struct {
+	restBase    string // REST根地址
+	apiPrefix   string // REST路径前缀
+	wsHost      string // K线WebSocket主机
+	weightLimit int    // 每分钟请求权重上限（IP限制）
+}
+
+var endpoints = map[types.Market]marketEndpoints{
+	types.MarketSpot:  {restBase: "https://api.binance.com", apiPrefix: "/api/v3", wsHost: "stream.binance.com:9443", weightLimit: 6000},
+	types.MarketUSDM:  {restBase: "https://fapi.binance.com", apiPrefix: "/fapi/v1", wsHost: "fstream.binance.com", weightLimit: 2400},
+	types.MarketCOINM: {restBase: "https://dapi.binance.com", apiPrefix: "/dapi/v1", wsHost: "dstream.binance.com", weightLimit: 2400},
+}
+
+// endpointsFor 获取市场对应的地址（未知市场按现货处理）
+func endpointsFor(market types.Market) marketEndpoints {
+	if e, ok := endpoints[market]; ok {
+		return e
+	}
+	return endpoints[types.MarketSpot]
+}
+
+// endpointMarket 根据REST路径判断所属市场
+func endpointMarket(endpoint string) types.Market {
+	switch {
+	case strings.HasPrefix(endpoint, "/fapi/"):
+		return types.MarketUSDM
+	case strings.HasPrefix(endpoint, "/dapi/"):
+		return types.MarketCOINM
+	}
+	return types.MarketSpot
+}
+
+// marketSelector 每个交易对的市场选择
+type marketSelector struct {
+	mu            sync.RWMutex
+	defaultMarket types.Market
+	markets       map[types.Symbol]types.Market
+}
+
+// SetMarkets 设置默认市场和每个交易对的市场（交易对统一为大写）
+func (c *Client) SetMarkets(defaultMarket types.Market, markets map[types.Symbol]types.Market) {
+	normalized := make(map[types.Symbol]types.Market, len(markets))
+	for symbol, market := range markets {
+		normalized[types.Symbol(strings.ToUpper(string(symbol)))] = market
+	}
+
+	c.markets.mu.Lock()
+	c.markets.defaultMarket = defaultMarket
+	c.markets.markets = normalized
+	c.markets.mu.Unlock()
+}
+
+// MarketOf 获取交易对所属的市场类型
+func (c *Client) MarketOf(symbol types.Symbol) types.Market {
+	c.markets.mu.RLock()
+	defer c.markets.mu.RUnlock()
+
+	if market, ok := c.markets.markets[types.Symbol(strings.ToUpper(string(symbol)))]; ok {
+		return market
+	}
+	if c.markets.defaultMarket != "" {
+		return c.markets.defaultMarket
+	}
+	return types.MarketSpot
+}
+
+// GetFuturesStats 获取合约的标记价格、资金费率和持仓量
+func (c *Client) GetFuturesStats(symbol types.Symbol) (*exchange.FuturesStats, error) {
+	market := c.MarketOf(symbol)
+	if !market.IsFutures() {
+		return nil, fmt.Errorf("%s 不是合约交易对（市场: %s）", symbol, market)
+	}
+	prefix := endpointsFor(market).apiPrefix
+
+	params := url.Values{}
+	params.Set("symbol", string(symbol))
+
+	body, err := c.getRaw(prefix+"/premiumIndex", params, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取标记价格失败: %w", err)
+	}
+	premium, err := parsePremiumIndex(body)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = c.getRaw(prefix+"/openInterest", params, false)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓量失败: %w", err)
+	}
+	var oi struct {
+		OpenInterest string `json:"openInterest"`
+	}
+	if err := json.Unmarshal(body, &oi); err != nil {
+		return nil, fmt.Errorf("解析持仓量失败: %w", err)
+	}
+
+	stats := &exchange.FuturesStats{
+		Symbol:          string(symbol),
+		Market:          market,
+		NextFundingTime: time.UnixMilli(premium.NextFundingTime),
+		UpdatedAt:       time.UnixMilli(premium.Time),
+	}
+	stats.MarkPrice, _ = strconv.ParseFloat(premium.MarkPrice, 64)
+	stats.IndexPrice, _ = strconv.ParseFloat(premium.IndexPrice, 64)
+	stats.FundingRate, _ = strconv.ParseFloat(premium.LastFundingRate, 64)
+	stats.OpenInterest, _ = strconv.ParseFloat(oi.OpenInterest, 64)
+	return stats, nil
+}
+
+// premiumIndex /premiumIndex 响应
+type premiumIndex struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+	Time            int64  `json:"time"`
+}
+
+// parsePremiumIndex 解析标记价格（U本位返回对象，币本位返回数组）
+func parsePremiumIndex(body []byte) (*premiumIndex, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var list []premiumIndex
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, fmt.Errorf("解析标记价格失败: %w", err)
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("标记价格为空")
+		}
+		return &list[0], nil
+	}
+
+	var p premiumIndex
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("解析标记价格失败: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,109 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// 确保 Client 实现 MarketDataProvider 接口
+var _ exchange.MarketDataProvider = (*Client)(nil)
+
+// Name 数据源名称
+func (c *Client) Name() string {
+	return "binance"
+}
+
+// SubscribeKlines 订阅实时K线流（按交易对所属市场连接现货或合约WebSocket）
+func (c *Client) SubscribeKlines(symbol types.Symbol, interval string) (exchange.KlineStream, error) {
+	ws := NewWebSocketClient(symbol, interval, c.MarketOf(symbol))
+	ws.SetBackfill(func(since time.Time) ([]types.Kline, error) {
+		return c.GetKlinesRange(symbol, interval, since, time.Now())
+	})
+	if err := ws.Connect(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// exchangeInfoResponse /api/v3/exchangeInfo 响应（只解析需要的字段）
+type exchangeInfoResponse struct {
+	Symbols []struct {
+		Symbol string `json:"symbol"`
+		Status string `json:"status"`
+		// 合约交易对的状态字段为contractStatus（币本位）或status（U本位）
+		ContractStatus string `json:"contractStatus"`
+		BaseAsset      string `json:"baseAsset"`
+		QuoteAsset     string `json:"quoteAsset"`
+		Filters        []struct {
+			FilterType  string `json:"filterType"`
+			TickSize    string `json:"tickSize"`
+			StepSize    string `json:"stepSize"`
+			MinQty      string `json:"minQty"`
+			MinNotional string `json:"minNotional"`
+			Notional    string `json:"notional"` // U本位MIN_NOTIONAL使用该字段
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+// GetSymbolInfo 获取交易对元数据（按交易对所属市场请求现货或合约接口）
+func (c *Client) GetSymbolInfo(symbol types.Symbol) (*exchange.SymbolInfo, error) {
+	market := c.MarketOf(symbol)
+	params := url.Values{}
+	if !market.IsFutures() {
+		// 合约的exchangeInfo不支持symbol参数，返回全部交易对
+		params.Set("symbol", string(symbol))
+	}
+
+	body, err := c.getRaw(endpointsFor(market).apiPrefix+"/exchangeInfo", params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp exchangeInfoResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析交易对信息失败: %w", err)
+	}
+	idx := -1
+	for i := range resp.Symbols {
+		if resp.Symbols[i].Symbol == string(symbol) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("未知的交易对: %s", symbol)
+	}
+
+	s := resp.Symbols[idx]
+	if s.Status == "" {
+		s.Status = s.ContractStatus
+	}
+	info := &exchange.SymbolInfo{
+		Symbol:     s.Symbol,
+		Status:     s.Status,
+		BaseAsset:  s.BaseAsset,
+		QuoteAsset: s.QuoteAsset,
+	}
+	for _, f := range s.Filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			info.TickSize, _ = strconv.ParseFloat(f.TickSize, 64)
+		case "LOT_SIZE":
+			info.StepSize, _ = strconv.ParseFloat(f.StepSize, 64)
+			info.MinQty, _ = strconv.ParseFloat(f.MinQty, 64)
+		case "NOTIONAL", "MIN_NOTIONAL":
+			value := f.MinNotional
+			if value == "" {
+				value = f.Notional
+			}
+			info.MinNotional, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,76 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// 确保 Client 实现 SymbolLister 接口
+var _ exchange.SymbolLister = (*Client)(nil)
+
+// ticker24hr /ticker/24hr 响应中的单个交易对（只解析需要的字段）
+type ticker24hr struct {
+	Symbol      string `json:"symbol"`
+	LastPrice   string `json:"lastPrice"`
+	QuoteVolume string `json:"quoteVolume"`
+	BaseVolume  string `json:"baseVolume"` // 币本位合约没有quoteVolume
+}
+
+// ListSymbols 获取指定市场的所有交易对（exchangeInfo）及其24小时成交额（ticker/24hr）
+func (c *Client) ListSymbols(market types.Market) ([]exchange.SymbolTicker, error) {
+	if market == "" {
+		market = types.MarketSpot
+	}
+	prefix := endpointsFor(market).apiPrefix
+
+	body, err := c.getRaw(prefix+"/exchangeInfo", url.Values{}, false)
+	if err != nil {
+		return nil, err
+	}
+	var info exchangeInfoResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("解析交易对信息失败: %w", err)
+	}
+
+	body, err = c.getRaw(prefix+"/ticker/24hr", url.Values{}, false)
+	if err != nil {
+		return nil, err
+	}
+	var tickers []ticker24hr
+	if err := json.Unmarshal(body, &tickers); err != nil {
+		return nil, fmt.Errorf("解析24小时行情失败: %w", err)
+	}
+	bySymbol := make(map[string]ticker24hr, len(tickers))
+	for _, t := range tickers {
+		bySymbol[t.Symbol] = t
+	}
+
+	result := make([]exchange.SymbolTicker, 0, len(info.Symbols))
+	for _, s := range info.Symbols {
+		status := s.Status
+		if status == "" {
+			status = s.ContractStatus
+		}
+		ticker := exchange.SymbolTicker{
+			Symbol:     s.Symbol,
+			Status:     status,
+			BaseAsset:  s.BaseAsset,
+			QuoteAsset: s.QuoteAsset,
+		}
+		if t, ok := bySymbol[s.Symbol]; ok {
+			ticker.LastPrice, _ = strconv.ParseFloat(t.LastPrice, 64)
+			volume := t.QuoteVolume
+			if volume == "" {
+				volume = t.BaseVolume
+			}
+			ticker.QuoteVolume, _ = strconv.ParseFloat(volume, 64)
+		}
+		result = append(result, ticker)
+	}
+	return result, nil
+}
@@ -0,0 +1,358 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// Binance订单状态
+const (
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCanceled        = "CANCELED"
+	OrderStatusRejected        = "REJECTED"
+	OrderStatusExpired         = "EXPIRED"
+)
+
+// Balance 账户资产余额（现货和合约统一格式）
+type Balance struct {
+	Asset         string  `json:"asset"`
+	Free          float64 `json:"free"`           // 可用余额（合约为availableBalance）
+	Locked        float64 `json:"locked"`         // 冻结余额（仅现货）
+	Total         float64 `json:"total"`          // 总余额（现货为free+locked，合约为钱包余额）
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏（仅合约）
+}
+
+// Order Binance订单（现货和合约统一格式）
+type Order struct {
+	Symbol        string       `json:"symbol"`
+	Market        types.Market `json:"market"`
+	OrderID       int64        `json:"order_id"`
+	ClientOrderID string       `json:"client_order_id"`
+	Side          string       `json:"side"`   // BUY、SELL
+	Type          string       `json:"type"`   // MARKET、LIMIT、STOP_LOSS（现货）、STOP_MARKET（合约）等
+	Status        string       `json:"status"` // NEW、PARTIALLY_FILLED、FILLED、CANCELED、REJECTED、EXPIRED
+	TimeInForce   string       `json:"time_in_force,omitempty"`
+	Price         float64      `json:"price"`
+	StopPrice     float64      `json:"stop_price,omitempty"`
+	Quantity      float64      `json:"quantity"`
+	ExecutedQty   float64      `json:"executed_qty"`
+	AvgPrice      float64      `json:"avg_price"` // 成交均价，未成交时为0
+	ReduceOnly    bool         `json:"reduce_only,omitempty"`
+	Time          time.Time    `json:"time"`
+	UpdateTime    time.Time    `json:"update_time"`
+}
+
+// Position 合约持仓
+type Position struct {
+	Symbol        string  `json:"symbol"`
+	PositionSide  string  `json:"position_side"` // BOTH（单向持仓）、LONG、SHORT（双向持仓）
+	Amount        float64 `json:"amount"`        // 持仓数量，空头为负数
+	EntryPrice    float64 `json:"entry_price"`
+	MarkPrice     float64 `json:"mark_price"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// IsFinal 订单是否已结束（不会再有成交）
+func (o *Order) IsFinal() bool {
+	switch o.Status {
+	case OrderStatusFilled, OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired:
+		return true
+	}
+	return false
+}
+
+// orderResponse 订单接口响应（现货和合约字段的并集）
+type orderResponse struct {
+	Symbol              string `json:"symbol"`
+	OrderID             int64  `json:"orderId"`
+	ClientOrderID       string `json:"clientOrderId"`
+	Side                string `json:"side"`
+	Type                string `json:"type"`
+	Status              string `json:"status"`
+	TimeInForce         string `json:"timeInForce"`
+	Price               string `json:"price"`
+	StopPrice           string `json:"stopPrice"`
+	OrigQty             string `json:"origQty"`
+	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"` // 现货成交额
+	AvgPrice            string `json:"avgPrice"`            // 合约成交均价
+	ReduceOnly          bool   `json:"reduceOnly"`
+	Time                int64  `json:"time"`
+	TransactTime        int64  `json:"transactTime"` // 现货下单响应
+	UpdateTime          int64  `json:"updateTime"`
+}
+
+// toOrder 转换为统一格式
+func (r *orderResponse) toOrder(market types.Market) Order {
+	order := Order{
+		Symbol:        r.Symbol,
+		Market:        market,
+		OrderID:       r.OrderID,
+		ClientOrderID: r.ClientOrderID,
+		Side:          r.Side,
+		Type:          r.Type,
+		Status:        r.Status,
+		TimeInForce:   r.TimeInForce,
+		ReduceOnly:    r.ReduceOnly,
+	}
+	order.Price, _ = strconv.ParseFloat(r.Price, 64)
+	order.StopPrice, _ = strconv.ParseFloat(r.StopPrice, 64)
+	order.Quantity, _ = strconv.ParseFloat(r.OrigQty, 64)
+	order.ExecutedQty, _ = strconv.ParseFloat(r.ExecutedQty, 64)
+	order.AvgPrice, _ = strconv.ParseFloat(r.AvgPrice, 64)
+	if order.AvgPrice == 0 && order.ExecutedQty > 0 {
+		// 现货没有成交均价字段，按成交额计算
+		quote, _ := strconv.ParseFloat(r.CummulativeQuoteQty, 64)
+		order.AvgPrice = quote / order.ExecutedQty
+	}
+
+	created := r.Time
+	if created == 0 {
+		created = r.TransactTime
+	}
+	updated := r.UpdateTime
+	if updated == 0 {
+		updated = created
+	}
+	if created != 0 {
+		order.Time = time.UnixMilli(created)
+	}
+	if updated != 0 {
+		order.UpdateTime = time.UnixMilli(updated)
+	}
+	return order
+}
+
+// parseOrder 解析单个订单
+func parseOrder(body []byte, market types.Market) (*Order, error) {
+	var resp orderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析订单失败: %w", err)
+	}
+	order := resp.toOrder(market)
+	return &order, nil
+}
+
+// GetBalances 获取指定市场的账户余额（只返回余额不为0的资产）
+// 现货: /api/v3/account，U本位: /fapi/v2/balance，币本位: /dapi/v1/balance
+func (c *Client) GetBalances(market types.Market) ([]Balance, error) {
+	if market == "" {
+		market = types.MarketSpot
+	}
+
+	if !market.IsFutures() {
+		body, err := c.request(http.MethodGet, "/api/v3/account", nil, true)
+		if err != nil {
+			return nil, fmt.Errorf("获取账户余额失败: %w", err)
+		}
+		var account struct {
+			Balances []struct {
+				Asset  string `json:"asset"`
+				Free   string `json:"free"`
+				Locked string `json:"locked"`
+			} `json:"balances"`
+		}
+		if err := json.Unmarshal(body, &account); err != nil {
+			return nil, fmt.Errorf("解析账户余额失败: %w", err)
+		}
+
+		balances := []Balance{}
+		for _, b := range account.Balances {
+			balance := Balance{Asset: b.Asset}
+			balance.Free, _ = strconv.ParseFloat(b.Free, 64)
+			balance.Locked, _ = strconv.ParseFloat(b.Locked, 64)
+			balance.Total = balance.Free + balance.Locked
+			if balance.Total != 0 {
+				balances = append(balances, balance)
+			}
+		}
+		return balances, nil
+	}
+
+	endpoint := "/fapi/v2/balance"
+	if market == types.MarketCOINM {
+		endpoint = "/dapi/v1/balance"
+	}
+	body, err := c.request(http.MethodGet, endpoint, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("获取合约余额失败: %w", err)
+	}
+	var list []struct {
+		Asset            string `json:"asset"`
+		Balance          string `json:"balance"`
+		AvailableBalance string `json:"availableBalance"`
+		CrossUnPnl       string `json:"crossUnPnl"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析合约余额失败: %w", err)
+	}
+
+	balances := []Balance{}
+	for _, b := range list {
+		balance := Balance{Asset: b.Asset}
+		balance.Total, _ = strconv.ParseFloat(b.Balance, 64)
+		balance.Free, _ = strconv.ParseFloat(b.AvailableBalance, 64)
+		balance.UnrealizedPnL, _ = strconv.ParseFloat(b.CrossUnPnl, 64)
+		if balance.Total != 0 || balance.UnrealizedPnL != 0 {
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
+
+// GetPositions 获取合约交易对的持仓（U本位: /fapi/v2/positionRisk，币本位: /dapi/v1/positionRisk）
+func (c *Client) GetPositions(symbol types.Symbol) ([]Position, error) {
+	market := c.MarketOf(symbol)
+	if !market.IsFutures() {
+		return nil, fmt.Errorf("%s 不是合约交易对（市场: %s）", symbol, market)
+	}
+	endpoint := "/fapi/v2/positionRisk"
+	params := url.Values{}
+	if market == types.MarketCOINM {
+		endpoint = "/dapi/v1/positionRisk"
+		params.Set("pair", strings.ToUpper(strings.SplitN(string(symbol), "_", 2)[0]))
+	} else {
+		params.Set("symbol", strings.ToUpper(string(symbol)))
+	}
+
+	body, err := c.request(http.MethodGet, endpoint, params, true)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var list []struct {
+		Symbol           string `json:"symbol"`
+		PositionSide     string `json:"positionSide"`
+		PositionAmt      string `json:"positionAmt"`
+		EntryPrice       string `json:"entryPrice"`
+		MarkPrice        string `json:"markPrice"`
+		UnRealizedProfit string `json:"unRealizedProfit"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析持仓失败: %w", err)
+	}
+
+	positions := []Position{}
+	for _, p := range list {
+		if !strings.EqualFold(p.Symbol, string(symbol)) {
+			continue
+		}
+		position := Position{Symbol: p.Symbol, PositionSide: p.PositionSide}
+		position.Amount, _ = strconv.ParseFloat(p.PositionAmt, 64)
+		position.EntryPrice, _ = strconv.ParseFloat(p.EntryPrice, 64)
+		position.MarkPrice, _ = strconv.ParseFloat(p.MarkPrice, 64)
+		position.UnrealizedPnL, _ = strconv.ParseFloat(p.UnRealizedProfit, 64)
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// GetOpenOrders 获取交易对的当前挂单（按交易对所属市场请求现货或合约接口）
+func (c *Client) GetOpenOrders(symbol types.Symbol) ([]Order, error) {
+	market := c.MarketOf(symbol)
+	params := url.Values{}
+	params.Set("symbol", strings.ToUpper(string(symbol)))
+
+	body, err := c.request(http.MethodGet, endpointsFor(market).apiPrefix+"/openOrders", params, true)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+	var list []orderResponse
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("解析挂单失败: %w", err)
+	}
+
+	orders := make([]Order, 0, len(list))
+	for i := range list {
+		orders = append(orders, list[i].toOrder(market))
+	}
+	return orders, nil
+}
+
+// PlaceOrder 下单（按交易对所属市场请求现货或合约接口）
+// 订单类型映射: market→MARKET，limit→LIMIT（GTC），stop→STOP_LOSS（现货）/STOP_MARKET（合约）；
+// 只减仓仅合约支持。clientOrderID为空时由Binance生成
+func (c *Client) PlaceOrder(req types.OrderRequest, clientOrderID string) (*Order, error) {
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	market := c.MarketOf(types.Symbol(req.Symbol))
+	if req.ReduceOnly && !market.IsFutures() {
+		return nil, fmt.Errorf("现货不支持只减仓订单")
+	}
+
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("side", strings.ToUpper(req.Side))
+	params.Set("quantity", formatDecimal(req.Quantity))
+	params.Set("newOrderRespType", "RESULT")
+	switch req.Type {
+	case types.OrderTypeMarket:
+		params.Set("type", "MARKET")
+	case types.OrderTypeLimit:
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", formatDecimal(req.Price))
+	case types.OrderTypeStop:
+		if market.IsFutures() {
+			params.Set("type", "STOP_MARKET")
+		} else {
+			params.Set("type", "STOP_LOSS")
+		}
+		params.Set("stopPrice", formatDecimal(req.StopPrice))
+	}
+	if req.ReduceOnly {
+		params.Set("reduceOnly", "true")
+	}
+	if clientOrderID != "" {
+		params.Set("newClientOrderId", clientOrderID)
+	}
+
+	body, err := c.request(http.MethodPost, endpointsFor(market).apiPrefix+"/order", params, true)
+	if err != nil {
+		return nil, fmt.Errorf("下单失败: %w", err)
+	}
+	return parseOrder(body, market)
+}
+
+// CancelOrder 撤销订单
+func (c *Client) CancelOrder(symbol types.Symbol, orderID int64) (*Order, error) {
+	market := c.MarketOf(symbol)
+	body, err := c.request(http.MethodDelete, endpointsFor(market).apiPrefix+"/order", orderParams(symbol, orderID), true)
+	if err != nil {
+		return nil, fmt.Errorf("撤单失败: %w", err)
+	}
+	return parseOrder(body, market)
+}
+
+// GetOrder 查询订单状态
+func (c *Client) GetOrder(symbol types.Symbol, orderID int64) (*Order, error) {
+	market := c.MarketOf(symbol)
+	body, err := c.request(http.MethodGet, endpointsFor(market).apiPrefix+"/order", orderParams(symbol, orderID), true)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	return parseOrder(body, market)
+}
+
+// orderParams 按订单ID定位订单的参数
+func orderParams(symbol types.Symbol, orderID int64) url.Values {
+	params := url.Values{}
+	params.Set("symbol", strings.ToUpper(string(symbol)))
+	params.Set("orderId", strconv.FormatInt(orderID, 10))
+	return params
+}
+
+// formatDecimal 格式化数量和价格（不使用科学计数法）
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
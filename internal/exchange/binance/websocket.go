@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/binance_cyan/indicators/internal/exchange"
 	"github.com/binance_cyan/indicators/pkg/types"
 	"github.com/gorilla/websocket"
 )
@@ -24,7 +27,7 @@ type KLineData struct {
 		Symbol               string `json:"s"` // 交易对
 		Interval             string `json:"i"` // K线周期
 		FirstTradeID         int64  `json:"f"` // 第一笔成交ID
-		LastTradeID          int64  `json:"l"` // 最后一笔成交ID
+		LastTradeID          int64  `json:"L"` // 最后一笔成交ID
 		OpenPrice            string `json:"o"` // 开盘价
 		ClosePrice           string `json:"c"` // 收盘价
 		HighPrice            string `json:"h"` // 最高价
@@ -39,58 +42,91 @@ type KLineData struct {
 	} `json:"k"`
 }
 
-// TickData 实时tick数据（从K线数据中提取）
-type TickData struct {
-	Symbol    string    `json:"symbol"`
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"`
-}
+const (
+	// reconnectMinDelay 重连等待的初始时间（之后每次翻倍）
+	reconnectMinDelay = 1 * time.Second
+	// reconnectMaxDelay 重连等待的最长时间
+	reconnectMaxDelay = 60 * time.Second
+	// readTimeout 超过该时间未收到任何消息视为连接失效，主动断开重连
+	readTimeout = 60 * time.Second
+	// staleAfter 已连接但超过该时间未收到K线更新时，状态为stale
+	staleAfter = 15 * time.Second
+)
 
-// WebSocketClient WebSocket客户端
+// BackfillFunc 断线重连后补齐缺口的K线获取函数，返回开盘时间不早于since的K线（从旧到新）
+type BackfillFunc func(since time.Time) ([]types.Kline, error)
+
+// WebSocketClient WebSocket客户端（实现 exchange.KlineStream）
+// 连接断开后按指数退避自动重连，重连成功后先通过REST补齐缺口K线再继续推送实时数据
 type WebSocketClient struct {
-	conn     *websocket.Conn
 	symbol   types.Symbol
 	interval string
-	baseURL  string
-	tickChan chan *TickData
+	market   types.Market
+	updates  chan *exchange.KlineUpdate
 	done     chan struct{}
+	once     sync.Once
+	backfill BackfillFunc
+	// streamBase 覆盖的K线流根地址（如本地模拟服务 ws://127.0.0.1:port），为空时使用正式环境
+	streamBase string
+
+	mu          sync.Mutex
+	conn        *websocket.Conn
+	state       exchange.ConnState
+	lastMessage time.Time // 最近一次收到K线更新的时间
+	lastOpen    time.Time // 最近一次收到的K线开盘时间
 }
 
-// NewWebSocketClient 创建WebSocket客户端
-func NewWebSocketClient(symbol types.Symbol, interval string, baseURL string) *WebSocketClient {
+// NewWebSocketClient 创建WebSocket客户端（market 决定连接现货或合约K线流）
+func NewWebSocketClient(symbol types.Symbol, interval string, market types.Market) *WebSocketClient {
 	return &WebSocketClient{
 		symbol:   symbol,
 		interval: interval,
-		baseURL:  baseURL,
-		tickChan: make(chan *TickData, 100),
+		market:   market,
+		updates:  make(chan *exchange.KlineUpdate, 100),
 		done:     make(chan struct{}),
+		state:    exchange.ConnReconnecting,
 	}
 }
 
-// Connect 连接WebSocket（现货K线流，强制使用正式环境）
-func (w *WebSocketClient) Connect() error {
-	// 现货WebSocket K线流格式：wss://stream.binance.com:9443/ws/{symbol}@kline_{interval}
+// SetBackfill 设置重连后补齐缺口使用的K线获取函数（需在Connect之前调用）
+func (w *WebSocketClient) SetBackfill(fn BackfillFunc) {
+	w.backfill = fn
+}
+
+// streamURL K线流地址
+// 格式：wss://{host}/ws/{symbol}@kline_{interval}
+// 现货: stream.binance.com:9443，U本位: fstream.binance.com，币本位: dstream.binance.com
+func (w *WebSocketClient) streamURL() string {
 	// symbol必须小写
 	symbolLower := strings.ToLower(string(w.symbol))
 	streamName := fmt.Sprintf("%s@kline_%s", symbolLower, w.interval)
+	if w.streamBase != "" {
+		return strings.TrimRight(w.streamBase, "/") + "/ws/" + streamName
+	}
 
 	u := url.URL{
 		Scheme: "wss",
-		Host:   "stream.binance.com:9443",
+		Host:   endpointsFor(w.market).wsHost,
 		Path:   "/ws/" + streamName,
 	}
-	wsURL := u.String()
-
-	log.Printf("连接币安正式环境现货K线WebSocket: %s (symbol: %s, interval: %s)", wsURL, symbolLower, w.interval)
+	return u.String()
+}
 
+// dial 建立一次WebSocket连接
+func (w *WebSocketClient) dial() (*websocket.Conn, error) {
 	// 设置WebSocket代理
 	proxyURL, proxyErr := url.Parse("http://127.0.0.1:7890")
 	var dialer websocket.Dialer
 	if proxyErr == nil {
-		log.Printf("已设置WebSocket代理: %s", proxyURL.String())
 		dialer = websocket.Dialer{
 			HandshakeTimeout: 30 * time.Second,
-			Proxy:            http.ProxyURL(proxyURL), // 设置代理
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				// 本地地址（模拟服务）不走代理
+				if ip := net.ParseIP(req.URL.Hostname()); (ip != nil && ip.IsLoopback()) || req.URL.Hostname() == "localhost" {
+					return nil, nil
+				}
+				return proxyURL, nil
+			},
 		}
 	} else {
 		log.Printf("设置WebSocket代理失败，使用直连: %v", proxyErr)
@@ -99,11 +135,20 @@ func (w *WebSocketClient) Connect() error {
 		}
 	}
 
+	conn, _, err := dialer.Dial(w.streamURL(), nil)
+	return conn, err
+}
+
+// Connect 连接WebSocket（K线流，强制使用正式环境）
+// 首次连接失败时返回错误；连接成功后断线由后台协程自动重连
+func (w *WebSocketClient) Connect() error {
+	log.Printf("连接币安正式环境%s K线WebSocket: %s (symbol: %s, interval: %s)", w.market, w.streamURL(), w.symbol, w.interval)
+
 	// 重试机制：最多重试3次
 	var conn *websocket.Conn
 	var err error
 	for i := 0; i < 3; i++ {
-		conn, _, err = dialer.Dial(wsURL, nil)
+		conn, err = w.dial()
 		if err == nil {
 			break
 		}
@@ -117,103 +162,251 @@ func (w *WebSocketClient) Connect() error {
 		return fmt.Errorf("WebSocket连接失败（已重试3次）: %w", err)
 	}
 
-	w.conn = conn
-
-	// 启动心跳协程
-	go w.heartbeat()
-
-	// 启动数据读取协程
-	go w.readLoop()
-
+	w.setConn(conn)
+	go w.run(conn)
 	return nil
 }
 
-// heartbeat 维持心跳（币安要求30秒内发送ping）
-func (w *WebSocketClient) heartbeat() {
-	ticker := time.NewTicker(25 * time.Second) // 25秒发送一次ping（小于30秒）
-	defer ticker.Stop()
+// run 连接生命周期：读取直到断开，然后重连、补齐缺口，直到Close
+func (w *WebSocketClient) run(conn *websocket.Conn) {
+	defer close(w.updates)
 
 	for {
+		err := w.serve(conn)
+		if w.closed() {
+			return
+		}
+		log.Printf("%s@%s WebSocket连接断开，准备重连: %v", w.symbol, w.interval, err)
+
+		w.mu.Lock()
+		w.state = exchange.ConnReconnecting
+		w.conn = nil
+		w.mu.Unlock()
+
+		conn = w.reconnect()
+		if conn == nil {
+			return
+		}
+		w.backfillGap()
+		w.setConn(conn)
+		// Close可能发生在重连期间（此时还没有可关闭的连接）
+		if w.closed() {
+			conn.Close()
+			return
+		}
+		log.Printf("%s@%s WebSocket已重连", w.symbol, w.interval)
+	}
+}
+
+// reconnect 按指数退避重连，Close后返回nil
+func (w *WebSocketClient) reconnect() *websocket.Conn {
+	delay := reconnectMinDelay
+	for attempt := 1; ; attempt++ {
 		select {
-		case <-ticker.C:
-			// 发送ping帧
-			if w.conn != nil {
-				if err := w.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-					log.Printf("发送心跳失败: %v", err)
-					return
-				}
+		case <-w.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		conn, err := w.dial()
+		if err == nil {
+			if w.closed() {
+				conn.Close()
+				return nil
 			}
+			return conn
+		}
+
+		log.Printf("%s@%s WebSocket重连失败（第%d次），%v后重试: %v", w.symbol, w.interval, attempt, delay*2, err)
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+// backfillGap 通过REST补齐断线期间的K线（从最近收到的K线开始，包含其最终值）
+func (w *WebSocketClient) backfillGap() {
+	w.mu.Lock()
+	since := w.lastOpen
+	w.mu.Unlock()
+	if w.backfill == nil || since.IsZero() {
+		return
+	}
+
+	klines, err := w.backfill(since)
+	if err != nil {
+		log.Printf("%s@%s 补齐缺口K线失败: %v", w.symbol, w.interval, err)
+		return
+	}
+
+	step, _ := types.IntervalDuration(w.interval)
+	now := time.Now()
+	for _, k := range klines {
+		update := &exchange.KlineUpdate{
+			Kline:     k,
+			Final:     step > 0 && !k.Timestamp.Add(step).After(now),
+			EventTime: now,
+		}
+		// 补齐的数据必须完整送达，通道满时等待
+		select {
+		case w.updates <- update:
 		case <-w.done:
 			return
 		}
+		w.markUpdate(k.Timestamp)
+	}
+	if len(klines) > 0 {
+		log.Printf("%s@%s 已补齐 %d 根K线（自 %s）", w.symbol, w.interval, len(klines), since.UTC().Format(time.RFC3339))
 	}
 }
 
-// readLoop 读取消息循环（解析K线数据）
-func (w *WebSocketClient) readLoop() {
-	defer close(w.tickChan)
+// serve 在单个连接上读取消息，连接出错时返回
+func (w *WebSocketClient) serve(conn *websocket.Conn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go w.heartbeat(conn, stop)
 
 	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		// 解析K线数据
+		var klineData KLineData
+		if err := json.Unmarshal(message, &klineData); err != nil {
+			log.Printf("解析K线数据失败: %v, 原始数据: %s", err, string(message))
+			continue
+		}
+
+		update, err := klineDataToUpdate(&klineData)
+		if err != nil {
+			log.Printf("解析K线数据失败: %v", err)
+			continue
+		}
+		w.markUpdate(update.Kline.Timestamp)
+
 		select {
-		case <-w.done:
-			return
+		case w.updates <- update:
 		default:
-			_, message, err := w.conn.ReadMessage()
-			if err != nil {
-				log.Printf("WebSocket读取错误: %v", err)
-				return
-			}
-
-			// 解析K线数据
-			var klineData KLineData
-			if err := json.Unmarshal(message, &klineData); err != nil {
-				log.Printf("解析K线数据失败: %v, 原始数据: %s", err, string(message))
-				continue
-			}
+			// 通道满了，跳过
+		}
+	}
+}
 
-			// 只处理K线完结的数据（x=true）或者实时更新的收盘价
-			k := klineData.KLine
+// heartbeat 维持心跳（币安要求30秒内发送ping）
+func (w *WebSocketClient) heartbeat(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(25 * time.Second) // 25秒发送一次ping（小于30秒）
+	defer ticker.Stop()
 
-			// 解析收盘价
-			price, err := parseFloat(k.ClosePrice)
-			if err != nil {
-				log.Printf("解析收盘价失败: %v", err)
-				continue
+	for {
+		select {
+		case <-ticker.C:
+			// 发送ping帧
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				log.Printf("发送心跳失败: %v", err)
+				return
 			}
+		case <-stop:
+			return
+		}
+	}
+}
 
-			// 使用K线结束时间作为时间戳
-			timestamp := time.UnixMilli(k.CloseTime)
-			if k.CloseTime == 0 {
-				timestamp = time.UnixMilli(k.StartTime)
-			}
+// setConn 记录当前连接并标记为已连接
+func (w *WebSocketClient) setConn(conn *websocket.Conn) {
+	w.mu.Lock()
+	w.conn = conn
+	w.state = exchange.ConnConnected
+	w.lastMessage = time.Now()
+	w.mu.Unlock()
+}
 
-			tick := &TickData{
-				Symbol:    klineData.Symbol,
-				Price:     price,
-				Timestamp: timestamp,
-			}
+// markUpdate 记录最近收到的K线
+func (w *WebSocketClient) markUpdate(openTime time.Time) {
+	w.mu.Lock()
+	w.lastMessage = time.Now()
+	if openTime.After(w.lastOpen) {
+		w.lastOpen = openTime
+	}
+	w.mu.Unlock()
+}
 
-			select {
-			case w.tickChan <- tick:
-			default:
-				// 通道满了，跳过
-			}
+// closed 是否已调用Close
+func (w *WebSocketClient) closed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// klineDataToUpdate 将币安K线事件转换为K线更新（Timestamp为K线开盘时间）
+func klineDataToUpdate(data *KLineData) (*exchange.KlineUpdate, error) {
+	k := data.KLine
+	values := make([]float64, 5)
+	for i, s := range []string{k.OpenPrice, k.HighPrice, k.LowPrice, k.ClosePrice, k.BaseVolume} {
+		v, err := parseFloat(s)
+		if err != nil {
+			return nil, fmt.Errorf("解析价格失败: %w", err)
 		}
+		values[i] = v
+	}
+
+	symbol := data.Symbol
+	if symbol == "" {
+		symbol = k.Symbol
 	}
+	return &exchange.KlineUpdate{
+		Kline: types.Kline{
+			Symbol:    symbol,
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+			Timestamp: time.UnixMilli(k.StartTime),
+		},
+		Final:     k.IsFinal,
+		EventTime: time.UnixMilli(data.EventTime),
+	}, nil
 }
 
-// GetTickChan 获取tick数据通道
-func (w *WebSocketClient) GetTickChan() <-chan *TickData {
-	return w.tickChan
+// Updates 获取K线更新通道（断线重连期间保持打开，Close后关闭）
+func (w *WebSocketClient) Updates() <-chan *exchange.KlineUpdate {
+	return w.updates
 }
 
-// Close 关闭连接
-func (w *WebSocketClient) Close() error {
-	close(w.done)
-	if w.conn != nil {
-		return w.conn.Close()
+// State 获取连接状态
+func (w *WebSocketClient) State() exchange.ConnState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed() {
+		return exchange.ConnClosed
 	}
-	return nil
+	if w.state == exchange.ConnConnected && time.Since(w.lastMessage) > staleAfter {
+		return exchange.ConnStale
+	}
+	return w.state
+}
+
+// Close 关闭连接并停止重连
+func (w *WebSocketClient) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		w.mu.Lock()
+		conn := w.conn
+		w.mu.Unlock()
+		if conn != nil {
+			err = conn.Close()
+		}
+	})
+	return err
 }
 
 func parseFloat(s string) (float64, error) {
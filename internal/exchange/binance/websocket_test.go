@@ -0,0 +1,153 @@
+package binance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+	"github.com/gorilla/websocket"
+)
+
+// klineEvent 构造一条K线推送消息，收盘价用于区分同一根K线的不同推送
+func klineEvent(openTime time.Time, close float64, final bool) KLineData {
+	var e KLineData
+	e.EventType = "kline"
+	e.EventTime = openTime.UnixMilli()
+	e.Symbol = "BTCUSDT"
+	e.KLine.StartTime = openTime.UnixMilli()
+	e.KLine.CloseTime = openTime.Add(time.Hour).UnixMilli() - 1
+	e.KLine.Symbol = "BTCUSDT"
+	e.KLine.Interval = "1h"
+	price := strconv.FormatFloat(close, 'f', -1, 64)
+	e.KLine.OpenPrice, e.KLine.ClosePrice, e.KLine.HighPrice, e.KLine.LowPrice = price, price, price, price
+	e.KLine.BaseVolume = "1"
+	e.KLine.IsFinal = final
+	return e
+}
+
+// waitState 轮询连接状态直到等于want
+func waitState(t *testing.T, w *WebSocketClient, want exchange.ConnState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for w.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("连接状态 %s，期望 %s", w.State(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// nextUpdate 读取下一条K线更新
+func nextUpdate(t *testing.T, w *WebSocketClient) *exchange.KlineUpdate {
+	t.Helper()
+	select {
+	case u, ok := <-w.Updates():
+		if !ok {
+			t.Fatal("更新通道已关闭")
+		}
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待K线更新超时")
+		return nil
+	}
+}
+
+func TestWebSocketReconnectBackfill(t *testing.T) {
+	// t3为尚未收盘的K线，t0~t2均已完结
+	t3 := time.Now().Add(time.Hour).Truncate(time.Hour)
+	t2, t1, t0 := t3.Add(-2*time.Hour), t3.Add(-3*time.Hour), t3.Add(-4*time.Hour)
+
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws/btcusdt@kline_1h" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if atomic.AddInt32(&conns, 1) == 1 {
+			// 第一次连接推送两条后直接断开
+			conn.WriteJSON(klineEvent(t0, 100, true))
+			conn.WriteJSON(klineEvent(t1, 101, false))
+			return
+		}
+		conn.WriteJSON(klineEvent(t3, 204, false))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := NewWebSocketClient("BTCUSDT", "1h", types.MarketSpot)
+	client.streamBase = "ws" + strings.TrimPrefix(srv.URL, "http")
+	var since time.Time
+	var backfillState exchange.ConnState
+	client.SetBackfill(func(from time.Time) ([]types.Kline, error) {
+		since, backfillState = from, client.State()
+		return []types.Kline{
+			{Timestamp: t1, Close: 201},
+			{Timestamp: t2, Close: 202},
+			{Timestamp: t3, Close: 203},
+		}, nil
+	})
+	if err := client.Connect(); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	waitState(t, client, exchange.ConnConnected)
+
+	want := []struct {
+		open  time.Time
+		close float64
+		final bool
+	}{
+		{t0, 100, true},
+		{t1, 101, false},
+		// 断线后补齐的K线按时间顺序在实时推送之前到达
+		{t1, 201, true},
+		{t2, 202, true},
+		{t3, 203, false},
+		{t3, 204, false},
+	}
+	for i, w := range want {
+		u := nextUpdate(t, client)
+		if !u.Kline.Timestamp.Equal(w.open) || u.Kline.Close != w.close || u.Final != w.final {
+			t.Fatalf("第%d条更新 %v close=%v final=%v，期望 %v close=%v final=%v",
+				i, u.Kline.Timestamp, u.Kline.Close, u.Final, w.open, w.close, w.final)
+		}
+		if i == 1 {
+			waitState(t, client, exchange.ConnReconnecting)
+		}
+	}
+	if !since.Equal(t1) {
+		t.Fatalf("补齐起点 %v，期望最后一根K线的开盘时间 %v", since, t1)
+	}
+	if backfillState != exchange.ConnReconnecting {
+		t.Fatalf("补齐期间连接状态 %s，期望 %s", backfillState, exchange.ConnReconnecting)
+	}
+	waitState(t, client, exchange.ConnConnected)
+
+	client.Close()
+	if st := client.State(); st != exchange.ConnClosed {
+		t.Fatalf("关闭后连接状态 %s", st)
+	}
+	select {
+	case _, ok := <-client.Updates():
+		if ok {
+			t.Fatal("关闭后不应再有更新")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("关闭后更新通道未关闭")
+	}
+}
@@ -0,0 +1,294 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// MemoryProvider 内存行情数据源（用于离线测试和回放）
+// 通过 SetKlines 预置历史K线，通过 Push 向实时订阅者推送更新
+type MemoryProvider struct {
+	mu      sync.Mutex
+	klines  map[string][]types.Kline
+	symbols map[types.Symbol]*SymbolInfo
+	markets map[types.Symbol]types.Market
+	futures map[types.Symbol]*FuturesStats
+	streams map[string]map[*memoryStream]bool
+}
+
+// NewMemoryProvider 创建内存行情数据源
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		klines:  make(map[string][]types.Kline),
+		symbols: make(map[types.Symbol]*SymbolInfo),
+		markets: make(map[types.Symbol]types.Market),
+		futures: make(map[types.Symbol]*FuturesStats),
+		streams: make(map[string]map[*memoryStream]bool),
+	}
+}
+
+func memoryKey(symbol types.Symbol, interval string) string {
+	return fmt.Sprintf("%s@%s", symbol, interval)
+}
+
+// Name 数据源名称
+func (p *MemoryProvider) Name() string {
+	return "memory"
+}
+
+// SetKlines 设置指定symbol和interval的历史K线（按开盘时间排序后保存）
+func (p *MemoryProvider) SetKlines(symbol types.Symbol, interval string, klines []types.Kline) {
+	sorted := make([]types.Kline, len(klines))
+	copy(sorted, klines)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	p.mu.Lock()
+	p.klines[memoryKey(symbol, interval)] = sorted
+	p.mu.Unlock()
+}
+
+// SetSymbolInfo 设置交易对元数据
+func (p *MemoryProvider) SetSymbolInfo(info SymbolInfo) {
+	p.mu.Lock()
+	p.symbols[types.Symbol(info.Symbol)] = &info
+	p.mu.Unlock()
+}
+
+// SetFuturesStats 设置合约数据，对应交易对按 stats.Market 视为合约市场
+func (p *MemoryProvider) SetFuturesStats(stats FuturesStats) {
+	symbol := types.Symbol(stats.Symbol)
+	p.mu.Lock()
+	p.markets[symbol] = stats.Market
+	p.futures[symbol] = &stats
+	p.mu.Unlock()
+}
+
+// MarketOf 获取交易对所属的市场类型（未设置合约数据的为现货）
+func (p *MemoryProvider) MarketOf(symbol types.Symbol) types.Market {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if market, ok := p.markets[symbol]; ok {
+		return market
+	}
+	return types.MarketSpot
+}
+
+// GetFuturesStats 获取合约数据
+func (p *MemoryProvider) GetFuturesStats(symbol types.Symbol) (*FuturesStats, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats, ok := p.futures[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%s 不是合约交易对", symbol)
+	}
+	result := *stats
+	return &result, nil
+}
+
+// Push 推送一条实时K线更新：同时写入历史K线（同一开盘时间则覆盖）并发送给所有订阅者
+func (p *MemoryProvider) Push(symbol types.Symbol, interval string, update KlineUpdate) {
+	key := memoryKey(symbol, interval)
+
+	p.mu.Lock()
+	klines := p.klines[key]
+	if n := len(klines); n > 0 && klines[n-1].Timestamp.Equal(update.Kline.Timestamp) {
+		klines[n-1] = update.Kline
+	} else {
+		klines = append(klines, update.Kline)
+	}
+	p.klines[key] = klines
+
+	streams := make([]*memoryStream, 0, len(p.streams[key]))
+	for s := range p.streams[key] {
+		streams = append(streams, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range streams {
+		u := update
+		s.send(&u)
+	}
+}
+
+// GetKlines 获取最近limit根K线
+func (p *MemoryProvider) GetKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
+	return p.GetRecentKlines(symbol, interval, limit)
+}
+
+// GetRecentKlines 获取最近limit根K线
+func (p *MemoryProvider) GetRecentKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	klines := p.klines[memoryKey(symbol, interval)]
+	if limit > 0 && len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	result := make([]types.Kline, len(klines))
+	copy(result, klines)
+	return result, nil
+}
+
+// GetKlinesRange 获取开盘时间在[start, end]内的K线
+func (p *MemoryProvider) GetKlinesRange(symbol types.Symbol, interval string, start, end time.Time) ([]types.Kline, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("无效的时间范围: %s - %s", start, end)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := []types.Kline{}
+	for _, k := range p.klines[memoryKey(symbol, interval)] {
+		if !k.Timestamp.Before(start) && !k.Timestamp.After(end) {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+// SubscribeKlines 订阅实时K线流
+func (p *MemoryProvider) SubscribeKlines(symbol types.Symbol, interval string) (KlineStream, error) {
+	key := memoryKey(symbol, interval)
+	s := &memoryStream{
+		provider: p,
+		key:      key,
+		updates:  make(chan *KlineUpdate, 100),
+		state:    ConnConnected,
+	}
+
+	p.mu.Lock()
+	if p.streams[key] == nil {
+		p.streams[key] = make(map[*memoryStream]bool)
+	}
+	p.streams[key][s] = true
+	p.mu.Unlock()
+
+	return s, nil
+}
+
+// SetStreamState 设置指定symbol和interval所有实时流的连接状态（用于模拟断线）
+func (p *MemoryProvider) SetStreamState(symbol types.Symbol, interval string, state ConnState) {
+	p.mu.Lock()
+	streams := make([]*memoryStream, 0, len(p.streams[memoryKey(symbol, interval)]))
+	for s := range p.streams[memoryKey(symbol, interval)] {
+		streams = append(streams, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range streams {
+		s.mu.Lock()
+		if !s.closed {
+			s.state = state
+		}
+		s.mu.Unlock()
+	}
+}
+
+// GetSymbolInfo 获取交易对元数据
+func (p *MemoryProvider) GetSymbolInfo(symbol types.Symbol) (*SymbolInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, ok := p.symbols[symbol]
+	if !ok {
+		return nil, fmt.Errorf("未知的交易对: %s", symbol)
+	}
+	result := *info
+	return &result, nil
+}
+
+// ListSymbols 获取通过 SetSymbolInfo 设置的交易对（market为空时返回全部）
+// 24小时成交额按最近24小时的1h K线（收盘价×成交量）估算，没有1h K线时为0
+func (p *MemoryProvider) ListSymbols(market types.Market) ([]SymbolTicker, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tickers := make([]SymbolTicker, 0, len(p.symbols))
+	for symbol, info := range p.symbols {
+		m, ok := p.markets[symbol]
+		if !ok {
+			m = types.MarketSpot
+		}
+		if market != "" && m != market {
+			continue
+		}
+		ticker := SymbolTicker{
+			Symbol:     info.Symbol,
+			Status:     info.Status,
+			BaseAsset:  info.BaseAsset,
+			QuoteAsset: info.QuoteAsset,
+		}
+		klines := p.klines[memoryKey(symbol, "1h")]
+		if n := len(klines); n > 0 {
+			ticker.LastPrice = klines[n-1].Close
+			for i := n - 1; i >= 0 && i >= n-24; i-- {
+				ticker.QuoteVolume += klines[i].Close * klines[i].Volume
+			}
+		}
+		tickers = append(tickers, ticker)
+	}
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].Symbol < tickers[j].Symbol })
+	return tickers, nil
+}
+
+// memoryStream 内存K线流
+type memoryStream struct {
+	provider *MemoryProvider
+	key      string
+	updates  chan *KlineUpdate
+	mu       sync.Mutex
+	state    ConnState
+	closed   bool
+}
+
+// send 非阻塞发送（通道满时丢弃，与真实行情流一致）
+func (s *memoryStream) send(update *KlineUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.updates <- update:
+	default:
+	}
+}
+
+// Updates K线更新通道
+func (s *memoryStream) Updates() <-chan *KlineUpdate {
+	return s.updates
+}
+
+// State 获取连接状态
+func (s *memoryStream) State() ConnState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ConnClosed
+	}
+	return s.state
+}
+
+// Close 关闭K线流
+func (s *memoryStream) Close() error {
+	s.provider.mu.Lock()
+	delete(s.provider.streams[s.key], s)
+	s.provider.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.state = ConnClosed
+		close(s.updates)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// MarketDataProvider 行情数据源接口
+// 服务层只依赖该接口，Binance是其中一种实现，离线测试可使用 MemoryProvider
+type MarketDataProvider interface {
+	// Name 数据源名称（如 "binance"）
+	Name() string
+	// GetKlines 获取最近limit根K线（从旧到新，单次请求，受交易所单次上限限制）
+	GetKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error)
+	// GetRecentKlines 获取最近limit根K线（从旧到新，超过单次上限时自动分页）
+	GetRecentKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error)
+	// GetKlinesRange 获取开盘时间在[start, end]内的K线（从旧到新）
+	GetKlinesRange(symbol types.Symbol, interval string, start, end time.Time) ([]types.Kline, error)
+	// SubscribeKlines 订阅实时K线流
+	SubscribeKlines(symbol types.Symbol, interval string) (KlineStream, error)
+	// GetSymbolInfo 获取交易对元数据
+	GetSymbolInfo(symbol types.Symbol) (*SymbolInfo, error)
+}
+
+// KlineStream 实时K线流
+type KlineStream interface {
+	// Updates K线更新通道，断线重连期间保持打开，Close后关闭
+	Updates() <-chan *KlineUpdate
+	// State 连接状态
+	State() ConnState
+	// Close 关闭K线流
+	Close() error
+}
+
+// KlineUpdate 实时K线更新（正在形成的K线或刚完结的K线）
+type KlineUpdate struct {
+	Kline     types.Kline // Timestamp为K线开盘时间
+	Final     bool        // K线是否已完结
+	EventTime time.Time   // 事件时间
+}
+
+// SymbolInfo 交易对元数据
+type SymbolInfo struct {
+	Symbol      string  `json:"symbol"`
+	Status      string  `json:"status"` // 如 "TRADING"
+	BaseAsset   string  `json:"base_asset"`
+	QuoteAsset  string  `json:"quote_asset"`
+	TickSize    float64 `json:"tick_size"`    // 价格最小变动单位
+	StepSize    float64 `json:"step_size"`    // 数量最小变动单位
+	MinQty      float64 `json:"min_qty"`      // 最小下单数量
+	MinNotional float64 `json:"min_notional"` // 最小下单金额
+}
+
+// SymbolLister 支持列出交易对的行情数据源（可选接口，市场扫描用于确定交易对范围）
+type SymbolLister interface {
+	// ListSymbols 获取指定市场的所有交易对及其24小时成交额
+	ListSymbols(market types.Market) ([]SymbolTicker, error)
+}
+
+// SymbolTicker 交易对及其24小时行情概要
+type SymbolTicker struct {
+	Symbol      string  `json:"symbol"`
+	Status      string  `json:"status"`
+	BaseAsset   string  `json:"base_asset"`
+	QuoteAsset  string  `json:"quote_asset"`
+	LastPrice   float64 `json:"last_price"`
+	QuoteVolume float64 `json:"quote_volume"` // 24小时成交额（币本位合约为以基础资产计的成交量）
+}
+
+// FuturesDataProvider 支持合约数据的行情数据源（可选接口）
+type FuturesDataProvider interface {
+	// MarketOf 获取交易对所属的市场类型
+	MarketOf(symbol types.Symbol) types.Market
+	// GetFuturesStats 获取合约的标记价格、资金费率和持仓量（仅合约市场）
+	GetFuturesStats(symbol types.Symbol) (*FuturesStats, error)
+}
+
+// FuturesStats 合约数据
+type FuturesStats struct {
+	Symbol          string       `json:"symbol"`
+	Market          types.Market `json:"market"`
+	MarkPrice       float64      `json:"mark_price"`
+	IndexPrice      float64      `json:"index_price"`
+	FundingRate     float64      `json:"funding_rate"` // 最近一次资金费率
+	NextFundingTime time.Time    `json:"next_funding_time"`
+	OpenInterest    float64      `json:"open_interest"` // 持仓量（U本位为币数量，币本位为合约张数）
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// ConnState 实时流连接状态
+type ConnState string
+
+const (
+	ConnConnected    ConnState = "connected"    // 已连接且数据正常
+	ConnReconnecting ConnState = "reconnecting" // 连接断开，正在重连或补齐缺口
+	ConnStale        ConnState = "stale"        // 已连接但长时间未收到数据
+	ConnClosed       ConnState = "closed"       // 已关闭
+)
@@ -0,0 +1,438 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// KlineKey K线序列标识
+type KlineKey struct {
+	Market   types.Market
+	Symbol   types.Symbol
+	Interval string
+}
+
+// String 返回 "spot:BTCUSDT@1h" 形式的标识
+func (k KlineKey) String() string {
+	return fmt.Sprintf("%s:%s@%s", k.Market, k.Symbol, k.Interval)
+}
+
+// KlineStore 已收盘K线的持久化存储（按 市场/交易对/周期/开盘时间 唯一）
+type KlineStore interface {
+	// SaveKlines 保存K线，相同开盘时间的K线覆盖
+	SaveKlines(ctx context.Context, key KlineKey, klines []types.Kline) error
+	// LoadKlines 加载开盘时间在[start, end]内的K线（从旧到新）
+	LoadKlines(ctx context.Context, key KlineKey, start, end time.Time) ([]types.Kline, error)
+	// ListSeries 列出已存储的K线序列及其最早开盘时间
+	ListSeries(ctx context.Context) (map[KlineKey]time.Time, error)
+}
+
+// EmptyRangeStore 可选接口：持久化上游确认没有数据的已收盘区间（如上市之前、停牌期间），重启后不再重复请求
+type EmptyRangeStore interface {
+	// SaveEmptyRange 保存开盘时间在[start, end]内没有K线的区间
+	SaveEmptyRange(ctx context.Context, key KlineKey, start, end time.Time) error
+	// LoadEmptyRanges 加载已保存的空区间（可以重叠，顺序不限）
+	LoadEmptyRanges(ctx context.Context, key KlineKey) ([][2]time.Time, error)
+}
+
+// emptyConfirmDelay K线收盘后经过该时长上游仍没有数据，才确认为空区间（避免刚收盘的K线上游尚未生成）
+const emptyConfirmDelay = time.Minute
+
+// StoredProvider 带本地K线存储的行情数据源
+// 历史K线优先从本地读取，只向上游请求缺失的区间（缺口修复）和正在形成的K线；
+// 上游返回的和实时流中已收盘的K线写入本地存储
+type StoredProvider struct {
+	upstream MarketDataProvider
+	store    KlineStore
+
+	mu     sync.Mutex
+	empty  map[KlineKey][][2]time.Time // 上游确认没有数据的已收盘区间（按开盘时间，闭区间，已排序合并），不再请求
+	loaded map[KlineKey]bool           // 是否已从本地存储加载空区间
+}
+
+// 确保 StoredProvider 实现相关接口
+var (
+	_ MarketDataProvider  = (*StoredProvider)(nil)
+	_ FuturesDataProvider = (*StoredProvider)(nil)
+)
+
+// NewStoredProvider 创建带本地存储的行情数据源
+func NewStoredProvider(upstream MarketDataProvider, store KlineStore) *StoredProvider {
+	return &StoredProvider{
+		upstream: upstream,
+		store:    store,
+		empty:    make(map[KlineKey][][2]time.Time),
+		loaded:   make(map[KlineKey]bool),
+	}
+}
+
+// Name 数据源名称
+func (p *StoredProvider) Name() string {
+	return p.upstream.Name()
+}
+
+// key 获取K线序列标识
+func (p *StoredProvider) key(symbol types.Symbol, interval string) KlineKey {
+	return KlineKey{Market: p.MarketOf(symbol), Symbol: symbol, Interval: interval}
+}
+
+// fixedStep 固定时长且按Unix纪元对齐的K线周期才使用本地存储（秒、分、时、日线）
+// 周线、月线等对齐规则不同，直接请求上游
+func fixedStep(interval string) (time.Duration, bool) {
+	if interval == "" {
+		return 0, false
+	}
+	switch unit := interval[len(interval)-1:]; {
+	case unit == "s", unit == "m", unit == "h", interval == "1d":
+		step, err := types.IntervalDuration(interval)
+		return step, err == nil && step > 0
+	}
+	return 0, false
+}
+
+// GetKlines 获取最近limit根K线（本地已有的已收盘K线不再请求上游）
+func (p *StoredProvider) GetKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
+	return p.GetRecentKlines(symbol, interval, limit)
+}
+
+// GetRecentKlines 获取最近limit根K线（本地已有的已收盘K线不再请求上游）
+func (p *StoredProvider) GetRecentKlines(symbol types.Symbol, interval string, limit int) ([]types.Kline, error) {
+	step, ok := fixedStep(interval)
+	if !ok || limit <= 0 {
+		return p.upstream.GetRecentKlines(symbol, interval, limit)
+	}
+
+	now := time.Now()
+	start := now.Truncate(step).Add(-step * time.Duration(limit-1))
+	klines, err := p.GetKlinesRange(symbol, interval, start, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// GetKlinesRange 获取开盘时间在[start, end]内的K线
+// 已收盘的K线从本地读取并修复缺口，正在形成的K线始终从上游获取
+func (p *StoredProvider) GetKlinesRange(symbol types.Symbol, interval string, start, end time.Time) ([]types.Kline, error) {
+	step, ok := fixedStep(interval)
+	if !ok {
+		return p.upstream.GetKlinesRange(symbol, interval, start, end)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("无效的时间范围: %s - %s", start, end)
+	}
+
+	key := p.key(symbol, interval)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 开盘时间按周期对齐，正在形成的K线开盘时间为 formingOpen
+	first := start.Truncate(step)
+	if first.Before(start) {
+		first = first.Add(step)
+	}
+	formingOpen := now.Truncate(step)
+	closedEnd := end
+	if !closedEnd.Before(formingOpen) {
+		closedEnd = formingOpen.Add(-step)
+	}
+
+	byTime := make(map[int64]types.Kline)
+	if !closedEnd.Before(first) {
+		local, err := p.store.LoadKlines(ctx, key, first, closedEnd)
+		if err != nil {
+			log.Printf("读取本地K线 %s 失败，改为请求上游: %v", key, err)
+			local = nil
+		}
+		for _, k := range local {
+			byTime[k.Timestamp.UnixMilli()] = k
+		}
+
+		// 修复缺口：只请求本地缺失、且未确认为空的区间
+		confirmed := now.Add(-emptyConfirmDelay).Truncate(step).Add(-step) // 最后一根可确认为空的开盘时间
+		for _, gap := range missingRanges(byTime, p.emptyRanges(ctx, key), first, closedEnd, step) {
+			fetched, err := p.fetchAndStore(ctx, key, gap[0], gap[1], step)
+			if err != nil {
+				return nil, err
+			}
+			got := make(map[int64]types.Kline, len(fetched))
+			for _, k := range fetched {
+				byTime[k.Timestamp.UnixMilli()] = k
+				got[k.Timestamp.UnixMilli()] = k
+			}
+
+			// 上游也没有数据的部分（上市之前、停牌期间）记录为空区间，之后不再请求
+			last := gap[1]
+			if last.After(confirmed) {
+				last = confirmed
+			}
+			for _, r := range missingRanges(got, nil, gap[0], last, step) {
+				p.addEmptyRange(ctx, key, r, step)
+			}
+		}
+	}
+
+	// 正在形成的K线（不写入本地）
+	if !end.Before(formingOpen) && !formingOpen.Before(start) {
+		forming, err := p.upstream.GetKlinesRange(symbol, interval, formingOpen, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range forming {
+			byTime[k.Timestamp.UnixMilli()] = k
+		}
+	}
+
+	klines := make([]types.Kline, 0, len(byTime))
+	for _, k := range byTime {
+		if k.Timestamp.Before(start) || k.Timestamp.After(end) {
+			continue
+		}
+		klines = append(klines, k)
+	}
+	sort.Slice(klines, func(i, j int) bool {
+		return klines[i].Timestamp.Before(klines[j].Timestamp)
+	})
+	return klines, nil
+}
+
+// fetchAndStore 从上游获取[start, end]内的已收盘K线并写入本地
+func (p *StoredProvider) fetchAndStore(ctx context.Context, key KlineKey, start, end time.Time, step time.Duration) ([]types.Kline, error) {
+	fetched, err := p.upstream.GetKlinesRange(key.Symbol, key.Interval, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("修复K线缺口 %s [%s, %s] 失败: %w", key, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), err)
+	}
+
+	closed := closedKlines(fetched, step, time.Now())
+	if len(closed) > 0 {
+		if err := p.store.SaveKlines(ctx, key, closed); err != nil {
+			log.Printf("保存K线 %s 到本地失败: %v", key, err)
+		}
+	}
+	return fetched, nil
+}
+
+// emptyRanges 获取K线序列已确认的空区间（首次使用时从本地存储加载）
+func (p *StoredProvider) emptyRanges(ctx context.Context, key KlineKey) [][2]time.Time {
+	p.mu.Lock()
+	loaded := p.loaded[key]
+	p.mu.Unlock()
+
+	if !loaded {
+		var stored [][2]time.Time
+		if es, ok := p.store.(EmptyRangeStore); ok {
+			var err error
+			if stored, err = es.LoadEmptyRanges(ctx, key); err != nil {
+				log.Printf("读取本地K线空区间 %s 失败: %v", key, err)
+			}
+		}
+		step, _ := fixedStep(key.Interval)
+		p.mu.Lock()
+		if !p.loaded[key] {
+			p.loaded[key] = true
+			for _, r := range stored {
+				p.empty[key] = mergeRange(p.empty[key], r, step)
+			}
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][2]time.Time(nil), p.empty[key]...)
+}
+
+// addEmptyRange 记录上游确认没有数据的区间，并保存到本地存储（如果支持）
+func (p *StoredProvider) addEmptyRange(ctx context.Context, key KlineKey, r [2]time.Time, step time.Duration) {
+	p.mu.Lock()
+	p.empty[key] = mergeRange(p.empty[key], r, step)
+	p.mu.Unlock()
+
+	if es, ok := p.store.(EmptyRangeStore); ok {
+		if err := es.SaveEmptyRange(ctx, key, r[0], r[1]); err != nil {
+			log.Printf("保存K线空区间 %s 失败: %v", key, err)
+		}
+	}
+}
+
+// mergeRange 把区间r并入已排序的区间列表，重叠或相邻（间隔一个周期）的区间合并
+func mergeRange(ranges [][2]time.Time, r [2]time.Time, step time.Duration) [][2]time.Time {
+	merged := make([][2]time.Time, 0, len(ranges)+1)
+	for _, cur := range ranges {
+		switch {
+		case cur[1].Add(step).Before(r[0]):
+			merged = append(merged, cur)
+		case r[1].Add(step).Before(cur[0]):
+			merged = append(merged, r)
+			r = cur
+		default:
+			if cur[0].Before(r[0]) {
+				r[0] = cur[0]
+			}
+			if cur[1].After(r[1]) {
+				r[1] = cur[1]
+			}
+		}
+	}
+	return append(merged, r)
+}
+
+// missingRanges 计算[first, last]内本地缺失的K线区间（按开盘时间，闭区间）
+// empty 为已确认没有数据的区间（已排序），其中的K线不算缺失
+func missingRanges(have map[int64]types.Kline, empty [][2]time.Time, first, last time.Time, step time.Duration) [][2]time.Time {
+	var ranges [][2]time.Time
+	var gapStart time.Time
+	inGap := false
+
+	for t, j := first, 0; !t.After(last); t = t.Add(step) {
+		for j < len(empty) && empty[j][1].Before(t) {
+			j++
+		}
+		_, ok := have[t.UnixMilli()]
+		if ok || (j < len(empty) && !t.Before(empty[j][0])) {
+			if inGap {
+				ranges = append(ranges, [2]time.Time{gapStart, t.Add(-step)})
+				inGap = false
+			}
+			continue
+		}
+		if !inGap {
+			gapStart = t
+			inGap = true
+		}
+	}
+	if inGap {
+		ranges = append(ranges, [2]time.Time{gapStart, last})
+	}
+	return ranges
+}
+
+// closedKlines 过滤出已收盘的K线
+func closedKlines(klines []types.Kline, step time.Duration, now time.Time) []types.Kline {
+	closed := make([]types.Kline, 0, len(klines))
+	for _, k := range klines {
+		if !k.Timestamp.Add(step).After(now) {
+			closed = append(closed, k)
+		}
+	}
+	return closed
+}
+
+// RepairGaps 检查所有已存储的K线序列，补齐从最早一根到当前的缺口（启动时调用）
+func (p *StoredProvider) RepairGaps(ctx context.Context) error {
+	series, err := p.store.ListSeries(ctx)
+	if err != nil {
+		return fmt.Errorf("列出本地K线序列失败: %w", err)
+	}
+
+	for key, earliest := range series {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, ok := fixedStep(key.Interval); !ok {
+			continue
+		}
+		// 市场配置变化后的旧序列不再修复
+		if p.MarketOf(key.Symbol) != key.Market {
+			continue
+		}
+		if _, err := p.GetKlinesRange(key.Symbol, key.Interval, earliest, time.Now()); err != nil {
+			log.Printf("修复本地K线 %s 失败: %v", key, err)
+			continue
+		}
+		log.Printf("本地K线 %s 缺口检查完成", key)
+	}
+	return nil
+}
+
+// SubscribeKlines 订阅实时K线流，已收盘的K线同时写入本地
+func (p *StoredProvider) SubscribeKlines(symbol types.Symbol, interval string) (KlineStream, error) {
+	upstream, err := p.upstream.SubscribeKlines(symbol, interval)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fixedStep(interval); !ok {
+		return upstream, nil
+	}
+
+	s := &storedStream{
+		KlineStream: upstream,
+		updates:     make(chan *KlineUpdate, 100),
+	}
+	go s.forward(p.store, p.key(symbol, interval))
+	return s, nil
+}
+
+// GetSymbolInfo 获取交易对元数据
+func (p *StoredProvider) GetSymbolInfo(symbol types.Symbol) (*SymbolInfo, error) {
+	return p.upstream.GetSymbolInfo(symbol)
+}
+
+// ListSymbols 获取交易对列表（由上游提供）
+func (p *StoredProvider) ListSymbols(market types.Market) ([]SymbolTicker, error) {
+	lister, ok := p.upstream.(SymbolLister)
+	if !ok {
+		return nil, fmt.Errorf("数据源 %s 不支持列出交易对", p.upstream.Name())
+	}
+	return lister.ListSymbols(market)
+}
+
+// MarketOf 获取交易对所属的市场类型（上游不支持合约时为现货）
+func (p *StoredProvider) MarketOf(symbol types.Symbol) types.Market {
+	if fp, ok := p.upstream.(FuturesDataProvider); ok {
+		return fp.MarketOf(symbol)
+	}
+	return types.MarketSpot
+}
+
+// GetFuturesStats 获取合约数据
+func (p *StoredProvider) GetFuturesStats(symbol types.Symbol) (*FuturesStats, error) {
+	fp, ok := p.upstream.(FuturesDataProvider)
+	if !ok {
+		return nil, fmt.Errorf("数据源 %s 不支持合约数据", p.upstream.Name())
+	}
+	return fp.GetFuturesStats(symbol)
+}
+
+// storedStream 转发上游K线流，并保存已收盘的K线
+type storedStream struct {
+	KlineStream
+	updates chan *KlineUpdate
+}
+
+// forward 转发上游更新直到上游通道关闭
+func (s *storedStream) forward(store KlineStore, key KlineKey) {
+	defer close(s.updates)
+
+	for update := range s.KlineStream.Updates() {
+		if update.Final {
+			k := update.Kline
+			if k.Symbol == "" {
+				k.Symbol = strings.ToUpper(string(key.Symbol))
+			}
+			if err := store.SaveKlines(context.Background(), key, []types.Kline{k}); err != nil {
+				log.Printf("保存已收盘K线 %s 失败: %v", key, err)
+			}
+		}
+		select {
+		case s.updates <- update:
+		default:
+			// 通道满了，跳过（与上游K线流一致）
+		}
+	}
+}
+
+// Updates K线更新通道
+func (s *storedStream) Updates() <-chan *KlineUpdate {
+	return s.updates
+}
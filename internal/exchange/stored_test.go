@@ -0,0 +1,354 @@
+package exchange
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// countingProvider 记录 GetKlinesRange 请求的上游数据源
+type countingProvider struct {
+	*MemoryProvider
+	mu    sync.Mutex
+	calls [][2]time.Time
+}
+
+func (c *countingProvider) GetKlinesRange(symbol types.Symbol, interval string, start, end time.Time) ([]types.Kline, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, [2]time.Time{start, end})
+	c.mu.Unlock()
+	return c.MemoryProvider.GetKlinesRange(symbol, interval, start, end)
+}
+
+// takeCalls 取出并清空已记录的请求
+func (c *countingProvider) takeCalls() [][2]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := c.calls
+	c.calls = nil
+	return calls
+}
+
+// memoryKlineStore 内存K线存储（同时保存空区间）
+type memoryKlineStore struct {
+	mu     sync.Mutex
+	series map[KlineKey]map[int64]types.Kline
+	empty  map[KlineKey][][2]time.Time
+}
+
+func newMemoryKlineStore() *memoryKlineStore {
+	return &memoryKlineStore{
+		series: make(map[KlineKey]map[int64]types.Kline),
+		empty:  make(map[KlineKey][][2]time.Time),
+	}
+}
+
+func (s *memoryKlineStore) SaveEmptyRange(ctx context.Context, key KlineKey, start, end time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.empty[key] = append(s.empty[key], [2]time.Time{start, end})
+	return nil
+}
+
+func (s *memoryKlineStore) LoadEmptyRanges(ctx context.Context, key KlineKey) ([][2]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][2]time.Time(nil), s.empty[key]...), nil
+}
+
+func (s *memoryKlineStore) SaveKlines(ctx context.Context, key KlineKey, klines []types.Kline) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.series[key] == nil {
+		s.series[key] = make(map[int64]types.Kline)
+	}
+	for _, k := range klines {
+		s.series[key][k.Timestamp.UnixMilli()] = k
+	}
+	return nil
+}
+
+func (s *memoryKlineStore) LoadKlines(ctx context.Context, key KlineKey, start, end time.Time) ([]types.Kline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	klines := []types.Kline{}
+	for _, k := range s.series[key] {
+		if !k.Timestamp.Before(start) && !k.Timestamp.After(end) {
+			klines = append(klines, k)
+		}
+	}
+	sort.Slice(klines, func(i, j int) bool { return klines[i].Timestamp.Before(klines[j].Timestamp) })
+	return klines, nil
+}
+
+func (s *memoryKlineStore) ListSeries(ctx context.Context) (map[KlineKey]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := make(map[KlineKey]time.Time)
+	for key, klines := range s.series {
+		for _, k := range klines {
+			if earliest, ok := series[key]; !ok || k.Timestamp.Before(earliest) {
+				series[key] = k.Timestamp
+			}
+		}
+	}
+	return series, nil
+}
+
+func (s *memoryKlineStore) remove(key KlineKey, t time.Time) {
+	s.mu.Lock()
+	delete(s.series[key], t.UnixMilli())
+	s.mu.Unlock()
+}
+
+func (s *memoryKlineStore) count(key KlineKey) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.series[key])
+}
+
+// newStoredTest 创建上市时间为 bars 根K线之前的1h行情（最后一根为正在形成的K线）
+func newStoredTest(bars int) (*StoredProvider, *countingProvider, *memoryKlineStore, []types.Kline) {
+	forming := time.Now().Truncate(time.Hour)
+	klines := make([]types.Kline, bars)
+	for i := range klines {
+		klines[i] = types.Kline{
+			Symbol:    "TESTUSDT",
+			Open:      100 + float64(i),
+			High:      101 + float64(i),
+			Low:       99 + float64(i),
+			Close:     100.5 + float64(i),
+			Volume:    1,
+			Timestamp: forming.Add(-time.Duration(bars-1-i) * time.Hour),
+		}
+	}
+
+	upstream := &countingProvider{MemoryProvider: NewMemoryProvider()}
+	upstream.SetKlines("TESTUSDT", "1h", klines)
+	store := newMemoryKlineStore()
+	return NewStoredProvider(upstream, store), upstream, store, klines
+}
+
+var storedTestKey = KlineKey{Market: types.MarketSpot, Symbol: "TESTUSDT", Interval: "1h"}
+
+// assertRecent 检查结果为上游最近的limit根K线
+func assertRecent(t *testing.T, got, all []types.Kline, limit int) {
+	t.Helper()
+	want := all[len(all)-limit:]
+	if len(got) != len(want) {
+		t.Fatalf("K线数量 %d，期望 %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Close != want[i].Close {
+			t.Fatalf("索引%d: %s %v，期望 %s %v", i, got[i].Timestamp, got[i].Close, want[i].Timestamp, want[i].Close)
+		}
+	}
+}
+
+func TestStoredProviderColdFillThenCached(t *testing.T) {
+	p, upstream, store, all := newStoredTest(300)
+
+	got, err := p.GetRecentKlines("TESTUSDT", "1h", 100)
+	if err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	assertRecent(t, got, all, 100)
+	if calls := upstream.takeCalls(); len(calls) != 2 {
+		t.Fatalf("冷启动请求上游 %d 次，期望 2 次（已收盘区间 + 正在形成的K线）: %v", len(calls), calls)
+	}
+	if n := store.count(storedTestKey); n != 99 {
+		t.Fatalf("本地保存 %d 根K线，期望 99 根已收盘K线", n)
+	}
+
+	got, err = p.GetRecentKlines("TESTUSDT", "1h", 100)
+	if err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	assertRecent(t, got, all, 100)
+	calls := upstream.takeCalls()
+	if len(calls) != 1 {
+		t.Fatalf("第二次请求上游 %d 次，期望 1 次: %v", len(calls), calls)
+	}
+	if forming := all[len(all)-1].Timestamp; !calls[0][0].Equal(forming) {
+		t.Fatalf("第二次请求的起始时间 %s，期望正在形成的K线 %s", calls[0][0], forming)
+	}
+}
+
+func TestStoredProviderRepairsInteriorGap(t *testing.T) {
+	p, upstream, store, all := newStoredTest(300)
+	if _, err := p.GetRecentKlines("TESTUSDT", "1h", 100); err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	upstream.takeCalls()
+
+	// 删除中间的5根K线
+	for i := 10; i < 15; i++ {
+		store.remove(storedTestKey, all[len(all)-1-i].Timestamp)
+	}
+
+	got, err := p.GetRecentKlines("TESTUSDT", "1h", 100)
+	if err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	assertRecent(t, got, all, 100)
+
+	calls := upstream.takeCalls()
+	if len(calls) != 2 {
+		t.Fatalf("修复缺口请求上游 %d 次，期望 2 次（缺口 + 正在形成的K线）: %v", len(calls), calls)
+	}
+	gapStart, gapEnd := all[len(all)-15].Timestamp, all[len(all)-11].Timestamp
+	if !calls[0][0].Equal(gapStart) || !calls[0][1].Equal(gapEnd) {
+		t.Fatalf("缺口请求 [%s, %s]，期望 [%s, %s]", calls[0][0], calls[0][1], gapStart, gapEnd)
+	}
+	if n := store.count(storedTestKey); n != 99 {
+		t.Fatalf("修复后本地有 %d 根K线，期望 99", n)
+	}
+}
+
+func TestStoredProviderStartBeforeListing(t *testing.T) {
+	p, upstream, _, all := newStoredTest(50)
+
+	// 请求的范围早于上市时间：只返回上市以来的K线
+	got, err := p.GetRecentKlines("TESTUSDT", "1h", 200)
+	if err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	assertRecent(t, got, all, 50)
+	upstream.takeCalls()
+
+	// 再次请求不再重复请求上市之前的区间
+	for i := 0; i < 3; i++ {
+		got, err = p.GetRecentKlines("TESTUSDT", "1h", 200)
+		if err != nil {
+			t.Fatalf("获取K线失败: %v", err)
+		}
+		assertRecent(t, got, all, 50)
+		if calls := upstream.takeCalls(); len(calls) != 1 {
+			t.Fatalf("第%d次重复请求上游 %d 次，期望 1 次（只请求正在形成的K线）: %v", i+2, len(calls), calls)
+		}
+	}
+}
+
+// withoutBars 从上游删除第from到第to根（从0开始，含两端）K线，模拟停牌期间没有数据
+func withoutBars(upstream *countingProvider, all []types.Kline, from, to int) {
+	kept := append(append([]types.Kline(nil), all[:from]...), all[to+1:]...)
+	upstream.SetKlines("TESTUSDT", "1h", kept)
+}
+
+func TestStoredProviderSkipsConfirmedEmptyGap(t *testing.T) {
+	p, upstream, store, all := newStoredTest(300)
+	withoutBars(upstream, all, 250, 259)
+
+	got, err := p.GetRecentKlines("TESTUSDT", "1h", 100)
+	if err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	if len(got) != 90 {
+		t.Fatalf("K线数量 %d，期望 90（上游缺少10根）", len(got))
+	}
+	upstream.takeCalls()
+
+	// 删除缺口两侧的本地K线：只请求这两根，上游确认为空的区间不再请求
+	store.remove(storedTestKey, all[249].Timestamp)
+	store.remove(storedTestKey, all[260].Timestamp)
+	for i := 0; i < 3; i++ {
+		got, err = p.GetRecentKlines("TESTUSDT", "1h", 100)
+		if err != nil {
+			t.Fatalf("获取K线失败: %v", err)
+		}
+		if len(got) != 90 {
+			t.Fatalf("K线数量 %d，期望 90", len(got))
+		}
+		calls := upstream.takeCalls()
+		want := 1 // 正在形成的K线
+		if i == 0 {
+			want = 3 // 两根被删除的K线 + 正在形成的K线
+		}
+		if len(calls) != want {
+			t.Fatalf("第%d次请求上游 %d 次，期望 %d 次: %v", i+1, len(calls), want, calls)
+		}
+		if i == 0 && (!calls[0][0].Equal(all[249].Timestamp) || !calls[0][1].Equal(all[249].Timestamp) ||
+			!calls[1][0].Equal(all[260].Timestamp) || !calls[1][1].Equal(all[260].Timestamp)) {
+			t.Fatalf("应只请求被删除的K线: %v", calls)
+		}
+	}
+}
+
+func TestStoredProviderSkipsWholeEmptyRange(t *testing.T) {
+	p, upstream, _, all := newStoredTest(50)
+
+	// 整个范围都早于上市时间
+	start, end := all[0].Timestamp.Add(-100*time.Hour), all[0].Timestamp.Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		got, err := p.GetKlinesRange("TESTUSDT", "1h", start, end)
+		if err != nil {
+			t.Fatalf("获取K线失败: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("K线数量 %d，期望 0", len(got))
+		}
+		want := 0
+		if i == 0 {
+			want = 1
+		}
+		if calls := upstream.takeCalls(); len(calls) != want {
+			t.Fatalf("第%d次请求上游 %d 次，期望 %d 次: %v", i+1, len(calls), want, calls)
+		}
+	}
+}
+
+func TestStoredProviderPersistsEmptyRanges(t *testing.T) {
+	p, upstream, store, all := newStoredTest(300)
+	withoutBars(upstream, all, 250, 259)
+	if _, err := p.GetRecentKlines("TESTUSDT", "1h", 100); err != nil {
+		t.Fatalf("获取K线失败: %v", err)
+	}
+	upstream.takeCalls()
+
+	// 重启后（新的 StoredProvider，同一个本地存储）启动修复不再请求已确认的空区间
+	restarted := NewStoredProvider(upstream, store)
+	if err := restarted.RepairGaps(context.Background()); err != nil {
+		t.Fatalf("修复缺口失败: %v", err)
+	}
+	if calls := upstream.takeCalls(); len(calls) != 1 {
+		t.Fatalf("启动修复请求上游 %d 次，期望 1 次（只请求正在形成的K线）: %v", len(calls), calls)
+	}
+}
+
+func TestMergeRange(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := func(n int) time.Time { return base.Add(time.Duration(n) * time.Hour) }
+	r := func(a, b int) [2]time.Time { return [2]time.Time{h(a), h(b)} }
+
+	var ranges [][2]time.Time
+	for _, add := range [][2]time.Time{r(10, 12), r(1, 2), r(20, 25), r(4, 5), r(3, 3), r(13, 14), r(22, 30)} {
+		ranges = mergeRange(ranges, add, time.Hour)
+	}
+	// 相邻（间隔一个周期）和重叠的区间合并，结果按时间排序
+	want := [][2]time.Time{r(1, 5), r(10, 14), r(20, 30)}
+	if len(ranges) != len(want) {
+		t.Fatalf("合并结果 %v，期望 %v", ranges, want)
+	}
+	for i := range want {
+		if !ranges[i][0].Equal(want[i][0]) || !ranges[i][1].Equal(want[i][1]) {
+			t.Fatalf("合并结果 %v，期望 %v", ranges, want)
+		}
+	}
+
+	// 空区间内的K线不算缺失
+	have := map[int64]types.Kline{h(0).UnixMilli(): {}, h(7).UnixMilli(): {}}
+	gaps := missingRanges(have, ranges, h(0), h(16), time.Hour)
+	wantGaps := [][2]time.Time{r(6, 6), r(8, 9), r(15, 16)}
+	if len(gaps) != len(wantGaps) {
+		t.Fatalf("缺失区间 %v，期望 %v", gaps, wantGaps)
+	}
+	for i := range wantGaps {
+		if !gaps[i][0].Equal(wantGaps[i][0]) || !gaps[i][1].Equal(wantGaps[i][1]) {
+			t.Fatalf("缺失区间 %v，期望 %v", gaps, wantGaps)
+		}
+	}
+}
@@ -0,0 +1,715 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/backtest"
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// AuditRepository 审计记录仓库接口
+type AuditRepository interface {
+	SaveAudit(ctx context.Context, audit *types.ExecutionAudit) error
+	// ListAudits 按时间倒序返回审计记录，symbol为空时返回所有交易对
+	ListAudits(ctx context.Context, symbol string, limit int) ([]types.ExecutionAudit, error)
+	// ListExecutorAudits 按时间正序返回执行器指定类型的所有审计记录（启动时恢复状态）
+	ListExecutorAudits(ctx context.Context, executor string, kinds ...string) ([]types.ExecutionAudit, error)
+}
+
+// Options 策略执行配置
+type Options struct {
+	Strategies         []StrategyConfig
+	Rules              []RuleBinding
+	RiskPerTrade       float64            // 每笔交易承担的风险占权益的比例，默认0.01
+	VolatilityMultiple float64            // 止损距离 = 5天平均波动价格值 × 倍数，默认1
+	MaxPosition        float64            // 单个交易对的最大持仓名义价值（计价货币），0表示不限制
+	MaxDailyLoss       float64            // 账户当日亏损达到当日起始权益的该比例后停止开仓（平仓不受限制），0表示不限制
+	QuantitySteps      map[string]float64 // 各交易对的下单数量步长（向下取整），未配置时不取整
+	KillSwitch         bool               // 启动时即开启熔断开关
+}
+
+// StrategyConfig 在实时流上运行的策略（与回测使用相同的策略实现）
+type StrategyConfig struct {
+	Name     string // 实例名称（审计记录的来源），默认为 策略名@交易对@周期
+	Strategy string
+	Params   backtest.StrategyParams
+	Symbol   string
+	Interval string
+}
+
+// RuleBinding 告警规则触发时执行的动作
+type RuleBinding struct {
+	RuleID int64           `json:"rule_id"`
+	Action backtest.Action `json:"action"` // long、short、close
+}
+
+// Status 策略执行状态
+type Status struct {
+	Executor     string           `json:"executor"`
+	KillSwitch   bool             `json:"kill_switch"`
+	KillReason   string           `json:"kill_reason,omitempty"`
+	KilledAt     *time.Time       `json:"killed_at,omitempty"`
+	RiskPerTrade float64          `json:"risk_per_trade"`
+	VolMultiple  float64          `json:"volatility_multiple"`
+	MaxPosition  float64          `json:"max_position"`
+	MaxDailyLoss float64          `json:"max_daily_loss"`
+	Strategies   []StrategyStatus `json:"strategies"`
+	Rules        []RuleBinding    `json:"rules"`
+	DailyEquity  *DailyEquity     `json:"daily_equity,omitempty"` // 当日还没有决策时为空
+}
+
+// StrategyStatus 策略实例状态
+type StrategyStatus struct {
+	Name       string    `json:"name"`
+	Strategy   string    `json:"strategy"`
+	Symbol     string    `json:"symbol"`
+	Interval   string    `json:"interval"`
+	LastBar    time.Time `json:"last_bar"`              // 最近一次评估的已收盘K线
+	LastSignal string    `json:"last_signal,omitempty"` // 最近一次非hold信号
+}
+
+// DailyEquity 账户当日（UTC）的起始权益和最近一次决策时的权益
+type DailyEquity struct {
+	Day         time.Time `json:"day"`
+	StartEquity float64   `json:"start_equity"`
+	Equity      float64   `json:"equity"`
+	LossRatio   float64   `json:"loss_ratio"` // (起始权益 - 权益) / 起始权益，盈利时为负
+}
+
+// strategyRunner 一个策略实例
+type strategyRunner struct {
+	cfg      StrategyConfig
+	strategy backtest.Strategy
+
+	mu         sync.Mutex
+	lastBar    time.Time
+	lastSignal string
+}
+
+// signal 待执行的信号
+type signal struct {
+	source     string
+	symbol     string
+	interval   string
+	action     backtest.Action
+	size       float64 // 仓位比例（0到1），按比例缩小每笔风险
+	reason     string
+	price      float64
+	volatility float64
+}
+
+// Engine 策略执行引擎：把策略信号和告警规则转换为订单，经执行器下单，并记录每个决策和订单
+type Engine struct {
+	hub      *service.RealtimeHub
+	alerts   *service.AlertService
+	executor Executor
+	repo     AuditRepository
+	opts     Options
+	runners  []*strategyRunner
+	rules    map[int64]backtest.Action
+
+	execMu sync.Mutex // 串行执行决策，避免并发信号基于同一持仓重复下单
+
+	mu         sync.Mutex
+	killed     bool
+	killReason string
+	killedAt   time.Time
+	day        *DailyEquity      // 账户当日权益，当天第一次决策时以审计记录持久化
+	intervals  map[string]string // 出现过的交易对及其周期（熔断平仓时获取最新价格）
+	orderSeq   int64
+}
+
+// NewEngine 创建策略执行引擎
+// alerts 为nil时不能配置规则动作，repo为nil时审计记录只保存在内存中
+func NewEngine(hub *service.RealtimeHub, alerts *service.AlertService, executor Executor, repo AuditRepository, opts Options) (*Engine, error) {
+	if executor == nil {
+		return nil, fmt.Errorf("未指定执行器")
+	}
+	if opts.RiskPerTrade == 0 {
+		opts.RiskPerTrade = 0.01
+	}
+	if opts.VolatilityMultiple == 0 {
+		opts.VolatilityMultiple = 1
+	}
+	if opts.RiskPerTrade < 0 || opts.RiskPerTrade > 1 {
+		return nil, fmt.Errorf("risk_per_trade 必须在0到1之间")
+	}
+	if opts.VolatilityMultiple < 0 || opts.MaxPosition < 0 {
+		return nil, fmt.Errorf("volatility_multiple 和 max_position 不能为负数")
+	}
+	if opts.MaxDailyLoss < 0 || opts.MaxDailyLoss >= 1 {
+		return nil, fmt.Errorf("max_daily_loss 必须在0到1之间")
+	}
+	steps := make(map[string]float64, len(opts.QuantitySteps))
+	for symbol, step := range opts.QuantitySteps {
+		if step < 0 {
+			return nil, fmt.Errorf("%s 的数量步长不能为负数", symbol)
+		}
+		steps[strings.ToUpper(symbol)] = step
+	}
+	opts.QuantitySteps = steps
+	if stepper, ok := executor.(quantityStepper); ok {
+		stepper.SetQuantitySteps(steps)
+	}
+	if repo == nil {
+		repo = newMemoryAuditRepository()
+	}
+
+	e := &Engine{
+		hub:       hub,
+		alerts:    alerts,
+		executor:  executor,
+		repo:      repo,
+		opts:      opts,
+		rules:     make(map[int64]backtest.Action),
+		intervals: make(map[string]string),
+		killed:    opts.KillSwitch,
+	}
+	if opts.KillSwitch {
+		e.killReason = "启动配置 kill_switch"
+		e.killedAt = time.Now()
+	}
+
+	names := make(map[string]bool)
+	for _, cfg := range opts.Strategies {
+		cfg.Symbol = strings.ToUpper(strings.TrimSpace(cfg.Symbol))
+		if cfg.Symbol == "" {
+			return nil, fmt.Errorf("策略 %s 未指定交易对", cfg.Strategy)
+		}
+		if cfg.Interval == "" {
+			cfg.Interval = "1h"
+		}
+		if _, err := types.IntervalToMinutes(cfg.Interval); err != nil {
+			return nil, err
+		}
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("%s@%s@%s", cfg.Strategy, cfg.Symbol, cfg.Interval)
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("策略实例名称重复: %s", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		strategy, err := backtest.NewStrategy(cfg.Strategy, cfg.Params)
+		if err != nil {
+			return nil, fmt.Errorf("策略实例 %s: %w", cfg.Name, err)
+		}
+		e.runners = append(e.runners, &strategyRunner{cfg: cfg, strategy: strategy})
+		e.intervals[cfg.Symbol] = cfg.Interval
+	}
+
+	for _, rule := range opts.Rules {
+		switch rule.Action {
+		case backtest.ActionLong, backtest.ActionShort, backtest.ActionClose:
+		default:
+			return nil, fmt.Errorf("规则 %d 的动作无效: %s（可选 long、short、close）", rule.RuleID, rule.Action)
+		}
+		if alerts == nil {
+			return nil, fmt.Errorf("未启用告警服务，不能配置规则动作")
+		}
+		e.rules[rule.RuleID] = rule.Action
+	}
+	return e, nil
+}
+
+// Start 由审计记录恢复状态，然后订阅策略的实时流和告警事件，ctx取消后停止
+func (e *Engine) Start(ctx context.Context) {
+	e.restore(ctx)
+
+	for _, r := range e.runners {
+		key := service.StreamKey{Symbol: types.Symbol(r.cfg.Symbol), Interval: r.cfg.Interval}
+		go e.hub.Watch(ctx, key, service.PolicyCoalesce, "策略执行", e.barCloseHandler(ctx, r))
+	}
+
+	if len(e.rules) > 0 {
+		ch := e.alerts.Subscribe()
+		go func() {
+			defer e.alerts.Unsubscribe(ch)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-ch:
+					if !ok {
+						return
+					}
+					e.onAlert(ctx, event)
+				}
+			}
+		}()
+	}
+
+	log.Printf("策略执行已启动（执行器: %s），共 %d 个策略，%d 条规则动作", e.executor.Name(), len(e.runners), len(e.rules))
+}
+
+// restore 由审计记录恢复当日起始权益，以及执行器按策略成交计算的持仓
+func (e *Engine) restore(ctx context.Context) {
+	starts, err := e.repo.ListExecutorAudits(ctx, e.executor.Name(), types.AuditKindDayStart)
+	if err != nil {
+		log.Printf("恢复当日起始权益失败（以当天下一次决策时的权益为准）: %v", err)
+	} else if n := len(starts); n > 0 {
+		last := starts[n-1]
+		if day := utcDay(last.Time); day.Equal(utcDay(time.Now())) {
+			e.mu.Lock()
+			e.day = &DailyEquity{Day: day, StartEquity: last.Equity, Equity: last.Equity}
+			e.mu.Unlock()
+			log.Printf("已恢复 %s 的当日起始权益: %.2f", day.Format("2006-01-02"), last.Equity)
+		}
+	}
+
+	if restorer, ok := e.executor.(fillRestorer); ok {
+		orders, err := e.repo.ListExecutorAudits(ctx, e.executor.Name(), types.AuditKindOrder)
+		if err != nil {
+			log.Printf("恢复策略持仓失败（视为没有持仓）: %v", err)
+		} else {
+			restorer.RestoreFills(orders)
+		}
+	}
+}
+
+// barCloseHandler 每根K线收盘后运行策略
+func (e *Engine) barCloseHandler(ctx context.Context, r *strategyRunner) func(*service.RealtimeData) {
+	return service.OnBarClose(func(data *service.RealtimeData, bar service.BarSnapshot, changes []service.ZoneChange) {
+		history := buildHistory(data)
+		if len(history) == 0 {
+			return
+		}
+
+		_, position, err := e.executor.Account(ctx, r.cfg.Symbol, data.Price)
+		if err != nil {
+			e.record(ctx, types.ExecutionAudit{
+				Kind:     types.AuditKindError,
+				Source:   r.cfg.Name,
+				Symbol:   r.cfg.Symbol,
+				Interval: r.cfg.Interval,
+				Price:    data.Price,
+				Message:  fmt.Sprintf("获取持仓失败，跳过K线 %s: %v", bar.BarTime.UTC().Format(time.RFC3339), err),
+			})
+			return
+		}
+
+		sig := r.strategy.OnBar(history, backtestPosition(position))
+		r.mu.Lock()
+		r.lastBar = bar.BarTime
+		if sig.Action != "" && sig.Action != backtest.ActionHold {
+			r.lastSignal = string(sig.Action)
+		}
+		r.mu.Unlock()
+		if sig.Action == "" || sig.Action == backtest.ActionHold {
+			return
+		}
+
+		e.execute(ctx, signal{
+			source:     r.cfg.Name,
+			symbol:     r.cfg.Symbol,
+			interval:   r.cfg.Interval,
+			action:     sig.Action,
+			size:       sig.Size,
+			reason:     sig.Reason,
+			price:      data.Price,
+			volatility: data.Volatility,
+		})
+	})
+}
+
+// onAlert 执行告警规则绑定的动作（价格和波动值取自告警所在实时流的最新数据）
+func (e *Engine) onAlert(ctx context.Context, event *types.AlertEvent) {
+	action, ok := e.rules[event.RuleID]
+	if !ok {
+		return
+	}
+
+	sig := signal{
+		source:   fmt.Sprintf("rule:%d", event.RuleID),
+		symbol:   strings.ToUpper(event.Symbol),
+		interval: event.Interval,
+		action:   action,
+		reason:   event.Message,
+		price:    event.Price,
+	}
+	if latest := e.hub.Latest(types.Symbol(sig.symbol), event.Interval); latest != nil {
+		sig.price = latest.Price
+		sig.volatility = latest.Volatility
+	}
+	e.execute(ctx, sig)
+}
+
+// backtestPosition 把带符号的持仓数量转换为策略使用的持仓
+func backtestPosition(quantity float64) backtest.Position {
+	switch {
+	case quantity > 0:
+		return backtest.Position{Side: backtest.ActionLong, Quantity: quantity}
+	case quantity < 0:
+		return backtest.Position{Side: backtest.ActionShort, Quantity: -quantity}
+	}
+	return backtest.Position{}
+}
+
+// execute 执行信号：反向持仓先只减仓平掉，再按波动率计算仓位开仓
+func (e *Engine) execute(ctx context.Context, sig signal) {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+
+	e.mu.Lock()
+	e.intervals[sig.symbol] = sig.interval
+	e.mu.Unlock()
+
+	base := types.ExecutionAudit{
+		Source:     sig.source,
+		Executor:   e.executor.Name(),
+		Symbol:     sig.symbol,
+		Interval:   sig.interval,
+		Action:     string(sig.action),
+		Reason:     sig.reason,
+		Price:      sig.price,
+		Volatility: sig.volatility,
+	}
+	e.record(ctx, withKind(base, types.AuditKindSignal, ""))
+
+	if killed, reason := e.killState(); killed {
+		e.record(ctx, withKind(base, types.AuditKindBlocked, "熔断开关已开启: "+reason))
+		return
+	}
+	if sig.price <= 0 {
+		e.record(ctx, withKind(base, types.AuditKindSkip, "没有最新价格"))
+		return
+	}
+
+	equity, position, err := e.executor.Account(ctx, sig.symbol, sig.price)
+	if err != nil {
+		e.record(ctx, withKind(base, types.AuditKindError, "获取账户失败: "+err.Error()))
+		return
+	}
+	base.Equity, base.Position = equity, position
+	day := e.trackDay(ctx, equity)
+	closeQty := e.closeQuantity(sig.symbol, position)
+	if closeQty == 0 {
+		position = 0
+	}
+
+	sameSide := (sig.action == backtest.ActionLong && position > 0) || (sig.action == backtest.ActionShort && position < 0)
+	switch {
+	case sig.action == backtest.ActionClose && position == 0:
+		e.record(ctx, withKind(base, types.AuditKindSkip, "没有持仓可平"))
+		return
+	case sameSide:
+		e.record(ctx, withKind(base, types.AuditKindSkip, "已持有同向仓位"))
+		return
+	case position != 0:
+		side := types.OrderSideSell
+		if position < 0 {
+			side = types.OrderSideBuy
+		}
+		req := types.OrderRequest{Symbol: sig.symbol, Side: side, Type: types.OrderTypeMarket, Quantity: closeQty, ReduceOnly: true}
+		if !e.submit(ctx, base, req, "平仓") {
+			return
+		}
+	}
+	if sig.action == backtest.ActionClose {
+		return
+	}
+
+	if killed, reason := e.killState(); killed {
+		e.record(ctx, withKind(base, types.AuditKindBlocked, "熔断开关已开启: "+reason))
+		return
+	}
+	if e.opts.MaxDailyLoss > 0 && day.LossRatio >= e.opts.MaxDailyLoss {
+		e.record(ctx, withKind(base, types.AuditKindBlocked,
+			fmt.Sprintf("当日亏损 %.2f%% 达到上限 %.2f%%（起始权益 %.2f），停止开仓", day.LossRatio*100, e.opts.MaxDailyLoss*100, day.StartEquity)))
+		return
+	}
+
+	qty, note := e.positionSize(sig, equity)
+	if qty <= 0 {
+		e.record(ctx, withKind(base, types.AuditKindSkip, note))
+		return
+	}
+	side := types.OrderSideBuy
+	if sig.action == backtest.ActionShort {
+		side = types.OrderSideSell
+	}
+	e.submit(ctx, base, types.OrderRequest{Symbol: sig.symbol, Side: side, Type: types.OrderTypeMarket, Quantity: qty}, "开仓: "+note)
+}
+
+// positionSize 按波动率计算开仓数量：数量 = 权益 × 每笔风险比例 × 信号仓位比例 / (5天平均波动价格值 × 倍数)
+// 名义价值超过最大持仓时截断，最后按数量步长向下取整。数量为0时返回原因
+func (e *Engine) positionSize(sig signal, equity float64) (float64, string) {
+	if sig.volatility <= 0 {
+		return 0, "5天平均波动价格值不足，无法计算仓位"
+	}
+	if equity <= 0 {
+		return 0, fmt.Sprintf("权益不足: %.2f", equity)
+	}
+
+	risk := equity * e.opts.RiskPerTrade
+	if sig.size > 0 && sig.size < 1 {
+		risk *= sig.size
+	}
+	stop := sig.volatility * e.opts.VolatilityMultiple
+	if stop <= 0 {
+		return 0, "止损距离为0，无法计算仓位"
+	}
+	qty := risk / stop
+	note := fmt.Sprintf("风险 %.2f，止损距离 %.4f（波动 %.4f × %.2f）", risk, stop, sig.volatility, e.opts.VolatilityMultiple)
+
+	if e.opts.MaxPosition > 0 && qty*sig.price > e.opts.MaxPosition {
+		qty = e.opts.MaxPosition / sig.price
+		note += fmt.Sprintf("，按最大持仓 %.2f 截断", e.opts.MaxPosition)
+	}
+	if step := e.opts.QuantitySteps[sig.symbol]; step > 0 {
+		qty = roundDownToStep(qty, step)
+		if qty <= 0 {
+			return 0, note + fmt.Sprintf("，不足一个数量步长 %v", step)
+		}
+	}
+	return qty, note
+}
+
+// closeQuantity 平仓数量：持仓数量按步长向下取整（如现货手续费从基础资产扣除后可用余额不在步长上），
+// 不足一个步长的余量无法下单，返回0视为没有持仓
+func (e *Engine) closeQuantity(symbol string, position float64) float64 {
+	qty := math.Abs(position)
+	if step := e.opts.QuantitySteps[symbol]; step > 0 {
+		qty = roundDownToStep(qty, step)
+	}
+	return qty
+}
+
+// roundDownToStep 按步长向下取整，并去掉浮点误差（如 3×0.1 → 0.3）
+func roundDownToStep(qty, step float64) float64 {
+	n := math.Floor(qty/step + 1e-9)
+	decimals := 0
+	if s := strconv.FormatFloat(step, 'f', -1, 64); strings.Contains(s, ".") {
+		decimals = len(s) - strings.Index(s, ".") - 1
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(n*step, 'f', decimals, 64), 64)
+	return rounded
+}
+
+// submit 下单并记录审计，订单提交成功（未被拒绝）时返回true
+func (e *Engine) submit(ctx context.Context, base types.ExecutionAudit, req types.OrderRequest, note string) bool {
+	audit := base
+	audit.Side = req.Side
+	audit.Quantity = req.Quantity
+	audit.ReduceOnly = req.ReduceOnly
+
+	result, err := e.executor.PlaceOrder(ctx, req, e.nextClientOrderID())
+	if err != nil {
+		e.record(ctx, withKind(audit, types.AuditKindError, fmt.Sprintf("%s下单失败: %v", note, err)))
+		return false
+	}
+	audit.OrderID = result.OrderID
+	audit.Status = result.Status
+	audit.FilledQty = result.FilledQty
+	audit.AvgPrice = result.AvgPrice
+	if result.Rejected() {
+		e.record(ctx, withKind(audit, types.AuditKindError, fmt.Sprintf("%s订单被拒绝: %s", note, result.Message)))
+		return false
+	}
+	e.record(ctx, withKind(audit, types.AuditKindOrder, note))
+	return true
+}
+
+// nextClientOrderID 生成客户端订单ID（Binance限制36个字符以内）
+func (e *Engine) nextClientOrderID() string {
+	e.mu.Lock()
+	e.orderSeq++
+	seq := e.orderSeq
+	e.mu.Unlock()
+	return fmt.Sprintf("se-%s-%d", strconv.FormatInt(time.Now().UnixMilli(), 36), seq)
+}
+
+// trackDay 记录账户当日（UTC）的起始权益和最新权益
+// 起始权益为当天第一次决策时的权益，同时写入审计记录，服务重启后由 restore 恢复
+func (e *Engine) trackDay(ctx context.Context, equity float64) DailyEquity {
+	today := utcDay(time.Now())
+
+	e.mu.Lock()
+	started := e.day == nil || !e.day.Day.Equal(today)
+	if started {
+		e.day = &DailyEquity{Day: today, StartEquity: equity}
+	}
+	e.day.Equity = equity
+	if e.day.StartEquity > 0 {
+		e.day.LossRatio = (e.day.StartEquity - equity) / e.day.StartEquity
+	}
+	day := *e.day
+	e.mu.Unlock()
+
+	if started {
+		e.record(ctx, types.ExecutionAudit{
+			Kind:     types.AuditKindDayStart,
+			Source:   "daily_equity",
+			Executor: e.executor.Name(),
+			Equity:   equity,
+			Message:  fmt.Sprintf("%s 起始权益 %.2f", today.Format("2006-01-02"), equity),
+		})
+	}
+	return day
+}
+
+// utcDay 时间所在的UTC自然日
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// killState 获取熔断开关状态
+func (e *Engine) killState() (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.killed, e.killReason
+}
+
+// Kill 开启熔断开关：之后的所有信号都不再下单；flatten为true时只减仓平掉已知交易对的持仓
+func (e *Engine) Kill(ctx context.Context, reason string, flatten bool) *Status {
+	if reason == "" {
+		reason = "手动开启"
+	}
+	e.mu.Lock()
+	e.killed = true
+	e.killReason = reason
+	e.killedAt = time.Now()
+	symbols := make([]string, 0, len(e.intervals))
+	for symbol := range e.intervals {
+		symbols = append(symbols, symbol)
+	}
+	e.mu.Unlock()
+	sort.Strings(symbols)
+
+	e.record(ctx, types.ExecutionAudit{Kind: types.AuditKindKill, Source: "kill_switch", Executor: e.executor.Name(), Message: reason})
+	if flatten {
+		e.flatten(ctx, symbols)
+	}
+	return e.Status()
+}
+
+// flatten 平掉各交易对的持仓
+func (e *Engine) flatten(ctx context.Context, symbols []string) {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+
+	for _, symbol := range symbols {
+		e.mu.Lock()
+		interval := e.intervals[symbol]
+		e.mu.Unlock()
+
+		base := types.ExecutionAudit{
+			Source:   "kill_switch",
+			Executor: e.executor.Name(),
+			Symbol:   symbol,
+			Interval: interval,
+			Action:   string(backtest.ActionClose),
+			Reason:   "熔断平仓",
+		}
+		if latest := e.hub.Latest(types.Symbol(symbol), interval); latest != nil {
+			base.Price = latest.Price
+		}
+
+		equity, position, err := e.executor.Account(ctx, symbol, base.Price)
+		if err != nil {
+			e.record(ctx, withKind(base, types.AuditKindError, "获取账户失败: "+err.Error()))
+			continue
+		}
+		base.Equity, base.Position = equity, position
+		qty := e.closeQuantity(symbol, position)
+		if qty == 0 {
+			continue
+		}
+		side := types.OrderSideSell
+		if position < 0 {
+			side = types.OrderSideBuy
+		}
+		e.submit(ctx, base, types.OrderRequest{Symbol: symbol, Side: side, Type: types.OrderTypeMarket, Quantity: qty, ReduceOnly: true}, "熔断平仓")
+	}
+}
+
+// Resume 关闭熔断开关
+func (e *Engine) Resume(ctx context.Context) *Status {
+	e.mu.Lock()
+	wasKilled := e.killed
+	e.killed = false
+	e.killReason = ""
+	e.killedAt = time.Time{}
+	e.mu.Unlock()
+
+	if wasKilled {
+		e.record(ctx, types.ExecutionAudit{Kind: types.AuditKindResume, Source: "kill_switch", Executor: e.executor.Name(), Message: "熔断开关已关闭"})
+	}
+	return e.Status()
+}
+
+// Status 获取执行状态
+func (e *Engine) Status() *Status {
+	status := &Status{
+		Executor:     e.executor.Name(),
+		RiskPerTrade: e.opts.RiskPerTrade,
+		VolMultiple:  e.opts.VolatilityMultiple,
+		MaxPosition:  e.opts.MaxPosition,
+		MaxDailyLoss: e.opts.MaxDailyLoss,
+		Strategies:   make([]StrategyStatus, 0, len(e.runners)),
+		Rules:        make([]RuleBinding, 0, len(e.rules)),
+	}
+
+	for _, r := range e.runners {
+		r.mu.Lock()
+		status.Strategies = append(status.Strategies, StrategyStatus{
+			Name:       r.cfg.Name,
+			Strategy:   r.cfg.Strategy,
+			Symbol:     r.cfg.Symbol,
+			Interval:   r.cfg.Interval,
+			LastBar:    r.lastBar,
+			LastSignal: r.lastSignal,
+		})
+		r.mu.Unlock()
+	}
+	for id, action := range e.rules {
+		status.Rules = append(status.Rules, RuleBinding{RuleID: id, Action: action})
+	}
+	sort.Slice(status.Rules, func(i, j int) bool { return status.Rules[i].RuleID < status.Rules[j].RuleID })
+
+	e.mu.Lock()
+	status.KillSwitch = e.killed
+	status.KillReason = e.killReason
+	if e.killed {
+		at := e.killedAt
+		status.KilledAt = &at
+	}
+	if e.day != nil && e.day.Day.Equal(utcDay(time.Now())) {
+		day := *e.day
+		status.DailyEquity = &day
+	}
+	e.mu.Unlock()
+	return status
+}
+
+// Audits 获取审计记录（按时间倒序）
+func (e *Engine) Audits(ctx context.Context, symbol string, limit int) ([]types.ExecutionAudit, error) {
+	return e.repo.ListAudits(ctx, strings.ToUpper(symbol), limit)
+}
+
+// withKind 复制审计记录并设置类型和说明
+func withKind(audit types.ExecutionAudit, kind, message string) types.ExecutionAudit {
+	audit.Kind = kind
+	audit.Message = message
+	return audit
+}
+
+// record 保存审计记录并写日志
+func (e *Engine) record(ctx context.Context, audit types.ExecutionAudit) {
+	if audit.Time.IsZero() {
+		audit.Time = time.Now()
+	}
+	log.Printf("策略执行 [%s] %s %s %s: %s", audit.Kind, audit.Source, audit.Symbol, audit.Action, audit.Message)
+	if err := e.repo.SaveAudit(ctx, &audit); err != nil {
+		log.Printf("保存策略执行审计记录失败: %v", err)
+	}
+}
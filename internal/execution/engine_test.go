@@ -0,0 +1,215 @@
+package execution
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/backtest"
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// stubExecutor 权益和持仓可调、下单即全部成交的执行器（成交不改变持仓）
+type stubExecutor struct {
+	mu         sync.Mutex
+	equity     float64
+	position   float64
+	orders     int
+	quantities []float64
+}
+
+func (s *stubExecutor) Name() string {
+	return "stub"
+}
+
+func (s *stubExecutor) Account(ctx context.Context, symbol string, price float64) (float64, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.equity, s.position, nil
+}
+
+func (s *stubExecutor) PlaceOrder(ctx context.Context, req types.OrderRequest, clientOrderID string) (*OrderResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders++
+	s.quantities = append(s.quantities, req.Quantity)
+	return &OrderResult{OrderID: "1", Status: "filled", FilledQty: req.Quantity, AvgPrice: 100}, nil
+}
+
+// setEquity 设置权益
+func (s *stubExecutor) setEquity(equity float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.equity = equity
+}
+
+// setPosition 设置持仓
+func (s *stubExecutor) setPosition(position float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.position = position
+}
+
+// lastQuantity 最近一笔订单的数量
+func (s *stubExecutor) lastQuantity() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.quantities) == 0 {
+		return 0
+	}
+	return s.quantities[len(s.quantities)-1]
+}
+
+// placed 已下单数量
+func (s *stubExecutor) placed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orders
+}
+
+// startEngine 创建并启动没有策略的引擎（由审计记录恢复状态）
+func startEngine(t *testing.T, executor Executor, repo AuditRepository) *Engine {
+	t.Helper()
+	e, err := NewEngine(nil, nil, executor, repo, Options{MaxDailyLoss: 0.05})
+	if err != nil {
+		t.Fatalf("创建引擎失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	e.Start(ctx)
+	return e
+}
+
+// longSignal 开多信号
+func longSignal(symbol string) signal {
+	return signal{source: "test", symbol: symbol, interval: "1h", action: backtest.ActionLong, price: 100, volatility: 5}
+}
+
+// dayStarts 审计记录中的当日起始权益
+func dayStarts(t *testing.T, repo AuditRepository, executor string) []types.ExecutionAudit {
+	t.Helper()
+	audits, err := repo.ListExecutorAudits(context.Background(), executor, types.AuditKindDayStart)
+	if err != nil {
+		t.Fatalf("查询审计记录失败: %v", err)
+	}
+	return audits
+}
+
+func TestDailyLossIsPerAccount(t *testing.T) {
+	executor := &stubExecutor{equity: 1000}
+	repo := newMemoryAuditRepository()
+	e := startEngine(t, executor, repo)
+	ctx := context.Background()
+
+	e.execute(ctx, longSignal("BTCUSDT"))
+	if executor.placed() != 1 {
+		t.Fatalf("首次决策应下单")
+	}
+	starts := dayStarts(t, repo, "stub")
+	if len(starts) != 1 || starts[0].Equity != 1000 {
+		t.Fatalf("应写入一条当日起始权益: %+v", starts)
+	}
+
+	// 另一个交易对的第一次决策沿用账户的起始权益，亏损10%超过上限
+	executor.setEquity(900)
+	e.execute(ctx, longSignal("ETHUSDT"))
+	if executor.placed() != 1 {
+		t.Fatalf("账户当日亏损超过上限后不应开仓")
+	}
+	if len(dayStarts(t, repo, "stub")) != 1 {
+		t.Fatalf("同一天只记录一次起始权益")
+	}
+	day := e.Status().DailyEquity
+	if day == nil || day.StartEquity != 1000 || day.Equity != 900 || day.LossRatio < 0.099 {
+		t.Fatalf("当日权益不正确: %+v", day)
+	}
+}
+
+func TestDailyEquityRestoredOnStart(t *testing.T) {
+	repo := newMemoryAuditRepository()
+	ctx := context.Background()
+	startEngine(t, &stubExecutor{equity: 1000}, repo).execute(ctx, longSignal("BTCUSDT"))
+
+	// 重启后按审计记录中的起始权益计算亏损：亏损6%超过上限
+	executor := &stubExecutor{equity: 940}
+	e := startEngine(t, executor, repo)
+	if day := e.Status().DailyEquity; day == nil || day.StartEquity != 1000 {
+		t.Fatalf("应恢复当日起始权益: %+v", day)
+	}
+	e.execute(ctx, longSignal("BTCUSDT"))
+	if executor.placed() != 0 {
+		t.Fatalf("重启后当日亏损超过上限不应开仓")
+	}
+	if len(dayStarts(t, repo, "stub")) != 1 {
+		t.Fatalf("恢复后不应重复记录起始权益")
+	}
+}
+
+func TestDailyEquityResetsOnNewDay(t *testing.T) {
+	repo := newMemoryAuditRepository()
+	ctx := context.Background()
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+	repo.SaveAudit(ctx, &types.ExecutionAudit{Time: yesterday, Kind: types.AuditKindDayStart, Executor: "stub", Equity: 2000})
+	// 其他执行器的记录不参与恢复
+	repo.SaveAudit(ctx, &types.ExecutionAudit{Time: time.Now(), Kind: types.AuditKindDayStart, Executor: "other", Equity: 5000})
+
+	executor := &stubExecutor{equity: 1000}
+	e := startEngine(t, executor, repo)
+	if day := e.Status().DailyEquity; day != nil {
+		t.Fatalf("前一天的起始权益不应恢复: %+v", day)
+	}
+
+	e.execute(ctx, longSignal("BTCUSDT"))
+	if executor.placed() != 1 {
+		t.Fatalf("新的一天应按当前权益重新开始并下单")
+	}
+	starts := dayStarts(t, repo, "stub")
+	if len(starts) != 2 || starts[1].Equity != 1000 || !utcDay(starts[1].Time).Equal(utcDay(time.Now())) {
+		t.Fatalf("应记录新一天的起始权益: %+v", starts)
+	}
+}
+
+func TestCloseQuantityRoundedToStep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := service.NewRealtimeHub(ctx, exchange.NewMemoryProvider(), nil, nil)
+	executor := &stubExecutor{equity: 1000}
+	e, err := NewEngine(hub, nil, executor, nil, Options{QuantitySteps: map[string]float64{"btcusdt": 0.00001}})
+	if err != nil {
+		t.Fatalf("创建引擎失败: %v", err)
+	}
+
+	// 手续费从基础资产扣除后可用余额不在步长上，平仓数量向下取整
+	executor.setPosition(0.000999)
+	e.execute(ctx, signal{source: "test", symbol: "BTCUSDT", interval: "1h", action: backtest.ActionClose, price: 100})
+	if executor.placed() != 1 || executor.lastQuantity() != 0.00099 {
+		t.Fatalf("平仓数量 %v，期望 0.00099", executor.lastQuantity())
+	}
+
+	// 不足一个步长的余量视为没有持仓：不平仓，开仓信号正常开仓
+	executor.setPosition(0.000009)
+	e.execute(ctx, signal{source: "test", symbol: "BTCUSDT", interval: "1h", action: backtest.ActionClose, price: 100})
+	if executor.placed() != 1 {
+		t.Fatalf("不足一个步长的余量不应平仓")
+	}
+	e.execute(ctx, longSignal("BTCUSDT"))
+	if executor.placed() != 2 {
+		t.Fatalf("不足一个步长的余量不应视为同向持仓")
+	}
+
+	// 熔断平仓同样按步长取整
+	executor.setPosition(0.0012345)
+	e.Kill(ctx, "test", true)
+	if executor.placed() != 3 || executor.lastQuantity() != 0.00123 {
+		t.Fatalf("熔断平仓数量 %v，期望 0.00123", executor.lastQuantity())
+	}
+	executor.setPosition(0.000009)
+	e.Resume(ctx)
+	e.Kill(ctx, "test", true)
+	if executor.placed() != 3 {
+		t.Fatalf("不足一个步长的余量不应熔断平仓")
+	}
+}
@@ -0,0 +1,316 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// 执行器名称
+const (
+	ExecutorPaper   = "paper"
+	ExecutorBinance = "binance"
+)
+
+// Executor 下单执行器（模拟账户或Binance账户）
+type Executor interface {
+	Name() string
+	// Account 获取权益和交易对的持仓数量（空头为负数），price为交易对的最新价格
+	Account(ctx context.Context, symbol string, price float64) (equity, position float64, err error)
+	// PlaceOrder 下单，clientOrderID用于在交易所侧去重和追踪
+	PlaceOrder(ctx context.Context, req types.OrderRequest, clientOrderID string) (*OrderResult, error)
+}
+
+// OrderResult 下单结果
+type OrderResult struct {
+	OrderID   string  `json:"order_id"`
+	Status    string  `json:"status"` // 小写的订单状态，如 new、filled、rejected
+	FilledQty float64 `json:"filled_qty"`
+	AvgPrice  float64 `json:"avg_price"`
+	Message   string  `json:"message,omitempty"` // 拒绝原因
+}
+
+// Rejected 订单是否被拒绝
+func (r *OrderResult) Rejected() bool {
+	return r.Status == types.OrderStatusRejected || r.Status == "expired"
+}
+
+// PaperExecutor 模拟账户执行器
+type PaperExecutor struct {
+	paper *service.PaperTradingService
+}
+
+// NewPaperExecutor 创建模拟账户执行器
+func NewPaperExecutor(paper *service.PaperTradingService) *PaperExecutor {
+	return &PaperExecutor{paper: paper}
+}
+
+// Name 执行器名称
+func (e *PaperExecutor) Name() string {
+	return ExecutorPaper
+}
+
+// Account 模拟账户的权益和持仓
+func (e *PaperExecutor) Account(ctx context.Context, symbol string, price float64) (float64, float64, error) {
+	snapshot := e.paper.Account()
+	for _, p := range snapshot.Positions {
+		if p.Symbol == symbol {
+			return snapshot.Equity, p.Quantity, nil
+		}
+	}
+	return snapshot.Equity, 0, nil
+}
+
+// PlaceOrder 向模拟账户下单（模拟账户不使用clientOrderID）
+func (e *PaperExecutor) PlaceOrder(ctx context.Context, req types.OrderRequest, clientOrderID string) (*OrderResult, error) {
+	order, err := e.paper.PlaceOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderResult{
+		OrderID:   strconv.FormatInt(order.ID, 10),
+		Status:    order.Status,
+		FilledQty: order.FilledQty,
+		AvgPrice:  order.FillPrice,
+		Message:   order.Reason,
+	}, nil
+}
+
+// fillRestorer 按策略自己的成交计算持仓的执行器，引擎启动时用审计记录中的订单恢复
+type fillRestorer interface {
+	RestoreFills(orders []types.ExecutionAudit)
+}
+
+// quantityStepper 自行调整下单数量的执行器，引擎创建时传入各交易对的数量步长
+type quantityStepper interface {
+	SetQuantitySteps(steps map[string]float64)
+}
+
+// BinanceExecutor Binance账户执行器（签名接口）
+// 现货只能做多：账户中可能有策略之外的基础资产，持仓为策略自己成交的净买入数量（不超过可用余额），
+// 卖出数量不超过该持仓；权益按计价资产加策略持有的各基础资产市值计算（其他交易对按最近一次的价格）。
+// U本位合约的权益为计价资产的钱包余额加未实现盈亏。币本位合约按张数下单，不支持
+type BinanceExecutor struct {
+	client     *binance.Client
+	quoteAsset string
+
+	mu       sync.Mutex
+	held     map[string]float64 // 各现货交易对由策略成交累计的净持仓
+	reserved map[string]float64 // 各现货交易对已提交、尚未返回的卖单数量
+	prices   map[string]float64 // 各现货交易对最近一次的价格
+	steps    map[string]float64 // 各交易对的下单数量步长
+}
+
+// NewBinanceExecutor 创建Binance账户执行器，quoteAsset为计价资产（默认USDT）
+func NewBinanceExecutor(client *binance.Client, quoteAsset string) *BinanceExecutor {
+	if quoteAsset == "" {
+		quoteAsset = "USDT"
+	}
+	return &BinanceExecutor{
+		client:     client,
+		quoteAsset: strings.ToUpper(quoteAsset),
+		held:       make(map[string]float64),
+		reserved:   make(map[string]float64),
+		prices:     make(map[string]float64),
+	}
+}
+
+// SetQuantitySteps 设置各交易对的下单数量步长（交易对为大写），现货卖单截断到净持仓后按步长向下取整
+func (e *BinanceExecutor) SetQuantitySteps(steps map[string]float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.steps = steps
+}
+
+// RestoreFills 由该执行器的订单审计记录（按时间正序）重建各现货交易对的净持仓
+func (e *BinanceExecutor) RestoreFills(orders []types.ExecutionAudit) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.held = make(map[string]float64)
+	for _, o := range orders {
+		if o.Kind == types.AuditKindOrder && e.client.MarketOf(types.Symbol(o.Symbol)) == types.MarketSpot {
+			e.applyFill(o.Symbol, o.Side, o.FilledQty)
+		}
+	}
+}
+
+// applyFill 按成交更新净持仓（调用方需持有mu），卖出不会使持仓为负
+func (e *BinanceExecutor) applyFill(symbol, side string, qty float64) {
+	if qty <= 0 {
+		return
+	}
+	held := e.held[symbol]
+	if side == types.OrderSideBuy {
+		held += qty
+	} else {
+		held = math.Max(0, held-qty)
+	}
+	if held > 0 {
+		e.held[symbol] = held
+	} else {
+		delete(e.held, symbol)
+	}
+}
+
+// spotPosition 策略在交易对上的持仓：净买入数量，不超过可用余额（调用方需持有mu）
+func (e *BinanceExecutor) spotPosition(symbol string, free map[string]float64) float64 {
+	base, err := e.baseAsset(symbol)
+	if err != nil {
+		return 0
+	}
+	return math.Min(e.held[symbol], free[base])
+}
+
+// Name 执行器名称
+func (e *BinanceExecutor) Name() string {
+	return ExecutorBinance
+}
+
+// Account Binance账户的权益和持仓
+func (e *BinanceExecutor) Account(ctx context.Context, symbol string, price float64) (float64, float64, error) {
+	market := e.client.MarketOf(types.Symbol(symbol))
+	switch market {
+	case types.MarketCOINM:
+		return 0, 0, fmt.Errorf("币本位合约 %s 不支持自动下单", symbol)
+	case types.MarketUSDM:
+		balances, err := e.client.GetBalances(market)
+		if err != nil {
+			return 0, 0, err
+		}
+		equity := 0.0
+		for _, b := range balances {
+			if b.Asset == e.quoteAsset {
+				equity = b.Total + b.UnrealizedPnL
+			}
+		}
+		positions, err := e.client.GetPositions(types.Symbol(symbol))
+		if err != nil {
+			return 0, 0, err
+		}
+		position := 0.0
+		for _, p := range positions {
+			position += p.Amount
+		}
+		return equity, position, nil
+	}
+
+	if _, err := e.baseAsset(symbol); err != nil {
+		return 0, 0, err
+	}
+	balances, err := e.client.GetBalances(types.MarketSpot)
+	if err != nil {
+		return 0, 0, err
+	}
+	var quote float64
+	free := make(map[string]float64, len(balances))
+	for _, b := range balances {
+		if b.Asset == e.quoteAsset {
+			quote = b.Total
+		}
+		free[b.Asset] = b.Free
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if price > 0 {
+		e.prices[symbol] = price
+	}
+	equity := quote
+	for s := range e.held {
+		equity += e.spotPosition(s, free) * e.prices[s]
+	}
+	return equity, e.spotPosition(symbol, free), nil
+}
+
+// PlaceOrder 向Binance下单
+// 现货的只减仓卖单转换为普通卖单，数量不超过策略未被其他卖单锁定的净持仓，并按数量步长向下取整；非只减仓的卖单（开空）被拒绝
+func (e *BinanceExecutor) PlaceOrder(ctx context.Context, req types.OrderRequest, clientOrderID string) (*OrderResult, error) {
+	req.Normalize()
+	market := e.client.MarketOf(types.Symbol(req.Symbol))
+	switch market {
+	case types.MarketCOINM:
+		return nil, fmt.Errorf("币本位合约 %s 不支持自动下单", req.Symbol)
+	case types.MarketSpot:
+		if req.Side == types.OrderSideSell && !req.ReduceOnly {
+			return nil, fmt.Errorf("现货不支持开空仓")
+		}
+		req.ReduceOnly = false
+	}
+
+	spot := market == types.MarketSpot
+	reserved := 0.0
+	if spot && req.Side == types.OrderSideSell {
+		qty, err := e.reserve(req.Symbol, req.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		req.Quantity, reserved = qty, qty
+	}
+
+	// 请求（含重试和时间同步）期间不持有mu，不阻塞其他交易对的下单和账户查询
+	order, err := e.client.PlaceOrder(req, clientOrderID)
+	if spot {
+		filled := 0.0
+		if err == nil {
+			filled = order.ExecutedQty
+		}
+		e.settle(req.Symbol, req.Side, reserved, filled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &OrderResult{
+		OrderID:   strconv.FormatInt(order.OrderID, 10),
+		Status:    strings.ToLower(order.Status),
+		FilledQty: order.ExecutedQty,
+		AvgPrice:  order.AvgPrice,
+	}, nil
+}
+
+// reserve 锁定现货卖单的数量：不超过净持仓中未被其他卖单锁定的部分，并按步长向下取整
+func (e *BinanceExecutor) reserve(symbol string, qty float64) (float64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	available := e.held[symbol] - e.reserved[symbol]
+	if available <= 0 {
+		return 0, fmt.Errorf("策略在 %s 上没有可卖出的持仓", symbol)
+	}
+	qty = math.Min(qty, available)
+	if step := e.steps[symbol]; step > 0 {
+		qty = roundDownToStep(qty, step)
+		if qty <= 0 {
+			return 0, fmt.Errorf("策略在 %s 上可卖出的持仓不足一个数量步长 %v", symbol, step)
+		}
+	}
+	e.reserved[symbol] += qty
+	return qty, nil
+}
+
+// settle 订单返回后释放锁定的卖出数量，并按成交更新净持仓
+func (e *BinanceExecutor) settle(symbol, side string, reserved, filled float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if reserved > 0 {
+		if left := e.reserved[symbol] - reserved; left > 1e-12 {
+			e.reserved[symbol] = left
+		} else {
+			delete(e.reserved, symbol)
+		}
+	}
+	e.applyFill(symbol, side, filled)
+}
+
+// baseAsset 由交易对和计价资产得到基础资产（如 BTCUSDT → BTC）
+func (e *BinanceExecutor) baseAsset(symbol string) (string, error) {
+	if !strings.HasSuffix(symbol, e.quoteAsset) || len(symbol) == len(e.quoteAsset) {
+		return "", fmt.Errorf("交易对 %s 的计价资产不是 %s", symbol, e.quoteAsset)
+	}
+	return strings.TrimSuffix(symbol, e.quoteAsset), nil
+}
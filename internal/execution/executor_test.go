@@ -0,0 +1,250 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// fakeSpot 本地现货账户模拟服务：市价单按固定价格全部成交并更新余额
+// gate不为nil时，每个订单请求需从gate取到一个令牌才处理（waiting为等待中的请求数）
+type fakeSpot struct {
+	mu       sync.Mutex
+	price    float64
+	balances map[string]float64
+	orders   []spotOrder
+	gate     chan struct{}
+	waiting  atomic.Int32
+}
+
+// spotOrder 模拟服务收到的订单
+type spotOrder struct {
+	side     string
+	quantity float64
+}
+
+func newFakeSpot(t *testing.T, balances map[string]float64) (*fakeSpot, *BinanceExecutor) {
+	f := &fakeSpot{price: 100, balances: balances}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	client := binance.NewClient("key", "secret", "https://api.binance.com")
+	client.SetRESTBase(types.MarketSpot, server.URL)
+	return f, NewBinanceExecutor(client, "USDT")
+}
+
+func (f *fakeSpot) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v3/order" && f.gate != nil {
+		f.waiting.Add(1)
+		<-f.gate
+		f.waiting.Add(-1)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/v3/time":
+		fmt.Fprint(w, `{"serverTime":1}`)
+	case "/api/v3/account":
+		var parts []string
+		for asset, free := range f.balances {
+			parts = append(parts, fmt.Sprintf(`{"asset":%q,"free":"%v","locked":"0"}`, asset, free))
+		}
+		fmt.Fprintf(w, `{"balances":[%s]}`, strings.Join(parts, ","))
+	case "/api/v3/order":
+		q := r.URL.Query()
+		qty, _ := strconv.ParseFloat(q.Get("quantity"), 64)
+		side := strings.ToLower(q.Get("side"))
+		f.orders = append(f.orders, spotOrder{side: side, quantity: qty})
+		base := strings.TrimSuffix(q.Get("symbol"), "USDT")
+		if side == types.OrderSideBuy {
+			f.balances[base] += qty
+			f.balances["USDT"] -= qty * f.price
+		} else {
+			f.balances[base] -= qty
+			f.balances["USDT"] += qty * f.price
+		}
+		fmt.Fprintf(w, `{"symbol":%q,"orderId":%d,"status":"FILLED","origQty":"%v","executedQty":"%v","cummulativeQuoteQty":"%v"}`,
+			q.Get("symbol"), len(f.orders), qty, qty, qty*f.price)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// setFree 设置资产余额
+func (f *fakeSpot) setFree(asset string, free float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[asset] = free
+}
+
+// sent 收到的订单
+func (f *fakeSpot) sent() []spotOrder {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]spotOrder(nil), f.orders...)
+}
+
+// assertAccount 检查执行器返回的权益和持仓
+func assertAccount(t *testing.T, e Executor, symbol string, price, wantEquity, wantPosition float64) {
+	t.Helper()
+	equity, position, err := e.Account(context.Background(), symbol, price)
+	if err != nil {
+		t.Fatalf("获取账户失败: %v", err)
+	}
+	if math.Abs(equity-wantEquity) > 1e-9 || math.Abs(position-wantPosition) > 1e-9 {
+		t.Fatalf("权益 %v、持仓 %v，期望 %v、%v", equity, position, wantEquity, wantPosition)
+	}
+}
+
+func TestBinanceSpotPositionFromOwnFills(t *testing.T) {
+	// 账户中原有的5 BTC不属于策略
+	f, e := newFakeSpot(t, map[string]float64{"USDT": 1000, "BTC": 5})
+	ctx := context.Background()
+	assertAccount(t, e, "BTCUSDT", 100, 1000, 0)
+
+	if _, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.5}, ""); err != nil {
+		t.Fatalf("买入失败: %v", err)
+	}
+	assertAccount(t, e, "BTCUSDT", 100, 1000, 0.5)
+
+	// 平仓卖单不超过策略买入的数量
+	result, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 2, ReduceOnly: true}, "")
+	if err != nil {
+		t.Fatalf("卖出失败: %v", err)
+	}
+	if orders := f.sent(); len(orders) != 2 || orders[1].quantity != 0.5 || result.FilledQty != 0.5 {
+		t.Fatalf("卖出数量应截断为0.5: %+v", orders)
+	}
+	assertAccount(t, e, "BTCUSDT", 100, 1000, 0)
+
+	// 策略没有持仓时不卖出账户中原有的资产
+	if _, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 1, ReduceOnly: true}, ""); err == nil {
+		t.Fatalf("没有持仓时卖出应失败")
+	}
+	if len(f.sent()) != 2 {
+		t.Fatalf("没有持仓时不应发送订单")
+	}
+}
+
+func TestBinanceSpotRestoreFills(t *testing.T) {
+	f, e := newFakeSpot(t, map[string]float64{"USDT": 1000, "BTC": 5, "ETH": 3})
+	e.RestoreFills([]types.ExecutionAudit{
+		{Kind: types.AuditKindOrder, Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 0.3, FilledQty: 0.3},
+		{Kind: types.AuditKindOrder, Symbol: "BTCUSDT", Side: types.OrderSideSell, Quantity: 0.1, FilledQty: 0.1},
+		{Kind: types.AuditKindError, Symbol: "BTCUSDT", Side: types.OrderSideBuy, Quantity: 1},
+		{Kind: types.AuditKindOrder, Symbol: "ETHUSDT", Side: types.OrderSideBuy, Quantity: 2, FilledQty: 2},
+	})
+
+	// 权益包含策略持有的各交易对（其他交易对按最近一次的价格）
+	assertAccount(t, e, "ETHUSDT", 10, 1000+2*10, 2)
+	assertAccount(t, e, "BTCUSDT", 100, 1000+0.2*100+2*10, 0.2)
+
+	// 持仓不超过可用余额
+	f.setFree("BTC", 0.15)
+	assertAccount(t, e, "BTCUSDT", 100, 1000+0.15*100+2*10, 0.15)
+}
+
+func TestEngineRestoresFillsOnStart(t *testing.T) {
+	_, e := newFakeSpot(t, map[string]float64{"USDT": 1000, "BTC": 5})
+	repo := newMemoryAuditRepository()
+	ctx := context.Background()
+	for _, audit := range []types.ExecutionAudit{
+		{Kind: types.AuditKindOrder, Executor: ExecutorBinance, Symbol: "BTCUSDT", Side: types.OrderSideBuy, FilledQty: 0.4},
+		{Kind: types.AuditKindOrder, Executor: ExecutorPaper, Symbol: "BTCUSDT", Side: types.OrderSideBuy, FilledQty: 1},
+	} {
+		audit := audit
+		repo.SaveAudit(ctx, &audit)
+	}
+
+	engine, err := NewEngine(nil, nil, e, repo, Options{})
+	if err != nil {
+		t.Fatalf("创建引擎失败: %v", err)
+	}
+	startCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	engine.Start(startCtx)
+	assertAccount(t, e, "BTCUSDT", 100, 1000+0.4*100, 0.4)
+}
+
+func TestBinanceSpotOrderDoesNotBlockAccount(t *testing.T) {
+	f, e := newFakeSpot(t, map[string]float64{"USDT": 1000})
+	f.gate = make(chan struct{}, 3)
+	e.SetQuantitySteps(map[string]float64{"BTCUSDT": 0.001})
+	ctx := context.Background()
+
+	f.gate <- struct{}{}
+	if _, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideBuy, Type: types.OrderTypeMarket, Quantity: 0.5}, ""); err != nil {
+		t.Fatalf("买入失败: %v", err)
+	}
+
+	// 两个卖单同时在途：第一个按步长取整为0.3，第二个只能卖出未被锁定的0.2
+	sell := func(qty float64) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: qty, ReduceOnly: true}, "")
+			done <- err
+		}()
+		return done
+	}
+	waitPending := func(n int32) {
+		deadline := time.Now().Add(5 * time.Second)
+		for f.waiting.Load() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待 %d 个在途订单超时", n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	first := sell(0.3004)
+	waitPending(1)
+	second := sell(2)
+	waitPending(2)
+
+	// 订单在途时账户查询不被阻塞，成交前持仓不变
+	accountDone := make(chan struct{})
+	go func() {
+		defer close(accountDone)
+		e.Account(ctx, "BTCUSDT", 100)
+	}()
+	select {
+	case <-accountDone:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("订单在途时账户查询被阻塞")
+	}
+	assertAccount(t, e, "BTCUSDT", 100, 1000, 0.5)
+
+	// 持仓全部被锁定，再卖出被拒绝
+	if _, err := e.PlaceOrder(ctx, types.OrderRequest{Symbol: "BTCUSDT", Side: types.OrderSideSell, Type: types.OrderTypeMarket, Quantity: 1, ReduceOnly: true}, ""); err == nil {
+		t.Fatalf("持仓已全部锁定时卖出应失败")
+	}
+
+	f.gate <- struct{}{}
+	f.gate <- struct{}{}
+	for _, done := range []<-chan error{first, second} {
+		if err := <-done; err != nil {
+			t.Fatalf("卖出失败: %v", err)
+		}
+	}
+	var sold []float64
+	for _, o := range f.sent()[1:] {
+		sold = append(sold, o.quantity)
+	}
+	sort.Float64s(sold)
+	if len(sold) != 2 || sold[0] != 0.2 || sold[1] != 0.3 {
+		t.Fatalf("卖出数量 %v，期望 [0.2 0.3]", sold)
+	}
+	assertAccount(t, e, "BTCUSDT", 100, 1000, 0)
+}
@@ -0,0 +1,83 @@
+package execution
+
+import (
+	"github.com/binance_cyan/indicators/internal/backtest"
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// historyBars 传给策略的已收盘K线快照数量
+const historyBars = 300
+
+// buildHistory 把实时数据中的已收盘K线转换为回测策略使用的快照（从旧到新，最后一个即刚收盘的K线）
+// 指标值直接取自实时数据（key规则与回测一致），不重新计算
+func buildHistory(data *service.RealtimeData) []*backtest.Snapshot {
+	last := len(data.Klines) - 1
+	if last > historyBars {
+		last = historyBars
+	}
+
+	history := make([]*backtest.Snapshot, 0, last)
+	for i := last; i >= 1; i-- {
+		history = append(history, buildSnapshot(data, i, len(history)))
+	}
+	return history
+}
+
+// buildSnapshot 转换第i根K线（0为正在形成的K线）
+func buildSnapshot(data *service.RealtimeData, i, index int) *backtest.Snapshot {
+	bar := service.BuildBarSnapshot(data, i)
+	k := data.Klines[i]
+	snap := &backtest.Snapshot{
+		Index: index,
+		Kline: types.Kline{
+			Symbol:    data.Symbol,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			Timestamp: k.Time,
+		},
+		Price:      (k.High + k.Low + k.Close) * (1.0 / 3.0),
+		CCI:        bar.CCI,
+		RSI:        bar.RSI,
+		MACD:       make(map[string]backtest.MACDPoint, len(bar.MACD)),
+		Custom:     bar.Custom,
+		Volatility: data.Volatility,
+	}
+	for key, m := range bar.MACD {
+		snap.MACD[key] = backtest.MACDPoint{Line: m.MacdLine, Signal: m.SignalLine, Histogram: m.Histogram}
+	}
+
+	b := data.Bollinger
+	if i < len(b.Upper) && i < len(b.Middle) && i < len(b.Lower) {
+		snap.Bollinger = backtest.BandPoint{Upper: b.Upper[i], Middle: b.Middle[i], Lower: b.Lower[i], Zone: bar.BollZone}
+	}
+	e := data.Envelope
+	if i < len(e.Upper) && i < len(e.Middle) && i < len(e.Lower) {
+		snap.Envelope = backtest.BandPoint{Upper: e.Upper[i], Middle: e.Middle[i], Lower: e.Lower[i], Zone: bar.EnvZone}
+	}
+
+	// 有数据的指标在该K线上都有值时才认为就绪（数据不足未计算的指标序列为空，不参与判断）
+	macdCount := 0
+	for _, m := range data.MACD {
+		if len(m.Histogram) > 0 {
+			macdCount++
+		}
+	}
+	snap.Ready = len(bar.CCI) == nonEmpty(data.CCI) && len(bar.RSI) == nonEmpty(data.RSI) && len(bar.MACD) == macdCount &&
+		(len(b.Middle) == 0 || snap.Bollinger.Middle != 0) && (len(e.Middle) == 0 || snap.Envelope.Middle != 0)
+	return snap
+}
+
+// nonEmpty 有数据的序列数量
+func nonEmpty(series map[string][]float64) int {
+	n := 0
+	for _, s := range series {
+		if len(s) > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,68 @@
+package execution
+
+import (
+	"context"
+	"sync"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// maxMemoryAudits 内存仓库保留的最大审计记录数量
+const maxMemoryAudits = 5000
+
+// memoryAuditRepository 内存审计记录仓库（MySQL不可用时使用，重启后丢失）
+type memoryAuditRepository struct {
+	mu     sync.Mutex
+	audits []types.ExecutionAudit
+	nextID int64
+}
+
+// newMemoryAuditRepository 创建内存审计记录仓库
+func newMemoryAuditRepository() *memoryAuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (m *memoryAuditRepository) SaveAudit(ctx context.Context, audit *types.ExecutionAudit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	audit.ID = m.nextID
+	m.audits = append(m.audits, *audit)
+	if len(m.audits) > maxMemoryAudits {
+		m.audits = m.audits[len(m.audits)-maxMemoryAudits:]
+	}
+	return nil
+}
+
+func (m *memoryAuditRepository) ListAudits(ctx context.Context, symbol string, limit int) ([]types.ExecutionAudit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	audits := []types.ExecutionAudit{}
+	for i := len(m.audits) - 1; i >= 0 && len(audits) < limit; i-- {
+		if symbol == "" || m.audits[i].Symbol == symbol {
+			audits = append(audits, m.audits[i])
+		}
+	}
+	return audits, nil
+}
+
+func (m *memoryAuditRepository) ListExecutorAudits(ctx context.Context, executor string, kinds ...string) ([]types.ExecutionAudit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	audits := []types.ExecutionAudit{}
+	for _, a := range m.audits {
+		if a.Executor != executor {
+			continue
+		}
+		for _, kind := range kinds {
+			if a.Kind == kind {
+				audits = append(audits, a)
+				break
+			}
+		}
+	}
+	return audits, nil
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// defaultQueueSize 待发送通知的队列长度
+const defaultQueueSize = 256
+
+// Options 通知服务配置
+type Options struct {
+	Streams   []service.StreamKey // 推送 bar_close 和 zone_change 通知的实时流
+	Users     []User
+	Templates map[string]string // 按事件类型覆盖默认消息模板
+	QueueSize int
+}
+
+// Dispatcher 通知服务
+// 在配置的实时流K线收盘和分区变化时、以及告警触发时渲染消息，按用户的路由规则和免打扰时段发送到各渠道
+type Dispatcher struct {
+	hub       *service.RealtimeHub
+	alerts    *service.AlertService
+	notifiers map[string]Notifier
+	users     []User
+	templates *Templates
+	streams   []service.StreamKey
+	queue     chan TemplateData
+	now       func() time.Time
+}
+
+// NewDispatcher 创建通知服务，alerts 为nil时不发送告警通知
+func NewDispatcher(hub *service.RealtimeHub, alerts *service.AlertService, notifiers []Notifier, opts Options) (*Dispatcher, error) {
+	templates, err := NewTemplates(opts.Templates)
+	if err != nil {
+		return nil, err
+	}
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	d := &Dispatcher{
+		hub:       hub,
+		alerts:    alerts,
+		notifiers: make(map[string]Notifier),
+		templates: templates,
+		streams:   opts.Streams,
+		queue:     make(chan TemplateData, queueSize),
+		now:       time.Now,
+	}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+
+	for _, user := range opts.Users {
+		if err := validateUser(&user); err != nil {
+			return nil, err
+		}
+		for channel := range user.Recipients {
+			if _, ok := d.notifiers[channel]; !ok {
+				return nil, fmt.Errorf("通知用户 %s 配置了 %s 地址，但未配置该渠道", user.Name, channel)
+			}
+		}
+		d.users = append(d.users, user)
+	}
+	return d, nil
+}
+
+// Start 启动发送队列，并订阅实时流和告警事件，ctx取消后停止
+func (d *Dispatcher) Start(ctx context.Context) {
+	go d.run(ctx)
+
+	if d.alerts != nil {
+		ch := d.alerts.Subscribe()
+		go func() {
+			defer d.alerts.Unsubscribe(ch)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-ch:
+					if !ok {
+						return
+					}
+					// 模板字段取自告警所在实时流的最新数据
+					data := TemplateData{Symbol: event.Symbol, Interval: event.Interval, Price: event.Price}
+					if latest := d.hub.Latest(types.Symbol(event.Symbol), event.Interval); latest != nil {
+						data = NewTemplateData(EventAlert, latest, 0)
+						data.Price = latest.Price
+					}
+					data.Event = EventAlert
+					data.Time = event.TriggeredAt
+					data.Alert = event
+					d.Notify(data)
+				}
+			}
+		}()
+	}
+
+	for _, key := range d.streams {
+		handler := service.OnBarClose(func(data *service.RealtimeData, bar service.BarSnapshot, changes []service.ZoneChange) {
+			d.Notify(NewTemplateData(EventBarClose, data, 1))
+			for i := range changes {
+				td := NewTemplateData(EventZoneChange, data, 1)
+				td.Zone = &changes[i]
+				d.Notify(td)
+			}
+		})
+		go d.hub.Watch(ctx, key, service.PolicyCoalesce, "通知服务", handler)
+	}
+
+	log.Printf("通知服务已启动，共 %d 个渠道，%d 个用户，%d 条实时流", len(d.notifiers), len(d.users), len(d.streams))
+}
+
+// Notify 把通知放入发送队列（队列满时丢弃）
+func (d *Dispatcher) Notify(data TemplateData) {
+	select {
+	case d.queue <- data:
+	default:
+		log.Printf("通知队列已满，丢弃 %s %s 通知", data.Symbol, data.Event)
+	}
+}
+
+// run 按顺序发送队列中的通知
+func (d *Dispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data := <-d.queue:
+			d.dispatch(ctx, data)
+		}
+	}
+}
+
+// dispatch 渲染消息并发送给所有匹配且不在免打扰时段的用户
+func (d *Dispatcher) dispatch(ctx context.Context, data TemplateData) {
+	msg, err := d.templates.Render(data)
+	if err != nil {
+		log.Printf("渲染通知失败: %v", err)
+		return
+	}
+
+	now := d.now()
+	for _, user := range d.users {
+		if !user.wants(data.Event, data.Symbol) {
+			continue
+		}
+		if user.Quiet.Contains(now) {
+			log.Printf("用户 %s 处于免打扰时段，跳过 %s %s 通知", user.Name, data.Symbol, data.Event)
+			continue
+		}
+
+		channels := make([]string, 0, len(user.Recipients))
+		for channel := range user.Recipients {
+			channels = append(channels, channel)
+		}
+		sort.Strings(channels)
+		for _, channel := range channels {
+			if err := d.notifiers[channel].Send(ctx, user.Recipients[channel], msg); err != nil {
+				log.Printf("向用户 %s 发送 %s 通知失败: %v", user.Name, channel, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package notify
+
+import "context"
+
+// 通知渠道
+const (
+	ChannelTelegram = "telegram"
+	ChannelSMTP     = "smtp"
+)
+
+// Message 一条通知消息
+type Message struct {
+	Subject string // 标题（邮件主题），Telegram不单独发送
+	Body    string
+}
+
+// Notifier 通知渠道接口
+type Notifier interface {
+	// Channel 渠道名称（telegram、smtp）
+	Channel() string
+	// Send 向接收方发送消息，recipient 为渠道内的地址（Telegram chat_id、邮箱地址）
+	Send(ctx context.Context, recipient string, msg Message) error
+}
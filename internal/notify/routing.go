@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User 通知接收人及其路由规则
+type User struct {
+	Name string
+	// Recipients 各渠道的地址，key 为渠道名称（telegram: chat_id，smtp: 邮箱地址）
+	Recipients map[string]string
+	Events     []string // 接收的事件类型，为空表示全部
+	Symbols    []string // 接收的交易对，为空表示全部
+	Quiet      *QuietHours
+}
+
+// wants 用户是否接收该事件
+func (u User) wants(event, symbol string) bool {
+	if len(u.Events) > 0 && !contains(u.Events, event) {
+		return false
+	}
+	if len(u.Symbols) > 0 && !contains(u.Symbols, strings.ToUpper(symbol)) {
+		return false
+	}
+	return true
+}
+
+// contains 切片中是否包含s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietHours 免打扰时段（按当地时间每天重复），时段内不发送通知
+type QuietHours struct {
+	Start    int // 开始时间（当天分钟数）
+	End      int // 结束时间（当天分钟数），小于 Start 表示跨越午夜
+	Location *time.Location
+}
+
+// ParseQuietHours 解析 "23:00"、"07:30" 形式的免打扰时段，timezone 为空时使用UTC
+func ParseQuietHours(start, end, timezone string) (*QuietHours, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	if s == e {
+		return nil, fmt.Errorf("免打扰开始和结束时间不能相同: %s", start)
+	}
+	loc := time.UTC
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区 %q: %w", timezone, err)
+		}
+	}
+	return &QuietHours{Start: s, End: e, Location: loc}, nil
+}
+
+// parseClock 解析 "HH:MM" 为当天分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 时间t是否处于免打扰时段
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	local := t.In(q.Location)
+	m := local.Hour()*60 + local.Minute()
+	if q.Start < q.End {
+		return m >= q.Start && m < q.End
+	}
+	return m >= q.Start || m < q.End
+}
+
+// validateUser 校验用户配置
+func validateUser(u *User) error {
+	if u.Name == "" {
+		return fmt.Errorf("通知用户名称不能为空")
+	}
+	if len(u.Recipients) == 0 {
+		return fmt.Errorf("通知用户 %s 没有配置任何渠道地址", u.Name)
+	}
+	for _, event := range u.Events {
+		if err := validateEvent(event); err != nil {
+			return fmt.Errorf("通知用户 %s: %w", u.Name, err)
+		}
+	}
+	symbols := make([]string, 0, len(u.Symbols))
+	for _, symbol := range u.Symbols {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+	u.Symbols = symbols
+	return nil
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host     string
+	Port     int    // 默认25
+	Username string // 为空时不认证
+	Password string
+	From     string // 发件人地址
+}
+
+// smtpTimeout 连接和发送一封邮件的总超时（与Telegram请求超时一致）
+const smtpTimeout = 10 * time.Second
+
+// SMTPNotifier 通过SMTP发送邮件（服务器支持时使用STARTTLS）
+type SMTPNotifier struct {
+	config  SMTPConfig
+	addr    string
+	timeout time.Duration
+}
+
+// NewSMTPNotifier 创建SMTP通知渠道
+func NewSMTPNotifier(config SMTPConfig) (*SMTPNotifier, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP服务器地址不能为空")
+	}
+	if config.From == "" {
+		return nil, fmt.Errorf("SMTP发件人不能为空")
+	}
+	if config.Port == 0 {
+		config.Port = 25
+	}
+	return &SMTPNotifier{
+		config:  config,
+		addr:    net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		timeout: smtpTimeout,
+	}, nil
+}
+
+// Channel 渠道名称
+func (s *SMTPNotifier) Channel() string {
+	return ChannelSMTP
+}
+
+// Send 发送邮件到指定地址
+// 连接和整个SMTP会话共用一个超时（ctx的截止时间更早时以其为准），ctx取消时立即断开连接
+func (s *SMTPNotifier) Send(ctx context.Context, to string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("收件人地址无效: %q", to)
+	}
+	if err := s.sendMail(ctx, to, s.buildMessage(to, msg)); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// sendMail 按 smtp.SendMail 的流程发送邮件，但连接和读写都有超时
+func (s *SMTPNotifier) sendMail(ctx context.Context, to string, body []byte) error {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn, err := net.DialTimeout("tcp", s.addr, time.Until(deadline))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP服务器不支持认证")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.config.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage 构造UTF-8纯文本邮件
+func (s *SMTPNotifier) buildMessage(to string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.config.From + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpTestServer 在本地端口上监听的SMTP测试服务器，serve处理每个连接
+func smtpTestServer(t *testing.T, serve func(conn net.Conn)) *SMTPNotifier {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	s, err := NewSMTPNotifier(SMTPConfig{Host: host, Port: p, From: "alerts@example.com"})
+	if err != nil {
+		t.Fatalf("创建SMTP通知渠道失败: %v", err)
+	}
+	return s
+}
+
+func TestSMTPSend(t *testing.T) {
+	received := make(chan string, 1)
+	s := smtpTestServer(t, func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 test ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					body.WriteString(l)
+				}
+				received <- body.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	})
+
+	if err := s.Send(context.Background(), "trader@example.com", Message{Subject: "BTC告警", Body: "价格突破"}); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	body := <-received
+	if !strings.Contains(body, "To: trader@example.com\r\n") || !strings.Contains(body, "价格突破") {
+		t.Fatalf("邮件内容不正确: %q", body)
+	}
+}
+
+func TestSMTPSendTimeout(t *testing.T) {
+	// 接受连接但从不应答，发送应在超时后返回而不是一直阻塞
+	s := smtpTestServer(t, func(conn net.Conn) {
+		time.Sleep(2 * time.Second)
+		conn.Close()
+	})
+	s.timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err := s.Send(context.Background(), "trader@example.com", Message{Subject: "test"})
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("应返回超时错误: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("超时未生效，耗时 %v", elapsed)
+	}
+
+	// ctx取消时立即断开
+	s.timeout = smtpTimeout
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start = time.Now()
+	if err := s.Send(ctx, "trader@example.com", Message{Subject: "test"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("应返回context.Canceled: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("取消未生效，耗时 %v", elapsed)
+	}
+}
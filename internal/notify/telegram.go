@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTelegramAPIURL Telegram Bot API地址
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramNotifier 通过Telegram Bot API的sendMessage发送消息
+type TelegramNotifier struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// NewTelegramNotifier 创建Telegram通知渠道，apiURL为空时使用官方地址（可指向本地桩服务）
+func NewTelegramNotifier(apiURL, token string) (*TelegramNotifier, error) {
+	if token == "" {
+		return nil, fmt.Errorf("Telegram bot token不能为空")
+	}
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+	return &TelegramNotifier{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Channel 渠道名称
+func (t *TelegramNotifier) Channel() string {
+	return ChannelTelegram
+}
+
+// telegramResponse Bot API响应
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+// Send 发送消息到chat_id
+func (t *TelegramNotifier) Send(ctx context.Context, chatID string, msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     msg.Body,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化Telegram消息失败: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Telegram请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// 错误信息中的URL包含token，这里不返回原始错误
+		return fmt.Errorf("发送Telegram消息失败: %s", redactToken(err.Error(), t.token))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("读取Telegram响应失败: %w", err)
+	}
+	var result telegramResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("解析Telegram响应失败（HTTP %d）: %w", resp.StatusCode, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("Telegram返回错误 %d: %s", result.ErrorCode, result.Description)
+	}
+	return nil
+}
+
+// redactToken 隐藏字符串中的bot token
+func redactToken(s, token string) string {
+	return strings.ReplaceAll(s, token, "***")
+}
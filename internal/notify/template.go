@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/service"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// 通知事件类型（与webhook事件类型一致）
+const (
+	EventBarClose   = types.WebhookEventBarClose
+	EventZoneChange = types.WebhookEventZoneChange
+	EventAlert      = types.WebhookEventAlert
+)
+
+// validateEvent 校验事件类型
+func validateEvent(event string) error {
+	if _, ok := defaultTemplates[event]; !ok {
+		return fmt.Errorf("不支持的通知事件类型: %s（可选 bar_close、zone_change、alert）", event)
+	}
+	return nil
+}
+
+// defaultTemplates 默认消息模板（text/template语法），渲染结果的第一行作为邮件主题
+var defaultTemplates = map[string]string{
+	EventBarClose: `{{.Symbol}} {{.Interval}} K线收盘 {{.Time.UTC.Format "2006-01-02 15:04"}} UTC
+收盘价: {{printf "%.4f" .Price}}  布林分区: {{.BollZone}}  包络分区: {{.EnvZone}}
+{{range $k, $v := .CCI}}CCI({{$k}}): {{printf "%.2f" $v}}
+{{end}}{{range $k, $v := .RSI}}RSI({{$k}}): {{printf "%.2f" $v}}
+{{end}}5日平均波动: {{printf "%.4f" .Volatility}}`,
+
+	EventZoneChange: `{{.Symbol}} {{.Interval}} {{if eq .Zone.Indicator "bollinger"}}布林线{{else}}包络线{{end}}分区 {{.Zone.From}} → {{.Zone.To}}
+收盘价: {{printf "%.4f" .Price}}（{{.Time.UTC.Format "2006-01-02 15:04"}} UTC）
+{{range $k, $v := .CCI}}CCI({{$k}}): {{printf "%.2f" $v}}
+{{end}}{{range $k, $v := .RSI}}RSI({{$k}}): {{printf "%.2f" $v}}
+{{end}}`,
+
+	EventAlert: `🔔 {{.Alert.Message}}
+价格: {{printf "%.4f" .Price}}  布林分区: {{.BollZone}}  包络分区: {{.EnvZone}}
+{{range $k, $v := .CCI}}CCI({{$k}}): {{printf "%.2f" $v}}
+{{end}}{{range $k, $v := .RSI}}RSI({{$k}}): {{printf "%.2f" $v}}
+{{end}}5日平均波动: {{printf "%.4f" .Volatility}}`,
+}
+
+// TemplateData 消息模板可用的字段
+type TemplateData struct {
+	Event      string
+	Symbol     string
+	Interval   string
+	Time       time.Time // bar_close/zone_change 为收盘K线的开盘时间，alert 为触发时间
+	Price      float64   // bar_close/zone_change 为收盘价，alert 为当前价格
+	BollZone   int
+	EnvZone    int
+	CCI        map[string]float64 // key 为实例key，如 "48"
+	RSI        map[string]float64
+	MACD       map[string]service.MACDPoint
+	Volatility float64
+	Zone       *service.ZoneChange // 仅 zone_change
+	Alert      *types.AlertEvent   // 仅 alert
+}
+
+// NewTemplateData 由实时数据中第i根K线（索引0是正在形成的K线）构造模板数据
+func NewTemplateData(event string, data *service.RealtimeData, i int) TemplateData {
+	td := TemplateData{Event: event, Symbol: data.Symbol, Interval: data.Interval}
+	if i < 0 || i >= len(data.Klines) {
+		td.Price = data.Price
+		return td
+	}
+	bar := service.BuildBarSnapshot(data, i)
+	td.Time = bar.BarTime
+	td.Price = bar.Close
+	td.BollZone = bar.BollZone
+	td.EnvZone = bar.EnvZone
+	td.CCI = bar.CCI
+	td.RSI = bar.RSI
+	td.MACD = bar.MACD
+	td.Volatility = bar.Volatility
+	return td
+}
+
+// Templates 各事件类型的消息模板
+type Templates struct {
+	templates map[string]*template.Template
+}
+
+// NewTemplates 解析消息模板，overrides 按事件类型覆盖默认模板
+func NewTemplates(overrides map[string]string) (*Templates, error) {
+	for event := range overrides {
+		if err := validateEvent(event); err != nil {
+			return nil, fmt.Errorf("消息模板: %w", err)
+		}
+	}
+
+	t := &Templates{templates: make(map[string]*template.Template)}
+	for event, text := range defaultTemplates {
+		if override, ok := overrides[event]; ok && strings.TrimSpace(override) != "" {
+			text = override
+		}
+		tmpl, err := template.New(event).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 消息模板失败: %w", event, err)
+		}
+		t.templates[event] = tmpl
+	}
+	return t, nil
+}
+
+// Render 渲染消息，第一行作为标题
+func (t *Templates) Render(data TemplateData) (Message, error) {
+	tmpl, ok := t.templates[data.Event]
+	if !ok {
+		return Message{}, fmt.Errorf("没有 %s 的消息模板", data.Event)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("渲染 %s 消息失败: %w", data.Event, err)
+	}
+	body := strings.TrimSpace(buf.String())
+	subject, _, _ := strings.Cut(body, "\n")
+	return Message{Subject: subject, Body: body}, nil
+}
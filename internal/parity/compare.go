@@ -0,0 +1,197 @@
+package parity
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// DefaultTolerance 默认绝对误差容差
+const DefaultTolerance = 1e-6
+
+// maxBarErrors 每列最多记录的超差K线数量
+const maxBarErrors = 20
+
+// Options 对比选项
+type Options struct {
+	Profile    string                // 参数集名称（仅用于报告）
+	Config     types.IndicatorConfig // 指标参数（周期为K线根数）
+	Tolerance  float64               // 默认绝对误差容差
+	Tolerances map[string]float64    // 按列覆盖容差
+	Skip       int                   // 在各列预热期之外额外跳过的最早K线数量
+}
+
+// BarError 单根K线的误差
+type BarError struct {
+	Time     time.Time `json:"time"`
+	Expected float64   `json:"expected"`
+	Actual   float64   `json:"actual"`
+	AbsError float64   `json:"abs_error"`
+}
+
+// ColumnResult 单个缓冲区列的对比结果
+type ColumnResult struct {
+	Column       string     `json:"column"`
+	Compared     int        `json:"compared"`
+	MaxAbsError  float64    `json:"max_abs_error"`
+	MeanAbsError float64    `json:"mean_abs_error"`
+	Tolerance    float64    `json:"tolerance"`
+	Failed       int        `json:"failed"`
+	Worst        *BarError  `json:"worst,omitempty"`
+	Failures     []BarError `json:"failures,omitempty"` // 最早的若干根超差K线
+	Pass         bool       `json:"pass"`
+	Note         string     `json:"note,omitempty"`
+}
+
+// Report 一份导出数据的对比报告
+type Report struct {
+	Name    string         `json:"name"`
+	Profile string         `json:"profile"`
+	Bars    int            `json:"bars"`
+	Columns []ColumnResult `json:"columns"`
+	Pass    bool           `json:"pass"`
+}
+
+// series Go实现计算出的一列及其预热长度
+type series struct {
+	values []float64 // 从旧到新
+	warmUp int       // 最早的warmUp根K线不参与对比
+}
+
+// Compare 使用给定参数计算Go指标，并与导出的缓冲区逐根对比
+func Compare(ds *Dataset, opts Options) *Report {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+
+	report := &Report{Name: ds.Name, Profile: opts.Profile, Bars: ds.Len(), Pass: true}
+	computed := compute(ds, opts.Config)
+
+	for _, name := range ds.Columns {
+		tolerance := opts.Tolerance
+		if t, ok := opts.Tolerances[name]; ok {
+			tolerance = t
+		}
+		result := ColumnResult{Column: name, Tolerance: tolerance}
+
+		actual, ok := computed[name]
+		if !ok {
+			result.Note = "未知列或与参数集不匹配，已跳过"
+			result.Pass = true
+			report.Columns = append(report.Columns, result)
+			continue
+		}
+
+		expected := ds.Buffers[name]
+		start := actual.warmUp + opts.Skip
+		sum := 0.0
+		for i := start; i < ds.Len(); i++ {
+			if math.IsNaN(expected[i]) {
+				continue
+			}
+			absErr := math.Abs(expected[i] - actual.values[i])
+			bar := BarError{Time: ds.Times[i], Expected: expected[i], Actual: actual.values[i], AbsError: absErr}
+
+			result.Compared++
+			sum += absErr
+			if result.Worst == nil || absErr > result.MaxAbsError {
+				result.MaxAbsError = absErr
+				worst := bar
+				result.Worst = &worst
+			}
+			if absErr > tolerance {
+				result.Failed++
+				if len(result.Failures) < maxBarErrors {
+					result.Failures = append(result.Failures, bar)
+				}
+			}
+		}
+
+		if result.Compared == 0 {
+			result.Note = "预热期之后没有可对比的数据"
+			result.Pass = false
+		} else {
+			result.MeanAbsError = sum / float64(result.Compared)
+			result.Pass = result.Failed == 0
+		}
+		if !result.Pass {
+			report.Pass = false
+		}
+		report.Columns = append(report.Columns, result)
+	}
+	return report
+}
+
+// compute 使用Go批量函数计算所有可对比的列（周期不做缩放）
+// 列名：price、cci_<周期>、rsi_<周期>、macd_<快>_<慢>_{hist,line,signal}、boll_{upper,middle,lower}、env_{upper,middle,lower}
+func compute(ds *Dataset, config types.IndicatorConfig) map[string]series {
+	// Go指标数组索引0为最新数据
+	price := reverse(indicators.CalculateHLCC(reverse(ds.High), reverse(ds.Low), reverse(ds.Close)))
+	out := map[string]series{"price": {values: price}}
+	newestFirst := reverse(price)
+
+	add := func(name string, values []float64, warmUp int) {
+		if values == nil {
+			return // 数据不足
+		}
+		out[name] = series{values: reverse(values), warmUp: warmUp}
+	}
+	band := map[string]bool{}
+	for _, cfg := range config.Indicators {
+		inst, err := indicators.ResolveInstance(cfg)
+		if err != nil {
+			log.Printf("指标 %s 配置无效，已跳过: %v", cfg.Type, err)
+			continue
+		}
+		p := inst.Params
+		switch kind := inst.Indicator.Name(); kind {
+		case indicators.NameCCI:
+			period := p.Int("period")
+			add(fmt.Sprintf("cci_%d", period), indicators.CalculateCCIWithMA(newestFirst, period, p.MA()), period)
+		case indicators.NameRSI:
+			period := p.Int("period")
+			add(fmt.Sprintf("rsi_%d", period), indicators.CalculateRSIWithMA(newestFirst, period, p.MA()), period+1)
+		case indicators.NameMACD:
+			fast, slow, signalPeriod := p.Int("fast"), p.Int("slow"), p.Int("signal")
+			line, signal, hist := indicators.CalculateMACDWithMA(newestFirst, fast, slow, signalPeriod, p.MA())
+			prefix := fmt.Sprintf("macd_%d_%d", fast, slow)
+			add(prefix+"_hist", hist, slow)
+			add(prefix+"_line", line, slow+2)
+			add(prefix+"_signal", signal, slow+2+signalPeriod)
+		case indicators.NameBollinger, indicators.NameEnvelope:
+			// 导出文件中只有一组布林线/包络线，使用配置中的第一个实例
+			if band[kind] {
+				continue
+			}
+			band[kind] = true
+			period, deviation := p.Int("period"), p.Float("deviation")
+			prefix := "boll"
+			upper, middle, lower := indicators.CalculateBollingerWithMA(newestFirst, period, deviation, p.MA())
+			if kind == indicators.NameEnvelope {
+				prefix = "env"
+				upper, middle, lower = indicators.CalculateEnvelopeWithMA(newestFirst, period, deviation, p.MA())
+			}
+			add(prefix+"_upper", upper, period)
+			add(prefix+"_middle", middle, period)
+			add(prefix+"_lower", lower, period)
+		}
+	}
+
+	return out
+}
+
+// reverse 返回顺序颠倒的副本
+func reverse(values []float64) []float64 {
+	if values == nil {
+		return nil
+	}
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[len(values)-1-i] = v
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package parity
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/backtest"
+)
+
+// emptyValueThreshold MT5的EMPTY_VALUE为DBL_MAX，超过该阈值的值视为缺失
+const emptyValueThreshold = 1e300
+
+// ignoredColumns 非指标缓冲区的列（MT5 CopyRates 导出的其他字段）
+var ignoredColumns = map[string]bool{
+	"volume":      true,
+	"tick_volume": true,
+	"real_volume": true,
+	"spread":      true,
+}
+
+// Dataset 一份MT5导出数据：OHLC和指标缓冲区，均按时间从旧到新排列
+type Dataset struct {
+	Name    string
+	Times   []time.Time
+	Open    []float64
+	High    []float64
+	Low     []float64
+	Close   []float64
+	Buffers map[string][]float64 // 列名 -> 导出值，缺失为NaN
+	Columns []string             // 缓冲区列在文件中的顺序
+}
+
+// Len K线数量
+func (d *Dataset) Len() int {
+	return len(d.Times)
+}
+
+// LoadCSV 从CSV文件加载MT5导出数据
+// 首行必须为表头，包含 time,open,high,low,close，其余列为指标缓冲区（如 cci_48、macd_48_72_hist）
+func LoadCSV(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开导出文件失败: %w", err)
+	}
+	defer f.Close()
+	return ReadCSV(f, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+}
+
+// ReadCSV 从CSV数据读取MT5导出数据，格式同 LoadCSV
+func ReadCSV(r io.Reader, name string) (*Dataset, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取表头失败: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		header[i] = h
+		index[h] = i
+	}
+	for _, required := range []string{"time", "open", "high", "low", "close"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("表头缺少列: %s", required)
+		}
+	}
+
+	ds := &Dataset{Name: name, Buffers: make(map[string][]float64)}
+	for _, h := range header {
+		switch h {
+		case "time", "open", "high", "low", "close", "":
+			continue
+		}
+		if ignoredColumns[h] {
+			continue
+		}
+		if _, dup := ds.Buffers[h]; dup {
+			return nil, fmt.Errorf("表头列重复: %s", h)
+		}
+		ds.Buffers[h] = nil
+		ds.Columns = append(ds.Columns, h)
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取CSV第%d行失败: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) < len(header) {
+			return nil, fmt.Errorf("CSV第%d行列数不足: %d", line, len(record))
+		}
+
+		ts, err := backtest.ParseCSVTime(strings.TrimSpace(record[index["time"]]))
+		if err != nil {
+			return nil, fmt.Errorf("CSV第%d行: %w", line, err)
+		}
+		ds.Times = append(ds.Times, ts)
+
+		for _, col := range []struct {
+			name string
+			dst  *[]float64
+		}{{"open", &ds.Open}, {"high", &ds.High}, {"low", &ds.Low}, {"close", &ds.Close}} {
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[index[col.name]]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("CSV第%d行%s无效: %w", line, col.name, err)
+			}
+			*col.dst = append(*col.dst, v)
+		}
+
+		for _, name := range ds.Columns {
+			v, err := parseBufferValue(record[index[name]])
+			if err != nil {
+				return nil, fmt.Errorf("CSV第%d行%s无效: %w", line, name, err)
+			}
+			ds.Buffers[name] = append(ds.Buffers[name], v)
+		}
+	}
+
+	if ds.Len() == 0 {
+		return nil, fmt.Errorf("导出文件没有数据行")
+	}
+	ds.sortByTime()
+	return ds, nil
+}
+
+// parseBufferValue 解析缓冲区值，空值、EMPTY_VALUE 和 NaN 返回NaN
+func parseBufferValue(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "EMPTY_VALUE") {
+		return math.NaN(), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) >= emptyValueThreshold {
+		return math.NaN(), nil
+	}
+	return v, nil
+}
+
+// sortByTime 将所有列按时间从旧到新排序（MT5脚本可能按时间倒序导出）
+func (d *Dataset) sortByTime() {
+	if sort.SliceIsSorted(d.Times, func(i, j int) bool { return d.Times[i].Before(d.Times[j]) }) {
+		return
+	}
+	order := make([]int, d.Len())
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return d.Times[order[i]].Before(d.Times[order[j]]) })
+
+	times := make([]time.Time, len(order))
+	for i, src := range order {
+		times[i] = d.Times[src]
+	}
+	d.Times = times
+
+	permute := func(values []float64) []float64 {
+		out := make([]float64, len(order))
+		for i, src := range order {
+			out[i] = values[src]
+		}
+		return out
+	}
+	d.Open, d.High, d.Low, d.Close = permute(d.Open), permute(d.High), permute(d.Low), permute(d.Close)
+	for name, values := range d.Buffers {
+		d.Buffers[name] = permute(values)
+	}
+}
@@ -0,0 +1,111 @@
+package parity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// profiles 预置参数集，周期均为K线根数（与MT5图表上的输入参数一致，不做周期缩放）
+var profiles = map[string]func() types.IndicatorConfig{
+	// default 与 CCI指标/MACD指标/RSI指标.mq5 的默认输入一致
+	"default": types.GetDefaultConfig,
+	// mq5_ea 与 组合指标_可视化.mq5（EA参数）一致
+	"mq5_ea": func() types.IndicatorConfig {
+		period := func(typ string, p float64) types.IndicatorInstance {
+			return types.IndicatorInstance{Type: typ, Params: map[string]float64{"period": p}}
+		}
+		macd := func(fast, slow float64) types.IndicatorInstance {
+			return types.IndicatorInstance{Type: "macd", Params: map[string]float64{"fast": fast, "slow": slow, "signal": 2}}
+		}
+		return types.IndicatorConfig{Indicators: []types.IndicatorInstance{
+			period("cci", 24), period("cci", 48), period("cci", 120),
+			macd(24, 72), macd(72, 120),
+			period("rsi", 48), period("rsi", 72),
+			{Type: "bollinger", Params: map[string]float64{"period": 24, "deviation": 2.0}},
+			{Type: "envelope", Params: map[string]float64{"period": 24, "deviation": 2.28}},
+		}}
+	},
+}
+
+// Profile 获取预置参数集
+func Profile(name string) (types.IndicatorConfig, error) {
+	fn, ok := profiles[name]
+	if !ok {
+		return types.IndicatorConfig{}, fmt.Errorf("未知参数集: %s（可用: %v）", name, ProfileNames())
+	}
+	return fn(), nil
+}
+
+// ProfileNames 返回所有预置参数集名称
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Spec 对比用例的附加设置，放在导出文件旁的同名 .json 文件中
+type Spec struct {
+	Profile    string                 `json:"profile,omitempty"`    // 预置参数集
+	Config     *types.IndicatorConfig `json:"config,omitempty"`     // 自定义参数，优先于 profile
+	Tolerance  *float64               `json:"tolerance,omitempty"`  // 默认容差
+	Tolerances map[string]float64     `json:"tolerances,omitempty"` // 按列覆盖容差
+	Skip       *int                   `json:"skip,omitempty"`       // 额外跳过的最早K线数量
+}
+
+// LoadSpec 加载附加设置文件，文件不存在时返回nil
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用例设置失败: %w", err)
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("解析用例设置失败: %w", err)
+	}
+	return &spec, nil
+}
+
+// Apply 将附加设置合并到对比选项上
+func (s *Spec) Apply(opts Options) (Options, error) {
+	if s == nil {
+		return opts, nil
+	}
+	if s.Config != nil {
+		opts.Config = *s.Config
+		opts.Profile = "custom"
+	} else if s.Profile != "" {
+		config, err := Profile(s.Profile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Config = config
+		opts.Profile = s.Profile
+	}
+	if s.Tolerance != nil {
+		opts.Tolerance = *s.Tolerance
+	}
+	if len(s.Tolerances) > 0 {
+		merged := make(map[string]float64, len(opts.Tolerances)+len(s.Tolerances))
+		for k, v := range opts.Tolerances {
+			merged[k] = v
+		}
+		for k, v := range s.Tolerances {
+			merged[k] = v
+		}
+		opts.Tolerances = merged
+	}
+	if s.Skip != nil {
+		opts.Skip = *s.Skip
+	}
+	return opts, nil
+}
@@ -0,0 +1,254 @@
+#!/usr/bin/env python3
+"""生成Python参考实现的对比数据（不是MT5导出）。
+
+K线为随机生成的合成数据，指标值由本脚本计算，而不是由MT5运行MQ5指标得到。
+本脚本逐行移植 mq5指标/ 下 CCI指标、MACD指标、RSI指标、组合指标_可视化 的首次全量计算路径
+（prev_calculated == 0），只能验证Go实现与这份对MQ5源码的独立移植一致；
+移植本身与MT5的差异（终端行为、增量计算路径等）无法由这些数据发现。
+输出格式与 导出指标数据.mq5 一致：
+OHLC按品种精度输出，缓冲区保留10位小数，未计算的值输出为空（EMPTY_VALUE）。
+数组均为时间序列顺序（索引0是最新K线），与MQL5中 ArraySetAsSeries(true) 一致。
+
+用法: python3 gen_python_reference.py （在 testdata/python_ref 目录下生成全部用例）
+"""
+import datetime
+import math
+import random
+
+EMPTY = None
+
+
+def wma(price, rates_total, start_pos, period, buf):
+    """CCI/RSI/组合指标 中的 CalculateWMA"""
+    if rates_total < period:
+        return
+    den = period * (period + 1.0) / 2.0
+    max_i = rates_total - period
+    calc_start = min(start_pos, max_i)
+    for i in range(calc_start, -1, -1):
+        s = 0.0
+        for j in range(i, i + period):
+            s += price[j] * (period - (j - i))
+        buf[i] = s / den
+    if calc_start < max_i:
+        for i in range(max_i + 1, rates_total):
+            buf[i] = buf[calc_start]
+
+
+def wma_macd(price, rates_total, start_pos, period, buf):
+    """MACD指标 中的 CalculateWMA（数据不足时使用可用数据）"""
+    if rates_total < period:
+        return
+    den = period * (period + 1.0) / 2.0
+    max_i = rates_total - period
+    calc_start = min(start_pos, max_i) if max_i >= 0 else 0
+    if start_pos == 0 and max_i < 0:
+        n = min(period, rates_total)
+        s = sum(price[j] * (n - j) for j in range(n))
+        buf[0] = s / (n * (n + 1.0) / 2.0)
+    else:
+        for i in range(calc_start, -1, -1):
+            s = 0.0
+            for j in range(i, i + period):
+                s += price[j] * (period - (j - i))
+            buf[i] = s / den
+    if start_pos > max_i >= 0 and calc_start == max_i:
+        for i in range(max_i + 1, rates_total):
+            buf[i] = buf[calc_start]
+
+
+def cci(price, rates_total, start_pos, period):
+    out = [EMPTY] * rates_total
+    if rates_total < period:
+        return out
+    ma = [0.0] * rates_total
+    wma(price, rates_total, start_pos, period, ma)
+    mad = [0.0] * rates_total
+    max_i = rates_total - period
+    calc_start = min(start_pos, max_i)
+    for i in range(calc_start, -1, -1):
+        mad[i] = sum(abs(price[j] - ma[i]) for j in range(i, i + period)) * (1.0 / period)
+    if calc_start < max_i and start_pos > max_i:
+        for i in range(max_i + 1, rates_total):
+            mad[i] = mad[calc_start]
+    for i in range(start_pos, -1, -1):
+        out[i] = 0.0 if mad[i] == 0 else (price[i] - ma[i]) / (0.015 * mad[i])
+    return out
+
+
+def macd(price, rates_total, start_pos, fast, slow, signal):
+    hist = [EMPTY] * rates_total
+    line = [0.0] * rates_total
+    fast_wma = [0.0] * rates_total
+    slow_wma = [0.0] * rates_total
+    wma_start = start_pos
+    if start_pos == 0:
+        max_signal_i = rates_total - max(fast, slow)
+        wma_start = min(signal + 2, max_signal_i) if max_signal_i >= 0 else 0
+    wma_macd(price, rates_total, wma_start, fast, fast_wma)
+    wma_macd(price, rates_total, wma_start, slow, slow_wma)
+    diff = [0.0] * rates_total
+    for i in range(max(start_pos, signal + 2), -1, -1):
+        diff[i] = fast_wma[i] - slow_wma[i]
+        if i <= start_pos:
+            hist[i] = diff[i]
+    for i in range(max(start_pos, signal), -1, -1):
+        if i + 2 < rates_total:
+            line[i] = (diff[i + 1] + diff[i + 2]) * 0.5
+        elif i + 1 < rates_total:
+            line[i] = diff[i + 1]
+        else:
+            line[i] = diff[i]
+    # 导出脚本只导出柱状图和MACD线，信号线不参与对比
+    return hist, [v if i <= max(start_pos, signal) else EMPTY for i, v in enumerate(line)]
+
+
+def rsi(price, rates_total, start_pos, period):
+    out = [EMPTY] * rates_total
+    if rates_total < period + 1:
+        return out
+    gains = [0.0] * rates_total
+    losses = [0.0] * rates_total
+    calc_end = min(99, rates_total - 2) if start_pos == 0 else min(start_pos + period - 1, rates_total - 2)
+    for i in range(calc_end, -1, -1):
+        change = price[i] - price[i + 1]
+        gains[i], losses[i] = (change, 0.0) if change > 0 else (0.0, -change)
+    avg_gain = [0.0] * rates_total
+    avg_loss = [0.0] * rates_total
+    wma(gains, rates_total, start_pos, period, avg_gain)
+    wma(losses, rates_total, start_pos, period, avg_loss)
+    for i in range(start_pos, -1, -1):
+        rs = 100.0 if avg_loss[i] == 0 else avg_gain[i] / avg_loss[i]
+        out[i] = 100.0 - 100.0 / (1.0 + rs)
+    return out
+
+
+def std_window(price, rates_total, calc_start, period):
+    """布林线/包络线中的滑动标准差（总体标准差，以窗口SMA为均值）"""
+    std = [0.0] * rates_total
+    max_i = rates_total - period
+    if calc_start <= max_i:
+        window_sum = sum(price[calc_start:calc_start + period])
+        for i in range(calc_start, -1, -1):
+            if i != calc_start:
+                window_sum = window_sum - price[i + period] + price[i]
+            sma = window_sum / period
+            std[i] = math.sqrt(sum((price[j] - sma) ** 2 for j in range(i, i + period)) / period)
+    if calc_start < max_i:
+        for i in range(max_i + 1, rates_total):
+            std[i] = std[calc_start]
+    return std
+
+
+def bollinger(price, rates_total, start_pos, period, deviation):
+    upper, middle, lower = [EMPTY] * rates_total, [0.0] * rates_total, [EMPTY] * rates_total
+    if rates_total < period:
+        return upper, [EMPTY] * rates_total, lower
+    max_i = rates_total - period
+    calc_start = min(start_pos, max_i)
+    wma(price, rates_total, calc_start, period, middle)
+    std = std_window(price, rates_total, calc_start, period)
+    for i in range(start_pos, -1, -1):
+        idx = max_i if i > max_i else i
+        upper[i] = middle[idx] + deviation * std[idx]
+        lower[i] = middle[idx] - deviation * std[idx]
+    return upper, [v if i <= start_pos else EMPTY for i, v in enumerate(middle)], lower
+
+
+def envelope(price, rates_total, start_pos, period, deviation_percent):
+    upper, middle, lower = [EMPTY] * rates_total, [0.0] * rates_total, [EMPTY] * rates_total
+    if rates_total < period:
+        return upper, [EMPTY] * rates_total, lower
+    max_i = rates_total - period
+    calc_start = min(start_pos, max_i)
+    wma(price, rates_total, calc_start, period, middle)
+    for i in range(start_pos, -1, -1):
+        idx = max_i if i > max_i else i
+        upper[i] = middle[idx] * (1 + deviation_percent / 100.0)
+        lower[i] = middle[idx] * (1 - deviation_percent / 100.0)
+    return upper, [v if i <= start_pos else EMPTY for i, v in enumerate(middle)], lower
+
+
+def generate_rates(seed, bars, start, step, price, digits, flat_every=0):
+    """生成确定性的K线（从旧到新），flat_every>0 时每隔若干根插入一段价格不变的K线"""
+    rng = random.Random(seed)
+    rates = []
+    t = start
+    for i in range(bars):
+        if flat_every and i % flat_every < 3:
+            o = h = l = c = price
+        else:
+            o = price
+            c = o * (1 + rng.gauss(0, 0.004) + 0.0006 * math.sin(i / 37.0))
+            h = max(o, c) * (1 + abs(rng.gauss(0, 0.0015)))
+            l = min(o, c) * (1 - abs(rng.gauss(0, 0.0015)))
+        o, h, l, c = (round(v, digits) for v in (o, h, l, c))
+        rates.append((t, o, h, l, c))
+        price = c
+        t += step
+    return rates
+
+
+def export(path, rates, digits, p):
+    # MQL5 中 CopyRates 为从旧到新，指标数组为时间序列（索引0是最新）
+    n = len(rates)
+    series = list(reversed(rates))
+    price = [(r[2] + r[3] + r[4]) * (1.0 / 3.0) for r in series]
+
+    cci_start = n - max(p["cci"])
+    ccis = [cci(price, n, cci_start, period) for period in p["cci"]]
+    macd_start = n - max(p["macd"][1][1], 10)
+    macds = [macd(price, n, macd_start, *cfg) for cfg in p["macd"]]
+    rsi_start = n - max(p["rsi"])
+    rsis = [rsi(price, n, rsi_start, period) for period in p["rsi"]]
+    band_start = n - max(p["cci"][2], p["macd"][1][1], p["rsi"][1], p["boll"][0])
+    boll = bollinger(price, n, band_start, *p["boll"])
+    env = envelope(price, n, band_start, *p["env"])
+
+    def value(v):
+        return "" if v is None else "%.10f" % v
+
+    m1 = "macd_%d_%d" % tuple(p["macd"][0][:2])
+    m2 = "macd_%d_%d" % tuple(p["macd"][1][:2])
+    header = ("time,open,high,low,close," + ",".join("cci_%d" % c for c in p["cci"]) + "," +
+              "%s_hist,%s_hist,%s_line,%s_line," % (m1, m2, m1, m2) +
+              ",".join("rsi_%d" % r for r in p["rsi"]) + "," +
+              "boll_upper,boll_middle,boll_lower,env_upper,env_middle,env_lower\r\n")
+    fmt = "%%.%df" % digits
+    with open(path, "w", newline="") as f:
+        f.write(header)
+        for k, r in enumerate(rates):
+            i = n - 1 - k
+            cols = [r[0].strftime("%Y.%m.%d %H:%M:%S")] + [fmt % v for v in r[1:]]
+            cols += [value(c[i]) for c in ccis]
+            cols += [value(macds[0][0][i]), value(macds[1][0][i]), value(macds[0][1][i]), value(macds[1][1][i])]
+            cols += [value(s[i]) for s in rsis]
+            cols += [value(b[i]) for b in boll] + [value(e[i]) for e in env]
+            f.write(",".join(cols) + "\r\n")
+
+
+# mq5_ea 参数集（导出指标数据.mq5 的默认输入）
+MQ5_EA = {
+    "cci": [24, 48, 120],
+    "macd": [(24, 72, 2), (72, 120, 2)],
+    "rsi": [48, 72],
+    "boll": (24, 2.0),
+    "env": (24, 2.28),
+}
+
+# 修改过输入参数的用例（对应 synthetic_M15.json 中的 config）
+CUSTOM = {
+    "cci": [14, 20, 50],
+    "macd": [(12, 26, 2), (26, 50, 2)],
+    "rsi": [14, 21],
+    "boll": (20, 2.5),
+    "env": (14, 0.5),
+}
+
+if __name__ == "__main__":
+    export("synthetic_H1.csv",
+           generate_rates(11, 600, datetime.datetime(2024, 3, 1), datetime.timedelta(hours=1), 61234.5, 2),
+           2, MQ5_EA)
+    export("synthetic_M15.csv",
+           generate_rates(29, 400, datetime.datetime(2024, 5, 6, 8), datetime.timedelta(minutes=15), 3050.25, 2, flat_every=97),
+           2, CUSTOM)
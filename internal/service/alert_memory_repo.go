@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// maxMemoryAlertEvents 内存告警仓库保留的最大历史数量
+const maxMemoryAlertEvents = 1000
+
+// memoryAlertRepository 内存告警仓库（MySQL不可用时使用，重启后丢失）
+type memoryAlertRepository struct {
+	mu          sync.Mutex
+	rules       map[int64]types.AlertRule
+	events      []types.AlertEvent
+	nextRuleID  int64
+	nextEventID int64
+}
+
+// newMemoryAlertRepository 创建内存告警仓库
+func newMemoryAlertRepository() *memoryAlertRepository {
+	return &memoryAlertRepository{rules: make(map[int64]types.AlertRule)}
+}
+
+func (m *memoryAlertRepository) ListRules(ctx context.Context) ([]types.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rules := make([]types.AlertRule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	sortAlertRules(rules)
+	return rules, nil
+}
+
+func (m *memoryAlertRepository) SaveRule(ctx context.Context, rule *types.AlertRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rule.ID == 0 {
+		m.nextRuleID++
+		rule.ID = m.nextRuleID
+	} else if _, ok := m.rules[rule.ID]; !ok {
+		return fmt.Errorf("告警规则 %d 不存在", rule.ID)
+	}
+	m.rules[rule.ID] = *rule
+	return nil
+}
+
+func (m *memoryAlertRepository) DeleteRule(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.rules[id]; !ok {
+		return fmt.Errorf("告警规则 %d 不存在", id)
+	}
+	delete(m.rules, id)
+	return nil
+}
+
+func (m *memoryAlertRepository) SaveEvent(ctx context.Context, event *types.AlertEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextEventID++
+	event.ID = m.nextEventID
+	m.events = append(m.events, *event)
+	if len(m.events) > maxMemoryAlertEvents {
+		m.events = m.events[len(m.events)-maxMemoryAlertEvents:]
+	}
+	return nil
+}
+
+func (m *memoryAlertRepository) ListEvents(ctx context.Context, symbol types.Symbol, limit int) ([]types.AlertEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := []types.AlertEvent{}
+	for i := len(m.events) - 1; i >= 0 && len(events) < limit; i-- {
+		if symbol == "" || m.events[i].Symbol == string(symbol) {
+			events = append(events, m.events[i])
+		}
+	}
+	return events, nil
+}
+
+// sortAlertRules 按ID排序告警规则
+func sortAlertRules(rules []types.AlertRule) {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+}
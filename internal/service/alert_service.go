@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// AlertRepository 告警仓库接口
+type AlertRepository interface {
+	ListRules(ctx context.Context) ([]types.AlertRule, error)
+	SaveRule(ctx context.Context, rule *types.AlertRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	SaveEvent(ctx context.Context, event *types.AlertEvent) error
+	ListEvents(ctx context.Context, symbol types.Symbol, limit int) ([]types.AlertEvent, error)
+}
+
+// alertRuleState 规则的运行状态（用于去重和冷却）
+type alertRuleState struct {
+	active      bool      // 上次评估时条件是否满足（只在条件由不满足变为满足时触发）
+	lastBarTime time.Time // 上次触发所在K线
+	lastFiredAt time.Time // 上次触发时间
+}
+
+// alertWatcher 告警服务对一条实时流的订阅
+type alertWatcher struct {
+	cancel context.CancelFunc
+}
+
+// AlertService 告警服务
+// 为所有启用规则涉及的(symbol, interval)订阅实时流，每次重算后评估规则，
+// 触发的告警写入历史并推送给订阅者（WebSocket）
+type AlertService struct {
+	ctx         context.Context
+	hub         *RealtimeHub
+	repo        AlertRepository
+	rules       map[int64]types.AlertRule
+	states      map[int64]*alertRuleState
+	watchers    map[StreamKey]*alertWatcher
+	mu          sync.Mutex
+	subscribers map[chan *types.AlertEvent]bool
+	subMu       sync.RWMutex
+	now         func() time.Time
+}
+
+// NewAlertService 创建告警服务，repo为nil时规则和历史只保存在内存中
+func NewAlertService(hub *RealtimeHub, repo AlertRepository) *AlertService {
+	if repo == nil {
+		repo = newMemoryAlertRepository()
+	}
+	return &AlertService{
+		hub:         hub,
+		repo:        repo,
+		rules:       make(map[int64]types.AlertRule),
+		states:      make(map[int64]*alertRuleState),
+		watchers:    make(map[StreamKey]*alertWatcher),
+		subscribers: make(map[chan *types.AlertEvent]bool),
+		now:         time.Now,
+	}
+}
+
+// Start 加载规则并订阅相关实时流
+func (s *AlertService) Start(ctx context.Context) error {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return fmt.Errorf("加载告警规则失败: %w", err)
+	}
+
+	s.mu.Lock()
+	s.ctx = ctx
+	for _, rule := range rules {
+		s.rules[rule.ID] = rule
+	}
+	s.syncWatchersLocked()
+	s.mu.Unlock()
+
+	log.Printf("告警服务已启动，共 %d 条规则", len(rules))
+	return nil
+}
+
+// ListRules 获取告警规则，symbol为空时返回全部
+func (s *AlertService) ListRules(symbol types.Symbol) []types.AlertRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := []types.AlertRule{}
+	for _, rule := range s.rules {
+		if symbol == "" || rule.Symbol == string(symbol) {
+			rules = append(rules, rule)
+		}
+	}
+	sortAlertRules(rules)
+	return rules
+}
+
+// SaveRule 新增或更新告警规则
+func (s *AlertService) SaveRule(ctx context.Context, rule types.AlertRule) (types.AlertRule, error) {
+	rule.Normalize()
+	if err := rule.Validate(); err != nil {
+		return rule, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.ID != 0 {
+		existing, ok := s.rules[rule.ID]
+		if !ok {
+			return rule, fmt.Errorf("告警规则 %d 不存在", rule.ID)
+		}
+		rule.CreatedAt = existing.CreatedAt
+	} else {
+		rule.CreatedAt = time.Now()
+	}
+	rule.UpdatedAt = time.Now()
+
+	if err := s.repo.SaveRule(ctx, &rule); err != nil {
+		return rule, err
+	}
+
+	s.rules[rule.ID] = rule
+	// 规则变化后重新开始去重
+	delete(s.states, rule.ID)
+	s.syncWatchersLocked()
+	return rule, nil
+}
+
+// DeleteRule 删除告警规则
+func (s *AlertService) DeleteRule(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rules[id]; !ok {
+		return fmt.Errorf("告警规则 %d 不存在", id)
+	}
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+
+	delete(s.rules, id)
+	delete(s.states, id)
+	s.syncWatchersLocked()
+	return nil
+}
+
+// History 获取告警历史
+func (s *AlertService) History(ctx context.Context, symbol types.Symbol, limit int) ([]types.AlertEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return s.repo.ListEvents(ctx, symbol, limit)
+}
+
+// Subscribe 订阅告警事件
+func (s *AlertService) Subscribe() <-chan *types.AlertEvent {
+	ch := make(chan *types.AlertEvent, 32)
+	s.subMu.Lock()
+	s.subscribers[ch] = true
+	s.subMu.Unlock()
+	return ch
+}
+
+// Unsubscribe 取消订阅告警事件
+func (s *AlertService) Unsubscribe(ch <-chan *types.AlertEvent) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for sub := range s.subscribers {
+		if (<-chan *types.AlertEvent)(sub) == ch {
+			delete(s.subscribers, sub)
+			close(sub)
+			return
+		}
+	}
+}
+
+// syncWatchersLocked 根据启用的规则调整实时流订阅（调用方需持有s.mu）
+func (s *AlertService) syncWatchersLocked() {
+	if s.ctx == nil {
+		return
+	}
+
+	wanted := make(map[StreamKey]bool)
+	for _, rule := range s.rules {
+		if rule.Enabled {
+			wanted[StreamKey{Symbol: types.Symbol(rule.Symbol), Interval: rule.Interval}] = true
+		}
+	}
+
+	for key, watcher := range s.watchers {
+		if !wanted[key] {
+			watcher.cancel()
+			delete(s.watchers, key)
+		}
+	}
+
+	for key := range wanted {
+		if _, ok := s.watchers[key]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		s.watchers[key] = &alertWatcher{cancel: cancel}
+		// 告警只需评估最新数据，积压时合并为最新一条
+		go s.hub.Watch(ctx, key, PolicyCoalesce, "告警服务", s.Evaluate)
+	}
+}
+
+// Evaluate 对一次实时数据评估所有匹配的规则
+func (s *AlertService) Evaluate(data *RealtimeData) {
+	if data == nil || len(data.Klines) == 0 {
+		return
+	}
+
+	var fired []*types.AlertEvent
+
+	s.mu.Lock()
+	now := s.now()
+	for id, rule := range s.rules {
+		if !rule.Enabled || rule.Symbol != data.Symbol || rule.Interval != data.Interval {
+			continue
+		}
+
+		value, barIdx, matched := evaluateAlertRule(data, rule)
+
+		state := s.states[id]
+		if state == nil {
+			state = &alertRuleState{}
+			s.states[id] = state
+		}
+
+		// 只在条件由不满足变为满足时触发
+		wasActive := state.active
+		state.active = matched
+		if !matched || wasActive {
+			continue
+		}
+
+		if barIdx >= len(data.Klines) {
+			continue
+		}
+
+		// 同一根K线只触发一次
+		barTime := data.Klines[barIdx].Time
+		if barTime.Equal(state.lastBarTime) {
+			continue
+		}
+		// 冷却时间内不重复触发
+		if rule.Cooldown > 0 && now.Sub(state.lastFiredAt) < time.Duration(rule.Cooldown)*time.Second {
+			continue
+		}
+
+		state.lastBarTime = barTime
+		state.lastFiredAt = now
+		fired = append(fired, &types.AlertEvent{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			Symbol:      rule.Symbol,
+			Interval:    rule.Interval,
+			Source:      rule.Source,
+			Key:         rule.Key,
+			Condition:   rule.Condition,
+			Threshold:   rule.Threshold,
+			Value:       value,
+			Price:       data.Price,
+			BarTime:     barTime,
+			Message:     fmt.Sprintf("%s %s: %s（当前值 %.4f）", rule.Symbol, rule.Interval, rule.Describe(), value),
+			TriggeredAt: now,
+		})
+	}
+	s.mu.Unlock()
+
+	for _, event := range fired {
+		s.publish(event)
+	}
+}
+
+// publish 保存告警事件并推送给订阅者
+func (s *AlertService) publish(event *types.AlertEvent) {
+	if err := s.repo.SaveEvent(context.Background(), event); err != nil {
+		log.Printf("保存告警事件失败: %v", err)
+	}
+	log.Printf("🔔 告警触发: %s", event.Message)
+
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// 通道满了，跳过
+		}
+	}
+}
+
+// evaluateAlertRule 评估规则
+// 返回规则对应的指标值、判断所在的K线索引（索引0是正在形成的K线）以及条件是否满足
+func evaluateAlertRule(data *RealtimeData, rule types.AlertRule) (float64, int, bool) {
+	start := 0
+	if rule.OnClose {
+		start = 1
+	}
+	if rule.Source == types.AlertSourceDivergence {
+		return evaluateDivergenceRule(data, rule, start)
+	}
+
+	valueAt := alertValueFunc(data, rule)
+	current, ok := valueAt(start)
+	if !ok {
+		return 0, start, false
+	}
+
+	switch rule.Condition {
+	case types.AlertCondCrossAbove, types.AlertCondCrossBelow:
+		prev, ok := valueAt(start + 1)
+		if !ok {
+			return current, start, false
+		}
+		if rule.Condition == types.AlertCondCrossAbove {
+			return current, start, prev <= rule.Threshold && current > rule.Threshold
+		}
+		return current, start, prev >= rule.Threshold && current < rule.Threshold
+	}
+
+	// 比较类条件需连续满足 Bars 根K线
+	for i := start; i < start+rule.Bars; i++ {
+		v, ok := valueAt(i)
+		if !ok || !compareAlertValue(v, rule.Condition, rule.Threshold) {
+			return current, start, false
+		}
+	}
+	return current, start, true
+}
+
+// evaluateDivergenceRule 评估背离规则：最近 Bars 根K线内确认了符合条件的背离时满足
+// 返回的K线索引为背离中较近的摆动点，同一次背离只触发一次；OnClose 时不使用由正在形成的K线确认的背离
+func evaluateDivergenceRule(data *RealtimeData, rule types.AlertRule, start int) (float64, int, bool) {
+	barIndex := func(t time.Time) int {
+		for i, k := range data.Klines {
+			if k.Time.Equal(t) {
+				return i
+			}
+			if k.Time.Before(t) {
+				break
+			}
+		}
+		return -1
+	}
+
+	for _, d := range data.Divergences {
+		if !divergenceMatches(d, rule) {
+			continue
+		}
+		confirmed := barIndex(d.ConfirmedTime)
+		if confirmed < start || confirmed >= start+rule.Bars {
+			continue
+		}
+		if to := barIndex(d.ToTime); to >= 0 {
+			return d.ToValue, to, true
+		}
+	}
+	return 0, start, false
+}
+
+// divergenceMatches 背离是否符合规则的指标（key为 "rsi"、"rsi_48" 或为空）和条件
+func divergenceMatches(d DivergenceEvent, rule types.AlertRule) bool {
+	if rule.Key != "" && rule.Key != d.Source && rule.Key != d.Source+"_"+d.Key {
+		return false
+	}
+	kind := indicators.DivergenceKind(d.Kind)
+	switch rule.Condition {
+	case types.AlertCondBullish:
+		return kind.Bullish()
+	case types.AlertCondBearish:
+		return !kind.Bullish()
+	default:
+		return d.Kind == rule.Condition
+	}
+}
+
+// compareAlertValue 比较指标值和阈值
+func compareAlertValue(v float64, condition string, threshold float64) bool {
+	switch condition {
+	case types.AlertCondGreater:
+		return v > threshold
+	case types.AlertCondGreaterEqual:
+		return v >= threshold
+	case types.AlertCondLess:
+		return v < threshold
+	case types.AlertCondLessEqual:
+		return v <= threshold
+	}
+	return false
+}
+
+// alertValueFunc 获取规则对应指标在第i根K线（索引0是最新数据）的取值函数
+func alertValueFunc(data *RealtimeData, rule types.AlertRule) func(i int) (float64, bool) {
+	fromSeries := func(series []float64) func(i int) (float64, bool) {
+		return func(i int) (float64, bool) {
+			if i < 0 || i >= len(series) {
+				return 0, false
+			}
+			return series[i], true
+		}
+	}
+
+	switch rule.Source {
+	case types.AlertSourcePrice:
+		return func(i int) (float64, bool) {
+			if i < 0 || i >= len(data.Klines) {
+				return 0, false
+			}
+			return data.Klines[i].Close, true
+		}
+	case types.AlertSourceCCI:
+		return fromSeries(data.CCI[rule.Key])
+	case types.AlertSourceRSI:
+		return fromSeries(data.RSI[rule.Key])
+	case types.AlertSourceMACDLine:
+		return fromSeries(data.MACD[rule.Key].MacdLine)
+	case types.AlertSourceMACDSignal:
+		return fromSeries(data.MACD[rule.Key].SignalLine)
+	case types.AlertSourceMACDHistogram:
+		return fromSeries(data.MACD[rule.Key].Histogram)
+	case types.AlertSourceBollZone:
+		b := data.Bollinger
+		return zoneValueFunc(data.Klines, b.Upper, b.Middle, b.Lower)
+	case types.AlertSourceEnvZone:
+		e := data.Envelope
+		return zoneValueFunc(data.Klines, e.Upper, e.Middle, e.Lower)
+	case types.AlertSourceVolatility:
+		// 5天平均波动只有当前值（按自然天计算，K线内不变化）
+		return func(i int) (float64, bool) {
+			if i < 0 || i >= len(data.Klines) {
+				return 0, false
+			}
+			return data.Volatility, true
+		}
+	}
+
+	return func(int) (float64, bool) { return 0, false }
+}
+
+// zoneValueFunc 计算第i根K线收盘价所在的通道分区号
+func zoneValueFunc(klines []KlineData, upper, middle, lower []float64) func(i int) (float64, bool) {
+	return func(i int) (float64, bool) {
+		if i < 0 || i >= len(klines) || i >= len(middle) || i >= len(upper) || i >= len(lower) {
+			return 0, false
+		}
+		return float64(indicators.Zone(klines[i].Close, middle[i], upper[i], lower[i])), true
+	}
+}
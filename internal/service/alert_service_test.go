@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// alertStep 一次实时推送
+type alertStep struct {
+	bar    int       // 正在形成的K线序号（开盘时间为 基准时间 + bar 小时），变化表示有新K线
+	values []float64 // 收盘价和CCI(48)（索引0为正在形成的K线）
+	after  int       // 距第一次推送经过的分钟数
+	fire   bool      // 是否应触发
+}
+
+// alertData 构造一次推送的实时数据，values 同时作为收盘价和CCI(48)
+func alertData(base time.Time, step alertStep) *RealtimeData {
+	data := &RealtimeData{
+		Symbol:   "TESTUSDT",
+		Interval: "1h",
+		Price:    step.values[0],
+		Klines:   make([]KlineData, len(step.values)),
+		CCI:      map[string][]float64{"48": step.values},
+	}
+	for i, v := range step.values {
+		data.Klines[i] = KlineData{Time: base.Add(time.Duration(step.bar-i) * time.Hour), Open: v, High: v, Low: v, Close: v}
+	}
+	return data
+}
+
+func TestAlertEvaluate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  types.AlertRule
+		steps []alertStep
+	}{
+		{
+			name: "穿越只在条件由不满足变为满足时触发",
+			rule: types.AlertRule{Source: types.AlertSourceCCI, Key: "48", Condition: types.AlertCondCrossAbove, Threshold: 100},
+			steps: []alertStep{
+				{bar: 0, values: []float64{99, 98}},
+				{bar: 0, values: []float64{101, 98}, fire: true},
+				{bar: 0, values: []float64{120, 98}}, // 仍满足，不重复触发
+				{bar: 1, values: []float64{90, 120}},
+				{bar: 1, values: []float64{105, 120}}, // 前一根在阈值之上，不算上穿
+				{bar: 2, values: []float64{95, 99}},
+				{bar: 2, values: []float64{101, 99}, fire: true},
+			},
+		},
+		{
+			name: "下穿",
+			rule: types.AlertRule{Source: types.AlertSourceCCI, Key: "48", Condition: types.AlertCondCrossBelow, Threshold: -100},
+			steps: []alertStep{
+				{bar: 0, values: []float64{-90, -95}},
+				{bar: 0, values: []float64{-101, -95}, fire: true},
+				{bar: 1, values: []float64{-110, -101}}, // 前一根已在阈值之下
+			},
+		},
+		{
+			name: "连续N根已收盘K线满足",
+			rule: types.AlertRule{Source: types.AlertSourcePrice, Condition: types.AlertCondGreater, Threshold: 100, Bars: 3, OnClose: true},
+			steps: []alertStep{
+				{bar: 0, values: []float64{105, 101, 101, 99}}, // 只有2根已收盘K线满足
+				{bar: 1, values: []float64{90, 105, 101, 101}, fire: true},
+				{bar: 1, values: []float64{80, 105, 101, 101}}, // 正在形成的K线不参与判断
+				{bar: 2, values: []float64{200, 90, 105, 101}},
+				{bar: 3, values: []float64{200, 200, 90, 105}},
+				{bar: 4, values: []float64{200, 200, 200, 90}},
+				{bar: 5, values: []float64{50, 200, 200, 200}, fire: true},
+			},
+		},
+		{
+			name: "同一根K线只触发一次",
+			rule: types.AlertRule{Source: types.AlertSourcePrice, Condition: types.AlertCondGreaterEqual, Threshold: 100},
+			steps: []alertStep{
+				{bar: 0, values: []float64{100}, fire: true},
+				{bar: 0, values: []float64{99}},
+				{bar: 0, values: []float64{101}}, // 重新满足，但仍是同一根K线
+				{bar: 1, values: []float64{99, 101}},
+				{bar: 1, values: []float64{102, 101}, fire: true},
+			},
+		},
+		{
+			name: "冷却时间内不重复触发",
+			rule: types.AlertRule{Source: types.AlertSourcePrice, Condition: types.AlertCondLess, Threshold: 100, Cooldown: 600},
+			steps: []alertStep{
+				{bar: 0, values: []float64{99}, after: 0, fire: true},
+				{bar: 1, values: []float64{101, 99}, after: 2},
+				{bar: 2, values: []float64{98, 101}, after: 9}, // 距上次触发不足10分钟
+				{bar: 3, values: []float64{101, 98}, after: 9},
+				{bar: 4, values: []float64{97, 101}, after: 10, fire: true}, // 恰好10分钟，冷却结束
+				{bar: 5, values: []float64{101, 97}, after: 11},
+				{bar: 6, values: []float64{96, 101}, after: 12},
+			},
+		},
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAlertService(nil, nil)
+			var now time.Time
+			svc.now = func() time.Time { return now }
+
+			rule := tt.rule
+			rule.Symbol, rule.Interval, rule.Enabled = "TESTUSDT", "1h", true
+			if _, err := svc.SaveRule(context.Background(), rule); err != nil {
+				t.Fatalf("保存规则失败: %v", err)
+			}
+			events := svc.Subscribe()
+
+			for i, step := range tt.steps {
+				now = base.Add(time.Duration(step.after) * time.Minute)
+				data := alertData(base, step)
+				svc.Evaluate(data)
+
+				select {
+				case event := <-events:
+					if !step.fire {
+						t.Fatalf("第%d次推送不应触发: %s", i+1, event.Message)
+					}
+					// 触发所在K线：OnClose 为最近一根已收盘K线，否则为正在形成的K线
+					idx := 0
+					if rule.OnClose {
+						idx = 1
+					}
+					if !event.BarTime.Equal(data.Klines[idx].Time) || event.Value != step.values[idx] {
+						t.Fatalf("第%d次推送: K线 %s 值 %v", i+1, event.BarTime, event.Value)
+					}
+				default:
+					if step.fire {
+						t.Fatalf("第%d次推送应触发", i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAlertEvaluateIgnoresOtherStreams(t *testing.T) {
+	svc := NewAlertService(nil, nil)
+	rules := []types.AlertRule{
+		{Symbol: "TESTUSDT", Interval: "4h", Source: types.AlertSourcePrice, Condition: types.AlertCondGreater, Threshold: 0, Enabled: true},
+		{Symbol: "OTHERUSDT", Interval: "1h", Source: types.AlertSourcePrice, Condition: types.AlertCondGreater, Threshold: 0, Enabled: true},
+		{Symbol: "TESTUSDT", Interval: "1h", Source: types.AlertSourcePrice, Condition: types.AlertCondGreater, Threshold: 0},
+	}
+	for _, rule := range rules {
+		if _, err := svc.SaveRule(context.Background(), rule); err != nil {
+			t.Fatalf("保存规则失败: %v", err)
+		}
+	}
+	events := svc.Subscribe()
+
+	svc.Evaluate(alertData(time.Now(), alertStep{values: []float64{100, 99}}))
+	select {
+	case event := <-events:
+		t.Fatalf("其他周期、交易对和未启用的规则不应触发: %s", event.Message)
+	default:
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// ConfigHistoryRepository 带版本历史的配置仓库接口（database.ConfigRepository 实现）
+type ConfigHistoryRepository interface {
+	ConfigRepository
+	SaveConfigVersion(ctx context.Context, symbol types.Symbol, config types.IndicatorConfig, meta types.ConfigChangeMeta) (*types.ConfigVersion, error)
+	ListConfigVersions(ctx context.Context, symbol types.Symbol, limit int) ([]types.ConfigVersion, error)
+	GetConfigVersion(ctx context.Context, symbol types.Symbol, version int) (*types.ConfigVersion, error)
+}
+
+// ConfigDiff 两个配置版本的差异
+type ConfigDiff struct {
+	Symbol  string               `json:"symbol"`
+	From    int                  `json:"from"`
+	To      int                  `json:"to"` // 0 表示当前生效的配置
+	Changes []types.ConfigChange `json:"changes"`
+}
+
+// configHistory 获取支持版本历史的配置仓库，未配置数据库时返回错误
+func (h *RealtimeHub) configHistory() (ConfigHistoryRepository, error) {
+	repo, ok := h.configRepo.(ConfigHistoryRepository)
+	if !ok || repo == nil {
+		return nil, fmt.Errorf("配置历史不可用（未配置数据库）")
+	}
+	return repo, nil
+}
+
+// ListConfigVersions 获取指定symbol的配置历史（按版本倒序）
+func (h *RealtimeHub) ListConfigVersions(ctx context.Context, symbol types.Symbol, limit int) ([]types.ConfigVersion, error) {
+	repo, err := h.configHistory()
+	if err != nil {
+		return nil, err
+	}
+	return repo.ListConfigVersions(ctx, symbol, limit)
+}
+
+// DiffConfigVersions 比较两个配置版本，to 为0时与当前生效的配置比较
+func (h *RealtimeHub) DiffConfigVersions(ctx context.Context, symbol types.Symbol, from, to int) (*ConfigDiff, error) {
+	repo, err := h.configHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	fromVersion, err := repo.GetConfigVersion(ctx, symbol, from)
+	if err != nil {
+		return nil, err
+	}
+	toConfig := h.GetConfig(symbol)
+	if to > 0 {
+		toVersion, err := repo.GetConfigVersion(ctx, symbol, to)
+		if err != nil {
+			return nil, err
+		}
+		toConfig = toVersion.Config
+	}
+
+	return &ConfigDiff{
+		Symbol:  string(symbol),
+		From:    from,
+		To:      to,
+		Changes: indicators.DiffConfig(fromVersion.Config, toConfig),
+	}, nil
+}
+
+// RollbackConfig 将指定symbol的配置恢复为某个历史版本：作为新版本保存，并立即让实时流按恢复的配置推送
+func (h *RealtimeHub) RollbackConfig(ctx context.Context, symbol types.Symbol, version int, author, note string) (*types.ConfigVersion, error) {
+	repo, err := h.configHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := repo.GetConfigVersion(ctx, symbol, version)
+	if err != nil {
+		return nil, err
+	}
+	// 注册表可能在该版本保存后发生变化，恢复前重新校验
+	if err := indicators.ValidateConfig(target.Config); err != nil {
+		return nil, fmt.Errorf("版本 %d 的配置已失效: %w", version, err)
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("回滚到版本 %d", version)
+	}
+	saved, err := h.UpdateConfigWithMeta(symbol, target.Config, types.ConfigChangeMeta{
+		Author:       author,
+		Note:         note,
+		Action:       types.ConfigActionRollback,
+		RollbackFrom: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s 的配置已回滚到版本 %d（新版本 %d）", symbol, version, saved.Version)
+	return saved, nil
+}
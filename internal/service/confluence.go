@@ -0,0 +1,354 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// maxConfluenceTimeframes 单次汇总的周期数量上限
+const maxConfluenceTimeframes = 8
+
+// TimeframeSummary 单个周期的指标概要（均为最新K线的值）
+type TimeframeSummary struct {
+	Interval string             `json:"interval"`
+	BarTime  time.Time          `json:"bar_time"` // 最新K线的开盘时间
+	Bars     int                `json:"bars"`     // 重采样后的K线数量
+	Close    float64            `json:"close"`
+	BollZone int                `json:"boll_zone"`
+	EnvZone  int                `json:"env_zone"`
+	CCI      map[string]float64 `json:"cci"`
+	CCISign  int                `json:"cci_sign"` // 所有CCI实例同为正时为1，同为负时为-1，否则为0
+	RSI      map[string]float64 `json:"rsi,omitempty"`
+	MACD     map[string]float64 `json:"macd_histogram,omitempty"`
+	Bias     int                `json:"bias"` // CCI方向与布林线分区方向的综合：1 偏多、-1 偏空、0 中性
+}
+
+// ConfluenceSummary 多周期共振概要：各周期由同一条基础K线序列重采样后按同一份配置计算
+type ConfluenceSummary struct {
+	Symbol       string             `json:"symbol"`
+	BaseInterval string             `json:"base_interval"`
+	Timestamp    time.Time          `json:"timestamp"`
+	Price        float64            `json:"price"`
+	Timeframes   []TimeframeSummary `json:"timeframes"`
+	Bullish      int                `json:"bullish"` // 偏多的周期数
+	Bearish      int                `json:"bearish"` // 偏空的周期数
+	Bias         int                `json:"bias"`    // 所有周期同为偏多时为1，同为偏空时为-1，否则为0
+}
+
+// defaultHigherTimeframes 未指定周期时在基础周期之外汇总的更高周期候选
+var defaultHigherTimeframes = []string{"1h", "4h", "1d"}
+
+// DefaultTimeframes 默认汇总的周期：基础周期加上两个可由其重采样得到的更高周期（如15m -> 15m、1h、4h）
+func DefaultTimeframes(base string) []string {
+	result := []string{base}
+	for _, tf := range defaultHigherTimeframes {
+		if len(result) == 3 {
+			break
+		}
+		if tf != base && types.CheckResample(base, tf) == nil {
+			result = append(result, tf)
+		}
+	}
+	return result
+}
+
+// ParseTimeframes 校验周期列表：每个周期都必须能由基础周期重采样得到，重复的周期只保留一个
+func ParseTimeframes(base string, timeframes []string) ([]string, error) {
+	if len(timeframes) == 0 {
+		return nil, fmt.Errorf("缺少周期")
+	}
+	if len(timeframes) > maxConfluenceTimeframes {
+		return nil, fmt.Errorf("周期数量超过上限 %d", maxConfluenceTimeframes)
+	}
+
+	seen := make(map[string]bool, len(timeframes))
+	var result []string
+	for _, tf := range timeframes {
+		if seen[tf] {
+			continue
+		}
+		if err := types.CheckResample(base, tf); err != nil {
+			return nil, err
+		}
+		seen[tf] = true
+		result = append(result, tf)
+	}
+	return result, nil
+}
+
+// ComputeConfluence 由基础周期的K线（从旧到新）重采样出各周期，按配置计算指标并汇总
+// 配置中的周期参数基于小时，在每个周期上按该周期缩放，因此各周期覆盖的时间跨度一致
+func ComputeConfluence(symbol types.Symbol, base string, klines []types.Kline, config types.IndicatorConfig, timeframes []string) (*ConfluenceSummary, error) {
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("K线数据为空")
+	}
+
+	summary := &ConfluenceSummary{
+		Symbol:       string(symbol),
+		BaseInterval: base,
+		Timestamp:    time.Now(),
+		Price:        klines[len(klines)-1].Close,
+		Timeframes:   make([]TimeframeSummary, 0, len(timeframes)),
+	}
+	for _, tf := range timeframes {
+		resampled, err := types.ResampleKlines(klines, base, tf)
+		if err != nil {
+			return nil, err
+		}
+		if len(resampled) == 0 {
+			log.Printf("%s 的 %s K线不足以重采样为 %s，已跳过", symbol, base, tf)
+			continue
+		}
+
+		engine := newIndicatorEngine(config, tf)
+		engine.load(resampled)
+		summary.Timeframes = append(summary.Timeframes, summarizeTimeframe(tf, resampled, engine.results(resampled)))
+	}
+	summary.tally()
+	return summary, nil
+}
+
+// tally 汇总各周期的偏向
+func (s *ConfluenceSummary) tally() {
+	s.Bullish, s.Bearish, s.Bias = 0, 0, 0
+	for _, tf := range s.Timeframes {
+		switch tf.Bias {
+		case 1:
+			s.Bullish++
+		case -1:
+			s.Bearish++
+		}
+	}
+	switch n := len(s.Timeframes); {
+	case n > 0 && s.Bullish == n:
+		s.Bias = 1
+	case n > 0 && s.Bearish == n:
+		s.Bias = -1
+	}
+}
+
+// Covers 是否包含所有指定周期
+func (s *ConfluenceSummary) Covers(timeframes []string) bool {
+	have := make(map[string]bool, len(s.Timeframes))
+	for _, tf := range s.Timeframes {
+		have[tf.Interval] = true
+	}
+	for _, tf := range timeframes {
+		if !have[tf] {
+			return false
+		}
+	}
+	return true
+}
+
+// Select 按指定周期的顺序取出子集并重新汇总偏向（缓存中没有的周期被跳过）
+func (s *ConfluenceSummary) Select(timeframes []string) *ConfluenceSummary {
+	byInterval := make(map[string]TimeframeSummary, len(s.Timeframes))
+	for _, tf := range s.Timeframes {
+		byInterval[tf.Interval] = tf
+	}
+	selected := *s
+	selected.Timeframes = make([]TimeframeSummary, 0, len(timeframes))
+	for _, tf := range timeframes {
+		if summary, ok := byInterval[tf]; ok {
+			selected.Timeframes = append(selected.Timeframes, summary)
+		}
+	}
+	selected.tally()
+	return &selected
+}
+
+// confluenceCache 实时流的多周期共振缓存
+// 只在周期合集变化、指标序列整体重算（配置变化、窗口重载）或其中某个周期的K线收盘时重新计算
+type confluenceCache struct {
+	mu         sync.Mutex
+	timeframes []string
+	barTime    time.Time // 计算时最新基础K线的开盘时间
+	epoch      uint64
+	summary    *ConfluenceSummary
+}
+
+// get 获取缓存的概要，需要时按K线窗口（从旧到新）重新计算；计算失败时保留上一次的结果
+func (c *confluenceCache) get(symbol types.Symbol, base string, klines []types.Kline, config types.IndicatorConfig, epoch uint64, timeframes []string) *ConfluenceSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(timeframes) == 0 || len(klines) == 0 {
+		c.summary, c.timeframes = nil, nil
+		return nil
+	}
+	latest := klines[len(klines)-1].Timestamp
+	if c.summary != nil && c.epoch == epoch && equalStrings(c.timeframes, timeframes) && !barClosed(c.barTime, latest, timeframes) {
+		return c.summary
+	}
+
+	summary, err := ComputeConfluence(symbol, base, klines, config, timeframes)
+	if err != nil {
+		log.Printf("计算 %s@%s 多周期共振失败: %v", symbol, base, err)
+		return c.summary
+	}
+	c.summary, c.timeframes, c.barTime, c.epoch = summary, timeframes, latest, epoch
+	return summary
+}
+
+// barClosed 最新基础K线从 prev 变为 latest 时，是否有某个周期的K线收盘
+func barClosed(prev, latest time.Time, timeframes []string) bool {
+	if !latest.After(prev) {
+		return false
+	}
+	for _, tf := range timeframes {
+		before, err1 := types.ResampleBucket(prev, tf)
+		after, err2 := types.ResampleBucket(latest, tf)
+		if err1 != nil || err2 != nil || !before.Equal(after) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTimeframes 按周期时长从小到大排序
+func sortTimeframes(timeframes []string) {
+	sort.SliceStable(timeframes, func(i, j int) bool {
+		a, _ := types.IntervalDuration(timeframes[i])
+		b, _ := types.IntervalDuration(timeframes[j])
+		return a < b
+	})
+}
+
+// equalStrings 两个字符串切片是否相同
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfluenceFromData 由实时流推送的数据计算多周期概要（K线窗口即基础序列）
+func ConfluenceFromData(data *RealtimeData, config types.IndicatorConfig, timeframes []string) (*ConfluenceSummary, error) {
+	// RealtimeData 中索引0是最新K线，重采样需要从旧到新
+	klines := make([]types.Kline, len(data.Klines))
+	for i, k := range data.Klines {
+		klines[len(klines)-1-i] = types.Kline{
+			Symbol:    data.Symbol,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			Timestamp: k.Time,
+		}
+	}
+	return ComputeConfluence(types.Symbol(data.Symbol), data.Interval, klines, config, timeframes)
+}
+
+// summarizeTimeframe 提取单个周期的最新指标值
+func summarizeTimeframe(interval string, klines []types.Kline, results engineResults) TimeframeSummary {
+	last := klines[len(klines)-1]
+	summary := TimeframeSummary{
+		Interval: interval,
+		BarTime:  last.Timestamp,
+		Bars:     len(klines),
+		Close:    last.Close,
+		CCI:      latestValues(results.series(indicators.NameCCI, "cci")),
+		RSI:      latestValues(results.series(indicators.NameRSI, "rsi")),
+	}
+
+	macd := make(map[string]float64)
+	for key, values := range results.macd() {
+		if len(values.Histogram) > 0 {
+			macd[key] = values.Histogram[0]
+		}
+	}
+	if len(macd) > 0 {
+		summary.MACD = macd
+	}
+
+	if upper, middle, lower := results.band(indicators.NameBollinger); len(middle) > 0 {
+		summary.BollZone = indicators.Zone(last.Close, middle[0], upper[0], lower[0])
+	}
+	if upper, middle, lower := results.band(indicators.NameEnvelope); len(middle) > 0 {
+		summary.EnvZone = indicators.Zone(last.Close, middle[0], upper[0], lower[0])
+	}
+
+	positive, negative := 0, 0
+	for _, v := range summary.CCI {
+		switch {
+		case v > 0:
+			positive++
+		case v < 0:
+			negative++
+		}
+	}
+	switch n := len(summary.CCI); {
+	case n > 0 && positive == n:
+		summary.CCISign = 1
+	case n > 0 && negative == n:
+		summary.CCISign = -1
+	}
+
+	summary.Bias = sign(summary.CCISign + sign(summary.BollZone))
+	return summary
+}
+
+// latestValues 取每个序列的最新值（索引0）
+func latestValues(series map[string][]float64) map[string]float64 {
+	result := make(map[string]float64, len(series))
+	for key, values := range series {
+		if len(values) > 0 {
+			result[key] = values[0]
+		}
+	}
+	return result
+}
+
+// sign 返回整数的符号
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Confluence 获取指定symbol的多周期概要
+// 基础周期的实时流正在运行时使用其缓存的概要或K线窗口，否则按实时流相同的窗口（7天）获取K线
+func (h *RealtimeHub) Confluence(symbol types.Symbol, base string, timeframes []string) (*ConfluenceSummary, error) {
+	symbol = normalizeSymbol(symbol)
+	timeframes, err := ParseTimeframes(base, timeframes)
+	if err != nil {
+		return nil, err
+	}
+	config := h.GetConfig(symbol)
+
+	if data := h.Latest(symbol, base); data != nil {
+		// 实时流已为订阅者缓存了包含这些周期的概要时直接使用
+		if data.Confluence != nil && data.Confluence.Covers(timeframes) {
+			return data.Confluence.Select(timeframes), nil
+		}
+		return ConfluenceFromData(data, config, timeframes)
+	}
+
+	limit, err := types.CalculateKlinesForDays(7, base)
+	if err != nil {
+		return nil, err
+	}
+	klines, err := h.provider.GetRecentKlines(symbol, base, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取K线数据失败: %w", err)
+	}
+	return ComputeConfluence(symbol, base, klines, config, timeframes)
+}
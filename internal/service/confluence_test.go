@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// alignedKlines 生成最新一根位于4h周期第二个小时的1h K线（从旧到新）
+func alignedKlines(n int) []types.Kline {
+	klines := testKlines(n)
+	last := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for i := range klines {
+		klines[i].Timestamp = last.Add(-time.Duration(n-1-i) * time.Hour)
+	}
+	return klines
+}
+
+func TestConfluenceCacheRecomputesOnBarClose(t *testing.T) {
+	config := types.GetDefaultConfig()
+	klines := alignedKlines(300)
+	timeframes := []string{"4h"}
+
+	var cache confluenceCache
+	first := cache.get("TESTUSDT", "1h", klines, config, 1, timeframes)
+	if first == nil || len(first.Timeframes) != 1 {
+		t.Fatalf("首次计算结果不正确: %+v", first)
+	}
+
+	// 正在形成的K线更新不重新计算
+	forming := append([]types.Kline(nil), klines...)
+	forming[len(forming)-1].Close += 5
+	if got := cache.get("TESTUSDT", "1h", forming, config, 1, timeframes); got != first {
+		t.Fatalf("形成中的K线更新触发了重新计算")
+	}
+
+	// 1h K线收盘但4h K线未收盘，不重新计算
+	next := forming[len(forming)-1]
+	next.Timestamp = next.Timestamp.Add(time.Hour)
+	appended := append(forming[1:len(forming):len(forming)], next)
+	if got := cache.get("TESTUSDT", "1h", appended, config, 1, timeframes); got != first {
+		t.Fatalf("4h K线未收盘时重新计算了")
+	}
+
+	// 包含基础周期时，1h K线收盘即重新计算
+	withBase := []string{"1h", "4h"}
+	byBase := cache.get("TESTUSDT", "1h", appended, config, 1, withBase)
+	if byBase == first || len(byBase.Timeframes) != 2 {
+		t.Fatalf("周期合集变化后未重新计算: %+v", byBase)
+	}
+	next.Timestamp = next.Timestamp.Add(time.Hour)
+	appended = append(appended[1:len(appended):len(appended)], next)
+	if got := cache.get("TESTUSDT", "1h", appended, config, 1, withBase); got == byBase {
+		t.Fatalf("1h K线收盘后未重新计算")
+	}
+
+	// 4h K线收盘时重新计算
+	cached := cache.get("TESTUSDT", "1h", appended, config, 1, timeframes)
+	for i := 0; i < 2; i++ {
+		next.Timestamp = next.Timestamp.Add(time.Hour)
+		appended = append(appended[1:len(appended):len(appended)], next)
+	}
+	closed := cache.get("TESTUSDT", "1h", appended, config, 1, timeframes)
+	if closed == cached {
+		t.Fatalf("4h K线收盘后未重新计算")
+	}
+	if want := next.Timestamp.Truncate(4 * time.Hour); !closed.Timeframes[0].BarTime.Equal(want) {
+		t.Fatalf("最新4h K线时间 %v，期望 %v", closed.Timeframes[0].BarTime, want)
+	}
+
+	// 指标整体重算（Epoch变化）时重新计算
+	if got := cache.get("TESTUSDT", "1h", appended, config, 2, timeframes); got == closed {
+		t.Fatalf("Epoch变化后未重新计算")
+	}
+
+	// 没有订阅者需要时清空
+	if got := cache.get("TESTUSDT", "1h", appended, config, 2, nil); got != nil {
+		t.Fatalf("无周期时应返回nil")
+	}
+}
+
+func TestConfluenceSummarySelect(t *testing.T) {
+	summary := &ConfluenceSummary{
+		Symbol: "TESTUSDT",
+		Timeframes: []TimeframeSummary{
+			{Interval: "1h", Bias: 1},
+			{Interval: "4h", Bias: 1},
+			{Interval: "1d", Bias: -1},
+		},
+	}
+	summary.tally()
+	if summary.Bullish != 2 || summary.Bearish != 1 || summary.Bias != 0 {
+		t.Fatalf("汇总不正确: %+v", summary)
+	}
+
+	selected := summary.Select([]string{"4h", "1h"})
+	if len(selected.Timeframes) != 2 || selected.Timeframes[0].Interval != "4h" || selected.Timeframes[1].Interval != "1h" {
+		t.Fatalf("选取的周期不正确: %+v", selected.Timeframes)
+	}
+	if selected.Bullish != 2 || selected.Bearish != 0 || selected.Bias != 1 {
+		t.Fatalf("选取后的汇总不正确: %+v", selected)
+	}
+	if len(summary.Timeframes) != 3 || summary.Bias != 0 {
+		t.Fatalf("Select修改了缓存的概要: %+v", summary)
+	}
+
+	if !summary.Covers([]string{"1d", "1h"}) || summary.Covers([]string{"1h", "1w"}) {
+		t.Fatalf("Covers结果不正确")
+	}
+}
+
+func TestRealtimeServiceCachesConfluence(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	klines := testKlines(300)
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+	svc := startRealtime(t, ctx, provider)
+	if svc.Latest().Confluence != nil {
+		t.Fatalf("没有订阅者需要时不应计算多周期共振")
+	}
+
+	sub := svc.Subscribe(PolicyCoalesce)
+	defer svc.Unsubscribe(sub)
+	sub.SetTimeframes([]string{"1d", "4h"})
+
+	// 触发一次推送，按订阅者的周期合集计算
+	forming := klines[len(klines)-1]
+	forming.Close += 1
+	provider.Push("TESTUSDT", "1h", exchange.KlineUpdate{Kline: forming})
+	data := waitLatest(t, svc, func(d *RealtimeData) bool { return d.Confluence != nil })
+	cached := data.Confluence
+	if len(cached.Timeframes) != 2 || cached.Timeframes[0].Interval != "4h" || cached.Timeframes[1].Interval != "1d" {
+		t.Fatalf("缓存的周期不正确: %+v", cached.Timeframes)
+	}
+
+	// 形成中的K线继续更新，推送的数据沿用同一份概要
+	forming.Close += 2
+	provider.Push("TESTUSDT", "1h", exchange.KlineUpdate{Kline: forming})
+	data = waitLatest(t, svc, func(d *RealtimeData) bool { return d.Price == forming.Close })
+	if data.Confluence != cached {
+		t.Fatalf("形成中的K线更新重新计算了多周期共振")
+	}
+}
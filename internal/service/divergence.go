@@ -0,0 +1,88 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// DivergenceEvent 价格（HLCC）与振荡指标之间的一次背离
+type DivergenceEvent struct {
+	Source    string    `json:"source"` // cci、rsi、macd（MACD柱）
+	Key       string    `json:"key"`    // 指标实例key，如 "48"、"48_72"
+	Kind      string    `json:"kind"`   // regular_bullish、regular_bearish、hidden_bullish、hidden_bearish
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	FromPrice float64   `json:"from_price"`
+	ToPrice   float64   `json:"to_price"`
+	FromValue float64   `json:"from_value"`
+	ToValue   float64   `json:"to_value"`
+	// ConfirmedTime 确认较近摆动点的K线（其右侧第lookback根）的开盘时间
+	ConfirmedTime time.Time `json:"confirmed_time"`
+}
+
+// detectDivergences 对配置中的振荡指标检测背离（按较近摆动点从新到旧排列）
+// klines 为K线（从旧到新），振荡指标序列的索引0是最新数据
+func detectDivergences(klines []types.Kline, cci, rsi map[string][]float64, macd map[string]MACDValues, cfg *types.DivergenceConfig) []DivergenceEvent {
+	if cfg != nil && cfg.Disabled {
+		return nil
+	}
+	opts := indicators.DivergenceOptionsFromConfig(cfg)
+
+	n := len(klines)
+	price := make([]float64, n)
+	times := make([]time.Time, n)
+	for i := 0; i < n; i++ {
+		k := klines[n-1-i]
+		price[i] = hlcc(k)
+		times[i] = k.Timestamp
+	}
+
+	series := map[string]map[string][]float64{
+		indicators.NameCCI: cci,
+		indicators.NameRSI: rsi,
+	}
+	if len(macd) > 0 {
+		histograms := make(map[string][]float64, len(macd))
+		for key, values := range macd {
+			histograms[key] = values.Histogram
+		}
+		series[indicators.NameMACD] = histograms
+	}
+
+	sources := indicators.DivergenceSources
+	if cfg != nil && len(cfg.Sources) > 0 {
+		sources = cfg.Sources
+	}
+
+	var events []DivergenceEvent
+	for _, source := range sources {
+		keys := make([]string, 0, len(series[source]))
+		for key := range series[source] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			for _, d := range indicators.DetectDivergences(price, series[source][key], opts) {
+				events = append(events, DivergenceEvent{
+					Source:        source,
+					Key:           key,
+					Kind:          string(d.Kind),
+					FromTime:      times[d.From],
+					ToTime:        times[d.To],
+					FromPrice:     d.PriceFrom,
+					ToPrice:       d.PriceTo,
+					FromValue:     d.OscFrom,
+					ToValue:       d.OscTo,
+					ConfirmedTime: times[d.To-opts.Lookback],
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].ToTime.After(events[j].ToTime) })
+	return events
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"log"
 	"time"
 
 	"github.com/binance_cyan/indicators/internal/database"
-	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/internal/exchange"
 	"github.com/binance_cyan/indicators/pkg/indicators"
 	"github.com/binance_cyan/indicators/pkg/types"
 	"github.com/redis/go-redis/v9"
@@ -15,27 +17,50 @@ import (
 
 // IndicatorService 指标服务
 type IndicatorService struct {
-	binanceClient *binance.Client
-	cacheTTL      time.Duration
+	provider     exchange.MarketDataProvider
+	cacheTTL     time.Duration
+	configSource ConfigSource // 指标配置来源（通常为 RealtimeHub），为nil时使用默认配置
 }
 
 // NewIndicatorService 创建指标服务
-func NewIndicatorService(binanceClient *binance.Client, cacheTTL time.Duration) *IndicatorService {
+func NewIndicatorService(provider exchange.MarketDataProvider, cacheTTL time.Duration) *IndicatorService {
 	return &IndicatorService{
-		binanceClient: binanceClient,
-		cacheTTL:      cacheTTL,
+		provider: provider,
+		cacheTTL: cacheTTL,
 	}
 }
 
+// SetConfigSource 设置指标配置来源，计算时使用交易对配置中的指标实例（与实时推送一致）
+func (s *IndicatorService) SetConfigSource(source ConfigSource) {
+	s.configSource = source
+}
+
+// config 获取交易对的指标配置
+func (s *IndicatorService) config(symbol types.Symbol) types.IndicatorConfig {
+	if s.configSource != nil {
+		return s.configSource.GetConfig(symbol)
+	}
+	return types.GetDefaultConfig()
+}
+
 // IndicatorResult 指标计算结果
 type IndicatorResult struct {
 	Symbol    string                `json:"symbol"`
 	Interval  string                `json:"interval"`
 	Timestamp time.Time             `json:"timestamp"`
-	CCI       map[string][]float64  `json:"cci"`   // key: "48", "72", "168"
-	MACD      map[string]MACDValues `json:"macd"`  // key: "48_72", "72_168"
-	RSI       map[string][]float64  `json:"rsi"`   // key: "48", "72"
+	CCI       map[string][]float64  `json:"cci"`   // key: 实例key，如 "48"
+	MACD      map[string]MACDValues `json:"macd"`  // key: 实例key，如 "48_72"
+	RSI       map[string][]float64  `json:"rsi"`   // key: 实例key，如 "48"
 	Price     []float64             `json:"price"` // HLCC价格
+	// Bollinger、Envelope 配置中第一个布林线/包络线实例，分区号按最新收盘价计算
+	Bollinger BollingerData `json:"bollinger"`
+	Envelope  EnvelopeData  `json:"envelope"`
+	// Custom 内置字段之外的指标实例，key 为 "指标名称_实例key"（与实时推送一致）
+	Custom map[string]map[string][]float64 `json:"custom,omitempty"`
+	// Names 配置了显示名称的指标实例，key 为 "指标名称_实例key"
+	Names map[string]string `json:"names,omitempty"`
+	// Futures 合约数据（标记价格、资金费率、持仓量），仅合约市场
+	Futures *exchange.FuturesStats `json:"futures,omitempty"`
 }
 
 // MACDValues MACD值
@@ -46,16 +71,21 @@ type MACDValues struct {
 }
 
 // GetIndicators 获取指标数据
+// 指标实例、周期和移动平均方法取自交易对的配置，与实时推送使用同一个指标引擎计算
 func (s *IndicatorService) GetIndicators(ctx context.Context, symbol types.Symbol, interval string, limit int) (*IndicatorResult, error) {
-	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("indicators:%s:%s:%d", symbol, interval, limit)
+	config := s.config(symbol)
+
+	// 尝试从缓存获取（配置变化后使用新的缓存key）
+	cacheKey := fmt.Sprintf("indicators:%s:%s:%d:%s", symbol, interval, limit, configFingerprint(config))
 	cached, err := s.getFromCache(ctx, cacheKey)
 	if err == nil && cached != nil {
+		// 合约数据变化快，不使用缓存中的值
+		cached.Futures = s.futuresStats(symbol)
 		return cached, nil
 	}
 
-	// 从Binance获取K线数据
-	klines, err := s.binanceClient.GetKlines(symbol, interval, limit)
+	// 从行情数据源获取K线数据（超过单次请求上限时自动分页）
+	klines, err := s.provider.GetRecentKlines(symbol, interval, limit)
 	if err != nil {
 		return nil, fmt.Errorf("获取K线数据失败: %w", err)
 	}
@@ -80,55 +110,69 @@ func (s *IndicatorService) GetIndicators(ctx context.Context, symbol types.Symbo
 	// 计算HLCC价格
 	hlcc := indicators.CalculateHLCC(high, low, close)
 
-	// 计算CCI（使用MQ5的周期：48, 72, 168）
-	cciPeriods := []int{48, 72, 168}
-	cciResults := indicators.CalculateCCIMulti(hlcc, cciPeriods)
-	cciMap := make(map[string][]float64)
-	for i, period := range cciPeriods {
-		cciMap[fmt.Sprintf("%d", period)] = cciResults[i]
-	}
+	// 按配置计算所有指标实例
+	engine := newIndicatorEngine(config, interval)
+	engine.load(klines)
+	results := engine.results(klines)
 
-	// 计算MACD（使用MQ5的配置）
-	// MACD1: Fast=48, Slow=72, Signal=2
-	// MACD2: Fast=72, Slow=168, Signal=2
-	macd1Line, macd1Signal, macd1Hist := indicators.CalculateMACD(hlcc, 48, 72, 2)
-	macd2Line, macd2Signal, macd2Hist := indicators.CalculateMACD(hlcc, 72, 168, 2)
-	macdMap := make(map[string]MACDValues)
-	macdMap["48_72"] = MACDValues{
-		MacdLine:   macd1Line,
-		SignalLine: macd1Signal,
-		Histogram:  macd1Hist,
+	currentPrice := klines[len(klines)-1].Close
+	bollUpper, bollMiddle, bollLower := results.band(indicators.NameBollinger)
+	envUpper, envMiddle, envLower := results.band(indicators.NameEnvelope)
+	bollinger := BollingerData{Upper: bollUpper, Middle: bollMiddle, Lower: bollLower}
+	if len(bollMiddle) > 0 {
+		bollinger.Zone = indicators.Zone(currentPrice, bollMiddle[0], bollUpper[0], bollLower[0])
 	}
-	macdMap["72_168"] = MACDValues{
-		MacdLine:   macd2Line,
-		SignalLine: macd2Signal,
-		Histogram:  macd2Hist,
-	}
-
-	// 计算RSI（使用MQ5的周期：48, 72）
-	rsiPeriods := []int{48, 72}
-	rsiResults := indicators.CalculateRSIMulti(hlcc, rsiPeriods)
-	rsiMap := make(map[string][]float64)
-	for i, period := range rsiPeriods {
-		rsiMap[fmt.Sprintf("%d", period)] = rsiResults[i]
+	envelope := EnvelopeData{Upper: envUpper, Middle: envMiddle, Lower: envLower}
+	if len(envMiddle) > 0 {
+		envelope.Zone = indicators.Zone(currentPrice, envMiddle[0], envUpper[0], envLower[0])
 	}
 
 	result := &IndicatorResult{
 		Symbol:    string(symbol),
 		Interval:  interval,
 		Timestamp: time.Now(),
-		CCI:       cciMap,
-		MACD:      macdMap,
-		RSI:       rsiMap,
+		CCI:       results.series(indicators.NameCCI, "cci"),
+		MACD:      results.macd(),
+		RSI:       results.series(indicators.NameRSI, "rsi"),
 		Price:     hlcc,
+		Bollinger: bollinger,
+		Envelope:  envelope,
+		Custom:    results.custom(),
+		Names:     results.names,
 	}
 
 	// 保存到缓存
 	s.saveToCache(ctx, cacheKey, result)
 
+	result.Futures = s.futuresStats(symbol)
 	return result, nil
 }
 
+// configFingerprint 指标配置的摘要，用于区分不同配置的缓存
+func configFingerprint(config types.IndicatorConfig) string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "default"
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// futuresStats 获取合约数据（非合约市场或获取失败时返回nil）
+func (s *IndicatorService) futuresStats(symbol types.Symbol) *exchange.FuturesStats {
+	fp, ok := s.provider.(exchange.FuturesDataProvider)
+	if !ok || !fp.MarketOf(symbol).IsFutures() {
+		return nil
+	}
+	stats, err := fp.GetFuturesStats(symbol)
+	if err != nil {
+		log.Printf("获取 %s 合约数据失败: %v", symbol, err)
+		return nil
+	}
+	return stats
+}
+
 // getFromCache 从缓存获取
 func (s *IndicatorService) getFromCache(ctx context.Context, key string) (*IndicatorResult, error) {
 	if database.RDB == nil {
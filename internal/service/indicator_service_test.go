@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// staticConfig 固定的指标配置来源
+type staticConfig types.IndicatorConfig
+
+func (c staticConfig) GetConfig(symbol types.Symbol) types.IndicatorConfig {
+	return types.IndicatorConfig(c)
+}
+
+func TestGetIndicatorsUsesConfiguredInstances(t *testing.T) {
+	klines := testKlines(300)
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+
+	svc := NewIndicatorService(provider, 0)
+	svc.SetConfigSource(staticConfig{Indicators: []types.IndicatorInstance{
+		{Type: "cci", Params: map[string]float64{"period": 20}, MA: "ema"},
+		{Type: "rsi", Params: map[string]float64{"period": 14}, MA: "sma"},
+		{Type: "macd", Params: map[string]float64{"fast": 12, "slow": 26, "signal": 9}, MA: "smma"},
+	}})
+
+	result, err := svc.GetIndicators(context.Background(), "TESTUSDT", "1h", 300)
+	if err != nil {
+		t.Fatalf("获取指标失败: %v", err)
+	}
+	if len(result.CCI) != 1 || len(result.RSI) != 1 || len(result.MACD) != 1 {
+		t.Fatalf("结果应只包含配置的实例: cci=%v rsi=%v macd=%d", keys(result.CCI), keys(result.RSI), len(result.MACD))
+	}
+
+	price := result.Price
+	assertSameSeries(t, "cci", result.CCI, map[string][]float64{"20": indicators.CalculateCCIWithMA(price, 20, indicators.MAEMA)}, 0)
+	assertSameSeries(t, "rsi", result.RSI, map[string][]float64{"14": indicators.CalculateRSIWithMA(price, 14, indicators.MASMA)}, 0)
+	line, signal, hist := indicators.CalculateMACDWithMA(price, 12, 26, 9, indicators.MASMMA)
+	m, ok := result.MACD["12_26"]
+	if !ok {
+		t.Fatalf("缺少MACD实例 12_26: %v", result.MACD)
+	}
+	assertSameSeries(t, "macd", map[string][]float64{"line": m.MacdLine, "signal": m.SignalLine, "hist": m.Histogram},
+		map[string][]float64{"line": line, "signal": signal, "hist": hist}, 0)
+
+	// 移动平均方法不同，结果应不同
+	wma := indicators.CalculateCCIWithMA(price, 20, indicators.MAWMA)
+	if math.Abs(wma[0]-result.CCI["20"][0]) < 1e-9 {
+		t.Fatalf("CCI未使用配置的移动平均方法")
+	}
+}
+
+func TestGetIndicatorsBandsAndCustom(t *testing.T) {
+	klines := testKlines(300)
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+
+	svc := NewIndicatorService(provider, 0)
+	svc.SetConfigSource(staticConfig{Indicators: []types.IndicatorInstance{
+		{Type: "bollinger", Params: map[string]float64{"period": 20, "deviation": 2}},
+		{Type: "bollinger", Key: "wide", Name: "宽布林", Params: map[string]float64{"period": 40, "deviation": 3}},
+		{Type: "envelope", Params: map[string]float64{"period": 24, "deviation": 1.5}, MA: "sma"},
+	}})
+
+	result, err := svc.GetIndicators(context.Background(), "TESTUSDT", "1h", 300)
+	if err != nil {
+		t.Fatalf("获取指标失败: %v", err)
+	}
+	if len(result.CCI) != 0 || len(result.RSI) != 0 || len(result.MACD) != 0 {
+		t.Fatalf("未配置的指标不应出现在结果中")
+	}
+
+	upper, middle, lower := indicators.CalculateBollingerWithMA(result.Price, 20, 2, indicators.MAWMA)
+	assertSameSeries(t, "bollinger", map[string][]float64{"upper": result.Bollinger.Upper, "middle": result.Bollinger.Middle, "lower": result.Bollinger.Lower},
+		map[string][]float64{"upper": upper, "middle": middle, "lower": lower}, 0)
+	if want := indicators.Zone(klines[len(klines)-1].Close, middle[0], upper[0], lower[0]); result.Bollinger.Zone != want {
+		t.Fatalf("布林线分区 %d，期望 %d", result.Bollinger.Zone, want)
+	}
+	_, envMiddle, _ := indicators.CalculateEnvelopeWithMA(result.Price, 24, 1.5, indicators.MASMA)
+	assertSameSeries(t, "envelope", map[string][]float64{"middle": result.Envelope.Middle}, map[string][]float64{"middle": envMiddle}, 0)
+
+	wide, ok := result.Custom["bollinger_wide"]
+	if !ok {
+		t.Fatalf("第二个布林线实例应在 custom 中: %v", result.Custom)
+	}
+	_, wideMiddle, _ := indicators.CalculateBollingerWithMA(result.Price, 40, 3, indicators.MAWMA)
+	assertSameSeries(t, "bollinger_wide", map[string][]float64{"middle": wide["middle"]}, map[string][]float64{"middle": wideMiddle}, 0)
+	if result.Names["bollinger_wide"] != "宽布林" {
+		t.Fatalf("显示名称不正确: %v", result.Names)
+	}
+}
+
+func TestGetIndicatorsScalesPeriods(t *testing.T) {
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", testKlines(300))
+	provider.SetKlines("TESTUSDT", "15m", testKlines(300))
+
+	svc := NewIndicatorService(provider, 0)
+	svc.SetConfigSource(staticConfig{Indicators: []types.IndicatorInstance{
+		{Type: "cci", Params: map[string]float64{"period": 12}},
+	}})
+
+	result, err := svc.GetIndicators(context.Background(), "TESTUSDT", "15m", 300)
+	if err != nil {
+		t.Fatalf("获取指标失败: %v", err)
+	}
+	// 配置周期基于小时，15m K线上按 12*4=48 根计算，结果key仍为配置的实例key
+	want := indicators.CalculateCCIWithMA(result.Price, 48, indicators.MAWMA)
+	assertSameSeries(t, "cci", result.CCI, map[string][]float64{"12": want}, 0)
+}
+
+func keys(m map[string][]float64) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
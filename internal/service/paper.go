@@ -0,0 +1,621 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+const (
+	defaultPaperInterval       = "1m"
+	defaultPaperInitialBalance = 10000
+	defaultPaperMakerFee       = 0.0002
+	defaultPaperTakerFee       = 0.0004
+	// paperPriceWait 下单时等待首个实时价格的最长时间
+	paperPriceWait = 10 * time.Second
+	// paperPushInterval 只有价格变化时账户快照的最小推送间隔
+	paperPushInterval = time.Second
+	// paperQtyEpsilon 视为零持仓的数量
+	paperQtyEpsilon = 1e-12
+)
+
+// PaperRepository 模拟交易仓库接口（单账户）
+type PaperRepository interface {
+	// LoadAccount 读取账户，不存在时返回nil
+	LoadAccount(ctx context.Context) (*types.PaperAccount, error)
+	SaveAccount(ctx context.Context, account *types.PaperAccount) error
+	ListPositions(ctx context.Context) ([]types.PaperPosition, error)
+	// SavePosition 保存持仓，数量为0时删除
+	SavePosition(ctx context.Context, position *types.PaperPosition) error
+	// SaveOrder 保存订单（ID为0时新增并回填ID）
+	SaveOrder(ctx context.Context, order *types.PaperOrder) error
+	// ListOrders 获取订单（按ID倒序），symbol为空时返回全部，openOnly 时只返回未成交订单
+	ListOrders(ctx context.Context, symbol string, openOnly bool, limit int) ([]types.PaperOrder, error)
+	SaveTrade(ctx context.Context, trade *types.PaperTrade) error
+	ListTrades(ctx context.Context, symbol string, limit int) ([]types.PaperTrade, error)
+	// Reset 清空账户、持仓、订单和成交
+	Reset(ctx context.Context) error
+}
+
+// PaperOptions 模拟交易配置
+type PaperOptions struct {
+	Interval       string  // 订阅的实时流周期（只使用最新价格），默认1m
+	InitialBalance float64 // 初始资金（USDT），默认10000
+	MakerFee       float64 // 挂单成交手续费率，默认0.0002
+	TakerFee       float64 // 吃单成交手续费率，默认0.0004
+	Leverage       float64 // 杠杆倍数，默认1
+}
+
+// PaperTradingService 模拟交易服务
+// 订阅有持仓或挂单的交易对的实时流，按最新价格撮合限价单和止损单、计算浮动盈亏和保证金；
+// 账户、持仓、订单和成交写入仓库，账户变化推送给订阅者（WebSocket）
+type PaperTradingService struct {
+	ctx         context.Context
+	hub         *RealtimeHub
+	repo        PaperRepository
+	opts        PaperOptions
+	mu          sync.Mutex
+	account     types.PaperAccount
+	positions   map[string]*types.PaperPosition
+	orders      map[int64]*types.PaperOrder // 未成交订单
+	prices      map[string]float64
+	watchers    map[string]context.CancelFunc
+	lastPush    time.Time
+	subscribers map[chan *types.PaperAccountSnapshot]bool
+	subMu       sync.RWMutex
+}
+
+// NewPaperTradingService 创建模拟交易服务，repo为nil时状态只保存在内存中
+func NewPaperTradingService(hub *RealtimeHub, repo PaperRepository, opts PaperOptions) (*PaperTradingService, error) {
+	if repo == nil {
+		repo = newMemoryPaperRepository()
+	}
+	if opts.Interval == "" {
+		opts.Interval = defaultPaperInterval
+	}
+	if _, err := types.IntervalToMinutes(opts.Interval); err != nil {
+		return nil, err
+	}
+	if opts.InitialBalance <= 0 {
+		opts.InitialBalance = defaultPaperInitialBalance
+	}
+	if opts.MakerFee < 0 || opts.TakerFee < 0 {
+		return nil, fmt.Errorf("手续费率不能为负数")
+	}
+	if opts.MakerFee == 0 && opts.TakerFee == 0 {
+		opts.MakerFee = defaultPaperMakerFee
+		opts.TakerFee = defaultPaperTakerFee
+	}
+	if opts.Leverage <= 0 {
+		opts.Leverage = 1
+	}
+
+	return &PaperTradingService{
+		hub:         hub,
+		repo:        repo,
+		opts:        opts,
+		positions:   make(map[string]*types.PaperPosition),
+		orders:      make(map[int64]*types.PaperOrder),
+		prices:      make(map[string]float64),
+		watchers:    make(map[string]context.CancelFunc),
+		subscribers: make(map[chan *types.PaperAccountSnapshot]bool),
+	}, nil
+}
+
+// Start 加载账户、持仓和未成交订单，并订阅相关实时流
+func (s *PaperTradingService) Start(ctx context.Context) error {
+	account, err := s.repo.LoadAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("加载模拟账户失败: %w", err)
+	}
+	positions, err := s.repo.ListPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("加载模拟持仓失败: %w", err)
+	}
+	orders, err := s.repo.ListOrders(ctx, "", true, 0)
+	if err != nil {
+		return fmt.Errorf("加载模拟订单失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	if account == nil {
+		account = s.newAccount()
+		if err := s.repo.SaveAccount(ctx, account); err != nil {
+			return err
+		}
+	}
+	s.account = *account
+	for i := range positions {
+		p := positions[i]
+		s.positions[p.Symbol] = &p
+	}
+	for i := range orders {
+		o := orders[i]
+		s.orders[o.ID] = &o
+	}
+	s.syncWatchersLocked()
+
+	log.Printf("模拟交易已启动，余额 %.2f，持仓 %d 个，挂单 %d 个", s.account.Balance, len(s.positions), len(s.orders))
+	return nil
+}
+
+// newAccount 创建初始账户
+func (s *PaperTradingService) newAccount() *types.PaperAccount {
+	return &types.PaperAccount{
+		InitialBalance: s.opts.InitialBalance,
+		Balance:        s.opts.InitialBalance,
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// PlaceOrder 下单：市价单立即按最新价格成交；限价单可立即成交时按最新价格吃单成交，否则挂单；
+// 止损单在触发价已被触及时拒绝，否则挂单
+func (s *PaperTradingService) PlaceOrder(ctx context.Context, req types.OrderRequest) (*types.PaperOrder, error) {
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.ctx == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("模拟交易服务未启动")
+	}
+	s.ensureWatcherLocked(req.Symbol)
+	price := s.prices[req.Symbol]
+	s.mu.Unlock()
+
+	if price <= 0 {
+		var err error
+		if price, err = s.waitPrice(ctx, req.Symbol); err != nil {
+			s.mu.Lock()
+			s.syncWatchersLocked()
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	if s.prices[req.Symbol] <= 0 {
+		s.prices[req.Symbol] = price
+	}
+	price = s.prices[req.Symbol]
+
+	now := time.Now()
+	order := &types.PaperOrder{OrderRequest: req, Status: types.OrderStatusNew, CreatedAt: now, UpdatedAt: now}
+	if err := s.repo.SaveOrder(ctx, order); err != nil {
+		s.syncWatchersLocked()
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	switch {
+	case req.Type == types.OrderTypeMarket || (req.Type == types.OrderTypeLimit && limitCrosses(req, price)):
+		s.fillLocked(order, price, types.LiquidityTaker)
+	case req.Type == types.OrderTypeStop && stopTriggered(req, price):
+		s.rejectLocked(order, fmt.Sprintf("触发价 %v 已被最新价格 %v 触及", req.StopPrice, price))
+	default:
+		s.orders[order.ID] = order
+	}
+	s.syncWatchersLocked()
+	result := *order
+	snapshot := s.snapshotLocked()
+	s.mu.Unlock()
+
+	s.publish(snapshot)
+	return &result, nil
+}
+
+// waitPrice 临时订阅实时流，等待首次推送的最新价格
+func (s *PaperTradingService) waitPrice(ctx context.Context, symbol string) (float64, error) {
+	sub, err := s.hub.Subscribe(types.Symbol(symbol), s.opts.Interval, PolicyCoalesce)
+	if err != nil {
+		return 0, fmt.Errorf("获取 %s 实时价格失败: %w", symbol, err)
+	}
+	defer s.hub.Unsubscribe(sub)
+
+	timer := time.NewTimer(paperPriceWait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-timer.C:
+			return 0, fmt.Errorf("等待 %s 实时价格超时", symbol)
+		case data, ok := <-sub.C():
+			if !ok {
+				return 0, fmt.Errorf("%s 实时流已关闭", symbol)
+			}
+			if data.Price > 0 {
+				return data.Price, nil
+			}
+		}
+	}
+}
+
+// limitCrosses 限价单在最新价格下是否可以立即成交
+func limitCrosses(req types.OrderRequest, price float64) bool {
+	if req.Side == types.OrderSideBuy {
+		return price <= req.Price
+	}
+	return price >= req.Price
+}
+
+// stopTriggered 止损单在最新价格下是否已触发
+func stopTriggered(req types.OrderRequest, price float64) bool {
+	if req.Side == types.OrderSideBuy {
+		return price >= req.StopPrice
+	}
+	return price <= req.StopPrice
+}
+
+// CancelOrder 撤销未成交订单
+func (s *PaperTradingService) CancelOrder(ctx context.Context, id int64) (*types.PaperOrder, error) {
+	s.mu.Lock()
+	order, ok := s.orders[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("未成交订单 %d 不存在", id)
+	}
+	order.Status = types.OrderStatusCanceled
+	order.UpdatedAt = time.Now()
+	delete(s.orders, id)
+	if err := s.repo.SaveOrder(ctx, order); err != nil {
+		log.Printf("保存模拟订单失败: %v", err)
+	}
+	s.syncWatchersLocked()
+	result := *order
+	snapshot := s.snapshotLocked()
+	s.mu.Unlock()
+
+	s.publish(snapshot)
+	return &result, nil
+}
+
+// Account 获取账户快照
+func (s *PaperTradingService) Account() *types.PaperAccountSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+// Orders 获取订单（按ID倒序）
+func (s *PaperTradingService) Orders(ctx context.Context, symbol string, openOnly bool, limit int) ([]types.PaperOrder, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return s.repo.ListOrders(ctx, symbol, openOnly, limit)
+}
+
+// Trades 获取成交记录（按时间倒序）
+func (s *PaperTradingService) Trades(ctx context.Context, symbol string, limit int) ([]types.PaperTrade, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return s.repo.ListTrades(ctx, symbol, limit)
+}
+
+// Reset 清空持仓、订单和成交，余额恢复为初始资金
+func (s *PaperTradingService) Reset(ctx context.Context) (*types.PaperAccountSnapshot, error) {
+	s.mu.Lock()
+	if err := s.repo.Reset(ctx); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.account = *s.newAccount()
+	if err := s.repo.SaveAccount(ctx, &s.account); err != nil {
+		log.Printf("保存模拟账户失败: %v", err)
+	}
+	s.positions = make(map[string]*types.PaperPosition)
+	s.orders = make(map[int64]*types.PaperOrder)
+	s.syncWatchersLocked()
+	snapshot := s.snapshotLocked()
+	s.mu.Unlock()
+
+	s.publish(snapshot)
+	return snapshot, nil
+}
+
+// onData 处理实时数据：更新最新价格并撮合该交易对的挂单
+func (s *PaperTradingService) onData(data *RealtimeData) {
+	if data == nil || data.Price <= 0 {
+		return
+	}
+	symbol := data.Symbol
+	price := data.Price
+
+	s.mu.Lock()
+	s.prices[symbol] = price
+
+	// 按下单顺序撮合
+	var ids []int64
+	for id, order := range s.orders {
+		if order.Symbol == symbol {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	changed := false
+	for _, id := range ids {
+		order := s.orders[id]
+		switch {
+		case order.Type == types.OrderTypeLimit && limitCrosses(order.OrderRequest, price):
+			// 挂单成交按委托价格
+			s.fillLocked(order, order.Price, types.LiquidityMaker)
+		case order.Type == types.OrderTypeStop && stopTriggered(order.OrderRequest, price):
+			s.fillLocked(order, price, types.LiquidityTaker)
+		default:
+			continue
+		}
+		delete(s.orders, id)
+		changed = true
+	}
+	if changed {
+		s.syncWatchersLocked()
+	}
+
+	_, hasPosition := s.positions[symbol]
+	if !changed && (!hasPosition || time.Since(s.lastPush) < paperPushInterval) {
+		s.mu.Unlock()
+		return
+	}
+	snapshot := s.snapshotLocked()
+	s.mu.Unlock()
+
+	s.publish(snapshot)
+}
+
+// fillLocked 按价格成交订单，保证金不足或只减仓订单没有可减少的持仓时拒绝（调用方需持有s.mu）
+func (s *PaperTradingService) fillLocked(order *types.PaperOrder, price float64, liquidity string) {
+	current := types.PaperPosition{Symbol: order.Symbol}
+	if p, ok := s.positions[order.Symbol]; ok {
+		current = *p
+	}
+
+	qty := order.Quantity
+	if order.ReduceOnly {
+		// 只减仓：方向必须与持仓相反，数量不超过持仓
+		if current.Quantity*order.SideSign() >= 0 {
+			s.rejectLocked(order, "只减仓订单没有可减少的持仓")
+			return
+		}
+		qty = math.Min(qty, math.Abs(current.Quantity))
+	}
+
+	rate := s.opts.TakerFee
+	if liquidity == types.LiquidityMaker {
+		rate = s.opts.MakerFee
+	}
+	fee := qty * price * rate
+	next, realized := applyPaperFill(current, qty*order.SideSign(), price)
+
+	// 增加敞口时检查保证金：成交后的占用保证金不能超过权益
+	if math.Abs(next.Quantity) > math.Abs(current.Quantity) {
+		balance := s.account.Balance + realized - fee
+		equity, used := balance, 0.0
+		for symbol, p := range s.positions {
+			if symbol == order.Symbol {
+				continue
+			}
+			mark := s.markPrice(p)
+			equity += p.Quantity * (mark - p.EntryPrice)
+			used += math.Abs(p.Quantity) * mark / s.opts.Leverage
+		}
+		equity += next.Quantity * (price - next.EntryPrice)
+		used += math.Abs(next.Quantity) * price / s.opts.Leverage
+		if used > equity {
+			s.rejectLocked(order, fmt.Sprintf("保证金不足：需要 %.2f，权益 %.2f", used, equity))
+			return
+		}
+	}
+
+	now := time.Now()
+	s.account.Balance += realized - fee
+	s.account.RealizedPnL += realized
+	s.account.Fees += fee
+	s.account.UpdatedAt = now
+
+	next.UpdatedAt = now
+	if next.Quantity == 0 {
+		delete(s.positions, order.Symbol)
+	} else {
+		s.positions[order.Symbol] = &next
+	}
+
+	order.Status = types.OrderStatusFilled
+	order.FillPrice = price
+	order.FilledQty = qty
+	order.Fee = fee
+	order.UpdatedAt = now
+
+	trade := &types.PaperTrade{
+		OrderID:     order.ID,
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		Quantity:    qty,
+		Price:       price,
+		Fee:         fee,
+		Liquidity:   liquidity,
+		RealizedPnL: realized,
+		Time:        now,
+	}
+
+	ctx := context.Background()
+	if err := s.repo.SaveOrder(ctx, order); err != nil {
+		log.Printf("保存模拟订单失败: %v", err)
+	}
+	if err := s.repo.SaveTrade(ctx, trade); err != nil {
+		log.Printf("保存模拟成交失败: %v", err)
+	}
+	if err := s.repo.SavePosition(ctx, &next); err != nil {
+		log.Printf("保存模拟持仓失败: %v", err)
+	}
+	if err := s.repo.SaveAccount(ctx, &s.account); err != nil {
+		log.Printf("保存模拟账户失败: %v", err)
+	}
+	log.Printf("模拟成交: %s %s %v @ %v（%s，手续费 %.4f，已实现盈亏 %.4f）",
+		order.Symbol, order.Side, qty, price, liquidity, fee, realized)
+}
+
+// rejectLocked 拒绝订单（调用方需持有s.mu）
+func (s *PaperTradingService) rejectLocked(order *types.PaperOrder, reason string) {
+	order.Status = types.OrderStatusRejected
+	order.Reason = reason
+	order.UpdatedAt = time.Now()
+	if err := s.repo.SaveOrder(context.Background(), order); err != nil {
+		log.Printf("保存模拟订单失败: %v", err)
+	}
+}
+
+// applyPaperFill 把一笔成交（数量带方向）计入持仓，返回新持仓和本次已实现盈亏
+func applyPaperFill(pos types.PaperPosition, qty, price float64) (types.PaperPosition, float64) {
+	realized := 0.0
+	q := pos.Quantity
+	if q == 0 || (q > 0) == (qty > 0) {
+		// 开仓或加仓：重新计算均价
+		total := q + qty
+		pos.EntryPrice = (math.Abs(q)*pos.EntryPrice + math.Abs(qty)*price) / math.Abs(total)
+		pos.Quantity = total
+	} else {
+		// 减仓、平仓或反手：平掉的部分按均价计算已实现盈亏，反手部分以成交价开仓
+		closing := math.Min(math.Abs(q), math.Abs(qty))
+		if q > 0 {
+			realized = closing * (price - pos.EntryPrice)
+		} else {
+			realized = closing * (pos.EntryPrice - price)
+		}
+		pos.Quantity = q + qty
+		if math.Abs(pos.Quantity) < paperQtyEpsilon {
+			pos.Quantity = 0
+			pos.EntryPrice = 0
+		} else if (pos.Quantity > 0) != (q > 0) {
+			pos.EntryPrice = price
+		}
+	}
+	pos.RealizedPnL += realized
+	return pos, realized
+}
+
+// markPrice 持仓的最新价格，尚无实时价格时使用开仓均价
+func (s *PaperTradingService) markPrice(p *types.PaperPosition) float64 {
+	if price := s.prices[p.Symbol]; price > 0 {
+		return price
+	}
+	return p.EntryPrice
+}
+
+// snapshotLocked 生成账户快照（调用方需持有s.mu）
+func (s *PaperTradingService) snapshotLocked() *types.PaperAccountSnapshot {
+	now := time.Now()
+	s.lastPush = now
+	snapshot := &types.PaperAccountSnapshot{
+		PaperAccount: s.account,
+		Leverage:     s.opts.Leverage,
+		Positions:    []types.PaperPosition{},
+		OpenOrders:   []types.PaperOrder{},
+		Time:         now,
+	}
+
+	for _, p := range s.positions {
+		pos := *p
+		pos.MarkPrice = s.markPrice(p)
+		pos.UnrealizedPnL = pos.Quantity * (pos.MarkPrice - pos.EntryPrice)
+		pos.Margin = math.Abs(pos.Quantity) * pos.MarkPrice / s.opts.Leverage
+		snapshot.UnrealizedPnL += pos.UnrealizedPnL
+		snapshot.UsedMargin += pos.Margin
+		snapshot.Positions = append(snapshot.Positions, pos)
+	}
+	sort.Slice(snapshot.Positions, func(i, j int) bool {
+		return snapshot.Positions[i].Symbol < snapshot.Positions[j].Symbol
+	})
+	for _, o := range s.orders {
+		snapshot.OpenOrders = append(snapshot.OpenOrders, *o)
+	}
+	sort.Slice(snapshot.OpenOrders, func(i, j int) bool {
+		return snapshot.OpenOrders[i].ID < snapshot.OpenOrders[j].ID
+	})
+
+	snapshot.Equity = s.account.Balance + snapshot.UnrealizedPnL
+	snapshot.AvailableMargin = snapshot.Equity - snapshot.UsedMargin
+	return snapshot
+}
+
+// ensureWatcherLocked 确保订阅了交易对的实时流（调用方需持有s.mu）
+func (s *PaperTradingService) ensureWatcherLocked(symbol string) {
+	if _, ok := s.watchers[symbol]; ok || s.ctx == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.watchers[symbol] = cancel
+	key := StreamKey{Symbol: types.Symbol(symbol), Interval: s.opts.Interval}
+	// 撮合只需要最新价格，积压时合并为最新一条
+	go s.hub.Watch(ctx, key, PolicyCoalesce, "模拟交易", s.onData)
+}
+
+// syncWatchersLocked 只保留有持仓或挂单的交易对的实时流订阅（调用方需持有s.mu）
+func (s *PaperTradingService) syncWatchersLocked() {
+	wanted := make(map[string]bool)
+	for symbol := range s.positions {
+		wanted[symbol] = true
+	}
+	for _, order := range s.orders {
+		wanted[order.Symbol] = true
+	}
+
+	for symbol, cancel := range s.watchers {
+		if !wanted[symbol] {
+			cancel()
+			delete(s.watchers, symbol)
+			delete(s.prices, symbol)
+		}
+	}
+	for symbol := range wanted {
+		s.ensureWatcherLocked(symbol)
+	}
+}
+
+// Subscribe 订阅账户快照
+func (s *PaperTradingService) Subscribe() <-chan *types.PaperAccountSnapshot {
+	ch := make(chan *types.PaperAccountSnapshot, 16)
+	s.subMu.Lock()
+	s.subscribers[ch] = true
+	s.subMu.Unlock()
+	return ch
+}
+
+// Unsubscribe 取消订阅账户快照
+func (s *PaperTradingService) Unsubscribe(ch <-chan *types.PaperAccountSnapshot) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for sub := range s.subscribers {
+		if (<-chan *types.PaperAccountSnapshot)(sub) == ch {
+			delete(s.subscribers, sub)
+			close(sub)
+			return
+		}
+	}
+}
+
+// publish 推送账户快照
+func (s *PaperTradingService) publish(snapshot *types.PaperAccountSnapshot) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- snapshot:
+		default:
+			// 通道满了，跳过（之后的快照包含完整状态）
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// maxMemoryPaperRecords 内存仓库保留的最大已结束订单和成交数量
+const maxMemoryPaperRecords = 1000
+
+// memoryPaperRepository 内存模拟交易仓库（MySQL不可用时使用，重启后丢失）
+type memoryPaperRepository struct {
+	mu          sync.Mutex
+	account     *types.PaperAccount
+	positions   map[string]types.PaperPosition
+	orders      map[int64]types.PaperOrder
+	trades      []types.PaperTrade
+	nextOrderID int64
+	nextTradeID int64
+}
+
+// newMemoryPaperRepository 创建内存模拟交易仓库
+func newMemoryPaperRepository() *memoryPaperRepository {
+	return &memoryPaperRepository{
+		positions: make(map[string]types.PaperPosition),
+		orders:    make(map[int64]types.PaperOrder),
+	}
+}
+
+func (m *memoryPaperRepository) LoadAccount(ctx context.Context) (*types.PaperAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.account == nil {
+		return nil, nil
+	}
+	account := *m.account
+	return &account, nil
+}
+
+func (m *memoryPaperRepository) SaveAccount(ctx context.Context, account *types.PaperAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *account
+	m.account = &saved
+	return nil
+}
+
+func (m *memoryPaperRepository) ListPositions(ctx context.Context) ([]types.PaperPosition, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	positions := make([]types.PaperPosition, 0, len(m.positions))
+	for _, p := range m.positions {
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions, nil
+}
+
+func (m *memoryPaperRepository) SavePosition(ctx context.Context, position *types.PaperPosition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if position.Quantity == 0 {
+		delete(m.positions, position.Symbol)
+		return nil
+	}
+	m.positions[position.Symbol] = *position
+	return nil
+}
+
+func (m *memoryPaperRepository) SaveOrder(ctx context.Context, order *types.PaperOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if order.ID == 0 {
+		m.nextOrderID++
+		order.ID = m.nextOrderID
+	} else if _, ok := m.orders[order.ID]; !ok {
+		return fmt.Errorf("模拟订单 %d 不存在", order.ID)
+	}
+	m.orders[order.ID] = *order
+
+	// 超出上限时删除最早的已结束订单
+	if len(m.orders) > maxMemoryPaperRecords {
+		var oldest int64
+		for id, o := range m.orders {
+			if o.Status != types.OrderStatusNew && (oldest == 0 || id < oldest) {
+				oldest = id
+			}
+		}
+		delete(m.orders, oldest)
+	}
+	return nil
+}
+
+func (m *memoryPaperRepository) ListOrders(ctx context.Context, symbol string, openOnly bool, limit int) ([]types.PaperOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	orders := []types.PaperOrder{}
+	for _, o := range m.orders {
+		if (symbol == "" || o.Symbol == symbol) && (!openOnly || o.Status == types.OrderStatusNew) {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	if limit > 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (m *memoryPaperRepository) SaveTrade(ctx context.Context, trade *types.PaperTrade) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextTradeID++
+	trade.ID = m.nextTradeID
+	m.trades = append(m.trades, *trade)
+	if len(m.trades) > maxMemoryPaperRecords {
+		m.trades = m.trades[len(m.trades)-maxMemoryPaperRecords:]
+	}
+	return nil
+}
+
+func (m *memoryPaperRepository) ListTrades(ctx context.Context, symbol string, limit int) ([]types.PaperTrade, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trades := []types.PaperTrade{}
+	for i := len(m.trades) - 1; i >= 0 && len(trades) < limit; i-- {
+		if symbol == "" || m.trades[i].Symbol == symbol {
+			trades = append(trades, m.trades[i])
+		}
+	}
+	return trades, nil
+}
+
+func (m *memoryPaperRepository) Reset(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.account = nil
+	m.positions = make(map[string]types.PaperPosition)
+	m.orders = make(map[int64]types.PaperOrder)
+	m.trades = nil
+	return nil
+}
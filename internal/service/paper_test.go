@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+func TestApplyPaperFill(t *testing.T) {
+	tests := []struct {
+		name         string
+		pos          types.PaperPosition
+		qty, price   float64
+		wantQty      float64
+		wantEntry    float64
+		wantRealized float64
+	}{
+		{name: "开仓", qty: 2, price: 100, wantQty: 2, wantEntry: 100},
+		{name: "加多仓按数量加权均价", pos: types.PaperPosition{Quantity: 1, EntryPrice: 100}, qty: 3, price: 120, wantQty: 4, wantEntry: 115},
+		{name: "加空仓按数量加权均价", pos: types.PaperPosition{Quantity: -1, EntryPrice: 100}, qty: -1, price: 90, wantQty: -2, wantEntry: 95},
+		{name: "多仓部分平仓均价不变", pos: types.PaperPosition{Quantity: 2, EntryPrice: 100}, qty: -0.5, price: 110, wantQty: 1.5, wantEntry: 100, wantRealized: 5},
+		{name: "空仓部分平仓", pos: types.PaperPosition{Quantity: -2, EntryPrice: 100}, qty: 1, price: 90, wantQty: -1, wantEntry: 100, wantRealized: 10},
+		{name: "全部平仓", pos: types.PaperPosition{Quantity: 2, EntryPrice: 100}, qty: -2, price: 95, wantQty: 0, wantEntry: 0, wantRealized: -10},
+		{name: "浮点误差内视为平仓", pos: types.PaperPosition{Quantity: 0.3, EntryPrice: 100}, qty: -0.1 - 0.2, price: 100, wantQty: 0, wantEntry: 0},
+		// 反手：平掉的部分按均价计算盈亏，剩余部分以成交价开仓
+		{name: "多翻空", pos: types.PaperPosition{Quantity: 1, EntryPrice: 100}, qty: -3, price: 110, wantQty: -2, wantEntry: 110, wantRealized: 10},
+		{name: "空翻多", pos: types.PaperPosition{Quantity: -1, EntryPrice: 100}, qty: 2, price: 110, wantQty: 1, wantEntry: 110, wantRealized: -10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pos.RealizedPnL = 1
+			got, realized := applyPaperFill(tt.pos, tt.qty, tt.price)
+			if math.Abs(got.Quantity-tt.wantQty) > 1e-9 || math.Abs(got.EntryPrice-tt.wantEntry) > 1e-9 || math.Abs(realized-tt.wantRealized) > 1e-9 {
+				t.Fatalf("持仓 %v @ %v、已实现 %v，期望 %v @ %v、%v", got.Quantity, got.EntryPrice, realized, tt.wantQty, tt.wantEntry, tt.wantRealized)
+			}
+			if math.Abs(got.RealizedPnL-(1+tt.wantRealized)) > 1e-9 {
+				t.Fatalf("累计已实现盈亏 %v，期望 %v", got.RealizedPnL, 1+tt.wantRealized)
+			}
+		})
+	}
+}
+
+// paperKlines 最新收盘价为100的1h K线
+func paperKlines() []types.Kline {
+	klines := testKlines(300)
+	last := &klines[len(klines)-1]
+	last.Close = 100
+	last.High = math.Max(last.High, 100)
+	last.Low = math.Min(last.Low, 100)
+	return klines
+}
+
+// startPaper 启动使用内存行情的模拟交易服务（杠杆1倍，余额10000，挂单手续费0.001，吃单0.002）
+func startPaper(t *testing.T, provider *exchange.MemoryProvider) *PaperTradingService {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	hub := NewRealtimeHub(ctx, provider, nil, nil)
+	s, err := NewPaperTradingService(hub, nil, PaperOptions{Interval: "1h", InitialBalance: 10000, MakerFee: 0.001, TakerFee: 0.002})
+	if err != nil {
+		t.Fatalf("创建模拟交易服务失败: %v", err)
+	}
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("启动模拟交易服务失败: %v", err)
+	}
+	return s
+}
+
+// pushPaperPrice 推送正在形成的K线的新价格
+func pushPaperPrice(provider *exchange.MemoryProvider, klines []types.Kline, price float64) {
+	forming := klines[len(klines)-1]
+	forming.Close = price
+	forming.High = math.Max(forming.High, price)
+	forming.Low = math.Min(forming.Low, price)
+	provider.Push("TESTUSDT", "1h", exchange.KlineUpdate{Kline: forming})
+}
+
+// waitPaper 等待账户快照满足条件
+func waitPaper(t *testing.T, s *PaperTradingService, cond func(*types.PaperAccountSnapshot) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond(s.Account()) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("等待模拟账户状态超时")
+}
+
+// setPaperPrice 推送新价格，等待模拟交易服务收到（需有持仓或挂单）
+func setPaperPrice(t *testing.T, s *PaperTradingService, provider *exchange.MemoryProvider, klines []types.Kline, price float64) {
+	t.Helper()
+	pushPaperPrice(provider, klines, price)
+	waitPaper(t, s, func(*types.PaperAccountSnapshot) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.prices["TESTUSDT"] == price
+	})
+}
+
+// placePaper 下单并检查订单状态
+func placePaper(t *testing.T, s *PaperTradingService, req types.OrderRequest, status string) *types.PaperOrder {
+	t.Helper()
+	req.Symbol = "TESTUSDT"
+	if req.Type == "" {
+		req.Type = types.OrderTypeMarket
+	}
+	order, err := s.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if order.Status != status {
+		t.Fatalf("订单状态 %s（%s），期望 %s", order.Status, order.Reason, status)
+	}
+	return order
+}
+
+// assertPaperPosition 检查持仓数量和均价
+func assertPaperPosition(t *testing.T, s *PaperTradingService, qty, entry float64) {
+	t.Helper()
+	var got types.PaperPosition
+	for _, p := range s.Account().Positions {
+		if p.Symbol == "TESTUSDT" {
+			got = p
+		}
+	}
+	if math.Abs(got.Quantity-qty) > 1e-9 || math.Abs(got.EntryPrice-entry) > 1e-9 {
+		t.Fatalf("持仓 %v @ %v，期望 %v @ %v", got.Quantity, got.EntryPrice, qty, entry)
+	}
+}
+
+// assertPaperBalance 检查余额、累计已实现盈亏和累计手续费
+func assertPaperBalance(t *testing.T, s *PaperTradingService, realized, fees float64) {
+	t.Helper()
+	account := s.Account()
+	if math.Abs(account.RealizedPnL-realized) > 1e-9 || math.Abs(account.Fees-fees) > 1e-9 ||
+		math.Abs(account.Balance-(10000+realized-fees)) > 1e-9 {
+		t.Fatalf("余额 %v、已实现 %v、手续费 %v，期望已实现 %v、手续费 %v", account.Balance, account.RealizedPnL, account.Fees, realized, fees)
+	}
+}
+
+func TestPaperMarketOrders(t *testing.T) {
+	klines := paperKlines()
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+	s := startPaper(t, provider)
+
+	// 首次下单时还没有价格，等待实时流的首次推送后按最新价格吃单成交
+	order := placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 1}, types.OrderStatusFilled)
+	if order.FillPrice != 100 || math.Abs(order.Fee-0.2) > 1e-9 {
+		t.Fatalf("成交价 %v、手续费 %v，期望 100、0.2", order.FillPrice, order.Fee)
+	}
+	assertPaperPosition(t, s, 1, 100)
+
+	// 加仓：按数量加权重新计算均价
+	setPaperPrice(t, s, provider, klines, 130)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 2}, types.OrderStatusFilled)
+	assertPaperPosition(t, s, 3, 120)
+	fees := 0.2 + 2*130*0.002
+	assertPaperBalance(t, s, 0, fees)
+
+	// 部分平仓：按均价计算已实现盈亏，均价不变
+	setPaperPrice(t, s, provider, klines, 140)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Quantity: 1}, types.OrderStatusFilled)
+	assertPaperPosition(t, s, 2, 120)
+	fees += 140 * 0.002
+	assertPaperBalance(t, s, 20, fees)
+
+	// 反手：平掉2个（已实现 2×(110-120)），剩余1个以成交价开空
+	setPaperPrice(t, s, provider, klines, 110)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Quantity: 3}, types.OrderStatusFilled)
+	assertPaperPosition(t, s, -1, 110)
+	fees += 3 * 110 * 0.002
+	assertPaperBalance(t, s, 0, fees)
+
+	// 只减仓：数量截断到持仓，平仓后没有持仓时拒绝
+	order = placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 5, ReduceOnly: true}, types.OrderStatusFilled)
+	if order.FilledQty != 1 {
+		t.Fatalf("只减仓成交数量 %v，期望 1", order.FilledQty)
+	}
+	assertPaperPosition(t, s, 0, 0)
+	fees += 110 * 0.002
+	assertPaperBalance(t, s, 0, fees)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Quantity: 1, ReduceOnly: true}, types.OrderStatusRejected)
+
+	// 同向的只减仓订单被拒绝
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 1}, types.OrderStatusFilled)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 1, ReduceOnly: true}, types.OrderStatusRejected)
+	assertPaperPosition(t, s, 1, 110)
+}
+
+func TestPaperMarginCheck(t *testing.T) {
+	klines := paperKlines()
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+	s := startPaper(t, provider)
+
+	// 1倍杠杆：名义价值不能超过权益（含本次手续费）
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 100}, types.OrderStatusRejected)
+	assertPaperPosition(t, s, 0, 0)
+	assertPaperBalance(t, s, 0, 0)
+
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 90}, types.OrderStatusFilled)
+	fees := 90 * 100 * 0.002
+	// 继续加仓超过权益时拒绝，余额和持仓不变
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Quantity: 10}, types.OrderStatusRejected)
+	assertPaperPosition(t, s, 90, 100)
+	assertPaperBalance(t, s, 0, fees)
+
+	// 减少敞口不检查保证金：价格下跌后权益不足以覆盖占用保证金，仍可减仓
+	setPaperPrice(t, s, provider, klines, 50)
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Quantity: 30}, types.OrderStatusFilled)
+	assertPaperPosition(t, s, 60, 100)
+	// 反手后空仓的占用保证金超过权益时拒绝
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Quantity: 300}, types.OrderStatusRejected)
+	assertPaperPosition(t, s, 60, 100)
+}
+
+func TestPaperLimitAndStopOrders(t *testing.T) {
+	klines := paperKlines()
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+	s := startPaper(t, provider)
+
+	// 限价单可立即成交时按最新价格吃单成交
+	order := placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Price: 105, Quantity: 1}, types.OrderStatusFilled)
+	if order.FillPrice != 100 || math.Abs(order.Fee-100*0.002) > 1e-9 {
+		t.Fatalf("成交价 %v、手续费 %v，期望按最新价格100吃单成交", order.FillPrice, order.Fee)
+	}
+	fees := 100 * 0.002
+
+	// 未触及的限价单和止损单挂单
+	limit := placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Type: types.OrderTypeLimit, Price: 90, Quantity: 1}, types.OrderStatusNew)
+	stop := placePaper(t, s, types.OrderRequest{Side: types.OrderSideSell, Type: types.OrderTypeStop, StopPrice: 80, Quantity: 2, ReduceOnly: true}, types.OrderStatusNew)
+	// 触发价已被触及的止损单拒绝
+	placePaper(t, s, types.OrderRequest{Side: types.OrderSideBuy, Type: types.OrderTypeStop, StopPrice: 95, Quantity: 1}, types.OrderStatusRejected)
+	if open := s.Account().OpenOrders; len(open) != 2 {
+		t.Fatalf("挂单数量 %d，期望 2", len(open))
+	}
+
+	// 价格跌破限价：按委托价格挂单成交（挂单手续费）
+	setPaperPrice(t, s, provider, klines, 89)
+	assertPaperPosition(t, s, 2, 95)
+	fees += 90 * 0.001
+	assertPaperBalance(t, s, 0, fees)
+
+	// 价格跌破触发价：止损单按最新价格吃单成交（之后没有持仓和挂单，不再订阅价格）
+	pushPaperPrice(provider, klines, 79)
+	waitPaper(t, s, func(a *types.PaperAccountSnapshot) bool { return len(a.OpenOrders) == 0 })
+	assertPaperPosition(t, s, 0, 0)
+	fees += 2 * 79 * 0.002
+	assertPaperBalance(t, s, 2*(79-95), fees)
+
+	orders, err := s.Orders(context.Background(), "TESTUSDT", false, 0)
+	if err != nil {
+		t.Fatalf("获取订单失败: %v", err)
+	}
+	for _, o := range orders {
+		switch o.ID {
+		case limit.ID:
+			if o.Status != types.OrderStatusFilled || o.FillPrice != 90 {
+				t.Fatalf("限价单 %+v", o)
+			}
+		case stop.ID:
+			if o.Status != types.OrderStatusFilled || o.FillPrice != 79 || o.FilledQty != 2 {
+				t.Fatalf("止损单 %+v", o)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import "time"
+
+// Tail 返回只包含最新n根K线及其指标值的副本（索引0为最新数据），用于增量推送
+// 价格、分区号、波动值等标量字段保持不变
+func (d *RealtimeData) Tail(n int) *RealtimeData {
+	tail := *d
+	if n >= len(d.Klines) {
+		return &tail
+	}
+
+	tail.Klines = d.Klines[:n]
+	tail.CCI = headSeries(d.CCI, n)
+	tail.RSI = headSeries(d.RSI, n)
+	if d.MACD != nil {
+		tail.MACD = make(map[string]MACDValues, len(d.MACD))
+		for key, m := range d.MACD {
+			tail.MACD[key] = MACDValues{
+				MacdLine:   head(m.MacdLine, n),
+				SignalLine: head(m.SignalLine, n),
+				Histogram:  head(m.Histogram, n),
+			}
+		}
+	}
+	tail.Bollinger.Upper = head(d.Bollinger.Upper, n)
+	tail.Bollinger.Middle = head(d.Bollinger.Middle, n)
+	tail.Bollinger.Lower = head(d.Bollinger.Lower, n)
+	tail.Envelope.Upper = head(d.Envelope.Upper, n)
+	tail.Envelope.Middle = head(d.Envelope.Middle, n)
+	tail.Envelope.Lower = head(d.Envelope.Lower, n)
+	if d.Custom != nil {
+		tail.Custom = make(map[string]map[string][]float64, len(d.Custom))
+		for key, outputs := range d.Custom {
+			tail.Custom[key] = headSeries(outputs, n)
+		}
+	}
+	return &tail
+}
+
+// ChangedSince 返回自上次推送（当时最新K线的开盘时间为last）以来需要重发的K线数量：
+// 正在形成的K线和之后新增的K线。last不在当前窗口内时返回false，需要重新发送快照
+func (d *RealtimeData) ChangedSince(last time.Time) (int, bool) {
+	for i, k := range d.Klines {
+		if k.Time.Equal(last) {
+			return i + 1, true
+		}
+		if k.Time.Before(last) {
+			break
+		}
+	}
+	return 0, false
+}
+
+// head 返回前n个元素
+func head(values []float64, n int) []float64 {
+	if len(values) <= n {
+		return values
+	}
+	return values[:n]
+}
+
+// headSeries 对每个序列取前n个元素
+func headSeries(series map[string][]float64, n int) map[string][]float64 {
+	if series == nil {
+		return nil
+	}
+	out := make(map[string][]float64, len(series))
+	for key, values := range series {
+		out[key] = head(values, n)
+	}
+	return out
+}
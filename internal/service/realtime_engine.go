@@ -0,0 +1,205 @@
+package service
+
+import (
+	"log"
+
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// engineSlot 引擎中的一个指标实例
+type engineSlot struct {
+	instance *indicators.Instance
+	params   indicators.Params // 按K线周期缩放后的参数
+	stream   indicators.Stream // 不支持增量计算的指标为nil，推送时批量计算
+}
+
+// indicatorEngine 指标引擎
+// 按注册表创建指标实例；支持增量计算的指标在tick时只更新最新K线，新K线时追加，避免每次全量重算
+type indicatorEngine struct {
+	config   types.IndicatorConfig
+	interval string
+	slots    []*engineSlot
+}
+
+// engineResults 指标结果
+type engineResults struct {
+	values map[string]map[string]map[string][]float64 // 指标名称 -> 实例key -> 输出序列名称 -> 数组（索引0是最新数据）
+	first  map[string]string                          // 指标名称 -> 配置中第一个实例的key
+	names  map[string]string                          // "指标名称_实例key" -> 显示名称（仅设置了显示名称的实例）
+}
+
+// newIndicatorEngine 根据配置和K线周期创建指标引擎
+func newIndicatorEngine(config types.IndicatorConfig, interval string) *indicatorEngine {
+	engine := &indicatorEngine{
+		config:   config.Clone(),
+		interval: interval,
+	}
+
+	seen := make(map[string]bool, len(config.Indicators))
+	for _, cfg := range config.Indicators {
+		instance, err := indicators.ResolveInstance(cfg)
+		if err != nil {
+			log.Printf("指标 %s 配置无效，已跳过: %v", cfg.Type, err)
+			continue
+		}
+		id := cfg.Type + "_" + instance.Key
+		if seen[id] {
+			log.Printf("指标 %s 的key %s 重复，已跳过", cfg.Type, instance.Key)
+			continue
+		}
+		seen[id] = true
+
+		params, err := indicators.ScaleParams(instance.Indicator, instance.Params, interval)
+		if err != nil {
+			log.Printf("指标 %s %v, 使用原值", cfg.Type, err)
+		}
+		slot := &engineSlot{
+			instance: instance,
+			params:   params,
+		}
+		if streamer, ok := instance.Indicator.(indicators.Streamer); ok {
+			slot.stream = streamer.NewStream(slot.params)
+		}
+		engine.slots = append(engine.slots, slot)
+	}
+
+	return engine
+}
+
+// hlcc 计算单根K线的 (H+L+C)/3 价格（与 indicators.CalculateHLCC 一致）
+func hlcc(k types.Kline) float64 {
+	return (k.High + k.Low + k.Close) * (1.0 / 3.0)
+}
+
+// load 用完整K线序列（从旧到新）重建所有增量状态
+func (e *indicatorEngine) load(klines []types.Kline) {
+	e.reset()
+	for _, k := range klines {
+		e.appendBar(k)
+	}
+}
+
+// appendBar 追加一根新K线
+func (e *indicatorEngine) appendBar(k types.Kline) {
+	price := hlcc(k)
+	for _, slot := range e.slots {
+		if slot.stream != nil {
+			slot.stream.Append(price)
+		}
+	}
+}
+
+// updateLast 更新正在形成的最新K线
+func (e *indicatorEngine) updateLast(k types.Kline) {
+	price := hlcc(k)
+	for _, slot := range e.slots {
+		if slot.stream != nil {
+			slot.stream.UpdateLast(price)
+		}
+	}
+}
+
+// reset 清空所有增量状态
+func (e *indicatorEngine) reset() {
+	for _, slot := range e.slots {
+		if slot.stream != nil {
+			slot.stream.Reset()
+		}
+	}
+}
+
+// results 获取所有指标结果
+// klines 为当前K线缓存（从旧到新），仅用于不支持增量计算的指标
+func (e *indicatorEngine) results(klines []types.Kline) engineResults {
+	var input *indicators.Input
+	results := engineResults{
+		values: make(map[string]map[string]map[string][]float64),
+		first:  make(map[string]string),
+	}
+
+	for _, slot := range e.slots {
+		var values map[string][]float64
+		if slot.stream != nil {
+			values = slot.stream.Values()
+		} else {
+			if input == nil {
+				in := indicators.NewInputFromKlines(klines)
+				input = &in
+			}
+			var err error
+			values, err = slot.instance.Indicator.Compute(*input, slot.params)
+			if err != nil {
+				values = nil
+			}
+		}
+
+		kind, key := slot.instance.Indicator.Name(), slot.instance.Key
+		if results.values[kind] == nil {
+			results.values[kind] = make(map[string]map[string][]float64)
+			results.first[kind] = key
+		}
+		results.values[kind][key] = values
+		if slot.instance.Name != "" {
+			if results.names == nil {
+				results.names = make(map[string]string)
+			}
+			results.names[kind+"_"+key] = slot.instance.Name
+		}
+	}
+
+	return results
+}
+
+// series 获取指定指标的单个输出序列，key -> 数组
+func (r engineResults) series(kind, output string) map[string][]float64 {
+	result := make(map[string][]float64)
+	for key, values := range r.values[kind] {
+		result[key] = values[output]
+	}
+	return result
+}
+
+// macd 获取MACD结果
+func (r engineResults) macd() map[string]MACDValues {
+	result := make(map[string]MACDValues)
+	for key, values := range r.values[indicators.NameMACD] {
+		result[key] = MACDValues{
+			MacdLine:   values["macd_line"],
+			SignalLine: values["signal_line"],
+			Histogram:  values["histogram"],
+		}
+	}
+	return result
+}
+
+// band 获取通道类指标（布林线、包络线）在配置中的第一个实例
+func (r engineResults) band(kind string) (upper, middle, lower []float64) {
+	values, ok := r.values[kind][r.first[kind]]
+	if !ok {
+		return nil, nil, nil
+	}
+	return values["upper"], values["middle"], values["lower"]
+}
+
+// custom 获取内置展示字段之外的指标结果，key 为 "指标名称_实例key"
+// 包括其他注册表指标，以及布林线、包络线除第一个之外的实例
+func (r engineResults) custom() map[string]map[string][]float64 {
+	var result map[string]map[string][]float64
+	for kind, instances := range r.values {
+		switch kind {
+		case indicators.NameCCI, indicators.NameMACD, indicators.NameRSI:
+			continue
+		}
+		for key, values := range instances {
+			if (kind == indicators.NameBollinger || kind == indicators.NameEnvelope) && key == r.first[kind] {
+				continue
+			}
+			if result == nil {
+				result = make(map[string]map[string][]float64)
+			}
+			result[kind+"_"+key] = values
+		}
+	}
+	return result
+}
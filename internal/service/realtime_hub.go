@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// StreamKey 实时流标识（symbol + interval）
+type StreamKey struct {
+	Symbol   types.Symbol
+	Interval string
+}
+
+// String 返回 "BTCUSDT@1h" 形式的标识
+func (k StreamKey) String() string {
+	return fmt.Sprintf("%s@%s", k.Symbol, k.Interval)
+}
+
+// ParseStreamKey 解析 "BTCUSDT@1h" 形式的实时流标识（交易对转为大写）
+func ParseStreamKey(s string) (StreamKey, error) {
+	symbol, interval, ok := strings.Cut(strings.TrimSpace(s), "@")
+	if !ok || symbol == "" {
+		return StreamKey{}, fmt.Errorf("实时流格式应为 SYMBOL@INTERVAL: %q", s)
+	}
+	if _, err := types.IntervalToMinutes(interval); err != nil {
+		return StreamKey{}, err
+	}
+	return StreamKey{Symbol: normalizeSymbol(types.Symbol(symbol)), Interval: interval}, nil
+}
+
+// StreamInfo 实时流状态（用于API展示）
+type StreamInfo struct {
+	Symbol      string `json:"symbol"`
+	Interval    string `json:"interval"`
+	Market      string `json:"market"`
+	Subscribers int    `json:"subscribers"`
+	Dropped     uint64 `json:"dropped"` // 所有订阅者因读取过慢被丢弃的消息总数
+	Ready       bool   `json:"ready"`
+	// SubscriberStats 每个订阅者的统计
+	SubscriberStats []SubscriptionStats `json:"subscriber_stats"`
+}
+
+// hubStream 数据中心内的一条实时流水线
+type hubStream struct {
+	service *RealtimeService
+	cancel  context.CancelFunc
+	refs    int           // 订阅者引用计数
+	ready   chan struct{} // 启动完成后关闭
+	err     error         // 启动错误（ready关闭后可读）
+}
+
+// RealtimeHub 实时数据中心
+// 按(symbol, interval)维护独立的实时流水线，按订阅者引用计数，最后一个订阅者离开时销毁
+type RealtimeHub struct {
+	ctx          context.Context
+	provider     exchange.MarketDataProvider
+	indicatorSvc *IndicatorService
+	configRepo   ConfigRepository                       // 配置仓库接口
+	configs      map[types.Symbol]types.IndicatorConfig // 每个symbol的配置（所有周期共享）
+	configMu     sync.RWMutex
+	streams      map[StreamKey]*hubStream
+	mu           sync.Mutex
+}
+
+// NewRealtimeHub 创建实时数据中心
+// ctx 为所有流水线的父context，取消后所有流水线停止
+func NewRealtimeHub(ctx context.Context, provider exchange.MarketDataProvider, indicatorSvc *IndicatorService, configRepo ConfigRepository) *RealtimeHub {
+	return &RealtimeHub{
+		ctx:          ctx,
+		provider:     provider,
+		indicatorSvc: indicatorSvc,
+		configRepo:   configRepo,
+		configs:      make(map[types.Symbol]types.IndicatorConfig),
+		streams:      make(map[StreamKey]*hubStream),
+	}
+}
+
+// normalizeSymbol 统一交易对为大写（Binance REST接口要求大写）
+func normalizeSymbol(symbol types.Symbol) types.Symbol {
+	return types.Symbol(strings.ToUpper(string(symbol)))
+}
+
+// Subscribe 订阅指定symbol和interval的实时数据
+// 如果对应的流水线不存在则创建并启动，启动失败时返回错误
+// policy 为订阅者读取过慢时的处理策略；返回的订阅使用完毕后必须调用 Unsubscribe
+func (h *RealtimeHub) Subscribe(symbol types.Symbol, interval string, policy SlowConsumerPolicy) (*Subscription, error) {
+	symbol = normalizeSymbol(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol不能为空")
+	}
+	if _, err := types.IntervalToMinutes(interval); err != nil {
+		return nil, err
+	}
+
+	key := StreamKey{Symbol: symbol, Interval: interval}
+
+	h.mu.Lock()
+	stream, exists := h.streams[key]
+	if exists {
+		stream.refs++
+		h.mu.Unlock()
+
+		// 等待其他订阅者触发的启动完成
+		<-stream.ready
+		if stream.err != nil {
+			h.release(key, stream)
+			return nil, stream.err
+		}
+		return stream.service.Subscribe(policy), nil
+	}
+
+	// 创建新的流水线（启动过程涉及网络请求，在锁外进行）
+	streamCtx, cancel := context.WithCancel(h.ctx)
+	stream = &hubStream{
+		service: NewRealtimeService(h.provider, h.indicatorSvc, symbol, interval, h),
+		cancel:  cancel,
+		refs:    1,
+		ready:   make(chan struct{}),
+	}
+	h.streams[key] = stream
+	h.mu.Unlock()
+
+	// 确保配置已加载
+	h.GetConfig(symbol)
+
+	// 先订阅再启动，保证能收到启动后的首次推送
+	sub := stream.service.Subscribe(policy)
+	stream.err = stream.service.Start(streamCtx)
+	close(stream.ready)
+
+	if stream.err != nil {
+		log.Printf("启动实时流 %s 失败: %v", key, stream.err)
+		h.release(key, stream)
+		return nil, stream.err
+	}
+
+	log.Printf("实时流 %s 已启动", key)
+	return sub, nil
+}
+
+// Unsubscribe 取消订阅（可重复调用），最后一个订阅者离开时停止对应流水线
+// 被 PolicyDisconnect 断开的订阅同样需要调用，以释放其引用
+func (h *RealtimeHub) Unsubscribe(sub *Subscription) {
+	if sub == nil || !sub.released.CompareAndSwap(false, true) {
+		return
+	}
+
+	h.mu.Lock()
+	stream, exists := h.streams[sub.key]
+	h.mu.Unlock()
+
+	sub.owner.Unsubscribe(sub)
+	if !exists || stream.service != sub.owner {
+		return
+	}
+	h.release(sub.key, stream)
+}
+
+// release 减少引用计数，归零时停止并移除流水线
+func (h *RealtimeHub) release(key StreamKey, stream *hubStream) {
+	h.mu.Lock()
+	stream.refs--
+	if stream.refs > 0 {
+		h.mu.Unlock()
+		return
+	}
+	if h.streams[key] == stream {
+		delete(h.streams, key)
+	}
+	h.mu.Unlock()
+
+	stream.cancel()
+	if err := stream.service.Close(); err != nil {
+		log.Printf("关闭实时流 %s 失败: %v", key, err)
+	}
+	log.Printf("实时流 %s 已停止（无订阅者）", key)
+}
+
+// Watch 持续订阅一条实时流并对每次推送调用fn，直到ctx取消
+// 订阅失败或流水线被关闭时每30秒重试；name 用于日志（如 "告警服务"）
+func (h *RealtimeHub) Watch(ctx context.Context, key StreamKey, policy SlowConsumerPolicy, name string, fn func(*RealtimeData)) {
+	for {
+		sub, err := h.Subscribe(key.Symbol, key.Interval, policy)
+		if err != nil {
+			log.Printf("%s订阅 %s 失败，30秒后重试: %v", name, key, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+				continue
+			}
+		}
+
+		closed := consumeRealtime(ctx, sub.C(), fn)
+		h.Unsubscribe(sub)
+		if !closed {
+			return
+		}
+	}
+}
+
+// consumeRealtime 处理实时数据直到ctx取消（返回false）或通道关闭（返回true）
+func consumeRealtime(ctx context.Context, ch <-chan *RealtimeData, fn func(*RealtimeData)) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case data, ok := <-ch:
+			if !ok {
+				return true
+			}
+			fn(data)
+		}
+	}
+}
+
+// Latest 获取指定实时流最近一次推送的数据，流不存在或尚未推送时返回nil
+func (h *RealtimeHub) Latest(symbol types.Symbol, interval string) *RealtimeData {
+	key := StreamKey{Symbol: normalizeSymbol(symbol), Interval: interval}
+
+	h.mu.Lock()
+	stream, exists := h.streams[key]
+	h.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	select {
+	case <-stream.ready:
+		if stream.err != nil {
+			return nil
+		}
+		return stream.service.Latest()
+	default:
+		return nil
+	}
+}
+
+// Streams 获取当前所有实时流的状态
+func (h *RealtimeHub) Streams() []StreamInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	infos := make([]StreamInfo, 0, len(h.streams))
+	for key, stream := range h.streams {
+		ready := false
+		select {
+		case <-stream.ready:
+			ready = stream.err == nil
+		default:
+		}
+		stats := stream.service.Subscriptions()
+		var dropped uint64
+		for _, st := range stats {
+			dropped += st.Dropped
+		}
+		infos = append(infos, StreamInfo{
+			Symbol:          string(key.Symbol),
+			Interval:        key.Interval,
+			Market:          string(stream.service.Market()),
+			Subscribers:     stream.refs,
+			Dropped:         dropped,
+			Ready:           ready,
+			SubscriberStats: stats,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Symbol != infos[j].Symbol {
+			return infos[i].Symbol < infos[j].Symbol
+		}
+		return infos[i].Interval < infos[j].Interval
+	})
+	return infos
+}
+
+// streamsForSymbol 获取指定symbol的所有已启动流水线
+func (h *RealtimeHub) streamsForSymbol(symbol types.Symbol) []*RealtimeService {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var services []*RealtimeService
+	for key, stream := range h.streams {
+		if key.Symbol != symbol {
+			continue
+		}
+		select {
+		case <-stream.ready:
+			if stream.err == nil {
+				services = append(services, stream.service)
+			}
+		default:
+		}
+	}
+	return services
+}
+
+// GetConfig 获取指定symbol的配置
+// 内存中没有时从配置仓库加载，仍没有则使用默认配置
+func (h *RealtimeHub) GetConfig(symbol types.Symbol) types.IndicatorConfig {
+	h.configMu.RLock()
+	config, exists := h.configs[symbol]
+	h.configMu.RUnlock()
+	if exists {
+		return config
+	}
+
+	config = types.GetDefaultConfig()
+	if h.configRepo != nil {
+		dbConfig, err := h.configRepo.GetConfig(context.Background(), symbol)
+		if err != nil {
+			log.Printf("加载 %s 的配置失败，使用默认配置: %v", symbol, err)
+		} else {
+			config = *dbConfig
+		}
+	}
+
+	h.configMu.Lock()
+	// 并发加载时以先写入的为准
+	if existing, ok := h.configs[symbol]; ok {
+		config = existing
+	} else {
+		h.configs[symbol] = config
+	}
+	h.configMu.Unlock()
+
+	return config
+}
+
+// UpdateConfig 更新指定symbol的配置，并立即让该symbol的所有实时流按新配置推送
+func (h *RealtimeHub) UpdateConfig(symbol types.Symbol, config types.IndicatorConfig) error {
+	_, err := h.UpdateConfigWithMeta(symbol, config, types.ConfigChangeMeta{})
+	return err
+}
+
+// UpdateConfigWithMeta 更新配置并记录作者、备注等历史信息，返回新保存的版本（配置未持久化时为nil）
+func (h *RealtimeHub) UpdateConfigWithMeta(symbol types.Symbol, config types.IndicatorConfig, meta types.ConfigChangeMeta) (*types.ConfigVersion, error) {
+	h.configMu.Lock()
+	h.configs[symbol] = config
+	h.configMu.Unlock()
+
+	// 持久化到数据库（支持历史版本时同时记录一条历史）
+	var saved *types.ConfigVersion
+	ctx := context.Background()
+	if repo, ok := h.configRepo.(ConfigHistoryRepository); ok && repo != nil {
+		version, err := repo.SaveConfigVersion(ctx, symbol, config, meta)
+		if err != nil {
+			log.Printf("保存 %s 的配置到数据库失败: %v", symbol, err)
+			return nil, fmt.Errorf("保存配置失败: %w", err)
+		}
+		saved = version
+		log.Printf("✓ 已保存 %s 的配置到数据库（版本 %d）", symbol, version.Version)
+	} else if h.configRepo != nil {
+		if err := h.configRepo.SaveConfig(ctx, symbol, config); err != nil {
+			log.Printf("保存 %s 的配置到数据库失败: %v", symbol, err)
+			return nil, fmt.Errorf("保存配置失败: %w", err)
+		}
+		log.Printf("✓ 已保存 %s 的配置到数据库", symbol)
+	} else {
+		log.Printf("警告: configRepo 为 nil，配置未持久化")
+	}
+
+	for _, service := range h.streamsForSymbol(symbol) {
+		service.calculateAndPush()
+	}
+
+	return saved, nil
+}
+
+// Close 停止所有实时流
+func (h *RealtimeHub) Close() error {
+	h.mu.Lock()
+	streams := h.streams
+	h.streams = make(map[StreamKey]*hubStream)
+	h.mu.Unlock()
+
+	for key, stream := range streams {
+		stream.cancel()
+		if err := stream.service.Close(); err != nil {
+			log.Printf("关闭实时流 %s 失败: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -2,31 +2,37 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"math"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/binance_cyan/indicators/internal/exchange/binance"
+	"github.com/binance_cyan/indicators/internal/exchange"
 	"github.com/binance_cyan/indicators/pkg/indicators"
 	"github.com/binance_cyan/indicators/pkg/types"
 )
 
-// RealtimeService 实时数据服务
+// RealtimeService 实时数据服务（单个symbol+interval的实时流水线）
+// 每个实例持有独立的K线缓存、实时K线流和指标计算，由 RealtimeHub 按需创建和销毁
 type RealtimeService struct {
-	binanceClient *binance.Client
-	wsClient      *binance.WebSocketClient
-	indicatorSvc  *IndicatorService
-	symbol        types.Symbol
-	interval      string
-	klines        []types.Kline
-	configs       map[types.Symbol]types.IndicatorConfig // 每个symbol的配置
-	configMu      sync.RWMutex
-	mu            sync.RWMutex
-	subscribers   map[chan *RealtimeData]bool
-	subMu         sync.RWMutex
-	configRepo    ConfigRepository // 配置仓库接口
+	provider     exchange.MarketDataProvider
+	stream       exchange.KlineStream
+	indicatorSvc *IndicatorService
+	symbol       types.Symbol
+	interval     string
+	market       types.Market           // 所属市场（现货/U本位/币本位）
+	futures      *exchange.FuturesStats // 合约数据（仅合约市场，受mu保护）
+	klines       []types.Kline
+	limit        int              // K线窗口大小
+	engine       *indicatorEngine // 增量指标引擎（受mu保护）
+	epoch        uint64           // 指标序列整体重算的次数（受mu保护）
+	configSource ConfigSource     // 配置来源（通常为 RealtimeHub）
+	mu           sync.RWMutex
+	subscribers  map[*Subscription]struct{}
+	latest       *RealtimeData // 最近一次推送的数据（受subMu保护）
+	subMu        sync.RWMutex
+	confluence   confluenceCache // 多周期共振缓存
 }
 
 // ConfigRepository 配置仓库接口
@@ -35,9 +41,15 @@ type ConfigRepository interface {
 	SaveConfig(ctx context.Context, symbol types.Symbol, config types.IndicatorConfig) error
 }
 
+// ConfigSource 指标配置来源
+type ConfigSource interface {
+	GetConfig(symbol types.Symbol) types.IndicatorConfig
+}
+
 // RealtimeData 实时数据
 type RealtimeData struct {
 	Symbol     string                `json:"symbol"`
+	Interval   string                `json:"interval"`
 	Timestamp  time.Time             `json:"timestamp"`
 	Price      float64               `json:"price"`
 	Klines     []KlineData           `json:"klines"`
@@ -46,7 +58,21 @@ type RealtimeData struct {
 	RSI        map[string][]float64  `json:"rsi"`
 	Bollinger  BollingerData         `json:"bollinger"`
 	Envelope   EnvelopeData          `json:"envelope"`
-	Volatility float64               `json:"volatility"` // 5天平均波动价格值（不包括当前日）
+	Volatility float64               `json:"volatility"`           // 5天平均波动价格值（不包括当前日）
+	Market     string                `json:"market,omitempty"`     // 所属市场：usdm、coinm（现货不输出）
+	Connection string                `json:"connection,omitempty"` // 实时K线流状态：connected、reconnecting、stale
+	// Futures 合约数据（标记价格、资金费率、持仓量），仅合约市场
+	Futures *exchange.FuturesStats `json:"futures,omitempty"`
+	// Custom 内置字段之外的注册表指标，key 为 "指标名称_实例key"
+	Custom map[string]map[string][]float64 `json:"custom,omitempty"`
+	// Names 配置了显示名称的指标实例，key 为 "指标名称_实例key"
+	Names map[string]string `json:"names,omitempty"`
+	// Divergences 价格与CCI/RSI/MACD柱之间的背离（按较近摆动点从新到旧排列）
+	Divergences []DivergenceEvent `json:"divergences,omitempty"`
+	// Confluence 订阅者所需周期合集的多周期共振概要，只在其中某个周期的K线收盘时重新计算（不随数据推送）
+	Confluence *ConfluenceSummary `json:"-"`
+	// Epoch K线窗口重载或配置变化导致全部历史值重算时递增，相同Epoch之间只有最新几根K线的值会变化
+	Epoch uint64 `json:"-"`
 }
 
 // KlineData K线数据
@@ -76,161 +102,35 @@ type EnvelopeData struct {
 }
 
 // NewRealtimeService 创建实时数据服务
-func NewRealtimeService(binanceClient *binance.Client, indicatorSvc *IndicatorService, symbol types.Symbol, interval string, configRepo ConfigRepository) *RealtimeService {
-	service := &RealtimeService{
-		binanceClient: binanceClient,
-		indicatorSvc:  indicatorSvc,
-		symbol:        symbol,
-		interval:      interval,
-		configs:       make(map[types.Symbol]types.IndicatorConfig),
-		subscribers:   make(map[chan *RealtimeData]bool),
-		configRepo:    configRepo,
-	}
-
-	// 加载当前symbol的配置
-	if configRepo != nil {
-		ctx := context.Background()
-		config, err := configRepo.GetConfig(ctx, symbol)
-		if err != nil {
-			log.Printf("加载 %s 的配置失败，使用默认配置: %v", symbol, err)
-			defaultConfig := types.GetDefaultConfig()
-			service.configs[symbol] = defaultConfig
-		} else {
-			service.configs[symbol] = *config
-		}
-	} else {
-		// 如果没有配置仓库，使用默认配置
-		service.configs[symbol] = types.GetDefaultConfig()
+func NewRealtimeService(provider exchange.MarketDataProvider, indicatorSvc *IndicatorService, symbol types.Symbol, interval string, configSource ConfigSource) *RealtimeService {
+	market := types.MarketSpot
+	if fp, ok := provider.(exchange.FuturesDataProvider); ok {
+		market = fp.MarketOf(symbol)
 	}
-
-	return service
-}
-
-// UpdateConfig 更新当前symbol的配置
-func (r *RealtimeService) UpdateConfig(symbol types.Symbol, config types.IndicatorConfig) error {
-	r.configMu.Lock()
-	r.configs[symbol] = config
-	r.configMu.Unlock()
-
-	// 持久化到数据库
-	if r.configRepo != nil {
-		ctx := context.Background()
-		if err := r.configRepo.SaveConfig(ctx, symbol, config); err != nil {
-			log.Printf("保存 %s 的配置到数据库失败: %v", symbol, err)
-			return fmt.Errorf("保存配置失败: %w", err)
-		}
-		log.Printf("✓ 已保存 %s 的配置到数据库", symbol)
-	} else {
-		log.Printf("警告: configRepo 为 nil，配置未持久化")
+	return &RealtimeService{
+		provider:     provider,
+		indicatorSvc: indicatorSvc,
+		symbol:       symbol,
+		interval:     interval,
+		market:       market,
+		configSource: configSource,
+		subscribers:  make(map[*Subscription]struct{}),
 	}
-
-	return nil
 }
 
-// GetConfig 获取指定symbol的配置
-func (r *RealtimeService) GetConfig(symbol types.Symbol) types.IndicatorConfig {
-	r.configMu.RLock()
-	defer r.configMu.RUnlock()
-
-	config, exists := r.configs[symbol]
-	if !exists {
-		// 如果内存中没有，尝试从数据库加载
-		if r.configRepo != nil {
-			ctx := context.Background()
-			dbConfig, err := r.configRepo.GetConfig(ctx, symbol)
-			if err == nil {
-				r.configMu.RUnlock()
-				r.configMu.Lock()
-				r.configs[symbol] = *dbConfig
-				r.configMu.Unlock()
-				r.configMu.RLock()
-				return *dbConfig
-			}
-		}
-		// 如果都没有，返回默认配置
-		return types.GetDefaultConfig()
-	}
-
-	return config
+// Symbol 获取交易对
+func (r *RealtimeService) Symbol() types.Symbol {
+	return r.symbol
 }
 
-// UpdateSymbolAndInterval 更新交易对和周期
-func (r *RealtimeService) UpdateSymbolAndInterval(symbol types.Symbol, interval string) {
-	r.mu.Lock()
-	oldSymbol := r.symbol
-	oldInterval := r.interval
-	needUpdate := oldSymbol != symbol || oldInterval != interval
-	r.symbol = symbol
-	r.interval = interval
-	r.mu.Unlock()
-
-	// 如果symbol或interval发生变化，重新初始化K线数据
-	if needUpdate {
-		// 如果symbol变化，加载新symbol的配置
-		if oldSymbol != symbol {
-			// 从数据库加载新symbol的配置
-			if r.configRepo != nil {
-				ctx := context.Background()
-				config, err := r.configRepo.GetConfig(ctx, symbol)
-				if err != nil {
-					log.Printf("加载 %s 的配置失败，使用默认配置: %v", symbol, err)
-					defaultConfig := types.GetDefaultConfig()
-					r.configMu.Lock()
-					r.configs[symbol] = defaultConfig
-					r.configMu.Unlock()
-				} else {
-					r.configMu.Lock()
-					r.configs[symbol] = *config
-					r.configMu.Unlock()
-					log.Printf("已加载 %s 的配置", symbol)
-				}
-			} else {
-				// 如果没有配置仓库，使用默认配置
-				r.configMu.Lock()
-				if _, exists := r.configs[symbol]; !exists {
-					r.configs[symbol] = types.GetDefaultConfig()
-				}
-				r.configMu.Unlock()
-			}
-		}
-
-		// 重新获取K线数据（至少7天，确保有足够的数据计算5天平均波动价格）
-		limit, err := types.CalculateKlinesForDays(7, interval)
-		if err != nil {
-			log.Printf("计算K线数量失败: %v, 使用默认值500", err)
-			limit = 500
-		}
-
-		klines, err := r.binanceClient.GetKlines(symbol, interval, limit)
-		if err != nil {
-			log.Printf("更新K线数据失败: %v", err)
-			return
-		}
-
-		r.mu.Lock()
-		r.klines = klines
-		r.mu.Unlock()
-
-		// 如果symbol变化，需要重新连接WebSocket
-		// 注意：WebSocket连接是按symbol的，interval变化不需要重连WebSocket
-		if oldSymbol != symbol {
-			// 关闭旧的WebSocket连接（通过设置done channel）
-			if r.wsClient != nil {
-				// WebSocket会在下次tickLoop中检测到done信号并关闭
-				// 这里我们重新创建连接
-				r.wsClient = nil
-			}
-
-			// 重新创建WebSocket客户端
-			r.wsClient = binance.NewWebSocketClient(symbol, interval, r.binanceClient.BaseURL())
-			if err := r.wsClient.Connect(); err != nil {
-				log.Printf("重新连接WebSocket失败: %v", err)
-			}
-		}
+// Interval 获取K线周期
+func (r *RealtimeService) Interval() string {
+	return r.interval
+}
 
-		// 立即推送一次新数据
-		r.calculateAndPush()
-	}
+// Market 获取所属市场
+func (r *RealtimeService) Market() types.Market {
+	return r.market
 }
 
 // Start 启动实时服务
@@ -243,21 +143,26 @@ func (r *RealtimeService) Start(ctx context.Context) error {
 		limit = 500
 	}
 
-	// 初始化K线数据（获取14天的数据）
-	klines, err := r.binanceClient.GetKlines(r.symbol, r.interval, limit)
+	// 初始化K线数据（超过单次请求上限时自动分页）
+	klines, err := r.provider.GetRecentKlines(r.symbol, r.interval, limit)
 	if err != nil {
 		return err
 	}
 
 	r.mu.Lock()
-	r.klines = klines
+	r.limit = limit
+	r.replaceKlines(klines)
 	r.mu.Unlock()
 
-	// 创建WebSocket客户端（使用K线流）
-	r.wsClient = binance.NewWebSocketClient(r.symbol, r.interval, r.binanceClient.BaseURL())
-	if err := r.wsClient.Connect(); err != nil {
+	// 合约市场同时获取标记价格、资金费率和持仓量
+	r.refreshFutures()
+
+	// 订阅实时K线流
+	stream, err := r.provider.SubscribeKlines(r.symbol, r.interval)
+	if err != nil {
 		return err
 	}
+	r.stream = stream
 
 	// 启动tick处理循环
 	go r.tickLoop(ctx)
@@ -276,24 +181,24 @@ func (r *RealtimeService) tickLoop(ctx context.Context) {
 	// 立即推送一次初始数据
 	r.calculateAndPush()
 
+	updates := r.stream.Updates()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case tick := <-r.wsClient.GetTickChan():
-			// 更新最新K线的价格
-			r.mu.Lock()
-			if len(r.klines) > 0 {
-				lastIdx := len(r.klines) - 1
-				r.klines[lastIdx].Close = tick.Price
-				if tick.Price > r.klines[lastIdx].High {
-					r.klines[lastIdx].High = tick.Price
-				}
-				if tick.Price < r.klines[lastIdx].Low {
-					r.klines[lastIdx].Low = tick.Price
-				}
+		case update, ok := <-updates:
+			if !ok {
+				// K线流已关闭，停止读取（避免在已关闭的通道上空转），K线仍由REST轮询更新
+				log.Printf("%s@%s 实时K线流已关闭", r.symbol, r.interval)
+				updates = nil
+				continue
+			}
+
+			// 合并到K线缓存，指标只增量更新最新K线；与缓存无法衔接时重新获取完整窗口
+			if !r.applyUpdate(update.Kline) {
+				log.Printf("%s@%s 实时K线出现缺口，重新获取完整窗口", r.symbol, r.interval)
+				r.reloadKlines()
 			}
-			r.mu.Unlock()
 
 			// 计算并推送数据
 			r.calculateAndPush()
@@ -304,12 +209,8 @@ func (r *RealtimeService) tickLoop(ctx context.Context) {
 	}
 }
 
-// calculateAndPush 计算指标并推送（立即执行版本，用于初始化）
-func (r *RealtimeService) calculateAndPushImmediate() {
-	r.calculateAndPush()
-}
-
 // klineUpdateLoop K线更新循环
+// 每次只获取最近几根K线合并到缓存，出现数据缺口时才重新获取完整窗口
 func (r *RealtimeService) klineUpdateLoop(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -319,165 +220,242 @@ func (r *RealtimeService) klineUpdateLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			// 计算至少7天需要多少根K线（确保有足够的数据计算5天平均波动价格）
-			limit, err := types.CalculateKlinesForDays(7, r.interval)
-			if err != nil {
-				log.Printf("计算K线数量失败: %v, 使用默认值500", err)
-				limit = 500
-			}
-
-			// 检查是否有新K线（获取14天的数据）
-			klines, err := r.binanceClient.GetKlines(r.symbol, r.interval, limit)
+			klines, err := r.provider.GetKlines(r.symbol, r.interval, recentKlinesLimit)
 			if err != nil {
 				continue
 			}
 
-			r.mu.Lock()
-			// 如果K线数量增加，说明有新K线
-			if len(klines) > len(r.klines) || (len(klines) > 0 && len(r.klines) > 0 && klines[len(klines)-1].Timestamp.After(r.klines[len(r.klines)-1].Timestamp)) {
-				r.klines = klines
+			if !r.mergeKlines(klines) {
+				log.Printf("%s@%s K线数据出现缺口，重新获取完整窗口", r.symbol, r.interval)
+				r.reloadKlines()
 			}
-			r.mu.Unlock()
+			r.refreshFutures()
 		}
 	}
 }
 
-// calculateAndPush 计算指标并推送
-func (r *RealtimeService) calculateAndPush() {
+// refreshFutures 更新合约数据（非合约市场直接返回，失败时保留上一次的数据）
+func (r *RealtimeService) refreshFutures() {
+	if !r.market.IsFutures() {
+		return
+	}
+	fp, ok := r.provider.(exchange.FuturesDataProvider)
+	if !ok {
+		return
+	}
+
+	stats, err := fp.GetFuturesStats(r.symbol)
+	if err != nil {
+		log.Printf("获取 %s 合约数据失败: %v", r.symbol, err)
+		return
+	}
+
+	r.mu.Lock()
+	r.futures = stats
+	r.mu.Unlock()
+}
+
+// recentKlinesLimit 增量更新时每次获取的K线数量
+const recentKlinesLimit = 10
+
+// reloadKlines 重新获取完整K线窗口并重建指标
+func (r *RealtimeService) reloadKlines() {
 	r.mu.RLock()
-	klines := make([]types.Kline, len(r.klines))
-	copy(klines, r.klines)
+	limit := r.limit
 	r.mu.RUnlock()
 
-	if len(klines) == 0 {
+	klines, err := r.provider.GetRecentKlines(r.symbol, r.interval, limit)
+	if err != nil {
+		log.Printf("重新获取 %s@%s K线失败: %v", r.symbol, r.interval, err)
 		return
 	}
 
-	// 准备数据
-	high := make([]float64, len(klines))
-	low := make([]float64, len(klines))
-	close := make([]float64, len(klines))
-	open := make([]float64, len(klines))
+	r.mu.Lock()
+	r.replaceKlines(klines)
+	r.mu.Unlock()
+}
 
-	// 反转数组，使索引0为最新数据
-	for i := 0; i < len(klines); i++ {
-		idx := len(klines) - 1 - i
-		high[i] = klines[idx].High
-		low[i] = klines[idx].Low
-		close[i] = klines[idx].Close
-		open[i] = klines[idx].Open
+// mergeKlines 将最新获取的K线合并到缓存
+// 有新K线时：缓存中的最新K线更新为最终值，新K线增量追加到指标引擎
+// 新数据与缓存无法衔接（出现数据缺口）时返回false，由调用方重新获取完整窗口
+func (r *RealtimeService) mergeKlines(fetched []types.Kline) bool {
+	if len(fetched) == 0 {
+		return true
 	}
 
-	// 获取当前symbol的配置
-	r.mu.RLock()
-	currentSymbol := r.symbol
-	r.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.klines) == 0 {
+		return false
+	}
+
+	// 在新数据中查找缓存的最新K线
+	last := r.klines[len(r.klines)-1]
+	idx := -1
+	for i := len(fetched) - 1; i >= 0; i-- {
+		if fetched[i].Timestamp.Equal(last.Timestamp) {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		// 新数据全部晚于缓存，说明中间有缺口
+		return !fetched[0].Timestamp.After(last.Timestamp)
+	}
 
-	r.configMu.RLock()
-	config, exists := r.configs[currentSymbol]
-	if !exists {
-		config = types.GetDefaultConfig()
+	// 没有新K线，保留tick更新的数据
+	if idx == len(fetched)-1 {
+		return true
 	}
-	r.configMu.RUnlock()
 
-	// 根据K线周期缩放所有周期参数（配置基于小时）
-	scalePeriod := func(period int) int {
-		scaled, err := types.ScalePeriod(period, r.interval)
-		if err != nil {
-			log.Printf("缩放周期失败: %v, 使用原值: %d", err, period)
-			return period
+	// 之前正在形成的K线已完结，使用最终值
+	r.klines[len(r.klines)-1] = fetched[idx]
+	if r.engine != nil {
+		r.engine.updateLast(fetched[idx])
+	}
+
+	// 追加新K线
+	for _, k := range fetched[idx+1:] {
+		r.klines = append(r.klines, k)
+		if r.engine != nil {
+			r.engine.appendBar(k)
+		}
+	}
+
+	r.trimKlines()
+	return true
+}
+
+// applyUpdate 将实时K线流的更新合并到缓存
+// 与最新K线同一根时覆盖其OHLCV；为下一根K线时追加；早于最新K线的更新忽略
+// 与缓存无法衔接（中间缺少K线）时返回false，由调用方重新获取完整窗口
+func (r *RealtimeService) applyUpdate(k types.Kline) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.klines) == 0 {
+		return false
+	}
+
+	lastIdx := len(r.klines) - 1
+	last := r.klines[lastIdx]
+	if k.Symbol == "" {
+		k.Symbol = last.Symbol
+	}
+
+	switch {
+	case k.Timestamp.Equal(last.Timestamp):
+		r.klines[lastIdx] = k
+		if r.engine != nil {
+			r.engine.updateLast(k)
+		}
+	case k.Timestamp.Before(last.Timestamp):
+		// 补齐或延迟到达的旧数据，缓存中已有
+	default:
+		step, err := types.IntervalDuration(r.interval)
+		if err != nil || k.Timestamp.Sub(last.Timestamp) > step {
+			return false
+		}
+		r.klines = append(r.klines, k)
+		if r.engine != nil {
+			r.engine.appendBar(k)
 		}
-		return scaled
-	}
-
-	// 计算HLCC价格
-	hlcc := indicators.CalculateHLCC(high, low, close)
-
-	// 计算CCI指标（使用缩放后的周期）
-	cciPeriod1 := scalePeriod(config.CCI_Period1)
-	cciPeriod2 := scalePeriod(config.CCI_Period2)
-	cciPeriod3 := scalePeriod(config.CCI_Period3)
-	cciPeriods := []int{cciPeriod1, cciPeriod2, cciPeriod3}
-	cciResults := indicators.CalculateCCIMulti(hlcc, cciPeriods)
-	cciMap := make(map[string][]float64)
-	// 使用原始配置值作为key，但实际计算使用缩放后的值
-	cciMap[fmt.Sprintf("%d", config.CCI_Period1)] = cciResults[0]
-	cciMap[fmt.Sprintf("%d", config.CCI_Period2)] = cciResults[1]
-	cciMap[fmt.Sprintf("%d", config.CCI_Period3)] = cciResults[2]
-
-	// 计算MACD指标（使用缩放后的参数）
-	macd1Fast := scalePeriod(config.MACD_Fast1)
-	macd1Slow := scalePeriod(config.MACD_Slow1)
-	macd1Signal := scalePeriod(config.MACD_Signal1)
-	macd2Fast := scalePeriod(config.MACD_Fast2)
-	macd2Slow := scalePeriod(config.MACD_Slow2)
-	macd2Signal := scalePeriod(config.MACD_Signal2)
-
-	macd1Line, macd1SignalLine, macd1Hist := indicators.CalculateMACD(hlcc, macd1Fast, macd1Slow, macd1Signal)
-	macd2Line, macd2SignalLine, macd2Hist := indicators.CalculateMACD(hlcc, macd2Fast, macd2Slow, macd2Signal)
-	macdMap := make(map[string]MACDValues)
-	macdKey1 := fmt.Sprintf("%d_%d", config.MACD_Fast1, config.MACD_Slow1)
-	macdKey2 := fmt.Sprintf("%d_%d", config.MACD_Fast2, config.MACD_Slow2)
-	macdMap[macdKey1] = MACDValues{
-		MacdLine:   macd1Line,
-		SignalLine: macd1SignalLine,
-		Histogram:  macd1Hist,
-	}
-	macdMap[macdKey2] = MACDValues{
-		MacdLine:   macd2Line,
-		SignalLine: macd2SignalLine,
-		Histogram:  macd2Hist,
-	}
-
-	// 计算RSI指标（使用缩放后的周期）
-	rsiPeriod1 := scalePeriod(config.RSI_Period1)
-	rsiPeriod2 := scalePeriod(config.RSI_Period2)
-	rsiPeriods := []int{rsiPeriod1, rsiPeriod2}
-	rsiResults := indicators.CalculateRSIMulti(hlcc, rsiPeriods)
-	rsiMap := make(map[string][]float64)
-	// 使用原始配置值作为key
-	rsiMap[fmt.Sprintf("%d", config.RSI_Period1)] = rsiResults[0]
-	rsiMap[fmt.Sprintf("%d", config.RSI_Period2)] = rsiResults[1]
-
-	// 计算布林线（使用缩放后的周期）
-	bollPeriod := scalePeriod(config.Boll_Period)
-	bollUpper, bollMiddle, bollLower := indicators.CalculateBollinger(hlcc, bollPeriod, config.Boll_Deviation)
-
-	// 计算包络线（使用缩放后的周期）
-	envPeriod := scalePeriod(config.Env_Period)
-	envUpper, envMiddle, envLower := indicators.CalculateEnvelope(hlcc, envPeriod, config.Env_Deviation)
+		r.trimKlines()
+	}
+	return true
+}
+
+// trimKlines 缓存超过两倍窗口时裁剪并重建，避免无限增长（调用方需持有r.mu）
+func (r *RealtimeService) trimKlines() {
+	if r.limit > 0 && len(r.klines) > 2*r.limit {
+		trimmed := make([]types.Kline, r.limit)
+		copy(trimmed, r.klines[len(r.klines)-r.limit:])
+		r.replaceKlines(trimmed)
+	}
+}
+
+// replaceKlines 整体替换K线缓存并重建指标引擎（调用方需持有r.mu）
+func (r *RealtimeService) replaceKlines(klines []types.Kline) {
+	r.klines = klines
+	r.epoch++
+	if r.engine != nil {
+		r.engine.load(r.klines)
+	}
+}
+
+// calculateAndPush 计算指标并推送
+// 指标由引擎按注册表维护，这里只在配置变化时全量重建
+func (r *RealtimeService) calculateAndPush() {
+	// 获取当前symbol的配置
+	config := types.GetDefaultConfig()
+	if r.configSource != nil {
+		config = r.configSource.GetConfig(r.symbol)
+	}
+
+	r.mu.Lock()
+	if len(r.klines) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	if r.engine == nil || !reflect.DeepEqual(r.engine.config, config) {
+		r.engine = newIndicatorEngine(config, r.interval)
+		r.engine.load(r.klines)
+		r.epoch++
+	}
+
+	klines := make([]types.Kline, len(r.klines))
+	copy(klines, r.klines)
+
+	results := r.engine.results(klines)
+	futures := r.futures
+	epoch := r.epoch
+	r.mu.Unlock()
+
+	cciMap := results.series(indicators.NameCCI, "cci")
+	macdMap := results.macd()
+	rsiMap := results.series(indicators.NameRSI, "rsi")
+	bollUpper, bollMiddle, bollLower := results.band(indicators.NameBollinger)
+	envUpper, envMiddle, envLower := results.band(indicators.NameEnvelope)
 
 	// 计算当前价格在布林线和包络线的分区号（索引0是最新数据）
 	// 分区规则：中轨为0，向上+1到+10，向下-1到-10，共20个分区
-	currentPrice := close[0]
-	bollZone := calculateZone(currentPrice, bollMiddle[0], bollUpper[0], bollLower[0])
-	envZone := calculateZone(currentPrice, envMiddle[0], envUpper[0], envLower[0])
+	currentPrice := klines[len(klines)-1].Close
+	bollZone, envZone := 0, 0
+	if len(bollMiddle) > 0 {
+		bollZone = indicators.Zone(currentPrice, bollMiddle[0], bollUpper[0], bollLower[0])
+	}
+	if len(envMiddle) > 0 {
+		envZone = indicators.Zone(currentPrice, envMiddle[0], envUpper[0], envLower[0])
+	}
 
 	// 计算5天平均波动价格值（不包括当前日，不受K线周期影响，固定取前5个自然天）
 	// klines数组是原始顺序（从旧到新），CalculateVolatility5Days需要这个顺序
-	// 但函数内部会跳过索引0（当前日），所以直接传入klines即可
 	volatility := indicators.CalculateVolatility5Days(klines)
 
-	// 准备K线数据
+	// 准备K线数据（反转数组，使索引0为最新数据）
 	klineData := make([]KlineData, len(klines))
 	for i := 0; i < len(klines); i++ {
-		idx := len(klines) - 1 - i
+		k := klines[len(klines)-1-i]
 		klineData[i] = KlineData{
-			Time:   klines[idx].Timestamp,
-			Open:   open[i],
-			High:   high[i],
-			Low:    low[i],
-			Close:  close[i],
-			Volume: klines[idx].Volume,
+			Time:   k.Timestamp,
+			Open:   k.Open,
+			High:   k.High,
+			Low:    k.Low,
+			Close:  k.Close,
+			Volume: k.Volume,
 		}
 	}
 
 	// 构建实时数据
 	data := &RealtimeData{
 		Symbol:     string(r.symbol),
+		Interval:   r.interval,
 		Timestamp:  time.Now(),
 		Volatility: volatility,
-		Price:      close[0],
+		Price:      currentPrice,
 		Klines:     klineData,
 		CCI:        cciMap,
 		MACD:       macdMap,
@@ -494,96 +472,102 @@ func (r *RealtimeService) calculateAndPush() {
 			Lower:  envLower,
 			Zone:   envZone,
 		},
+		Custom:      results.custom(),
+		Names:       results.names,
+		Divergences: detectDivergences(klines, cciMap, rsiMap, macdMap, config.Divergence),
+		Futures:     futures,
+		Epoch:       epoch,
+	}
+	if r.market.IsFutures() {
+		data.Market = string(r.market)
+	}
+	if r.stream != nil {
+		data.Connection = string(r.stream.State())
 	}
 
-	// 推送给所有订阅者
+	// 多周期共振按所有订阅者的周期合集计算一次，各订阅者推送时从中选取
 	r.subMu.RLock()
-	for ch := range r.subscribers {
-		select {
-		case ch <- data:
-		default:
-			// 通道满了，跳过
+	timeframes := r.confluenceTimeframes()
+	r.subMu.RUnlock()
+	data.Confluence = r.confluence.get(r.symbol, r.interval, klines, config, epoch, timeframes)
+
+	// 推送给所有订阅者，缓冲已满时按各自的慢消费者策略处理
+	r.subMu.Lock()
+	r.latest = data
+	for sub := range r.subscribers {
+		if !sub.deliver(data) {
+			log.Printf("%s@%s 订阅者 #%d 读取过慢，已断开（已丢弃 %d 条）", r.symbol, r.interval, sub.id, sub.dropped.Load())
+			delete(r.subscribers, sub)
+			sub.close()
 		}
 	}
-	r.subMu.RUnlock()
+	r.subMu.Unlock()
+}
+
+// confluenceTimeframes 所有订阅者需要的多周期共振周期合集，按周期从小到大排列（调用方需持有subMu）
+func (r *RealtimeService) confluenceTimeframes() []string {
+	seen := make(map[string]bool)
+	var timeframes []string
+	for sub := range r.subscribers {
+		for _, tf := range sub.timeframes {
+			if !seen[tf] {
+				seen[tf] = true
+				timeframes = append(timeframes, tf)
+			}
+		}
+	}
+	sortTimeframes(timeframes)
+	return timeframes
 }
 
 // Subscribe 订阅实时数据
-func (r *RealtimeService) Subscribe() <-chan *RealtimeData {
-	ch := make(chan *RealtimeData, 10)
+func (r *RealtimeService) Subscribe(policy SlowConsumerPolicy) *Subscription {
+	sub := newSubscription(r, policy)
 	r.subMu.Lock()
-	r.subscribers[ch] = true
+	r.subscribers[sub] = struct{}{}
 	r.subMu.Unlock()
-	return ch
+	return sub
 }
 
-// Unsubscribe 取消订阅
-// 注意：由于Go的类型系统限制，这个方法实际上不会删除通道
-// 通道会在WebSocket连接关闭时自动被GC回收
-func (r *RealtimeService) Unsubscribe(ch <-chan *RealtimeData) {
-	// 通道会在不再被引用时自动被GC回收
-	// 这里不需要手动删除，因为通道是只读的，我们无法直接匹配
+// Latest 获取最近一次推送的数据，尚未推送过时返回nil
+func (r *RealtimeService) Latest() *RealtimeData {
+	r.subMu.RLock()
+	defer r.subMu.RUnlock()
+	return r.latest
 }
 
-// Close 关闭服务
-func (r *RealtimeService) Close() error {
-	if r.wsClient != nil {
-		return r.wsClient.Close()
+// Unsubscribe 取消订阅并关闭其通道（可重复调用）
+func (r *RealtimeService) Unsubscribe(sub *Subscription) {
+	r.subMu.Lock()
+	delete(r.subscribers, sub)
+	sub.close()
+	r.subMu.Unlock()
+}
+
+// Subscriptions 获取所有订阅者的统计
+func (r *RealtimeService) Subscriptions() []SubscriptionStats {
+	r.subMu.RLock()
+	defer r.subMu.RUnlock()
+
+	stats := make([]SubscriptionStats, 0, len(r.subscribers))
+	for sub := range r.subscribers {
+		stats = append(stats, sub.Stats())
 	}
-	return nil
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
 }
 
-// calculateZone 计算价格所在的分区号
-// price: 当前价格
-// middle: 中轨价格
-// upper: 上轨价格
-// lower: 下轨价格
-// 返回分区号：-10到+10，0为中轨
-// 分区规则：中轨为0，向上等分10个分区（+1到+10），向下等分10个分区（-1到-10）
-func calculateZone(price, middle, upper, lower float64) int {
-	if middle == 0 {
-		return 0
-	}
-
-	// 如果价格在中轨，返回0
-	if math.Abs(price-middle) < 0.0001 {
-		return 0
-	}
-
-	// 计算价格相对于中轨的位置
-	if price > middle {
-		// 价格在中轨上方
-		upperRange := upper - middle
-		if upperRange <= 0 {
-			return 10 // 如果上轨等于中轨，返回最大分区
-		}
-		// 计算分区：0到+10
-		// 将上轨到中轨的范围等分为10个分区
-		ratio := (price - middle) / upperRange
-		zone := int(ratio * 10)
-		if zone >= 10 {
-			zone = 10
-		}
-		if zone < 1 {
-			zone = 1
-		}
-		return zone
-	} else {
-		// 价格在中轨下方
-		lowerRange := middle - lower
-		if lowerRange <= 0 {
-			return -10 // 如果下轨等于中轨，返回最小分区
-		}
-		// 计算分区：0到-10
-		// 将中轨到下轨的范围等分为10个分区
-		ratio := (middle - price) / lowerRange
-		zone := -int(ratio * 10)
-		if zone <= -10 {
-			zone = -10
-		}
-		if zone > -1 {
-			zone = -1
-		}
-		return zone
+// Close 关闭服务，剩余订阅者的通道随之关闭
+func (r *RealtimeService) Close() error {
+	r.subMu.Lock()
+	for sub := range r.subscribers {
+		delete(r.subscribers, sub)
+		sub.close()
 	}
+	r.subMu.Unlock()
+
+	if r.stream != nil {
+		return r.stream.Close()
+	}
+	return nil
 }
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// testKlines 生成以当前时间对齐结束的确定性1h K线（从旧到新）
+func testKlines(n int) []types.Kline {
+	last := time.Now().Truncate(time.Hour)
+	klines := make([]types.Kline, n)
+	price := 100.0
+	for i := 0; i < n; i++ {
+		open := price
+		price += math.Sin(float64(i)*0.31)*1.5 + math.Cos(float64(i)*0.07)*0.8
+		klines[i] = types.Kline{
+			Symbol:    "TESTUSDT",
+			Open:      open,
+			High:      math.Max(open, price) + 0.6,
+			Low:       math.Min(open, price) - 0.4,
+			Close:     price,
+			Volume:    10 + float64(i%7),
+			Timestamp: last.Add(-time.Duration(n-1-i) * time.Hour),
+		}
+	}
+	return klines
+}
+
+// startRealtime 启动实时服务并等待首次推送
+func startRealtime(t *testing.T, ctx context.Context, provider exchange.MarketDataProvider) *RealtimeService {
+	t.Helper()
+	svc := NewRealtimeService(provider, nil, "TESTUSDT", "1h", nil)
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("启动实时服务失败: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	waitLatest(t, svc, func(*RealtimeData) bool { return true })
+	return svc
+}
+
+// waitLatest 等待最近一次推送的数据满足条件
+func waitLatest(t *testing.T, svc *RealtimeService, cond func(*RealtimeData) bool) *RealtimeData {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data := svc.Latest(); data != nil && cond(data) {
+			return data
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待实时数据超时")
+	return nil
+}
+
+// assertSameSeries 比较两组指标序列的前n个值（n<=0时比较全部）
+func assertSameSeries(t *testing.T, name string, got, want map[string][]float64, n int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: 实例数量 %d，期望 %d", name, len(got), len(want))
+	}
+	for key, w := range want {
+		g := got[key]
+		count := len(w)
+		if n > 0 && n < count {
+			count = n
+		} else if len(g) != len(w) {
+			t.Fatalf("%s_%s: 长度 %d，期望 %d", name, key, len(g), len(w))
+		}
+		for i := 0; i < count; i++ {
+			if math.Abs(g[i]-w[i]) > 1e-6*math.Max(1, math.Abs(w[i])) {
+				t.Fatalf("%s_%s 索引%d: %.10f，期望 %.10f", name, key, i, g[i], w[i])
+			}
+		}
+	}
+}
+
+func TestRealtimeServiceIncrementalMatchesReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	klines := testKlines(400)
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", klines)
+
+	svc := startRealtime(t, ctx, provider)
+	first := svc.Latest()
+	if len(first.Klines) == 0 || !first.Klines[0].Time.Equal(klines[len(klines)-1].Timestamp) {
+		t.Fatalf("首次推送的最新K线不正确: %+v", first.Klines[0])
+	}
+	if len(first.CCI) == 0 || len(first.RSI) == 0 || len(first.MACD) == 0 {
+		t.Fatalf("默认配置的指标缺失: cci=%d rsi=%d macd=%d", len(first.CCI), len(first.RSI), len(first.MACD))
+	}
+
+	// 正在形成的K线多次更新：增量结果与重新加载完整窗口的结果一致
+	forming := klines[len(klines)-1]
+	for _, c := range []float64{forming.Close + 3, forming.Close - 2, forming.Close + 1.25} {
+		k := forming
+		k.Close = c
+		k.High = math.Max(k.High, c)
+		k.Low = math.Min(k.Low, c)
+		provider.Push("TESTUSDT", "1h", exchange.KlineUpdate{Kline: k})
+		waitLatest(t, svc, func(d *RealtimeData) bool { return d.Price == c })
+	}
+	updated := svc.Latest()
+
+	fresh := startRealtime(t, ctx, provider)
+	want := fresh.Latest()
+	if len(updated.Klines) != len(want.Klines) {
+		t.Fatalf("K线数量 %d，期望 %d", len(updated.Klines), len(want.Klines))
+	}
+	assertSameSeries(t, "cci", updated.CCI, want.CCI, 0)
+	assertSameSeries(t, "rsi", updated.RSI, want.RSI, 0)
+	for key, m := range want.MACD {
+		got := updated.MACD[key]
+		assertSameSeries(t, "macd_"+key, map[string][]float64{"line": got.MacdLine, "signal": got.SignalLine, "hist": got.Histogram},
+			map[string][]float64{"line": m.MacdLine, "signal": m.SignalLine, "hist": m.Histogram}, 0)
+	}
+
+	// 新K线开始：追加到窗口末尾，最新值与重新加载的结果一致
+	// （增量窗口比重新加载的窗口多一根K线，只比较两者都有完整数据的最新值）
+	next := forming
+	next.Timestamp = forming.Timestamp.Add(time.Hour)
+	next.Open, next.Close, next.High, next.Low = 101, 102, 103, 100
+	provider.Push("TESTUSDT", "1h", exchange.KlineUpdate{Kline: next})
+	appended := waitLatest(t, svc, func(d *RealtimeData) bool { return d.Klines[0].Time.Equal(next.Timestamp) })
+	if appended.Klines[1].Close != forming.Close+1.25 {
+		t.Fatalf("上一根K线的最终价格 %v，期望 %v", appended.Klines[1].Close, forming.Close+1.25)
+	}
+
+	reloaded := startRealtime(t, ctx, provider).Latest()
+	assertSameSeries(t, "cci", appended.CCI, reloaded.CCI, 1)
+	assertSameSeries(t, "rsi", appended.RSI, reloaded.RSI, 1)
+}
+
+func TestRealtimeServiceSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := exchange.NewMemoryProvider()
+	provider.SetKlines("TESTUSDT", "1h", testKlines(200))
+	svc := startRealtime(t, ctx, provider)
+
+	sub := svc.Subscribe(PolicyCoalesce)
+	select {
+	case data, ok := <-sub.C():
+		if !ok || data.Symbol != "TESTUSDT" || data.Interval != "1h" {
+			t.Fatalf("收到的数据不正确: %v %+v", ok, data)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("订阅者未收到推送")
+	}
+
+	svc.Unsubscribe(sub)
+	if _, ok := <-sub.C(); ok {
+		// 通道中可能残留一条数据，再读一次应已关闭
+		if _, ok := <-sub.C(); ok {
+			t.Fatalf("取消订阅后通道未关闭")
+		}
+	}
+	if len(svc.Subscriptions()) != 0 {
+		t.Fatalf("取消订阅后仍有订阅者: %d", len(svc.Subscriptions()))
+	}
+}
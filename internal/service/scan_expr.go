@@ -0,0 +1,341 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ScanFilter 扫描过滤表达式，如 "boll_zone >= 8 and rsi(48) < 30"
+// 语法：比较式通过 and（或 &&、逗号）、or（或 ||）组合，and 优先；比较式两侧为字段名或数字，
+// 运算符为 >、>=、<、<=、==（或 =）、!=；字段名 rsi(48)、macd(48,72) 等价于 rsi_48、macd_48_72
+type ScanFilter struct {
+	any [][]scanComparison // 外层为 or，内层为 and
+}
+
+// scanComparison 单个比较式
+type scanComparison struct {
+	left, right scanOperand
+	op          string
+}
+
+// scanOperand 比较式的一侧：字段或常数
+type scanOperand struct {
+	field string
+	value float64
+}
+
+// resolve 取操作数的值，字段不存在时返回false
+func (o scanOperand) resolve(fields map[string]float64) (float64, bool) {
+	if o.field == "" {
+		return o.value, true
+	}
+	v, ok := fields[o.field]
+	return v, ok
+}
+
+// ParseScanFilter 解析过滤表达式，空表达式匹配所有结果
+func ParseScanFilter(expr string) (*ScanFilter, error) {
+	tokens, err := scanTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &ScanFilter{}
+	var group []scanComparison
+	for i := 0; i < len(tokens); {
+		if i+3 > len(tokens) {
+			return nil, fmt.Errorf("过滤表达式不完整: %s", strings.Join(tokens[i:], " "))
+		}
+		cmp, err := parseComparison(tokens[i], tokens[i+1], tokens[i+2])
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, cmp)
+		i += 3
+
+		if i == len(tokens) {
+			break
+		}
+		switch strings.ToLower(tokens[i]) {
+		case "and", "&&", ",":
+		case "or", "||":
+			filter.any = append(filter.any, group)
+			group = nil
+		default:
+			return nil, fmt.Errorf("过滤表达式中应为 and 或 or，实际为: %s", tokens[i])
+		}
+		i++
+		if i == len(tokens) {
+			return nil, fmt.Errorf("过滤表达式以 %s 结尾", tokens[i-1])
+		}
+	}
+	if len(group) > 0 {
+		filter.any = append(filter.any, group)
+	}
+	return filter, nil
+}
+
+// Match 判断字段是否满足表达式，表达式引用的字段不存在时该比较式不满足
+func (f *ScanFilter) Match(fields map[string]float64) bool {
+	if f == nil || len(f.any) == 0 {
+		return true
+	}
+	for _, group := range f.any {
+		matched := true
+		for _, cmp := range group {
+			if !cmp.match(fields) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields 表达式引用的所有字段
+func (f *ScanFilter) Fields() []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, group := range f.any {
+		for _, cmp := range group {
+			for _, o := range []scanOperand{cmp.left, cmp.right} {
+				if o.field != "" && !seen[o.field] {
+					seen[o.field] = true
+					fields = append(fields, o.field)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// match 判断单个比较式
+func (c scanComparison) match(fields map[string]float64) bool {
+	l, ok := c.left.resolve(fields)
+	if !ok {
+		return false
+	}
+	r, ok := c.right.resolve(fields)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	}
+	return false
+}
+
+// parseComparison 解析 "左 运算符 右"
+func parseComparison(left, op, right string) (scanComparison, error) {
+	if op == "=" {
+		op = "=="
+	}
+	switch op {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return scanComparison{}, fmt.Errorf("未知的比较运算符: %s", op)
+	}
+	l, err := parseOperand(left)
+	if err != nil {
+		return scanComparison{}, err
+	}
+	r, err := parseOperand(right)
+	if err != nil {
+		return scanComparison{}, err
+	}
+	return scanComparison{left: l, right: r, op: op}, nil
+}
+
+// parseOperand 解析数字或字段名
+func parseOperand(token string) (scanOperand, error) {
+	if v, err := strconv.ParseFloat(token, 64); err == nil {
+		return scanOperand{value: v}, nil
+	}
+	if !isScanIdent(token) {
+		return scanOperand{}, fmt.Errorf("无效的字段: %s", token)
+	}
+	return scanOperand{field: token}, nil
+}
+
+// scanTokens 将表达式拆分为字段、数字、运算符和连接词，字段名中的 name(a,b) 规范化为 name_a_b
+func scanTokens(expr string) ([]string, error) {
+	var tokens []string
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("<>=!", r):
+			j := i + 1
+			if j < len(runes) && runes[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("无效的运算符: %c", r)
+			}
+			tokens = append(tokens, string(runes[i:i+2]))
+			i += 2
+		case r == ',':
+			tokens = append(tokens, ",")
+			i++
+		case isScanIdentRune(r) || r == '-' || r == '+':
+			j := i + 1
+			for j < len(runes) && isScanIdentRune(runes[j]) {
+				j++
+			}
+			token := strings.ToLower(string(runes[i:j]))
+			if j < len(runes) && runes[j] == '(' {
+				end := j
+				for end < len(runes) && runes[end] != ')' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, fmt.Errorf("缺少右括号: %s", string(runes[i:]))
+				}
+				for _, arg := range strings.Split(string(runes[j+1:end]), ",") {
+					arg = strings.TrimSpace(arg)
+					if arg == "" {
+						return nil, fmt.Errorf("字段参数为空: %s", string(runes[i:end+1]))
+					}
+					token += "_" + strings.ToLower(arg)
+				}
+				j = end + 1
+			}
+			tokens = append(tokens, token)
+			i = j
+		default:
+			return nil, fmt.Errorf("无效的字符: %c", r)
+		}
+	}
+	return tokens, nil
+}
+
+// isScanIdentRune 字段名和数字可包含的字符
+func isScanIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isScanIdent 是否为合法的字段名（以字母开头）
+func isScanIdent(token string) bool {
+	if token == "" || !unicode.IsLetter([]rune(token)[0]) {
+		return false
+	}
+	for _, r := range token {
+		if !isScanIdentRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanSortKey 排序字段
+type scanSortKey struct {
+	field string
+	desc  bool
+}
+
+// ParseScanSort 解析排序表达式：逗号分隔的字段，"-字段" 或 "字段 desc" 为降序，如 "-volatility,rsi(48)"
+func ParseScanSort(expr string) ([]scanSortKey, error) {
+	var keys []scanSortKey
+	for _, part := range splitTopLevel(expr) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := scanSortKey{}
+		fields := strings.Fields(part)
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "desc":
+				key.desc = true
+			case "asc":
+			default:
+				return nil, fmt.Errorf("无效的排序方向: %s", fields[1])
+			}
+			part = fields[0]
+		} else if len(fields) > 2 {
+			return nil, fmt.Errorf("无效的排序字段: %s", part)
+		}
+		if strings.HasPrefix(part, "-") {
+			key.desc = true
+			part = part[1:]
+		}
+		tokens, err := scanTokens(part)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) != 1 || !isScanIdent(tokens[0]) {
+			return nil, fmt.Errorf("无效的排序字段: %s", part)
+		}
+		key.field = tokens[0]
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// splitTopLevel 按括号外的逗号拆分
+func splitTopLevel(expr string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+// sortScanResults 按排序字段排序，字段缺失的结果排在最后；没有排序字段时按交易对排序
+func sortScanResults(results []ScanResult, keys []scanSortKey) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Values, results[j].Values
+		for _, key := range keys {
+			va, okA := a[key.field]
+			vb, okB := b[key.field]
+			switch {
+			case !okA && !okB:
+				continue
+			case !okA:
+				return false
+			case !okB:
+				return true
+			case va == vb:
+				continue
+			case key.desc:
+				return va > vb
+			default:
+				return va < vb
+			}
+		}
+		return results[i].Symbol < results[j].Symbol
+	})
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describe 将解析结果格式化为 "a > 1 and b < 2 or c == 3"，常数按%v输出
+func (f *ScanFilter) describe() string {
+	operand := func(o scanOperand) string {
+		if o.field != "" {
+			return o.field
+		}
+		return fmt.Sprintf("%v", o.value)
+	}
+	var groups []string
+	for _, group := range f.any {
+		var cmps []string
+		for _, c := range group {
+			cmps = append(cmps, operand(c.left)+" "+c.op+" "+operand(c.right))
+		}
+		groups = append(groups, strings.Join(cmps, " and "))
+	}
+	return strings.Join(groups, " or ")
+}
+
+func TestParseScanFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string // 解析结果，为空表示应解析失败
+	}{
+		{expr: "", want: ""},
+		{expr: "boll_zone >= 8", want: "boll_zone >= 8"},
+		// and（&&、逗号）优先于 or（||）
+		{expr: "a > 1 and b < 2 or c == 3", want: "a > 1 and b < 2 or c == 3"},
+		{expr: "a > 1 or b < 2 and c == 3", want: "a > 1 or b < 2 and c == 3"},
+		{expr: "a>1 && b<2 || c>3, d<4", want: "a > 1 and b < 2 or c > 3 and d < 4"},
+		{expr: "a > 1 AND b < 2 OR c != 3", want: "a > 1 and b < 2 or c != 3"},
+		// 负数、小数和前置加号
+		{expr: "bb_zone >= -8", want: "bb_zone >= -8"},
+		{expr: "bb_zone>=-8", want: "bb_zone >= -8"},
+		{expr: "volatility > 0.5 and price < +100", want: "volatility > 0.5 and price < 100"},
+		// 带参数的字段名规范化为下划线形式，大小写不敏感
+		{expr: "rsi(48) < 30", want: "rsi_48 < 30"},
+		{expr: "MACD(24, 72) > macd(24,72,9)", want: "macd_24_72 > macd_24_72_9"},
+		{expr: "rsi(48)<30,cci(14)>100", want: "rsi_48 < 30 and cci_14 > 100"},
+		// = 等价于 ==，两侧都可以是字段或常数
+		{expr: "boll_zone = env_zone", want: "boll_zone == env_zone"},
+		{expr: "8 <= boll_zone", want: "8 <= boll_zone"},
+
+		// 连接词位置错误
+		{expr: "a > 1 and"},
+		{expr: "a > 1 or"},
+		{expr: "a > 1,"},
+		{expr: "and a > 1"},
+		{expr: "a > 1 b < 2"},
+		{expr: "a > 1 xor b < 2"},
+		// 括号和参数
+		{expr: "rsi(48 < 30"},
+		{expr: "macd(24,) > 0"},
+		{expr: "rsi() > 0"},
+		// 运算符和操作数
+		{expr: "a => 1"},
+		{expr: "a <> 1"},
+		{expr: "a ! 1"},
+		{expr: "a & b"},
+		{expr: "a | b"},
+		{expr: "a > 1 ; b < 2"},
+		{expr: "a >"},
+		{expr: "a > b > c"},
+		{expr: "1abc > 1"},
+		{expr: "a > -"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseScanFilter(tt.expr)
+			if tt.want == "" && tt.expr != "" {
+				if err == nil {
+					t.Fatalf("应解析失败，实际为: %s", filter.describe())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if got := filter.describe(); got != tt.want {
+				t.Fatalf("解析结果 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanFilterMatch(t *testing.T) {
+	fields := map[string]float64{"boll_zone": -8, "rsi_48": 25, "cci_14": 50}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "", want: true},
+		{expr: "boll_zone >= -8", want: true},
+		{expr: "boll_zone > -8", want: false},
+		{expr: "boll_zone = -8 and rsi(48) < 30", want: true},
+		{expr: "boll_zone = -8 and cci(14) > 100", want: false},
+		{expr: "cci(14) > 100 or rsi(48) < 30", want: true},
+		{expr: "rsi_48 < cci_14", want: true},
+		// 字段不存在时该比较式不满足
+		{expr: "missing != 0", want: false},
+		{expr: "missing != 0 or cci(14) == 50", want: true},
+	}
+	for _, tt := range tests {
+		filter, err := ParseScanFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: 解析失败: %v", tt.expr, err)
+		}
+		if got := filter.Match(fields); got != tt.want {
+			t.Fatalf("%s: 匹配结果 %v，期望 %v", tt.expr, got, tt.want)
+		}
+	}
+
+	filter, _ := ParseScanFilter("rsi(48) < 30 and boll_zone > 0 or rsi(48) > 70")
+	if got := filter.Fields(); !reflect.DeepEqual(got, []string{"rsi_48", "boll_zone"}) {
+		t.Fatalf("引用的字段 %v", got)
+	}
+}
+
+func TestParseScanSort(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []scanSortKey
+		err  bool
+	}{
+		{expr: ""},
+		{expr: "volatility", want: []scanSortKey{{field: "volatility"}}},
+		{expr: "-volatility,rsi(48)", want: []scanSortKey{{field: "volatility", desc: true}, {field: "rsi_48"}}},
+		{expr: " -macd(24,72) , boll_zone desc, cci(14) ASC", want: []scanSortKey{
+			{field: "macd_24_72", desc: true}, {field: "boll_zone", desc: true}, {field: "cci_14"}}},
+		{expr: "-rsi(48) desc", want: []scanSortKey{{field: "rsi_48", desc: true}}},
+		{expr: "rsi sideways", err: true},
+		{expr: "rsi desc asc", err: true},
+		{expr: "-", err: true},
+		{expr: "-8", err: true},
+		{expr: "rsi(48", err: true},
+		{expr: "a>b", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseScanSort(tt.expr)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("应解析失败，实际为: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("排序字段 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortScanResults(t *testing.T) {
+	results := []ScanResult{
+		{Symbol: "DOGEUSDT", Values: map[string]float64{"rsi_48": 40}},
+		{Symbol: "ETHUSDT", Values: map[string]float64{"rsi_48": 60, "volatility": 1}},
+		{Symbol: "BTCUSDT", Values: map[string]float64{"rsi_48": 60, "volatility": 2}},
+		{Symbol: "ADAUSDT", Values: map[string]float64{}},
+	}
+	keys, err := ParseScanSort("-rsi(48),-volatility")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	sortScanResults(results, keys)
+
+	// 字段缺失的结果排在最后，全部相同时按交易对排序
+	var order []string
+	for _, r := range results {
+		order = append(order, r.Symbol)
+	}
+	if want := []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "ADAUSDT"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("排序结果 %v，期望 %v", order, want)
+	}
+}
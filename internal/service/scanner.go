@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binance_cyan/indicators/internal/exchange"
+	"github.com/binance_cyan/indicators/pkg/indicators"
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// ScannerOptions 市场扫描选项
+type ScannerOptions struct {
+	Interval       string        // K线周期
+	Refresh        time.Duration // 刷新间隔
+	Symbols        []string      // 固定的交易对范围，为空时从数据源按下面的条件筛选
+	Market         types.Market  // 筛选交易对的市场
+	QuoteAsset     string        // 报价资产，如 USDT
+	MinQuoteVolume float64       // 24小时成交额下限
+	MaxSymbols     int           // 按成交额从高到低最多扫描的交易对数量
+	Concurrency    int           // 同时计算的交易对数量
+}
+
+// ScanResult 单个交易对的最新指标状态
+type ScanResult struct {
+	Symbol      string    `json:"symbol"`
+	Interval    string    `json:"interval"`
+	BarTime     time.Time `json:"bar_time"` // 最新K线的开盘时间
+	Price       float64   `json:"price"`
+	Volatility  float64   `json:"volatility"` // 5天平均波动价格值
+	BollZone    int       `json:"boll_zone"`
+	EnvZone     int       `json:"env_zone"`
+	QuoteVolume float64   `json:"quote_volume"` // 24小时成交额（固定交易对范围时为0）
+	// Values 可用于过滤和排序的字段（包括上面的数值字段），key 为字段名，如 cci_48、rsi_72、macd_48_72
+	Values    map[string]float64 `json:"values"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// ScanQuery 扫描查询
+type ScanQuery struct {
+	Filter string // 过滤表达式，见 ParseScanFilter
+	Sort   string // 排序表达式，见 ParseScanSort
+	Limit  int    // 最多返回的数量，0 表示不限制
+}
+
+// ScanResponse 扫描结果
+type ScanResponse struct {
+	Interval  string       `json:"interval"`
+	UpdatedAt time.Time    `json:"updated_at"` // 最近一轮扫描完成的时间
+	Universe  int          `json:"universe"`   // 已有结果的交易对数量
+	Matched   int          `json:"matched"`    // 满足过滤条件的数量（截断前）
+	Results   []ScanResult `json:"results"`
+}
+
+// ScannerService 市场扫描服务
+// 定时为交易对范围内的每个交易对获取K线、按该交易对的指标配置计算，缓存最新值供过滤和排序
+type ScannerService struct {
+	provider     exchange.MarketDataProvider
+	configSource ConfigSource
+	opts         ScannerOptions
+	mu           sync.RWMutex
+	results      map[types.Symbol]ScanResult
+	updatedAt    time.Time
+}
+
+// NewScannerService 创建市场扫描服务
+func NewScannerService(provider exchange.MarketDataProvider, configSource ConfigSource, opts ScannerOptions) *ScannerService {
+	if opts.Interval == "" {
+		opts.Interval = "1h"
+	}
+	if opts.Refresh <= 0 {
+		opts.Refresh = 5 * time.Minute
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	return &ScannerService{
+		provider:     provider,
+		configSource: configSource,
+		opts:         opts,
+		results:      make(map[types.Symbol]ScanResult),
+	}
+}
+
+// Start 启动定时扫描（立即执行第一轮）
+func (s *ScannerService) Start(ctx context.Context) error {
+	if _, err := types.IntervalToMinutes(s.opts.Interval); err != nil {
+		return fmt.Errorf("扫描周期无效: %w", err)
+	}
+	if len(s.opts.Symbols) == 0 {
+		if _, ok := s.provider.(exchange.SymbolLister); !ok {
+			return fmt.Errorf("数据源 %s 不支持列出交易对，请在配置中指定 scanner.symbols", s.provider.Name())
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.opts.Refresh)
+		defer ticker.Stop()
+		for {
+			if err := s.scanOnce(ctx); err != nil {
+				log.Printf("市场扫描失败: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// universe 获取本轮扫描的交易对范围及其24小时成交额
+func (s *ScannerService) universe() ([]exchange.SymbolTicker, error) {
+	if len(s.opts.Symbols) > 0 {
+		tickers := make([]exchange.SymbolTicker, 0, len(s.opts.Symbols))
+		for _, symbol := range s.opts.Symbols {
+			tickers = append(tickers, exchange.SymbolTicker{Symbol: strings.ToUpper(strings.TrimSpace(symbol))})
+		}
+		return tickers, nil
+	}
+
+	lister, ok := s.provider.(exchange.SymbolLister)
+	if !ok {
+		return nil, fmt.Errorf("数据源 %s 不支持列出交易对", s.provider.Name())
+	}
+	all, err := lister.ListSymbols(s.opts.Market)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易对列表失败: %w", err)
+	}
+
+	var tickers []exchange.SymbolTicker
+	for _, t := range all {
+		if t.Status != "" && t.Status != "TRADING" {
+			continue
+		}
+		if s.opts.QuoteAsset != "" && !strings.EqualFold(t.QuoteAsset, s.opts.QuoteAsset) {
+			continue
+		}
+		if t.QuoteVolume < s.opts.MinQuoteVolume {
+			continue
+		}
+		tickers = append(tickers, t)
+	}
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].QuoteVolume > tickers[j].QuoteVolume })
+	if s.opts.MaxSymbols > 0 && len(tickers) > s.opts.MaxSymbols {
+		tickers = tickers[:s.opts.MaxSymbols]
+	}
+	return tickers, nil
+}
+
+// scanOnce 执行一轮扫描：计算失败的交易对保留上一轮的结果，已不在范围内的交易对被移除
+func (s *ScannerService) scanOnce(ctx context.Context) error {
+	tickers, err := s.universe()
+	if err != nil {
+		return err
+	}
+
+	limit, err := types.CalculateKlinesForDays(7, s.opts.Interval)
+	if err != nil {
+		return err
+	}
+
+	jobs := make(chan exchange.SymbolTicker)
+	results := make(chan ScanResult, len(tickers))
+	var wg sync.WaitGroup
+	for i := 0; i < s.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				result, err := s.scanSymbol(t, limit)
+				if err != nil {
+					log.Printf("扫描 %s 失败: %v", t.Symbol, err)
+					continue
+				}
+				results <- *result
+			}
+		}()
+	}
+
+feed:
+	for _, t := range tickers {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- t:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	inUniverse := make(map[types.Symbol]bool, len(tickers))
+	for _, t := range tickers {
+		inUniverse[types.Symbol(t.Symbol)] = true
+	}
+
+	s.mu.Lock()
+	for result := range results {
+		s.results[types.Symbol(result.Symbol)] = result
+	}
+	for symbol := range s.results {
+		if !inUniverse[symbol] {
+			delete(s.results, symbol)
+		}
+	}
+	s.updatedAt = time.Now()
+	count := len(s.results)
+	s.mu.Unlock()
+
+	log.Printf("市场扫描完成: %d/%d 个交易对（%s）", count, len(tickers), s.opts.Interval)
+	return ctx.Err()
+}
+
+// scanSymbol 获取单个交易对的K线并计算指标
+func (s *ScannerService) scanSymbol(t exchange.SymbolTicker, limit int) (*ScanResult, error) {
+	symbol := types.Symbol(t.Symbol)
+	klines, err := s.provider.GetRecentKlines(symbol, s.opts.Interval, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取K线失败: %w", err)
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("K线数据为空")
+	}
+
+	config := types.GetDefaultConfig()
+	if s.configSource != nil {
+		config = s.configSource.GetConfig(symbol)
+	}
+	engine := newIndicatorEngine(config, s.opts.Interval)
+	engine.load(klines)
+	return buildScanResult(t, s.opts.Interval, klines, engine.results(klines)), nil
+}
+
+// buildScanResult 将指标结果展开为扫描字段
+// CCI、RSI为 cci_<key>、rsi_<key>；MACD为 macd_<key>（柱）、macd_line_<key>、macd_signal_<key>；
+// 第一个实例另有不带key的别名（cci、rsi、macd）；布林线、包络线第一个实例为 boll_upper、env_middle 等；
+// 其他指标为 <指标名称>_<key>_<输出名称>，只有一个输出时省略输出名称
+func buildScanResult(t exchange.SymbolTicker, interval string, klines []types.Kline, results engineResults) *ScanResult {
+	last := klines[len(klines)-1]
+	values := make(map[string]float64)
+	latest := func(series []float64) (float64, bool) {
+		if len(series) == 0 {
+			return 0, false
+		}
+		return series[0], true
+	}
+	set := func(name string, series []float64) {
+		if v, ok := latest(series); ok {
+			values[name] = v
+		}
+	}
+
+	for kind, instances := range results.values {
+		first := results.first[kind]
+		for key, outputs := range instances {
+			switch kind {
+			case indicators.NameCCI, indicators.NameRSI:
+				set(kind+"_"+key, outputs[kind])
+				if key == first {
+					set(kind, outputs[kind])
+				}
+			case indicators.NameMACD:
+				set("macd_"+key, outputs["histogram"])
+				set("macd_line_"+key, outputs["macd_line"])
+				set("macd_signal_"+key, outputs["signal_line"])
+				if key == first {
+					set("macd", outputs["histogram"])
+					set("macd_line", outputs["macd_line"])
+					set("macd_signal", outputs["signal_line"])
+				}
+			default:
+				prefix := kind + "_" + key
+				if key == first && kind == indicators.NameBollinger {
+					prefix = "boll"
+				} else if key == first && kind == indicators.NameEnvelope {
+					prefix = "env"
+				}
+				for output, series := range outputs {
+					name := prefix + "_" + output
+					if len(outputs) == 1 && prefix != "boll" && prefix != "env" {
+						name = prefix
+					}
+					set(name, series)
+				}
+			}
+		}
+	}
+
+	result := &ScanResult{
+		Symbol:      t.Symbol,
+		Interval:    interval,
+		BarTime:     last.Timestamp,
+		Price:       last.Close,
+		Volatility:  indicators.CalculateVolatility5Days(klines),
+		QuoteVolume: t.QuoteVolume,
+		Values:      values,
+		UpdatedAt:   time.Now(),
+	}
+	if upper, middle, lower := results.band(indicators.NameBollinger); len(middle) > 0 {
+		result.BollZone = indicators.Zone(last.Close, middle[0], upper[0], lower[0])
+	}
+	if upper, middle, lower := results.band(indicators.NameEnvelope); len(middle) > 0 {
+		result.EnvZone = indicators.Zone(last.Close, middle[0], upper[0], lower[0])
+	}
+
+	values["price"] = result.Price
+	values["volatility"] = result.Volatility
+	values["boll_zone"] = float64(result.BollZone)
+	values["env_zone"] = float64(result.EnvZone)
+	values["quote_volume"] = result.QuoteVolume
+	if result.Price > 0 {
+		// 波动占价格的百分比，便于跨交易对比较
+		values["volatility_pct"] = result.Volatility / result.Price * 100
+	}
+	return result
+}
+
+// Scan 按过滤和排序表达式查询缓存的扫描结果
+func (s *ScannerService) Scan(query ScanQuery) (*ScanResponse, error) {
+	filter, err := ParseScanFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	sortKeys, err := ParseScanSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	response := &ScanResponse{
+		Interval:  s.opts.Interval,
+		UpdatedAt: s.updatedAt,
+		Universe:  len(s.results),
+		Results:   []ScanResult{},
+	}
+	for _, result := range s.results {
+		if filter.Match(result.Values) {
+			response.Results = append(response.Results, result)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortScanResults(response.Results, sortKeys)
+	response.Matched = len(response.Results)
+	if query.Limit > 0 && len(response.Results) > query.Limit {
+		response.Results = response.Results[:query.Limit]
+	}
+	return response, nil
+}
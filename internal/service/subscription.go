@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SlowConsumerPolicy 订阅者来不及读取（缓冲已满）时的处理策略
+type SlowConsumerPolicy string
+
+const (
+	// PolicyDropOldest 丢弃缓冲中最旧的一条，放入最新数据
+	PolicyDropOldest SlowConsumerPolicy = "drop_oldest"
+	// PolicyCoalesce 丢弃缓冲中的全部积压，只保留最新数据
+	PolicyCoalesce SlowConsumerPolicy = "coalesce"
+	// PolicyDisconnect 断开订阅（关闭通道），由订阅者决定是否重新订阅
+	PolicyDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+// subscriptionBuffer 每个订阅的缓冲大小
+const subscriptionBuffer = 10
+
+// ParseSlowConsumerPolicy 解析慢消费者策略，空字符串返回 PolicyDropOldest
+func ParseSlowConsumerPolicy(s string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return PolicyDropOldest, nil
+	case PolicyDropOldest, PolicyCoalesce, PolicyDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("未知的慢消费者策略: %s（可用: drop_oldest、coalesce、disconnect）", s)
+	}
+}
+
+// subscriptionSeq 订阅ID生成器
+var subscriptionSeq atomic.Uint64
+
+// Subscription 实时数据订阅句柄
+// 通过 C 读取数据；取消订阅或被 PolicyDisconnect 断开后通道关闭
+type Subscription struct {
+	id           uint64
+	key          StreamKey
+	policy       SlowConsumerPolicy
+	ch           chan *RealtimeData
+	owner        *RealtimeService
+	createdAt    time.Time
+	closed       bool     // 通道是否已关闭（受 owner.subMu 保护）
+	timeframes   []string // 需要多周期共振的周期（受 owner.subMu 保护）
+	delivered    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Bool
+	released     atomic.Bool // RealtimeHub 中的引用是否已释放
+}
+
+// SubscriptionStats 订阅统计
+type SubscriptionStats struct {
+	ID           uint64             `json:"id"`
+	Policy       SlowConsumerPolicy `json:"policy"`
+	Delivered    uint64             `json:"delivered"`    // 成功放入缓冲的消息数
+	Dropped      uint64             `json:"dropped"`      // 因缓冲已满被丢弃的消息数
+	Pending      int                `json:"pending"`      // 当前缓冲中未读取的消息数
+	Disconnected bool               `json:"disconnected"` // 是否因读取过慢被断开
+	Since        time.Time          `json:"since"`
+}
+
+// newSubscription 创建订阅
+func newSubscription(owner *RealtimeService, policy SlowConsumerPolicy) *Subscription {
+	if policy == "" {
+		policy = PolicyDropOldest
+	}
+	return &Subscription{
+		id:        subscriptionSeq.Add(1),
+		key:       StreamKey{Symbol: owner.symbol, Interval: owner.interval},
+		policy:    policy,
+		ch:        make(chan *RealtimeData, subscriptionBuffer),
+		owner:     owner,
+		createdAt: time.Now(),
+	}
+}
+
+// C 数据通道
+func (s *Subscription) C() <-chan *RealtimeData {
+	return s.ch
+}
+
+// Key 订阅的实时流
+func (s *Subscription) Key() StreamKey {
+	return s.key
+}
+
+// Policy 慢消费者策略
+func (s *Subscription) Policy() SlowConsumerPolicy {
+	return s.policy
+}
+
+// SetTimeframes 设置需要多周期共振的周期，实时流按所有订阅者的周期合集计算并缓存到 RealtimeData.Confluence
+// 周期需已通过 ParseTimeframes 校验，为空表示不需要
+func (s *Subscription) SetTimeframes(timeframes []string) {
+	s.owner.subMu.Lock()
+	s.timeframes = append([]string(nil), timeframes...)
+	s.owner.subMu.Unlock()
+}
+
+// Disconnected 是否因读取过慢被断开
+func (s *Subscription) Disconnected() bool {
+	return s.disconnected.Load()
+}
+
+// Stats 获取订阅统计
+func (s *Subscription) Stats() SubscriptionStats {
+	return SubscriptionStats{
+		ID:           s.id,
+		Policy:       s.policy,
+		Delivered:    s.delivered.Load(),
+		Dropped:      s.dropped.Load(),
+		Pending:      len(s.ch),
+		Disconnected: s.disconnected.Load(),
+		Since:        s.createdAt,
+	}
+}
+
+// deliver 按策略投递一条数据，返回false表示订阅应被断开（调用方需持有 owner.subMu 写锁）
+func (s *Subscription) deliver(data *RealtimeData) bool {
+	select {
+	case s.ch <- data:
+		s.delivered.Add(1)
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case PolicyDisconnect:
+		s.dropped.Add(1)
+		s.disconnected.Store(true)
+		return false
+	case PolicyCoalesce:
+		// 清空积压（订阅者可能同时在读取，用非阻塞接收）
+		for drained := false; !drained; {
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+				drained = true
+			}
+		}
+	default:
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+
+	select {
+	case s.ch <- data:
+		s.delivered.Add(1)
+	default:
+		// 只有 RealtimeService 会写入，清空后仍满不会发生；保险起见计为丢弃
+		s.dropped.Add(1)
+	}
+	return true
+}
+
+// close 关闭通道（调用方需持有 owner.subMu 写锁）
+func (s *Subscription) close() {
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
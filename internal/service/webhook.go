@@ -0,0 +1,571 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// Webhook请求头
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"     // 事件类型
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"  // 事件ID（重试时不变）
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp" // 发送时的Unix时间戳（秒）
+	WebhookHeaderSignature = "X-Webhook-Signature" // "sha256=" + HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+)
+
+const (
+	defaultWebhookMaxRetries = 3
+	defaultWebhookBackoff    = time.Second
+	defaultWebhookTimeout    = 10 * time.Second
+	defaultWebhookQueueSize  = 256
+	maxWebhookBackoff        = 5 * time.Minute
+	// maxWebhookResponseLog 投递记录中保存的响应体最大长度
+	maxWebhookResponseLog = 512
+)
+
+// WebhookDeliveryRepository webhook投递记录仓库接口
+type WebhookDeliveryRepository interface {
+	SaveDelivery(ctx context.Context, delivery *types.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, endpoint string, limit int) ([]types.WebhookDelivery, error)
+}
+
+// WebhookEndpoint webhook端点
+type WebhookEndpoint struct {
+	Name    string
+	URL     string
+	Secret  string   // HMAC-SHA256签名密钥，为空时不签名
+	Events  []string // 订阅的事件类型，为空表示全部
+	Symbols []string // 只推送这些交易对的事件，为空表示全部
+	// MaxRetries 失败后的最大重试次数，0 使用默认值3，负数表示不重试
+	MaxRetries int
+	Backoff    time.Duration // 首次重试前的等待时间，之后每次翻倍，默认1秒
+	Timeout    time.Duration // 单次请求超时，默认10秒
+}
+
+// WebhookEndpointInfo 端点信息（用于API展示，不包含密钥）
+type WebhookEndpointInfo struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	Symbols    []string `json:"symbols,omitempty"`
+	MaxRetries int      `json:"max_retries"`
+	Signed     bool     `json:"signed"`
+}
+
+// WebhookOptions webhook通知配置
+type WebhookOptions struct {
+	Streams   []StreamKey // 推送 bar_close 和 zone_change 事件的实时流
+	Endpoints []WebhookEndpoint
+	QueueSize int // 每个端点待发送事件的队列长度，默认256
+}
+
+// BarSnapshot 一根已收盘K线的指标快照（bar_close 事件的数据）
+type BarSnapshot struct {
+	BarTime    time.Time                     `json:"bar_time"`
+	Open       float64                       `json:"open"`
+	High       float64                       `json:"high"`
+	Low        float64                       `json:"low"`
+	Close      float64                       `json:"close"`
+	Volume     float64                       `json:"volume"`
+	CCI        map[string]float64            `json:"cci,omitempty"`
+	RSI        map[string]float64            `json:"rsi,omitempty"`
+	MACD       map[string]MACDPoint          `json:"macd,omitempty"`
+	BollZone   int                           `json:"boll_zone"`
+	EnvZone    int                           `json:"env_zone"`
+	Volatility float64                       `json:"volatility"`
+	Custom     map[string]map[string]float64 `json:"custom,omitempty"`
+}
+
+// MACDPoint 单根K线的MACD值
+type MACDPoint struct {
+	MacdLine   float64 `json:"macd_line"`
+	SignalLine float64 `json:"signal_line"`
+	Histogram  float64 `json:"histogram"`
+}
+
+// ZoneChange 收盘价所在通道分区的变化（zone_change 事件的数据）
+type ZoneChange struct {
+	Indicator string    `json:"indicator"` // bollinger、envelope
+	BarTime   time.Time `json:"bar_time"`
+	Price     float64   `json:"price"` // 收盘价
+	From      int       `json:"from"`  // 上一根已收盘K线的分区号
+	To        int       `json:"to"`
+}
+
+// webhookWorker 一个端点的发送队列（同一端点按顺序发送，互不阻塞）
+type webhookWorker struct {
+	endpoint WebhookEndpoint
+	queue    chan *types.WebhookEvent
+}
+
+// WebhookNotifier webhook通知服务
+// 在配置的实时流K线收盘时推送指标快照和分区变化，并转发告警服务触发的告警；
+// 每个端点独立排队发送，失败时按指数退避重试，每次尝试都写入投递记录
+type WebhookNotifier struct {
+	hub     *RealtimeHub
+	alerts  *AlertService
+	repo    WebhookDeliveryRepository
+	client  *http.Client
+	streams []StreamKey
+	workers []*webhookWorker
+	seq     atomic.Uint64
+}
+
+// NewWebhookNotifier 创建webhook通知服务
+// alerts 为nil时不推送告警事件，repo为nil时投递记录只保存在内存中
+func NewWebhookNotifier(hub *RealtimeHub, alerts *AlertService, repo WebhookDeliveryRepository, opts WebhookOptions) (*WebhookNotifier, error) {
+	if repo == nil {
+		repo = newMemoryWebhookRepository()
+	}
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+
+	n := &WebhookNotifier{
+		hub:     hub,
+		alerts:  alerts,
+		repo:    repo,
+		client:  &http.Client{},
+		streams: opts.Streams,
+	}
+	names := make(map[string]bool)
+	for _, endpoint := range opts.Endpoints {
+		if err := normalizeWebhookEndpoint(&endpoint); err != nil {
+			return nil, err
+		}
+		if names[endpoint.Name] {
+			return nil, fmt.Errorf("webhook端点名称重复: %s", endpoint.Name)
+		}
+		names[endpoint.Name] = true
+		n.workers = append(n.workers, &webhookWorker{
+			endpoint: endpoint,
+			queue:    make(chan *types.WebhookEvent, queueSize),
+		})
+	}
+	return n, nil
+}
+
+// normalizeWebhookEndpoint 校验端点并填充默认值
+func normalizeWebhookEndpoint(e *WebhookEndpoint) error {
+	if e.Name == "" {
+		return fmt.Errorf("webhook端点名称不能为空")
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook端点 %s 的URL无效: %q", e.Name, e.URL)
+	}
+	for _, eventType := range e.Events {
+		if err := types.ValidateWebhookEventType(eventType); err != nil {
+			return fmt.Errorf("webhook端点 %s: %w", e.Name, err)
+		}
+	}
+	symbols := make([]string, 0, len(e.Symbols))
+	for _, symbol := range e.Symbols {
+		symbols = append(symbols, string(normalizeSymbol(types.Symbol(symbol))))
+	}
+	e.Symbols = symbols
+
+	if e.MaxRetries == 0 {
+		e.MaxRetries = defaultWebhookMaxRetries
+	} else if e.MaxRetries < 0 {
+		e.MaxRetries = 0
+	}
+	if e.Backoff <= 0 {
+		e.Backoff = defaultWebhookBackoff
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = defaultWebhookTimeout
+	}
+	return nil
+}
+
+// wants 端点是否订阅了该事件（测试事件总是发送）
+func (e WebhookEndpoint) wants(event *types.WebhookEvent) bool {
+	if event.Type == types.WebhookEventTest {
+		return true
+	}
+	if len(e.Events) > 0 && !containsString(e.Events, event.Type) {
+		return false
+	}
+	if len(e.Symbols) > 0 && !containsString(e.Symbols, event.Symbol) {
+		return false
+	}
+	return true
+}
+
+// containsString 切片中是否包含s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Start 启动各端点的发送队列，并订阅实时流和告警事件，ctx取消后停止
+func (n *WebhookNotifier) Start(ctx context.Context) {
+	for _, w := range n.workers {
+		go n.run(ctx, w)
+	}
+
+	if n.alerts != nil {
+		ch := n.alerts.Subscribe()
+		go func() {
+			defer n.alerts.Unsubscribe(ch)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-ch:
+					if !ok {
+						return
+					}
+					n.Publish(&types.WebhookEvent{
+						Type:     types.WebhookEventAlert,
+						Symbol:   event.Symbol,
+						Interval: event.Interval,
+						Time:     event.TriggeredAt,
+						Data:     event,
+					})
+				}
+			}
+		}()
+	}
+
+	for _, key := range n.streams {
+		// 只关心K线收盘，积压时合并为最新一条（收盘K线的值在之后的推送中仍然存在）
+		go n.hub.Watch(ctx, key, PolicyCoalesce, "webhook通知", n.barCloseHandler())
+	}
+
+	log.Printf("webhook通知已启动，共 %d 个端点，%d 条实时流", len(n.workers), len(n.streams))
+}
+
+// run 按顺序发送一个端点的事件
+func (n *WebhookNotifier) run(ctx context.Context, w *webhookWorker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			n.deliver(ctx, w.endpoint, event)
+		}
+	}
+}
+
+// Publish 把事件放入所有订阅了它的端点的发送队列（队列满时丢弃）
+func (n *WebhookNotifier) Publish(event *types.WebhookEvent) {
+	n.prepare(event)
+	for _, w := range n.workers {
+		if !w.endpoint.wants(event) {
+			continue
+		}
+		select {
+		case w.queue <- event:
+		default:
+			log.Printf("webhook端点 %s 发送队列已满，丢弃事件 %s（%s）", w.endpoint.Name, event.ID, event.Type)
+		}
+	}
+}
+
+// prepare 填充事件ID和时间
+func (n *WebhookNotifier) prepare(event *types.WebhookEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("%d-%d", time.Now().UnixMilli(), n.seq.Add(1))
+	}
+}
+
+// Endpoints 获取所有端点（不包含密钥）
+func (n *WebhookNotifier) Endpoints() []WebhookEndpointInfo {
+	infos := make([]WebhookEndpointInfo, 0, len(n.workers))
+	for _, w := range n.workers {
+		e := w.endpoint
+		events := e.Events
+		if len(events) == 0 {
+			events = types.WebhookEventTypes
+		}
+		infos = append(infos, WebhookEndpointInfo{
+			Name:       e.Name,
+			URL:        e.URL,
+			Events:     events,
+			Symbols:    e.Symbols,
+			MaxRetries: e.MaxRetries,
+			Signed:     e.Secret != "",
+		})
+	}
+	return infos
+}
+
+// Deliveries 获取投递记录（按时间倒序），endpoint为空时返回所有端点
+func (n *WebhookNotifier) Deliveries(ctx context.Context, endpoint string, limit int) ([]types.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return n.repo.ListDeliveries(ctx, endpoint, limit)
+}
+
+// TestSend 向指定端点同步发送一条测试事件（不重试），返回投递记录
+func (n *WebhookNotifier) TestSend(ctx context.Context, name string) (*types.WebhookDelivery, error) {
+	for _, w := range n.workers {
+		if w.endpoint.Name != name {
+			continue
+		}
+		event := &types.WebhookEvent{
+			Type: types.WebhookEventTest,
+			Data: map[string]string{"message": "webhook测试消息"},
+		}
+		n.prepare(event)
+		body, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("序列化webhook事件失败: %w", err)
+		}
+		delivery := n.send(ctx, w.endpoint, event, body, 1)
+		n.saveDelivery(delivery)
+		return delivery, nil
+	}
+	return nil, fmt.Errorf("webhook端点 %s 不存在", name)
+}
+
+// deliver 发送事件，失败时按指数退避重试，返回最后一次尝试的记录
+func (n *WebhookNotifier) deliver(ctx context.Context, endpoint WebhookEndpoint, event *types.WebhookEvent) *types.WebhookDelivery {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("序列化webhook事件失败: %v", err)
+		return nil
+	}
+
+	var delivery *types.WebhookDelivery
+	for attempt := 1; ; attempt++ {
+		delivery = n.send(ctx, endpoint, event, body, attempt)
+		n.saveDelivery(delivery)
+		if delivery.Success {
+			return delivery
+		}
+		if attempt > endpoint.MaxRetries || !retryableWebhookStatus(delivery.StatusCode) {
+			log.Printf("webhook端点 %s 投递事件 %s（%s）失败，共尝试%d次: %s",
+				endpoint.Name, event.ID, event.Type, attempt, delivery.Error)
+			return delivery
+		}
+
+		backoff := endpoint.Backoff << (attempt - 1)
+		if backoff <= 0 || backoff > maxWebhookBackoff {
+			backoff = maxWebhookBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return delivery
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// retryableWebhookStatus 失败的请求是否值得重试：网络错误（状态码0）、429和5xx
+func retryableWebhookStatus(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// send 发送一次请求并返回投递记录（不保存）
+func (n *WebhookNotifier) send(ctx context.Context, endpoint WebhookEndpoint, event *types.WebhookEvent, body []byte, attempt int) *types.WebhookDelivery {
+	delivery := &types.WebhookDelivery{
+		Endpoint:  endpoint.Name,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Symbol:    event.Symbol,
+		URL:       endpoint.URL,
+		Attempt:   attempt,
+		Payload:   string(body),
+		CreatedAt: time.Now(),
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, endpoint.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return delivery
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEvent, event.Type)
+	req.Header.Set(WebhookHeaderDelivery, event.ID)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(WebhookHeaderSignature, "sha256="+SignWebhookPayload(endpoint.Secret, timestamp, body))
+	}
+
+	start := time.Now()
+	resp, err := n.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = fmt.Sprintf("请求失败: %v", err)
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseLog))
+	delivery.StatusCode = resp.StatusCode
+	delivery.Response = string(respBody)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// saveDelivery 保存投递记录
+func (n *WebhookNotifier) saveDelivery(delivery *types.WebhookDelivery) {
+	if err := n.repo.SaveDelivery(context.Background(), delivery); err != nil {
+		log.Printf("保存webhook投递记录失败: %v", err)
+	}
+}
+
+// SignWebhookPayload 计算webhook签名：HMAC-SHA256(secret, "<timestamp>.<body>") 的十六进制
+// 接收方用同一密钥和 X-Webhook-Timestamp 请求头重新计算并与 X-Webhook-Signature 比较
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// barCloseHandler 返回一条实时流的推送处理函数：K线收盘时推送指标快照，分区变化时推送分区变化
+func (n *WebhookNotifier) barCloseHandler() func(*RealtimeData) {
+	return OnBarClose(func(data *RealtimeData, bar BarSnapshot, changes []ZoneChange) {
+		n.Publish(&types.WebhookEvent{
+			Type:     types.WebhookEventBarClose,
+			Symbol:   data.Symbol,
+			Interval: data.Interval,
+			Data:     bar,
+		})
+		for _, change := range changes {
+			n.Publish(&types.WebhookEvent{
+				Type:     types.WebhookEventZoneChange,
+				Symbol:   data.Symbol,
+				Interval: data.Interval,
+				Data:     change,
+			})
+		}
+	})
+}
+
+// OnBarClose 返回一条实时流的推送处理函数：最新K线的开盘时间变化时，以刚收盘K线的指标快照
+// 和分区变化（与上一根已收盘K线相比）调用fn；首次推送只记录当前K线。返回的函数不能并发调用
+func OnBarClose(fn func(data *RealtimeData, bar BarSnapshot, changes []ZoneChange)) func(*RealtimeData) {
+	var lastBar time.Time
+	return func(data *RealtimeData) {
+		if data == nil || len(data.Klines) < 2 {
+			return
+		}
+		current := data.Klines[0].Time
+		if !current.After(lastBar) {
+			return
+		}
+		first := lastBar.IsZero()
+		lastBar = current
+		if first {
+			return
+		}
+
+		bar := BuildBarSnapshot(data, 1)
+		var changes []ZoneChange
+		channels := []struct {
+			name  string
+			value func(i int) (float64, bool)
+		}{
+			{"bollinger", zoneValueFunc(data.Klines, data.Bollinger.Upper, data.Bollinger.Middle, data.Bollinger.Lower)},
+			{"envelope", zoneValueFunc(data.Klines, data.Envelope.Upper, data.Envelope.Middle, data.Envelope.Lower)},
+		}
+		for _, ch := range channels {
+			to, ok1 := ch.value(1)
+			from, ok2 := ch.value(2)
+			if !ok1 || !ok2 || to == from {
+				continue
+			}
+			changes = append(changes, ZoneChange{
+				Indicator: ch.name,
+				BarTime:   bar.BarTime,
+				Price:     bar.Close,
+				From:      int(from),
+				To:        int(to),
+			})
+		}
+		fn(data, bar, changes)
+	}
+}
+
+// BuildBarSnapshot 提取第i根K线（索引0是正在形成的K线）的指标值
+func BuildBarSnapshot(data *RealtimeData, i int) BarSnapshot {
+	k := data.Klines[i]
+	snapshot := BarSnapshot{
+		BarTime:    k.Time,
+		Open:       k.Open,
+		High:       k.High,
+		Low:        k.Low,
+		Close:      k.Close,
+		Volume:     k.Volume,
+		Volatility: data.Volatility,
+	}
+
+	at := func(series []float64) (float64, bool) {
+		if i >= len(series) {
+			return 0, false
+		}
+		return series[i], true
+	}
+	pick := func(src map[string][]float64) map[string]float64 {
+		values := make(map[string]float64)
+		for key, series := range src {
+			if v, ok := at(series); ok {
+				values[key] = v
+			}
+		}
+		return values
+	}
+
+	snapshot.CCI = pick(data.CCI)
+	snapshot.RSI = pick(data.RSI)
+	snapshot.MACD = make(map[string]MACDPoint)
+	for key, m := range data.MACD {
+		line, ok1 := at(m.MacdLine)
+		signal, ok2 := at(m.SignalLine)
+		hist, ok3 := at(m.Histogram)
+		if ok1 && ok2 && ok3 {
+			snapshot.MACD[key] = MACDPoint{MacdLine: line, SignalLine: signal, Histogram: hist}
+		}
+	}
+	if len(data.Custom) > 0 {
+		snapshot.Custom = make(map[string]map[string]float64, len(data.Custom))
+		for name, outputs := range data.Custom {
+			snapshot.Custom[name] = pick(outputs)
+		}
+	}
+
+	b := data.Bollinger
+	if zone, ok := zoneValueFunc(data.Klines, b.Upper, b.Middle, b.Lower)(i); ok {
+		snapshot.BollZone = int(zone)
+	}
+	e := data.Envelope
+	if zone, ok := zoneValueFunc(data.Klines, e.Upper, e.Middle, e.Lower)(i); ok {
+		snapshot.EnvZone = int(zone)
+	}
+	return snapshot
+}
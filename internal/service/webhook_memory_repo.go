@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// maxMemoryWebhookDeliveries 内存仓库保留的最大投递记录数量
+const maxMemoryWebhookDeliveries = 1000
+
+// memoryWebhookRepository 内存webhook投递记录仓库（MySQL不可用时使用，重启后丢失）
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	deliveries []types.WebhookDelivery
+	nextID     int64
+}
+
+// newMemoryWebhookRepository 创建内存webhook投递记录仓库
+func newMemoryWebhookRepository() *memoryWebhookRepository {
+	return &memoryWebhookRepository{}
+}
+
+func (m *memoryWebhookRepository) SaveDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	delivery.ID = m.nextID
+	m.deliveries = append(m.deliveries, *delivery)
+	if len(m.deliveries) > maxMemoryWebhookDeliveries {
+		m.deliveries = m.deliveries[len(m.deliveries)-maxMemoryWebhookDeliveries:]
+	}
+	return nil
+}
+
+func (m *memoryWebhookRepository) ListDeliveries(ctx context.Context, endpoint string, limit int) ([]types.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deliveries := []types.WebhookDelivery{}
+	for i := len(m.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if endpoint == "" || m.deliveries[i].Endpoint == endpoint {
+			deliveries = append(deliveries, m.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// webhookRequest 测试服务器收到的一次请求
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer 按顺序返回给定状态码的测试服务器（用完后重复最后一个），记录收到的请求
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, webhookRequest{header: r.Header.Clone(), body: body})
+		status := s.statuses[0]
+		if len(s.statuses) > 1 {
+			s.statuses = s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		w.Write([]byte(http.StatusText(status)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received 已收到的请求
+func (s *webhookServer) received() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.requests...)
+}
+
+// startWebhook 创建并启动只有一个端点的webhook通知服务
+func startWebhook(t *testing.T, endpoint WebhookEndpoint) *WebhookNotifier {
+	t.Helper()
+	n, err := NewWebhookNotifier(nil, nil, nil, WebhookOptions{Endpoints: []WebhookEndpoint{endpoint}})
+	if err != nil {
+		t.Fatalf("创建webhook通知服务失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	n.Start(ctx)
+	return n
+}
+
+// waitDeliveries 等待投递记录达到指定数量，返回按尝试顺序排列的记录
+func waitDeliveries(t *testing.T, n *WebhookNotifier, count int) []types.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := n.Deliveries(context.Background(), "", 100)
+		if err != nil {
+			t.Fatalf("获取投递记录失败: %v", err)
+		}
+		if len(deliveries) >= count {
+			for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+				deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+			}
+			return deliveries
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待 %d 条投递记录超时", count)
+	return nil
+}
+
+func TestWebhookSignature(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	n := startWebhook(t, WebhookEndpoint{Name: "signed", URL: server.URL, Secret: "s3cret"})
+
+	n.Publish(&types.WebhookEvent{Type: types.WebhookEventBarClose, Symbol: "BTCUSDT", Interval: "1h", Data: BarSnapshot{Close: 100}})
+	deliveries := waitDeliveries(t, n, 1)
+	if !deliveries[0].Success || deliveries[0].StatusCode != http.StatusOK {
+		t.Fatalf("投递应成功: %+v", deliveries[0])
+	}
+
+	req := server.received()[0]
+	timestamp := req.header.Get(WebhookHeaderTimestamp)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("时间戳请求头无效: %q", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "." + string(req.body)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.header.Get(WebhookHeaderSignature) != want {
+		t.Fatalf("签名 %q，期望 %q", req.header.Get(WebhookHeaderSignature), want)
+	}
+
+	var event types.WebhookEvent
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatalf("请求体不是webhook事件: %v", err)
+	}
+	if event.Type != types.WebhookEventBarClose || event.Symbol != "BTCUSDT" || req.header.Get(WebhookHeaderEvent) != event.Type {
+		t.Fatalf("事件内容不正确: %+v", event)
+	}
+	if req.header.Get(WebhookHeaderDelivery) != event.ID || deliveries[0].EventID != event.ID {
+		t.Fatalf("事件ID不一致: 请求头 %q，请求体 %q，记录 %q", req.header.Get(WebhookHeaderDelivery), event.ID, deliveries[0].EventID)
+	}
+
+	// 未配置密钥时不签名
+	unsigned := newWebhookServer(t, http.StatusOK)
+	n = startWebhook(t, WebhookEndpoint{Name: "plain", URL: unsigned.URL})
+	n.Publish(&types.WebhookEvent{Type: types.WebhookEventBarClose, Symbol: "BTCUSDT"})
+	waitDeliveries(t, n, 1)
+	if sig := unsigned.received()[0].header.Get(WebhookHeaderSignature); sig != "" {
+		t.Fatalf("未配置密钥时不应签名: %q", sig)
+	}
+}
+
+func TestWebhookRetriesUntilDelivered(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	n := startWebhook(t, WebhookEndpoint{Name: "flaky", URL: server.URL, Secret: "s3cret", Backoff: time.Millisecond})
+
+	n.Publish(&types.WebhookEvent{Type: types.WebhookEventBarClose, Symbol: "BTCUSDT"})
+	deliveries := waitDeliveries(t, n, 3)
+	time.Sleep(50 * time.Millisecond)
+	if all := waitDeliveries(t, n, 3); len(all) != 3 {
+		t.Fatalf("成功后不应继续重试，共 %d 条记录", len(all))
+	}
+
+	for i, status := range []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK} {
+		d := deliveries[i]
+		if d.Attempt != i+1 || d.StatusCode != status || d.Success != (status == http.StatusOK) {
+			t.Fatalf("第%d次尝试记录不正确: %+v", i+1, d)
+		}
+		if d.EventID != deliveries[0].EventID || d.Endpoint != "flaky" {
+			t.Fatalf("重试的事件ID或端点变化: %+v", d)
+		}
+	}
+	if deliveries[0].Error == "" || deliveries[2].Error != "" {
+		t.Fatalf("失败记录应有错误信息，成功记录不应有: %q %q", deliveries[0].Error, deliveries[2].Error)
+	}
+
+	requests := server.received()
+	for _, req := range requests[1:] {
+		if req.header.Get(WebhookHeaderDelivery) != requests[0].header.Get(WebhookHeaderDelivery) {
+			t.Fatalf("重试时事件ID请求头变化")
+		}
+	}
+}
+
+func TestWebhookPermanentFailure(t *testing.T) {
+	server := newWebhookServer(t, http.StatusInternalServerError)
+	n := startWebhook(t, WebhookEndpoint{Name: "down", URL: server.URL, MaxRetries: 2, Backoff: time.Millisecond})
+
+	n.Publish(&types.WebhookEvent{Type: types.WebhookEventBarClose, Symbol: "BTCUSDT"})
+	deliveries := waitDeliveries(t, n, 3)
+	time.Sleep(50 * time.Millisecond)
+	if all := waitDeliveries(t, n, 3); len(all) != 3 || len(server.received()) != 3 {
+		t.Fatalf("最多尝试 1+2 次，实际记录 %d 条、请求 %d 次", len(all), len(server.received()))
+	}
+	for i, d := range deliveries {
+		if d.Attempt != i+1 || d.Success || d.StatusCode != http.StatusInternalServerError || d.Error == "" {
+			t.Fatalf("第%d次尝试应记录为失败: %+v", i+1, d)
+		}
+	}
+
+	// 4xx（429除外）不重试
+	rejected := newWebhookServer(t, http.StatusBadRequest)
+	n = startWebhook(t, WebhookEndpoint{Name: "rejected", URL: rejected.URL, Backoff: time.Millisecond})
+	n.Publish(&types.WebhookEvent{Type: types.WebhookEventBarClose, Symbol: "BTCUSDT"})
+	deliveries = waitDeliveries(t, n, 1)
+	time.Sleep(50 * time.Millisecond)
+	if len(rejected.received()) != 1 || deliveries[0].Success || deliveries[0].StatusCode != http.StatusBadRequest {
+		t.Fatalf("400应只尝试一次并记录为失败: 请求 %d 次，%+v", len(rejected.received()), deliveries[0])
+	}
+}
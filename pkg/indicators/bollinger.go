@@ -8,36 +8,22 @@ import "math"
 // deviation: 标准差倍数，默认2.0
 // 返回: upper, middle, lower
 func CalculateBollinger(price []float64, period int, deviation float64) (upper, middle, lower []float64) {
+	return CalculateBollingerWithMA(price, period, deviation, MAWMA)
+}
+
+// CalculateBollingerWithMA 使用指定移动平均方法计算布林线中轨（经典定义为 MASMA），标准差仍相对于窗口SMA
+func CalculateBollingerWithMA(price []float64, period int, deviation float64, method MAMethod) (upper, middle, lower []float64) {
 	if len(price) < period {
 		return nil, nil, nil
 	}
 
 	upper = make([]float64, len(price))
-	middle = make([]float64, len(price))
 	lower = make([]float64, len(price))
 
-	// 步骤1：计算中轨（使用WMA）
-	wmaDenominator := float64(period * (period + 1) / 2)
+	// 步骤1：计算中轨（默认WMA）
+	middle = CalculateMA(price, period, method)
 	maxI := len(price) - period
 
-	// 计算WMA
-	for i := maxI; i >= 0; i-- {
-		weightedSum := 0.0
-		for j := 0; j < period; j++ {
-			weight := float64(period - j) // 最新数据权重最大
-			weightedSum += price[i+j] * weight
-		}
-		middle[i] = weightedSum / wmaDenominator
-	}
-
-	// 对于前面的数据点，使用最近的有效WMA值
-	if maxI >= 0 && maxI < len(price)-1 {
-		lastWMA := middle[maxI]
-		for i := maxI + 1; i < len(price); i++ {
-			middle[i] = lastWMA
-		}
-	}
-
 	// 步骤2：计算标准差（使用滚动窗口，相对于窗口SMA）
 	std := make([]float64, len(price))
 	invPeriod := 1.0 / float64(period)
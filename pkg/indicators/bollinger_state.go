@@ -0,0 +1,124 @@
+package indicators
+
+import "math"
+
+// BollingerState 增量布林线（默认WMA中轨，标准差相对于窗口SMA）
+// 每次更新的开销为O(period)，结果与 CalculateBollingerWithMA 一致
+type BollingerState struct {
+	period        int
+	deviation     float64
+	invPeriod     float64
+	middle        MAState
+	window        priceWindow
+	windowSum     float64 // 最新窗口的价格和
+	prevWindowSum float64 // 上一根K线窗口的价格和（用于更新最新K线）
+	upper         barSeries
+	lower         barSeries
+}
+
+// NewBollingerState 创建增量布林线（WMA中轨，与 CalculateBollinger 一致）
+func NewBollingerState(period int, deviation float64) *BollingerState {
+	return NewBollingerStateWithMA(period, deviation, MAWMA)
+}
+
+// NewBollingerStateWithMA 使用指定移动平均方法创建增量布林线
+func NewBollingerStateWithMA(period int, deviation float64, method MAMethod) *BollingerState {
+	if period < 1 {
+		period = 1
+	}
+	return &BollingerState{
+		period:    period,
+		deviation: deviation,
+		invPeriod: 1.0 / float64(period),
+		middle:    NewMAState(period, method),
+		// 滑动窗口需要额外保存一个移出窗口的价格
+		window: priceWindow{size: period + 1},
+		upper:  barSeries{minBars: period, fill: true},
+		lower:  barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格
+func (s *BollingerState) Append(price float64) {
+	s.middle.Append(price)
+	s.window.push(price)
+	s.prevWindowSum = s.windowSum
+
+	upper, lower, ok := s.compute()
+	s.upper.push(upper, ok)
+	s.lower.push(lower, ok)
+}
+
+// UpdateLast 更新最新K线的价格
+func (s *BollingerState) UpdateLast(price float64) {
+	if s.upper.bars == 0 {
+		s.Append(price)
+		return
+	}
+	s.middle.UpdateLast(price)
+	s.window.setLast(price)
+
+	upper, lower, ok := s.compute()
+	s.upper.setLast(upper, ok)
+	s.lower.setLast(lower, ok)
+}
+
+// compute 计算最新K线的上下轨
+func (s *BollingerState) compute() (upper, lower float64, ok bool) {
+	if s.middle.Len() < s.period {
+		return 0, 0, false
+	}
+
+	if s.middle.Len() == s.period {
+		// 第一个完整窗口：直接求和
+		s.windowSum = 0
+		for j := 0; j < s.period; j++ {
+			s.windowSum += s.window.get(j)
+		}
+	} else {
+		// 滑动窗口：减去最旧的值，加上新的值
+		s.windowSum = s.prevWindowSum - s.window.get(s.period) + s.window.get(0)
+	}
+
+	windowSMA := s.windowSum * s.invPeriod
+	sumSq := 0.0
+	for j := 0; j < s.period; j++ {
+		diff := s.window.get(j) - windowSMA
+		sumSq += diff * diff
+	}
+	std := math.Sqrt(sumSq * s.invPeriod)
+
+	middle := s.middle.Value()
+	devValue := s.deviation * std
+	return middle + devValue, middle - devValue, true
+}
+
+// Value 获取最新的上轨、中轨、下轨值
+func (s *BollingerState) Value() (upper, middle, lower float64) {
+	upper, _ = s.upper.last()
+	lower, _ = s.lower.last()
+	return upper, s.middle.Value(), lower
+}
+
+// Ready 是否已有足够数据
+func (s *BollingerState) Ready() bool {
+	return s.middle.Ready()
+}
+
+// Values 获取完整布林线数组（索引0是最新数据），与 CalculateBollinger 返回格式一致
+func (s *BollingerState) Values() (upper, middle, lower []float64) {
+	if !s.Ready() {
+		return nil, nil, nil
+	}
+	return s.upper.series(), s.middle.Values(), s.lower.series()
+}
+
+// Reset 清空状态
+func (s *BollingerState) Reset() {
+	s.middle.Reset()
+	s.window.reset()
+	s.windowSum = 0
+	s.prevWindowSum = 0
+	s.upper.reset()
+	s.lower.reset()
+}
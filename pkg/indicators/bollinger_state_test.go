@@ -0,0 +1,45 @@
+package indicators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBandStatesMatchBatch(t *testing.T) {
+	prices := testPrices(120)
+	for _, method := range MAMethods() {
+		method := method
+		t.Run(fmt.Sprintf("bollinger_%s", method), func(t *testing.T) {
+			state := NewBollingerStateWithMA(20, 2, method)
+			walkBars(prices, func(p float64, first bool) {
+				if first {
+					state.Append(p)
+				} else {
+					state.UpdateLast(p)
+				}
+			}, func(bar int, history []float64) {
+				wantUpper, wantMiddle, wantLower := CalculateBollingerWithMA(history, 20, 2, method)
+				upper, middle, lower := state.Values()
+				assertSeries(t, "Upper", bar, upper, wantUpper)
+				assertSeries(t, "Middle", bar, middle, wantMiddle)
+				assertSeries(t, "Lower", bar, lower, wantLower)
+			})
+		})
+		t.Run(fmt.Sprintf("envelope_%s", method), func(t *testing.T) {
+			state := NewEnvelopeStateWithMA(14, 0.5, method)
+			walkBars(prices, func(p float64, first bool) {
+				if first {
+					state.Append(p)
+				} else {
+					state.UpdateLast(p)
+				}
+			}, func(bar int, history []float64) {
+				wantUpper, wantMiddle, wantLower := CalculateEnvelopeWithMA(history, 14, 0.5, method)
+				upper, middle, lower := state.Values()
+				assertSeries(t, "Upper", bar, upper, wantUpper)
+				assertSeries(t, "Middle", bar, middle, wantMiddle)
+				assertSeries(t, "Lower", bar, lower, wantLower)
+			})
+		})
+	}
+}
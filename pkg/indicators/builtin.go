@@ -0,0 +1,378 @@
+package indicators
+
+import "fmt"
+
+// 内置指标名称
+const (
+	NameWMA       = "wma"
+	NameSMA       = "sma"
+	NameEMA       = "ema"
+	NameSMMA      = "smma"
+	NameCCI       = "cci"
+	NameRSI       = "rsi"
+	NameMACD      = "macd"
+	NameBollinger = "bollinger"
+	NameEnvelope  = "envelope"
+)
+
+func init() {
+	for _, ind := range []Indicator{
+		wmaIndicator{},
+		smaIndicator{},
+		emaIndicator{},
+		smmaIndicator{},
+		cciIndicator{},
+		rsiIndicator{},
+		macdIndicator{},
+		bollingerIndicator{},
+		envelopeIndicator{},
+	} {
+		if err := Register(ind); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// periodParam 周期参数定义
+func periodParam(def float64, desc string) ParamSpec {
+	return ParamSpec{Name: "period", Type: ParamInt, Default: def, Min: 1, Description: desc, Scalable: true}
+}
+
+// errInsufficient 数据不足错误
+func errInsufficient(name string, need, have int) error {
+	return fmt.Errorf("%s 数据不足: 需要%d根K线, 当前%d根", name, need, have)
+}
+
+// wmaIndicator 加权移动平均
+type wmaIndicator struct{}
+
+func (wmaIndicator) Name() string        { return NameWMA }
+func (wmaIndicator) Description() string { return "加权移动平均（WMA）" }
+func (wmaIndicator) Params() []ParamSpec { return []ParamSpec{periodParam(24, "周期")} }
+func (wmaIndicator) Outputs() []string   { return []string{"wma"} }
+func (wmaIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i wmaIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	wma := CalculateWMA(in.Price, p.Int("period"))
+	if wma == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"wma": wma}, nil
+}
+
+func (wmaIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "wma", state: NewWMAState(p.Int("period"))}
+}
+
+// smaIndicator 简单移动平均
+type smaIndicator struct{}
+
+func (smaIndicator) Name() string        { return NameSMA }
+func (smaIndicator) Description() string { return "简单移动平均（SMA）" }
+func (smaIndicator) Params() []ParamSpec { return []ParamSpec{periodParam(24, "周期")} }
+func (smaIndicator) Outputs() []string   { return []string{"sma"} }
+func (smaIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i smaIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	sma := CalculateSMA(in.Price, p.Int("period"))
+	if sma == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"sma": sma}, nil
+}
+
+func (smaIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "sma", state: NewSMAState(p.Int("period"))}
+}
+
+// emaIndicator 指数移动平均
+type emaIndicator struct{}
+
+func (emaIndicator) Name() string        { return NameEMA }
+func (emaIndicator) Description() string { return "指数移动平均（EMA，以SMA为初值）" }
+func (emaIndicator) Params() []ParamSpec { return []ParamSpec{periodParam(24, "周期")} }
+func (emaIndicator) Outputs() []string   { return []string{"ema"} }
+func (emaIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i emaIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	ema := CalculateEMA(in.Price, p.Int("period"))
+	if ema == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"ema": ema}, nil
+}
+
+func (emaIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "ema", state: NewEMAState(p.Int("period"))}
+}
+
+// smmaIndicator 平滑移动平均
+type smmaIndicator struct{}
+
+func (smmaIndicator) Name() string        { return NameSMMA }
+func (smmaIndicator) Description() string { return "平滑移动平均（SMMA，Wilder平滑）" }
+func (smmaIndicator) Params() []ParamSpec { return []ParamSpec{periodParam(24, "周期")} }
+func (smmaIndicator) Outputs() []string   { return []string{"smma"} }
+func (smmaIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i smmaIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	smma := CalculateSMMA(in.Price, p.Int("period"))
+	if smma == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"smma": smma}, nil
+}
+
+func (smmaIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "smma", state: NewSMMAState(p.Int("period"))}
+}
+
+// cciIndicator 商品通道指数
+type cciIndicator struct{}
+
+func (cciIndicator) Name() string { return NameCCI }
+func (cciIndicator) Description() string {
+	return "商品通道指数（CCI，默认WMA均线 + 标准MAD）"
+}
+func (cciIndicator) Params() []ParamSpec {
+	return []ParamSpec{periodParam(48, "周期（小时）"), maParam()}
+}
+func (cciIndicator) Outputs() []string   { return []string{"cci"} }
+func (cciIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i cciIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	cci := CalculateCCIWithMA(in.Price, p.Int("period"), p.MA())
+	if cci == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"cci": cci}, nil
+}
+
+func (cciIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "cci", state: NewCCIStateWithMA(p.Int("period"), p.MA())}
+}
+
+// rsiIndicator 相对强弱指数
+type rsiIndicator struct{}
+
+func (rsiIndicator) Name() string        { return NameRSI }
+func (rsiIndicator) Description() string { return "相对强弱指数（RSI，默认WMA平滑）" }
+func (rsiIndicator) Params() []ParamSpec {
+	return []ParamSpec{periodParam(48, "周期（小时）"), maParam()}
+}
+func (rsiIndicator) Outputs() []string   { return []string{"rsi"} }
+func (rsiIndicator) WarmUp(p Params) int { return p.Int("period") + 1 }
+
+func (i rsiIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	rsi := CalculateRSIWithMA(in.Price, p.Int("period"), p.MA())
+	if rsi == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"rsi": rsi}, nil
+}
+
+func (rsiIndicator) NewStream(p Params) Stream {
+	return &singleStream{name: "rsi", state: NewRSIStateWithMA(p.Int("period"), p.MA())}
+}
+
+// macdIndicator MACD
+type macdIndicator struct{}
+
+func (macdIndicator) Name() string { return NameMACD }
+func (macdIndicator) Description() string {
+	return "MACD（默认WMA快慢线，MACD线为前两根K线差值均值）"
+}
+func (macdIndicator) Params() []ParamSpec {
+	return []ParamSpec{
+		{Name: "fast", Type: ParamInt, Default: 48, Min: 1, Description: "快线周期（小时）", Scalable: true},
+		{Name: "slow", Type: ParamInt, Default: 72, Min: 1, Description: "慢线周期（小时）", Scalable: true},
+		{Name: "signal", Type: ParamInt, Default: 2, Min: 1, Description: "信号线周期（小时）", Scalable: true},
+		maParam(),
+	}
+}
+func (macdIndicator) Outputs() []string { return []string{"macd_line", "signal_line", "histogram"} }
+
+func (macdIndicator) WarmUp(p Params) int {
+	warmUp := p.Int("slow")
+	if p.Int("fast") > warmUp {
+		warmUp = p.Int("fast")
+	}
+	return warmUp
+}
+
+func (i macdIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	macdLine, signalLine, histogram := CalculateMACDWithMA(in.Price, p.Int("fast"), p.Int("slow"), p.Int("signal"), p.MA())
+	if macdLine == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{
+		"macd_line":   macdLine,
+		"signal_line": signalLine,
+		"histogram":   histogram,
+	}, nil
+}
+
+func (macdIndicator) NewStream(p Params) Stream {
+	return &macdStream{state: NewMACDStateWithMA(p.Int("fast"), p.Int("slow"), p.Int("signal"), p.MA())}
+}
+
+// bollingerIndicator 布林线
+type bollingerIndicator struct{}
+
+func (bollingerIndicator) Name() string { return NameBollinger }
+func (bollingerIndicator) Description() string {
+	return "布林线（默认WMA中轨，标准差相对于窗口SMA）"
+}
+func (bollingerIndicator) Params() []ParamSpec {
+	return []ParamSpec{
+		periodParam(24, "周期（小时）"),
+		{Name: "deviation", Type: ParamFloat, Default: 2.0, Min: 0, Description: "标准差倍数"},
+		maParam(),
+	}
+}
+func (bollingerIndicator) Outputs() []string   { return []string{"upper", "middle", "lower"} }
+func (bollingerIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i bollingerIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	upper, middle, lower := CalculateBollingerWithMA(in.Price, p.Int("period"), p.Float("deviation"), p.MA())
+	if middle == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"upper": upper, "middle": middle, "lower": lower}, nil
+}
+
+func (bollingerIndicator) NewStream(p Params) Stream {
+	return &bandStream{state: NewBollingerStateWithMA(p.Int("period"), p.Float("deviation"), p.MA())}
+}
+
+// envelopeIndicator 包络线
+type envelopeIndicator struct{}
+
+func (envelopeIndicator) Name() string { return NameEnvelope }
+func (envelopeIndicator) Description() string {
+	return "包络线（默认WMA中轨，上下轨为百分比偏移）"
+}
+func (envelopeIndicator) Params() []ParamSpec {
+	return []ParamSpec{
+		periodParam(24, "周期（小时）"),
+		{Name: "deviation", Type: ParamFloat, Default: 2.28, Min: 0, Description: "偏差百分比"},
+		maParam(),
+	}
+}
+func (envelopeIndicator) Outputs() []string   { return []string{"upper", "middle", "lower"} }
+func (envelopeIndicator) WarmUp(p Params) int { return p.Int("period") }
+
+func (i envelopeIndicator) Compute(in Input, p Params) (map[string][]float64, error) {
+	upper, middle, lower := CalculateEnvelopeWithMA(in.Price, p.Int("period"), p.Float("deviation"), p.MA())
+	if middle == nil {
+		return nil, errInsufficient(i.Name(), i.WarmUp(p), len(in.Price))
+	}
+	return map[string][]float64{"upper": upper, "middle": middle, "lower": lower}, nil
+}
+
+func (envelopeIndicator) NewStream(p Params) Stream {
+	return &bandStream{state: NewEnvelopeStateWithMA(p.Int("period"), p.Float("deviation"), p.MA())}
+}
+
+// singleState 单输出增量状态
+type singleState interface {
+	Append(price float64) float64
+	UpdateLast(price float64) float64
+	Value() float64
+	Ready() bool
+	Values() []float64
+	Reset()
+}
+
+// singleStream 单输出增量流
+type singleStream struct {
+	name  string
+	state singleState
+}
+
+func (s *singleStream) Append(price float64)     { s.state.Append(price) }
+func (s *singleStream) UpdateLast(price float64) { s.state.UpdateLast(price) }
+func (s *singleStream) Reset()                   { s.state.Reset() }
+
+func (s *singleStream) Values() map[string][]float64 {
+	values := s.state.Values()
+	if values == nil {
+		return nil
+	}
+	return map[string][]float64{s.name: values}
+}
+
+func (s *singleStream) Last() map[string]float64 {
+	if !s.state.Ready() {
+		return nil
+	}
+	return map[string]float64{s.name: s.state.Value()}
+}
+
+// macdStream MACD增量流
+type macdStream struct {
+	state *MACDState
+}
+
+func (s *macdStream) Append(price float64)     { s.state.Append(price) }
+func (s *macdStream) UpdateLast(price float64) { s.state.UpdateLast(price) }
+func (s *macdStream) Reset()                   { s.state.Reset() }
+
+func (s *macdStream) Values() map[string][]float64 {
+	macdLine, signalLine, histogram := s.state.Values()
+	if macdLine == nil {
+		return nil
+	}
+	return map[string][]float64{
+		"macd_line":   macdLine,
+		"signal_line": signalLine,
+		"histogram":   histogram,
+	}
+}
+
+func (s *macdStream) Last() map[string]float64 {
+	if !s.state.Ready() {
+		return nil
+	}
+	macdLine, signalLine, histogram := s.state.Value()
+	return map[string]float64{
+		"macd_line":   macdLine,
+		"signal_line": signalLine,
+		"histogram":   histogram,
+	}
+}
+
+// bandState 通道类增量状态（布林线、包络线）
+type bandState interface {
+	Append(price float64)
+	UpdateLast(price float64)
+	Value() (upper, middle, lower float64)
+	Ready() bool
+	Values() (upper, middle, lower []float64)
+	Reset()
+}
+
+// bandStream 通道类增量流
+type bandStream struct {
+	state bandState
+}
+
+func (s *bandStream) Append(price float64)     { s.state.Append(price) }
+func (s *bandStream) UpdateLast(price float64) { s.state.UpdateLast(price) }
+func (s *bandStream) Reset()                   { s.state.Reset() }
+
+func (s *bandStream) Values() map[string][]float64 {
+	upper, middle, lower := s.state.Values()
+	if middle == nil {
+		return nil
+	}
+	return map[string][]float64{"upper": upper, "middle": middle, "lower": lower}
+}
+
+func (s *bandStream) Last() map[string]float64 {
+	if !s.state.Ready() {
+		return nil
+	}
+	upper, middle, lower := s.state.Value()
+	return map[string]float64{"upper": upper, "middle": middle, "lower": lower}
+}
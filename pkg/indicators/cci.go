@@ -8,12 +8,18 @@ import "math"
 // 返回CCI数组（索引0是最新数据）
 // 使用WMA作为移动平均（与MQ5保持一致）
 func CalculateCCI(price []float64, period int) []float64 {
+	return CalculateCCIWithMA(price, period, MAWMA)
+}
+
+// CalculateCCIWithMA 使用指定移动平均方法计算CCI（经典定义为 MASMA）
+// MAD 相对于同一条移动平均计算
+func CalculateCCIWithMA(price []float64, period int, method MAMethod) []float64 {
 	if len(price) < period {
 		return nil
 	}
 
-	// 计算WMA作为移动平均（与MQ5一致）
-	wma := CalculateWMA(price, period)
+	// 计算移动平均（默认WMA，与MQ5一致）
+	wma := CalculateMA(price, period, method)
 	if wma == nil {
 		return nil
 	}
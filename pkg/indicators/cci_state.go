@@ -0,0 +1,94 @@
+package indicators
+
+import "math"
+
+// CCIState 增量商品通道指数（CCI）
+// 输入 (H+L+C)/3 价格，每次更新的开销为O(period)，结果与 CalculateCCI 一致
+type CCIState struct {
+	period    int
+	invPeriod float64
+	wma       MAState
+	window    priceWindow
+	out       barSeries
+}
+
+// NewCCIState 创建增量CCI（WMA，与 CalculateCCI 一致）
+func NewCCIState(period int) *CCIState {
+	return NewCCIStateWithMA(period, MAWMA)
+}
+
+// NewCCIStateWithMA 使用指定移动平均方法创建增量CCI，结果与 CalculateCCIWithMA 一致
+func NewCCIStateWithMA(period int, method MAMethod) *CCIState {
+	if period < 1 {
+		period = 1
+	}
+	return &CCIState{
+		period:    period,
+		invPeriod: 1.0 / float64(period),
+		wma:       NewMAState(period, method),
+		window:    priceWindow{size: period},
+		out:       barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格，返回最新CCI值
+func (s *CCIState) Append(price float64) float64 {
+	s.wma.Append(price)
+	s.window.push(price)
+	v, ok := s.compute()
+	s.out.push(v, ok)
+	return v
+}
+
+// UpdateLast 更新最新K线的价格，返回最新CCI值
+func (s *CCIState) UpdateLast(price float64) float64 {
+	if s.out.bars == 0 {
+		return s.Append(price)
+	}
+	s.wma.UpdateLast(price)
+	s.window.setLast(price)
+	v, ok := s.compute()
+	s.out.setLast(v, ok)
+	return v
+}
+
+// compute 计算最新K线的CCI：(price - MA) / (0.015 * MAD)
+func (s *CCIState) compute() (float64, bool) {
+	if !s.window.full() {
+		return 0, false
+	}
+
+	maVal := s.wma.Value()
+	sumDev := 0.0
+	for j := 0; j < s.period; j++ {
+		sumDev += math.Abs(s.window.get(j) - maVal)
+	}
+	mad := sumDev * s.invPeriod
+	if mad == 0 {
+		return 0, true
+	}
+	return (s.window.get(0) - maVal) / (0.015 * mad), true
+}
+
+// Value 获取最新CCI值（未就绪时返回0）
+func (s *CCIState) Value() float64 {
+	v, _ := s.out.last()
+	return v
+}
+
+// Ready 是否已有足够数据
+func (s *CCIState) Ready() bool {
+	return s.out.bars >= s.period
+}
+
+// Values 获取完整CCI数组（索引0是最新数据），与 CalculateCCI 返回格式一致
+func (s *CCIState) Values() []float64 {
+	return s.out.series()
+}
+
+// Reset 清空状态
+func (s *CCIState) Reset() {
+	s.wma.Reset()
+	s.window.reset()
+	s.out.reset()
+}
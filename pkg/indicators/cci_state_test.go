@@ -0,0 +1,31 @@
+package indicators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCCIStateMatchesBatch(t *testing.T) {
+	prices := testPrices(160)
+	for _, method := range MAMethods() {
+		for _, period := range []int{2, 14, 48} {
+			method, period := method, period
+			t.Run(fmt.Sprintf("%s_%d", method, period), func(t *testing.T) {
+				state := NewCCIStateWithMA(period, method)
+				walkBars(prices, func(p float64, first bool) {
+					if first {
+						state.Append(p)
+					} else {
+						state.UpdateLast(p)
+					}
+				}, func(bar int, history []float64) {
+					want := CalculateCCIWithMA(history, period, method)
+					assertSeries(t, "CCI", bar, state.Values(), want)
+					if state.Ready() != (want != nil) {
+						t.Fatalf("第%d根K线: Ready=%v，批量结果nil=%v", bar, state.Ready(), want == nil)
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package indicators
+
+import (
+	"sort"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// DiffConfig 比较两个指标配置，按 type + 结果key 对应实例，返回新增、删除和修改的实例
+// 参数按补全默认值后的结果比较，因此省略默认参数不算变化；无法解析的实例按原始配置比较
+// 背离检测参数发生变化时在最后追加一条 divergence 类型的修改
+func DiffConfig(from, to types.IndicatorConfig) []types.ConfigChange {
+	fromInst, fromOrder := diffEntries(from)
+	toInst, toOrder := diffEntries(to)
+
+	changes := []types.ConfigChange{}
+	for _, id := range fromOrder {
+		a := fromInst[id]
+		b, ok := toInst[id]
+		if !ok {
+			changes = append(changes, types.ConfigChange{Change: types.ConfigChangeRemoved, Type: a.cfg.Type, Key: a.key, From: &a.cfg})
+			continue
+		}
+		if fields := diffFields(a, b); len(fields) > 0 {
+			changes = append(changes, types.ConfigChange{
+				Change: types.ConfigChangeModified, Type: a.cfg.Type, Key: a.key,
+				From: &a.cfg, To: &b.cfg, Fields: fields,
+			})
+		}
+	}
+	for _, id := range toOrder {
+		if _, ok := fromInst[id]; ok {
+			continue
+		}
+		b := toInst[id]
+		changes = append(changes, types.ConfigChange{Change: types.ConfigChangeAdded, Type: b.cfg.Type, Key: b.key, To: &b.cfg})
+	}
+	if fields := diffDivergence(from.Divergence, to.Divergence); len(fields) > 0 {
+		changes = append(changes, types.ConfigChange{
+			Change: types.ConfigChangeModified, Type: types.ConfigChangeTypeDivergence,
+			FromDivergence: from.Divergence, ToDivergence: to.Divergence, Fields: fields,
+		})
+	}
+	return changes
+}
+
+// diffDivergence 列出背离检测参数间发生变化的字段，按补全默认值后的结果比较（来源不计顺序）
+func diffDivergence(a, b *types.DivergenceConfig) []string {
+	var fields []string
+	if (a != nil && a.Disabled) != (b != nil && b.Disabled) {
+		fields = append(fields, "disabled")
+	}
+	optsA, optsB := DivergenceOptionsFromConfig(a), DivergenceOptionsFromConfig(b)
+	if optsA.Lookback != optsB.Lookback {
+		fields = append(fields, "lookback")
+	}
+	if optsA.MinRange != optsB.MinRange {
+		fields = append(fields, "min_range")
+	}
+	if optsA.MaxRange != optsB.MaxRange {
+		fields = append(fields, "max_range")
+	}
+
+	sourcesA, sourcesB := divergenceSourceSet(a), divergenceSourceSet(b)
+	same := len(sourcesA) == len(sourcesB)
+	for source := range sourcesA {
+		same = same && sourcesB[source]
+	}
+	if !same {
+		fields = append(fields, "sources")
+	}
+	return fields
+}
+
+// divergenceSourceSet 实际参与背离检测的振荡指标
+func divergenceSourceSet(cfg *types.DivergenceConfig) map[string]bool {
+	sources := DivergenceSources
+	if cfg != nil && len(cfg.Sources) > 0 {
+		sources = cfg.Sources
+	}
+	set := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		set[source] = true
+	}
+	return set
+}
+
+// diffEntry 参与比较的实例
+type diffEntry struct {
+	cfg      types.IndicatorInstance
+	key      string
+	params   map[string]float64
+	resolved bool // 为true时params已补全默认值，MA方法包含在params["ma"]中
+}
+
+// diffEntries 解析配置中的实例，返回 "type_key" 到实例的映射和配置顺序
+func diffEntries(config types.IndicatorConfig) (map[string]diffEntry, []string) {
+	entries := make(map[string]diffEntry, len(config.Indicators))
+	var order []string
+	for _, cfg := range config.Indicators {
+		entry := diffEntry{cfg: cfg, key: cfg.Key, params: cfg.Params}
+		if inst, err := ResolveInstance(cfg); err == nil {
+			entry.key = inst.Key
+			entry.params = inst.Params
+			entry.resolved = true
+		}
+		id := cfg.Type + "_" + entry.key
+		if _, dup := entries[id]; dup {
+			continue
+		}
+		entries[id] = entry
+		order = append(order, id)
+	}
+	return entries, order
+}
+
+// diffFields 列出两个实例间发生变化的字段
+func diffFields(a, b diffEntry) []string {
+	var fields []string
+	if a.cfg.Name != b.cfg.Name {
+		fields = append(fields, "name")
+	}
+	if (!a.resolved || !b.resolved) && a.cfg.MA != b.cfg.MA {
+		fields = append(fields, "ma")
+	}
+
+	names := make(map[string]bool, len(a.params)+len(b.params))
+	for name := range a.params {
+		names[name] = true
+	}
+	for name := range b.params {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		va, okA := a.params[name]
+		vb, okB := b.params[name]
+		if okA == okB && va == vb {
+			continue
+		}
+		if name != "ma" {
+			fields = append(fields, "params."+name)
+		} else if a.resolved && b.resolved {
+			fields = append(fields, "ma")
+		}
+	}
+	return fields
+}
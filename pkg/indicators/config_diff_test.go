@@ -0,0 +1,93 @@
+package indicators
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// divergenceChange 取出差异中的背离检测参数变化
+func divergenceChange(changes []types.ConfigChange) *types.ConfigChange {
+	for i := range changes {
+		if changes[i].Type == types.ConfigChangeTypeDivergence {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestDiffConfigInstances(t *testing.T) {
+	from := types.IndicatorConfig{Indicators: []types.IndicatorInstance{
+		{Type: NameCCI, Params: map[string]float64{"period": 14}},
+		{Type: NameRSI, Params: map[string]float64{"period": 14}},
+	}}
+	to := types.IndicatorConfig{Indicators: []types.IndicatorInstance{
+		{Type: NameCCI, Params: map[string]float64{"period": 14}, MA: "ema", Name: "快线"},
+		{Type: NameRSI, Params: map[string]float64{"period": 21}},
+	}}
+
+	changes := DiffConfig(from, to)
+	if len(changes) != 3 {
+		t.Fatalf("差异数量 %d，期望 3: %+v", len(changes), changes)
+	}
+	if c := changes[0]; c.Change != types.ConfigChangeModified || c.Type != NameCCI || !reflect.DeepEqual(c.Fields, []string{"name", "ma"}) {
+		t.Fatalf("CCI差异不正确: %+v", c)
+	}
+	if c := changes[1]; c.Change != types.ConfigChangeRemoved || c.Type != NameRSI || c.Key != "14" {
+		t.Fatalf("RSI 14 应被删除: %+v", c)
+	}
+	if c := changes[2]; c.Change != types.ConfigChangeAdded || c.Type != NameRSI || c.Key != "21" {
+		t.Fatalf("RSI 21 应被新增: %+v", c)
+	}
+	if divergenceChange(changes) != nil {
+		t.Fatalf("背离检测参数未变化")
+	}
+}
+
+func TestDiffConfigDivergence(t *testing.T) {
+	defaults := DefaultDivergenceOptions()
+	tests := []struct {
+		name     string
+		from, to *types.DivergenceConfig
+		fields   []string
+	}{
+		{"都为默认", nil, &types.DivergenceConfig{}, nil},
+		{"显式写出默认值", nil, &types.DivergenceConfig{Lookback: defaults.Lookback, MinRange: defaults.MinRange, MaxRange: defaults.MaxRange}, nil},
+		{"禁用", nil, &types.DivergenceConfig{Disabled: true}, []string{"disabled"}},
+		{"启用", &types.DivergenceConfig{Disabled: true}, &types.DivergenceConfig{}, []string{"disabled"}},
+		{"摆动点参数", &types.DivergenceConfig{Lookback: 3}, &types.DivergenceConfig{Lookback: 4}, []string{"lookback"}},
+		{"间隔范围", nil, &types.DivergenceConfig{MinRange: 8, MaxRange: 40}, []string{"min_range", "max_range"}},
+		{"来源", nil, &types.DivergenceConfig{Sources: []string{NameRSI}}, []string{"sources"}},
+		{"来源顺序不同", &types.DivergenceConfig{Sources: []string{NameMACD, NameRSI, NameCCI}}, nil, nil},
+		{"多个字段", &types.DivergenceConfig{Sources: []string{NameCCI}}, &types.DivergenceConfig{Disabled: true, MaxRange: 80}, []string{"disabled", "max_range", "sources"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := types.IndicatorConfig{Indicators: types.GetDefaultConfig().Indicators, Divergence: tt.from}
+			to := types.IndicatorConfig{Indicators: types.GetDefaultConfig().Indicators, Divergence: tt.to}
+			changes := DiffConfig(from, to)
+
+			c := divergenceChange(changes)
+			if tt.fields == nil {
+				if len(changes) != 0 {
+					t.Fatalf("不应有差异: %+v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || c == nil {
+				t.Fatalf("应只有背离检测参数的差异: %+v", changes)
+			}
+			if c.Change != types.ConfigChangeModified || c.Key != "" || c.From != nil || c.To != nil {
+				t.Fatalf("背离检测差异格式不正确: %+v", c)
+			}
+			if !reflect.DeepEqual(c.Fields, tt.fields) {
+				t.Fatalf("变化字段 %v，期望 %v", c.Fields, tt.fields)
+			}
+			if c.FromDivergence != tt.from || c.ToDivergence != tt.to {
+				t.Fatalf("变化前后的配置不正确: %+v", c)
+			}
+		})
+	}
+}
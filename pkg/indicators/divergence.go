@@ -0,0 +1,180 @@
+package indicators
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// DivergenceKind 背离类型
+type DivergenceKind string
+
+const (
+	DivergenceRegularBullish DivergenceKind = "regular_bullish" // 常规底背离：价格更低的低点，振荡指标更高的低点
+	DivergenceRegularBearish DivergenceKind = "regular_bearish" // 常规顶背离：价格更高的高点，振荡指标更低的高点
+	DivergenceHiddenBullish  DivergenceKind = "hidden_bullish"  // 隐藏底背离：价格更高的低点，振荡指标更低的低点
+	DivergenceHiddenBearish  DivergenceKind = "hidden_bearish"  // 隐藏顶背离：价格更低的高点，振荡指标更高的高点
+)
+
+// Bullish 是否为看涨背离（常规或隐藏）
+func (k DivergenceKind) Bullish() bool {
+	return k == DivergenceRegularBullish || k == DivergenceHiddenBullish
+}
+
+// Hidden 是否为隐藏背离
+func (k DivergenceKind) Hidden() bool {
+	return k == DivergenceHiddenBullish || k == DivergenceHiddenBearish
+}
+
+// DivergenceOptions 背离检测参数（均以K线根数计）
+type DivergenceOptions struct {
+	Lookback int // 摆动点左右两侧各需比较的K线数量，最新的Lookback根K线上不会确认摆动点
+	MinRange int // 两个摆动点之间的最小间隔
+	MaxRange int // 两个摆动点之间的最大间隔
+	Window   int // 只在最新的Window根K线内查找摆动点，0表示全部
+}
+
+// DefaultDivergenceOptions 默认背离检测参数
+func DefaultDivergenceOptions() DivergenceOptions {
+	return DivergenceOptions{Lookback: 5, MinRange: 5, MaxRange: 60, Window: 120}
+}
+
+// DivergenceSources 可参与背离检测的振荡指标
+var DivergenceSources = []string{NameCCI, NameRSI, NameMACD}
+
+// DivergenceOptionsFromConfig 由配置生成检测参数（未设置的字段使用默认值），Window为最大间隔的两倍
+func DivergenceOptionsFromConfig(cfg *types.DivergenceConfig) DivergenceOptions {
+	opts := DefaultDivergenceOptions()
+	if cfg == nil {
+		return opts
+	}
+	if cfg.Lookback > 0 {
+		opts.Lookback = cfg.Lookback
+	}
+	if cfg.MinRange > 0 {
+		opts.MinRange = cfg.MinRange
+	}
+	if cfg.MaxRange > 0 {
+		opts.MaxRange = cfg.MaxRange
+	}
+	opts.Window = 2 * opts.MaxRange
+	return opts
+}
+
+// ValidateDivergenceConfig 校验背离检测配置
+func ValidateDivergenceConfig(cfg *types.DivergenceConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Lookback < 0 || cfg.MinRange < 0 || cfg.MaxRange < 0 {
+		return fmt.Errorf("背离检测参数不能为负数")
+	}
+	opts := DivergenceOptionsFromConfig(cfg)
+	if opts.MinRange > opts.MaxRange {
+		return fmt.Errorf("背离检测的最小间隔 %d 大于最大间隔 %d", opts.MinRange, opts.MaxRange)
+	}
+	for _, source := range cfg.Sources {
+		known := false
+		for _, name := range DivergenceSources {
+			known = known || source == name
+		}
+		if !known {
+			return fmt.Errorf("不支持背离检测的指标: %s（可用: cci、rsi、macd）", source)
+		}
+	}
+	return nil
+}
+
+// Divergence 一次背离：价格与振荡指标在相邻两个同向摆动点上的走势相反（常规）或与趋势延续相反（隐藏）
+type Divergence struct {
+	Kind      DivergenceKind
+	From      int // 较早的摆动点索引（索引0是最新数据）
+	To        int // 较近的摆动点索引
+	PriceFrom float64
+	PriceTo   float64
+	OscFrom   float64
+	OscTo     float64
+}
+
+// FindSwings 查找摆动高点和低点（索引0是最新数据，结果按从新到旧排列）
+// 高点要求比左右各lookback根K线都高（与较新一侧相等时不算，较旧一侧允许相等，平台只取最新的一根）
+func FindSwings(series []float64, lookback int) (highs, lows []int) {
+	if lookback < 1 {
+		lookback = 1
+	}
+	for i := lookback; i+lookback < len(series); i++ {
+		isHigh, isLow := true, true
+		for k := 1; k <= lookback && (isHigh || isLow); k++ {
+			newer, older := series[i-k], series[i+k]
+			if series[i] <= newer || series[i] < older {
+				isHigh = false
+			}
+			if series[i] >= newer || series[i] > older {
+				isLow = false
+			}
+		}
+		if isHigh {
+			highs = append(highs, i)
+		}
+		if isLow {
+			lows = append(lows, i)
+		}
+	}
+	return highs, lows
+}
+
+// DetectDivergences 在价格的摆动点上比较振荡指标，返回所有背离（按较近摆动点从新到旧排列）
+// price 和 osc 的索引0均为最新数据；每个摆动点只与其前一个同向摆动点比较
+// osc 最旧一端连续相同的值视为预热期，其中的摆动点不参与比较
+func DetectDivergences(price, osc []float64, opts DivergenceOptions) []Divergence {
+	n := len(price)
+	if len(osc) < n {
+		n = len(osc)
+	}
+	// 振荡指标最旧一端连续相同的值为预热期（计算函数以首个有效值或0填充），不参与检测
+	for n > 1 && osc[n-2] == osc[n-1] {
+		n--
+	}
+	if opts.Window > 0 && opts.Window < n {
+		n = opts.Window
+	}
+	if opts.MaxRange <= 0 {
+		opts.MaxRange = n
+	}
+	highs, lows := FindSwings(price[:n], opts.Lookback)
+
+	var result []Divergence
+	pair := func(swings []int, high bool) {
+		for i := 0; i+1 < len(swings); i++ {
+			to, from := swings[i], swings[i+1]
+			if gap := from - to; gap < opts.MinRange || gap > opts.MaxRange {
+				continue
+			}
+			d := Divergence{
+				From: from, To: to,
+				PriceFrom: price[from], PriceTo: price[to],
+				OscFrom: osc[from], OscTo: osc[to],
+			}
+			switch {
+			case high && d.PriceTo > d.PriceFrom && d.OscTo < d.OscFrom:
+				d.Kind = DivergenceRegularBearish
+			case high && d.PriceTo < d.PriceFrom && d.OscTo > d.OscFrom:
+				d.Kind = DivergenceHiddenBearish
+			case !high && d.PriceTo < d.PriceFrom && d.OscTo > d.OscFrom:
+				d.Kind = DivergenceRegularBullish
+			case !high && d.PriceTo > d.PriceFrom && d.OscTo < d.OscFrom:
+				d.Kind = DivergenceHiddenBullish
+			default:
+				continue
+			}
+			result = append(result, d)
+		}
+	}
+	pair(highs, true)
+	pair(lows, false)
+
+	// 合并高点和低点的结果，按较近摆动点从新到旧排列
+	sort.SliceStable(result, func(i, j int) bool { return result[i].To < result[j].To })
+	return result
+}
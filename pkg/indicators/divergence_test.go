@@ -0,0 +1,161 @@
+package indicators
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+func TestFindSwings(t *testing.T) {
+	tests := []struct {
+		name     string
+		series   []float64 // 索引0为最新
+		lookback int
+		highs    []int
+		lows     []int
+	}{
+		{name: "单峰", series: []float64{1, 2, 3, 2, 1}, lookback: 2, highs: []int{2}},
+		{name: "单谷", series: []float64{3, 2, 1, 2, 3}, lookback: 2, lows: []int{2}},
+		{name: "两侧不足lookback根", series: []float64{1, 2, 3, 2, 1}, lookback: 3},
+		// 平台只取最新的一根：较旧一侧允许相等，较新一侧必须严格更高/更低
+		{name: "高点平台", series: []float64{1, 3, 3, 1, 0}, lookback: 1, highs: []int{1}},
+		{name: "低点平台", series: []float64{5, 1, 1, 5, 6}, lookback: 1, lows: []int{1}},
+		{name: "较新一侧相等", series: []float64{3, 3, 1, 0}, lookback: 1},
+		// 最新的lookback根K线上不确认摆动点
+		{name: "最新K线不确认", series: []float64{9, 1, 2, 3}, lookback: 1, lows: []int{1}},
+		{name: "lookback小于1按1处理", series: []float64{1, 2, 1}, lookback: 0, highs: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			highs, lows := FindSwings(tt.series, tt.lookback)
+			if !reflect.DeepEqual(highs, tt.highs) || !reflect.DeepEqual(lows, tt.lows) {
+				t.Fatalf("高点 %v、低点 %v，期望 %v、%v", highs, lows, tt.highs, tt.lows)
+			}
+		})
+	}
+}
+
+// swingHighs 在索引1和4上各有一个摆动高点（lookback为1），值分别为to和from
+func swingHighs(to, from float64) []float64 {
+	return []float64{5, to, 6, 4, from, 3, 2, 1}
+}
+
+// swingLows 在索引1和4上各有一个摆动低点（lookback为1），值分别为to和from
+func swingLows(to, from float64) []float64 {
+	return []float64{5, to, 6, 7, from, 8, 9, 10}
+}
+
+// oscAt 在索引1和4上取值为to和from的振荡指标序列（最旧一端没有相同的值）
+func oscAt(to, from float64) []float64 {
+	return []float64{0, to, 0, 0, from, 0, 1, 2}
+}
+
+func TestDetectDivergences(t *testing.T) {
+	opts := DivergenceOptions{Lookback: 1, MinRange: 2, MaxRange: 10}
+	tests := []struct {
+		name  string
+		price []float64
+		osc   []float64
+		opts  DivergenceOptions
+		kind  DivergenceKind // 为空表示没有背离
+	}{
+		{name: "常规顶背离", price: swingHighs(10, 8), osc: oscAt(50, 70), opts: opts, kind: DivergenceRegularBearish},
+		{name: "隐藏顶背离", price: swingHighs(8, 10), osc: oscAt(70, 50), opts: opts, kind: DivergenceHiddenBearish},
+		{name: "高点同向不算背离", price: swingHighs(10, 8), osc: oscAt(70, 50), opts: opts},
+		{name: "常规底背离", price: swingLows(1, 3), osc: oscAt(-50, -70), opts: opts, kind: DivergenceRegularBullish},
+		{name: "隐藏底背离", price: swingLows(3, 1), osc: oscAt(-70, -50), opts: opts, kind: DivergenceHiddenBullish},
+		{name: "低点同向不算背离", price: swingLows(1, 3), osc: oscAt(-70, -50), opts: opts},
+		{name: "振荡指标相等不算背离", price: swingHighs(10, 8), osc: oscAt(50, 50), opts: opts},
+
+		// 两个摆动点间隔3根：最小、最大间隔均包含边界
+		{name: "间隔等于最小和最大间隔", price: swingHighs(10, 8), osc: oscAt(50, 70),
+			opts: DivergenceOptions{Lookback: 1, MinRange: 3, MaxRange: 3}, kind: DivergenceRegularBearish},
+		{name: "间隔小于最小间隔", price: swingHighs(10, 8), osc: oscAt(50, 70),
+			opts: DivergenceOptions{Lookback: 1, MinRange: 4, MaxRange: 10}},
+		{name: "间隔大于最大间隔", price: swingHighs(10, 8), osc: oscAt(50, 70),
+			opts: DivergenceOptions{Lookback: 1, MinRange: 1, MaxRange: 2}},
+
+		// 只在最新的Window根K线内查找摆动点
+		{name: "较早的摆动点在窗口外", price: swingHighs(10, 8), osc: oscAt(50, 70),
+			opts: DivergenceOptions{Lookback: 1, MinRange: 2, MaxRange: 10, Window: 5}},
+		{name: "窗口包含两个摆动点", price: swingHighs(10, 8), osc: oscAt(50, 70),
+			opts: DivergenceOptions{Lookback: 1, MinRange: 2, MaxRange: 10, Window: 6}, kind: DivergenceRegularBearish},
+
+		// 振荡指标最旧一端连续相同的值为预热期，其中的摆动点不参与比较
+		{name: "较早的摆动点在预热期", price: swingHighs(10, 8), osc: []float64{0, 50, 0, 0, 70, 70, 70, 70}, opts: opts},
+		{name: "预热期之后的摆动点", price: swingHighs(10, 8), osc: []float64{0, 50, 0, 0, 70, 60, 70, 70}, opts: opts, kind: DivergenceRegularBearish},
+		// 振荡指标较短时只检测与之对齐的部分
+		{name: "振荡指标较短", price: swingHighs(10, 8), osc: []float64{0, 50, 0, 0}, opts: opts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectDivergences(tt.price, tt.osc, tt.opts)
+			if tt.kind == "" {
+				if len(got) != 0 {
+					t.Fatalf("不应检测到背离: %+v", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("应检测到1个背离: %+v", got)
+			}
+			d := got[0]
+			if d.Kind != tt.kind || d.To != 1 || d.From != 4 {
+				t.Fatalf("背离 %+v，期望 %s（To=1，From=4）", d, tt.kind)
+			}
+			if d.PriceTo != tt.price[1] || d.PriceFrom != tt.price[4] || d.OscTo != tt.osc[1] || d.OscFrom != tt.osc[4] {
+				t.Fatalf("背离的取值不正确: %+v", d)
+			}
+		})
+	}
+}
+
+func TestDetectDivergencesOrder(t *testing.T) {
+	// 高点在索引1和4（常规顶背离），低点在索引3和6（常规底背离），结果按较近摆动点从新到旧排列
+	price := []float64{5, 10, 6, 1, 8, 7, 2, 9, 9.5}
+	osc := []float64{0, 50, 0, -50, 70, 0, -70, 1, 2}
+	got := DetectDivergences(price, osc, DivergenceOptions{Lookback: 1, MinRange: 2, MaxRange: 10})
+	if len(got) != 2 || got[0].Kind != DivergenceRegularBearish || got[0].To != 1 || got[1].Kind != DivergenceRegularBullish || got[1].To != 3 {
+		t.Fatalf("背离顺序不正确: %+v", got)
+	}
+}
+
+func TestDivergenceOptionsFromConfig(t *testing.T) {
+	if got := DivergenceOptionsFromConfig(nil); got != DefaultDivergenceOptions() {
+		t.Fatalf("未配置时应使用默认值: %+v", got)
+	}
+	// 查找窗口为最大间隔的两倍，保证最大间隔的两个摆动点都在窗口内
+	got := DivergenceOptionsFromConfig(&types.DivergenceConfig{Lookback: 3, MaxRange: 30})
+	want := DivergenceOptions{Lookback: 3, MinRange: 5, MaxRange: 30, Window: 60}
+	if got != want {
+		t.Fatalf("检测参数 %+v，期望 %+v", got, want)
+	}
+	if got := DivergenceOptionsFromConfig(&types.DivergenceConfig{MinRange: 8}); got.Window != 2*DefaultDivergenceOptions().MaxRange || got.MinRange != 8 {
+		t.Fatalf("检测参数 %+v", got)
+	}
+}
+
+func TestValidateDivergenceConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *types.DivergenceConfig
+		ok   bool
+	}{
+		{name: "未配置", ok: true},
+		{name: "有效", cfg: &types.DivergenceConfig{MinRange: 3, MaxRange: 20, Sources: []string{"cci", "macd"}}, ok: true},
+		{name: "最小间隔等于最大间隔", cfg: &types.DivergenceConfig{MinRange: 20, MaxRange: 20}, ok: true},
+		{name: "负数", cfg: &types.DivergenceConfig{Lookback: -1}},
+		{name: "最小间隔大于最大间隔", cfg: &types.DivergenceConfig{MinRange: 30, MaxRange: 20}},
+		// 未设置的最大间隔取默认值60
+		{name: "最小间隔大于默认最大间隔", cfg: &types.DivergenceConfig{MinRange: 70}},
+		{name: "不支持的指标", cfg: &types.DivergenceConfig{Sources: []string{"bollinger"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDivergenceConfig(tt.cfg); (err == nil) != tt.ok {
+				t.Fatalf("校验结果 %v，期望通过=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package indicators
+
+// CalculateEMA 计算指数移动平均（EMA）
+// price: 价格数组（索引0是最新数据）
+// period: 周期，α = 2/(period+1)
+// 返回EMA数组（索引0是最新数据），以最早一个完整窗口的SMA为初值
+func CalculateEMA(price []float64, period int) []float64 {
+	return calculateSmoothedMA(price, period, emaAlpha(period))
+}
+
+// CalculateSMMA 计算平滑移动平均（SMMA，即Wilder平滑/RMA）
+// price: 价格数组（索引0是最新数据）
+// period: 周期，α = 1/period
+// 返回SMMA数组（索引0是最新数据），以最早一个完整窗口的SMA为初值
+func CalculateSMMA(price []float64, period int) []float64 {
+	return calculateSmoothedMA(price, period, smmaAlpha(period))
+}
+
+// emaAlpha EMA平滑系数
+func emaAlpha(period int) float64 {
+	return 2.0 / float64(period+1)
+}
+
+// smmaAlpha SMMA平滑系数
+func smmaAlpha(period int) float64 {
+	return 1.0 / float64(period)
+}
+
+// smoothStep 递推一步：prev + α*(price-prev)
+func smoothStep(prev, price, alpha float64) float64 {
+	return prev + alpha*(price-prev)
+}
+
+// calculateSmoothedMA 递推型移动平均（EMA/SMMA）
+func calculateSmoothedMA(price []float64, period int, alpha float64) []float64 {
+	if period < 1 || len(price) < period {
+		return nil
+	}
+
+	ma := make([]float64, len(price))
+	invPeriod := 1.0 / float64(period)
+
+	// 初值：最早一个完整窗口的SMA
+	maxI := len(price) - period
+	sum := 0.0
+	for j := 0; j < period; j++ {
+		sum += price[maxI+j]
+	}
+	ma[maxI] = sum * invPeriod
+
+	// 从后往前递推（因为索引0是最新数据）
+	for i := maxI - 1; i >= 0; i-- {
+		ma[i] = smoothStep(ma[i+1], price[i], alpha)
+	}
+
+	// 对于前面的数据点（不足period个），使用最近的有效值
+	for i := maxI + 1; i < len(price); i++ {
+		ma[i] = ma[maxI]
+	}
+
+	return ma
+}
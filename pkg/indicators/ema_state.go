@@ -0,0 +1,107 @@
+package indicators
+
+// smoothedState 增量递推型移动平均（EMA/SMMA 的共同实现）
+// 每次更新的开销为O(1)（初值窗口为O(period)），结果与对应批量函数一致
+type smoothedState struct {
+	period    int
+	alpha     float64
+	invPeriod float64
+	window    priceWindow
+	out       barSeries
+}
+
+func newSmoothedState(period int, alpha func(int) float64) smoothedState {
+	if period < 1 {
+		period = 1
+	}
+	return smoothedState{
+		period:    period,
+		alpha:     alpha(period),
+		invPeriod: 1.0 / float64(period),
+		window:    priceWindow{size: period},
+		out:       barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格，返回最新值
+func (s *smoothedState) Append(price float64) float64 {
+	s.window.push(price)
+	v, ok := s.compute(s.out.bars + 1)
+	s.out.push(v, ok)
+	return v
+}
+
+// UpdateLast 更新最新K线的价格，返回最新值
+func (s *smoothedState) UpdateLast(price float64) float64 {
+	if s.out.bars == 0 {
+		return s.Append(price)
+	}
+	s.window.setLast(price)
+	v, ok := s.compute(s.out.bars)
+	s.out.setLast(v, ok)
+	return v
+}
+
+// compute 计算第bars根K线（从1开始）的值：第period根为窗口SMA，之后基于上一根K线的值递推
+func (s *smoothedState) compute(bars int) (float64, bool) {
+	if bars < s.period {
+		return 0, false
+	}
+	if bars == s.period {
+		sum := 0.0
+		for j := 0; j < s.period; j++ {
+			sum += s.window.get(j)
+		}
+		return sum * s.invPeriod, true
+	}
+	return smoothStep(s.out.at(bars-2), s.window.get(0), s.alpha), true
+}
+
+// Value 获取最新值（未就绪时返回0）
+func (s *smoothedState) Value() float64 {
+	v, _ := s.out.last()
+	return v
+}
+
+// Ready 是否已有足够数据
+func (s *smoothedState) Ready() bool {
+	return s.out.bars >= s.period
+}
+
+// Len 已输入的K线数量
+func (s *smoothedState) Len() int {
+	return s.out.bars
+}
+
+// Values 获取完整数组（索引0是最新数据），与批量函数返回格式一致
+func (s *smoothedState) Values() []float64 {
+	return s.out.series()
+}
+
+// Reset 清空状态
+func (s *smoothedState) Reset() {
+	s.window.reset()
+	s.out.reset()
+}
+
+// EMAState 增量指数移动平均（EMA）
+// 使用方式与 WMAState 相同，结果与 CalculateEMA 对同一序列的计算结果一致
+type EMAState struct {
+	smoothedState
+}
+
+// NewEMAState 创建增量EMA
+func NewEMAState(period int) *EMAState {
+	return &EMAState{smoothedState: newSmoothedState(period, emaAlpha)}
+}
+
+// SMMAState 增量平滑移动平均（SMMA/Wilder平滑）
+// 使用方式与 WMAState 相同，结果与 CalculateSMMA 对同一序列的计算结果一致
+type SMMAState struct {
+	smoothedState
+}
+
+// NewSMMAState 创建增量SMMA
+func NewSMMAState(period int) *SMMAState {
+	return &SMMAState{smoothedState: newSmoothedState(period, smmaAlpha)}
+}
@@ -0,0 +1,78 @@
+package indicators
+
+// EnvelopeState 增量包络线（默认WMA中轨，上下轨为中轨的百分比偏移）
+// 每次更新的开销为O(period)，结果与 CalculateEnvelopeWithMA 一致
+type EnvelopeState struct {
+	multUpper float64
+	multLower float64
+	middle    MAState
+	upper     barSeries
+	lower     barSeries
+}
+
+// NewEnvelopeState 创建增量包络线（WMA中轨，与 CalculateEnvelope 一致）
+func NewEnvelopeState(period int, deviationPercent float64) *EnvelopeState {
+	return NewEnvelopeStateWithMA(period, deviationPercent, MAWMA)
+}
+
+// NewEnvelopeStateWithMA 使用指定移动平均方法创建增量包络线
+func NewEnvelopeStateWithMA(period int, deviationPercent float64, method MAMethod) *EnvelopeState {
+	if period < 1 {
+		period = 1
+	}
+	deviation := deviationPercent / 100.0
+	return &EnvelopeState{
+		multUpper: 1.0 + deviation,
+		multLower: 1.0 - deviation,
+		middle:    NewMAState(period, method),
+		upper:     barSeries{minBars: period, fill: true},
+		lower:     barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格
+func (s *EnvelopeState) Append(price float64) {
+	middle := s.middle.Append(price)
+	ok := s.middle.Ready()
+	s.upper.push(middle*s.multUpper, ok)
+	s.lower.push(middle*s.multLower, ok)
+}
+
+// UpdateLast 更新最新K线的价格
+func (s *EnvelopeState) UpdateLast(price float64) {
+	if s.upper.bars == 0 {
+		s.Append(price)
+		return
+	}
+	middle := s.middle.UpdateLast(price)
+	ok := s.middle.Ready()
+	s.upper.setLast(middle*s.multUpper, ok)
+	s.lower.setLast(middle*s.multLower, ok)
+}
+
+// Value 获取最新的上轨、中轨、下轨值
+func (s *EnvelopeState) Value() (upper, middle, lower float64) {
+	upper, _ = s.upper.last()
+	lower, _ = s.lower.last()
+	return upper, s.middle.Value(), lower
+}
+
+// Ready 是否已有足够数据
+func (s *EnvelopeState) Ready() bool {
+	return s.middle.Ready()
+}
+
+// Values 获取完整包络线数组（索引0是最新数据），与 CalculateEnvelope 返回格式一致
+func (s *EnvelopeState) Values() (upper, middle, lower []float64) {
+	if !s.Ready() {
+		return nil, nil, nil
+	}
+	return s.upper.series(), s.middle.Values(), s.lower.series()
+}
+
+// Reset 清空状态
+func (s *EnvelopeState) Reset() {
+	s.middle.Reset()
+	s.upper.reset()
+	s.lower.reset()
+}
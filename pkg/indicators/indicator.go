@@ -0,0 +1,171 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// ParamType 参数类型
+type ParamType string
+
+const (
+	ParamInt   ParamType = "int"
+	ParamFloat ParamType = "float"
+	// ParamEnum 枚举参数，取值为 Options 中的下标
+	ParamEnum ParamType = "enum"
+)
+
+// ParamSpec 指标参数定义
+type ParamSpec struct {
+	Name        string    `json:"name"`
+	Type        ParamType `json:"type"`
+	Default     float64   `json:"default"`
+	Min         float64   `json:"min"`
+	Description string    `json:"description"`
+	// Scalable 是否为基于小时的周期参数（实时服务会按K线周期缩放）
+	Scalable bool `json:"scalable"`
+	// Options 枚举参数的可选值（仅 ParamEnum）
+	Options []string `json:"options,omitempty"`
+}
+
+// Params 指标参数值
+type Params map[string]float64
+
+// Int 获取整数参数
+func (p Params) Int(name string) int {
+	return int(p[name])
+}
+
+// Float 获取浮点参数
+func (p Params) Float(name string) float64 {
+	return p[name]
+}
+
+// Input 指标输入（所有数组索引0是最新数据）
+type Input struct {
+	Open  []float64
+	High  []float64
+	Low   []float64
+	Close []float64
+	Price []float64 // (H+L+C)/3
+}
+
+// NewInput 根据HLC数组创建指标输入（索引0是最新数据）
+func NewInput(open, high, low, close []float64) Input {
+	return Input{
+		Open:  open,
+		High:  high,
+		Low:   low,
+		Close: close,
+		Price: CalculateHLCC(high, low, close),
+	}
+}
+
+// NewInputFromKlines 将K线（从旧到新）转换为指标输入（索引0是最新数据）
+func NewInputFromKlines(klines []types.Kline) Input {
+	n := len(klines)
+	open := make([]float64, n)
+	high := make([]float64, n)
+	low := make([]float64, n)
+	close := make([]float64, n)
+	for i := 0; i < n; i++ {
+		k := klines[n-1-i]
+		open[i] = k.Open
+		high[i] = k.High
+		low[i] = k.Low
+		close[i] = k.Close
+	}
+	return NewInput(open, high, low, close)
+}
+
+// Indicator 指标接口
+type Indicator interface {
+	// Name 指标名称（注册表中的唯一标识），如 "cci"
+	Name() string
+	// Description 指标说明
+	Description() string
+	// Params 参数定义
+	Params() []ParamSpec
+	// Outputs 输出序列名称，如 MACD 为 macd_line、signal_line、histogram
+	Outputs() []string
+	// WarmUp 产生有效值所需的最少K线数量
+	WarmUp(params Params) int
+	// Compute 批量计算，返回 输出序列名称 -> 数组（索引0是最新数据）
+	Compute(input Input, params Params) (map[string][]float64, error)
+}
+
+// Stream 增量计算流（按时间顺序输入价格）
+type Stream interface {
+	// Append 追加一根新K线的价格
+	Append(price float64)
+	// UpdateLast 更新正在形成的最新K线的价格
+	UpdateLast(price float64)
+	// Values 获取完整输出（与 Compute 返回格式一致）
+	Values() map[string][]float64
+	// Reset 清空状态
+	Reset()
+}
+
+// Streamer 支持增量计算的指标（可选接口）
+type Streamer interface {
+	NewStream(params Params) Stream
+}
+
+// LastValuer 可直接获取最新输出值的增量计算流（可选接口，逐根K线读取时避免生成完整数组）
+type LastValuer interface {
+	// Last 获取最新一根K线的输出值（输出序列名称 -> 值），数据不足时返回nil
+	Last() map[string]float64
+}
+
+// LastValues 获取增量计算流最新一根K线的输出值，数据不足时返回nil
+func LastValues(stream Stream) map[string]float64 {
+	if lv, ok := stream.(LastValuer); ok {
+		return lv.Last()
+	}
+	return Latest(stream.Values())
+}
+
+// Latest 获取完整输出（索引0是最新数据）中最新一根K线的值，输出为空时返回nil
+func Latest(values map[string][]float64) map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	last := make(map[string]float64, len(values))
+	for name, series := range values {
+		if len(series) == 0 {
+			return nil
+		}
+		last[name] = series[0]
+	}
+	return last
+}
+
+// ResolveParams 校验参数并补全默认值
+func ResolveParams(ind Indicator, params Params) (Params, error) {
+	resolved := make(Params, len(ind.Params()))
+	for _, spec := range ind.Params() {
+		v, ok := params[spec.Name]
+		if !ok {
+			v = spec.Default
+		}
+		if v < spec.Min {
+			return nil, fmt.Errorf("%s 参数 %s 不能小于 %v", ind.Name(), spec.Name, spec.Min)
+		}
+		if (spec.Type == ParamInt || spec.Type == ParamEnum) && v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s 参数 %s 必须为整数", ind.Name(), spec.Name)
+		}
+		if spec.Type == ParamEnum && int(v) >= len(spec.Options) {
+			return nil, fmt.Errorf("%s 参数 %s 超出可选范围（0-%d）", ind.Name(), spec.Name, len(spec.Options)-1)
+		}
+		resolved[spec.Name] = v
+	}
+
+	for name := range params {
+		if _, ok := resolved[name]; !ok {
+			return nil, fmt.Errorf("%s 不支持参数 %s", ind.Name(), name)
+		}
+	}
+	return resolved, nil
+}
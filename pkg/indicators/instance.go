@@ -0,0 +1,116 @@
+package indicators
+
+import (
+	"fmt"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// Instance 解析后的指标实例
+type Instance struct {
+	Indicator Indicator
+	Key       string // 结果key（同类型内唯一）
+	Name      string // 显示名称
+	Params    Params // 补全默认值后的参数（周期基于小时，未缩放）
+}
+
+// ResolveInstance 按注册表解析指标实例配置：校验类型和参数、补全默认值、生成结果key
+func ResolveInstance(cfg types.IndicatorInstance) (*Instance, error) {
+	ind, ok := Get(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("未注册的指标: %s", cfg.Type)
+	}
+
+	params := make(Params, len(cfg.Params)+1)
+	for name, v := range cfg.Params {
+		params[name] = v
+	}
+	if cfg.MA != "" {
+		method, err := ParseMAMethod(cfg.MA)
+		if err != nil {
+			return nil, err
+		}
+		if !hasParam(ind, "ma") {
+			return nil, fmt.Errorf("%s 不支持移动平均方法参数", cfg.Type)
+		}
+		params["ma"] = method.ParamValue()
+	}
+
+	resolved, err := ResolveParams(ind, params)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cfg.Key
+	if key == "" {
+		key = instanceKey(ind, resolved)
+	}
+	return &Instance{Indicator: ind, Key: key, Name: cfg.Name, Params: resolved}, nil
+}
+
+// ScaleParams 根据K线周期缩放周期参数（配置基于小时）
+// 无法缩放的参数保留原值，并返回遇到的第一个错误
+func ScaleParams(ind Indicator, params Params, interval string) (Params, error) {
+	scaled := make(Params, len(params))
+	for name, v := range params {
+		scaled[name] = v
+	}
+	var firstErr error
+	for _, spec := range ind.Params() {
+		if !spec.Scalable {
+			continue
+		}
+		period := params.Int(spec.Name)
+		s, err := types.ScalePeriod(period, interval)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("缩放参数 %s（%d）失败: %w", spec.Name, period, err)
+			}
+			continue
+		}
+		scaled[spec.Name] = float64(s)
+	}
+	return scaled, firstErr
+}
+
+// ValidateConfig 校验指标配置：所有实例均可解析，同类型实例的结果key不重复，背离检测参数有效
+func ValidateConfig(config types.IndicatorConfig) error {
+	seen := make(map[string]bool, len(config.Indicators))
+	for i, cfg := range config.Indicators {
+		inst, err := ResolveInstance(cfg)
+		if err != nil {
+			return fmt.Errorf("第%d个指标: %w", i+1, err)
+		}
+		id := cfg.Type + "_" + inst.Key
+		if seen[id] {
+			return fmt.Errorf("第%d个指标: %s 的key %s 重复，请设置不同的key", i+1, cfg.Type, inst.Key)
+		}
+		seen[id] = true
+	}
+	if err := ValidateDivergenceConfig(config.Divergence); err != nil {
+		return fmt.Errorf("divergence: %w", err)
+	}
+	return nil
+}
+
+// instanceKey 由周期参数生成结果key：MACD类为 "快_慢"，单周期指标为 "周期"，否则为指标名称
+func instanceKey(ind Indicator, params Params) string {
+	switch {
+	case hasParam(ind, "fast") && hasParam(ind, "slow"):
+		return fmt.Sprintf("%d_%d", params.Int("fast"), params.Int("slow"))
+	case hasParam(ind, "period"):
+		return fmt.Sprintf("%d", params.Int("period"))
+	default:
+		return ind.Name()
+	}
+}
+
+// hasParam 指标是否定义了指定参数
+func hasParam(ind Indicator, name string) bool {
+	for _, spec := range ind.Params() {
+		if spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
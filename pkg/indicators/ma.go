@@ -0,0 +1,123 @@
+package indicators
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MAMethod 移动平均方法
+type MAMethod string
+
+const (
+	// MAWMA 线性加权移动平均（默认，与MQ5 EA一致）
+	MAWMA MAMethod = "wma"
+	// MASMA 简单移动平均
+	MASMA MAMethod = "sma"
+	// MAEMA 指数移动平均（α = 2/(period+1)，以首个窗口的SMA为初值）
+	MAEMA MAMethod = "ema"
+	// MASMMA 平滑移动平均（Wilder平滑，α = 1/period，以首个窗口的SMA为初值）
+	MASMMA MAMethod = "smma"
+	// MALWMA 线性加权移动平均（MT5命名），计算与 MAWMA 相同
+	MALWMA MAMethod = "lwma"
+)
+
+// maMethods 可用的移动平均方法（顺序即注册表枚举参数的取值）
+var maMethods = []MAMethod{MAWMA, MASMA, MAEMA, MASMMA, MALWMA}
+
+// MAMethods 获取所有可用的移动平均方法
+func MAMethods() []MAMethod {
+	return append([]MAMethod(nil), maMethods...)
+}
+
+// ParamValue 在注册表枚举参数（ma）中对应的取值
+func (m MAMethod) ParamValue() float64 {
+	for i, method := range maMethods {
+		if method == m {
+			return float64(i)
+		}
+	}
+	return 0
+}
+
+// maParam 移动平均方法枚举参数定义
+func maParam() ParamSpec {
+	options := make([]string, len(maMethods))
+	for i, m := range maMethods {
+		options[i] = string(m)
+	}
+	return ParamSpec{Name: "ma", Type: ParamEnum, Default: 0, Min: 0, Description: "移动平均方法", Options: options}
+}
+
+// MA 获取枚举参数 ma 对应的移动平均方法（未设置时为 MAWMA）
+func (p Params) MA() MAMethod {
+	i := p.Int("ma")
+	if i < 0 || i >= len(maMethods) {
+		return MAWMA
+	}
+	return maMethods[i]
+}
+
+// ParseMAMethod 解析移动平均方法（不区分大小写），空字符串返回 MAWMA
+func ParseMAMethod(s string) (MAMethod, error) {
+	method := MAMethod(strings.ToLower(strings.TrimSpace(s)))
+	if method == "" {
+		return MAWMA, nil
+	}
+	for _, m := range maMethods {
+		if m == method {
+			return method, nil
+		}
+	}
+	return "", fmt.Errorf("未知的移动平均方法: %s（可用: wma、sma、ema、smma、lwma）", s)
+}
+
+// CalculateMA 按指定方法计算移动平均
+// price: 价格数组（索引0是最新数据）
+// period: 周期
+// method: 移动平均方法，未知方法按 MAWMA 处理
+// 返回移动平均数组（索引0是最新数据）
+func CalculateMA(price []float64, period int, method MAMethod) []float64 {
+	switch method {
+	case MASMA:
+		return CalculateSMA(price, period)
+	case MAEMA:
+		return CalculateEMA(price, period)
+	case MASMMA:
+		return CalculateSMMA(price, period)
+	default:
+		return CalculateWMA(price, period)
+	}
+}
+
+// MAState 增量移动平均
+// WMAState、SMAState、EMAState、SMMAState 均实现该接口
+type MAState interface {
+	// Append 追加一根新K线的价格，返回最新值
+	Append(price float64) float64
+	// UpdateLast 更新最新K线的价格，返回最新值
+	UpdateLast(price float64) float64
+	// Value 获取最新值（未就绪时返回0）
+	Value() float64
+	// Ready 是否已有足够数据
+	Ready() bool
+	// Len 已输入的K线数量
+	Len() int
+	// Values 获取完整数组（索引0是最新数据），与 CalculateMA 返回格式一致
+	Values() []float64
+	// Reset 清空状态
+	Reset()
+}
+
+// NewMAState 按指定方法创建增量移动平均，未知方法按 MAWMA 处理
+func NewMAState(period int, method MAMethod) MAState {
+	switch method {
+	case MASMA:
+		return NewSMAState(period)
+	case MAEMA:
+		return NewEMAState(period)
+	case MASMMA:
+		return NewSMMAState(period)
+	default:
+		return NewWMAState(period)
+	}
+}
@@ -0,0 +1,71 @@
+package indicators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMAStateMatchesBatch(t *testing.T) {
+	prices := testPrices(160)
+	for _, method := range MAMethods() {
+		for _, period := range []int{1, 2, 5, 14, 48} {
+			method, period := method, period
+			t.Run(fmt.Sprintf("%s_%d", method, period), func(t *testing.T) {
+				state := NewMAState(period, method)
+				walkBars(prices, func(p float64, first bool) {
+					if first {
+						state.Append(p)
+					} else {
+						state.UpdateLast(p)
+					}
+				}, func(bar int, history []float64) {
+					want := CalculateMA(history, period, method)
+					assertSeries(t, "MA", bar, state.Values(), want)
+					if state.Len() != bar+1 {
+						t.Fatalf("第%d根K线: Len=%d", bar, state.Len())
+					}
+					if state.Ready() != (want != nil) {
+						t.Fatalf("第%d根K线: Ready=%v，批量结果nil=%v", bar, state.Ready(), want == nil)
+					}
+					if want != nil && state.Value() != state.Values()[0] {
+						t.Fatalf("第%d根K线: Value=%v，Values[0]=%v", bar, state.Value(), state.Values()[0])
+					}
+				})
+			})
+		}
+	}
+}
+
+func TestMAStateConstructors(t *testing.T) {
+	prices := testPrices(80)
+	tests := []struct {
+		name  string
+		state MAState
+		batch func([]float64, int) []float64
+	}{
+		{"wma", NewWMAState(10), CalculateWMA},
+		{"lwma", NewMAState(10, MALWMA), CalculateWMA},
+		{"sma", NewSMAState(10), CalculateSMA},
+		{"ema", NewEMAState(10), CalculateEMA},
+		{"smma", NewSMMAState(10), CalculateSMMA},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			walkBars(prices, func(p float64, first bool) {
+				if first {
+					tt.state.Append(p)
+				} else {
+					tt.state.UpdateLast(p)
+				}
+			}, func(bar int, history []float64) {
+				assertSeries(t, tt.name, bar, tt.state.Values(), tt.batch(history, 10))
+			})
+
+			tt.state.Reset()
+			if tt.state.Len() != 0 || tt.state.Ready() || tt.state.Values() != nil {
+				t.Fatalf("Reset 后状态未清空")
+			}
+		})
+	}
+}
@@ -7,13 +7,18 @@ package indicators
 // signalPeriod: 信号周期
 // 返回: macdLine（MACD线），signalLine（信号线），histogram（柱状图值）
 func CalculateMACD(price []float64, fastPeriod, slowPeriod, signalPeriod int) (macdLine, signalLine, histogram []float64) {
+	return CalculateMACDWithMA(price, fastPeriod, slowPeriod, signalPeriod, MAWMA)
+}
+
+// CalculateMACDWithMA 使用指定移动平均方法计算MACD（快慢线和信号线使用同一方法，其余计算与 CalculateMACD 相同）
+func CalculateMACDWithMA(price []float64, fastPeriod, slowPeriod, signalPeriod int, method MAMethod) (macdLine, signalLine, histogram []float64) {
 	if len(price) < slowPeriod {
 		return nil, nil, nil
 	}
 
-	// 计算快速和慢速WMA
-	fastWMA := CalculateWMA(price, fastPeriod)
-	slowWMA := CalculateWMA(price, slowPeriod)
+	// 计算快速和慢速均线（默认WMA）
+	fastWMA := CalculateMA(price, fastPeriod, method)
+	slowWMA := CalculateMA(price, slowPeriod, method)
 
 	if fastWMA == nil || slowWMA == nil {
 		return nil, nil, nil
@@ -42,8 +47,8 @@ func CalculateMACD(price []float64, fastPeriod, slowPeriod, signalPeriod int) (m
 		}
 	}
 
-	// 信号线 = MACD线的WMA
-	signalLine = CalculateWMA(macdLine, signalPeriod)
+	// 信号线 = MACD线的均线
+	signalLine = CalculateMA(macdLine, signalPeriod, method)
 
 	// 柱状图值 = 当前均线差值（与MQ5一致）
 	histogram = make([]float64, len(price))
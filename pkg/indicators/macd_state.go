@@ -0,0 +1,115 @@
+package indicators
+
+// MACDState 增量MACD
+// 快慢线使用WMA（可指定其他方法），MACD线 = 前两根K线均线差值的平均值，信号线 = MACD线的均线，柱状图 = 当前均线差值
+// 结果与 CalculateMACDWithMA 一致（要求 fastPeriod <= slowPeriod）
+type MACDState struct {
+	fastPeriod   int
+	slowPeriod   int
+	signalPeriod int
+	fast         MAState
+	slow         MAState
+	signal       MAState
+	diff         barSeries // 均线差值（柱状图）
+	macd         barSeries // MACD线
+}
+
+// NewMACDState 创建增量MACD（WMA，与 CalculateMACD 一致）
+func NewMACDState(fastPeriod, slowPeriod, signalPeriod int) *MACDState {
+	return NewMACDStateWithMA(fastPeriod, slowPeriod, signalPeriod, MAWMA)
+}
+
+// NewMACDStateWithMA 使用指定移动平均方法创建增量MACD
+func NewMACDStateWithMA(fastPeriod, slowPeriod, signalPeriod int, method MAMethod) *MACDState {
+	minBars := slowPeriod
+	if fastPeriod > minBars {
+		minBars = fastPeriod
+	}
+	return &MACDState{
+		fastPeriod:   fastPeriod,
+		slowPeriod:   slowPeriod,
+		signalPeriod: signalPeriod,
+		fast:         NewMAState(fastPeriod, method),
+		slow:         NewMAState(slowPeriod, method),
+		signal:       NewMAState(signalPeriod, method),
+		diff:         barSeries{minBars: minBars},
+		macd:         barSeries{minBars: minBars},
+	}
+}
+
+// Append 追加一根新K线的价格
+func (s *MACDState) Append(price float64) {
+	s.fast.Append(price)
+	s.slow.Append(price)
+	s.diff.push(s.computeDiff(), true)
+	s.macd.push(s.computeMACD(), true)
+	s.signal.Append(s.macd.at(s.macd.bars - 1))
+}
+
+// UpdateLast 更新最新K线的价格
+func (s *MACDState) UpdateLast(price float64) {
+	if s.diff.bars == 0 {
+		s.Append(price)
+		return
+	}
+	s.fast.UpdateLast(price)
+	s.slow.UpdateLast(price)
+	s.diff.setLast(s.computeDiff(), true)
+	// MACD线只依赖之前K线的差值（仅第一根K线时依赖自身）
+	s.macd.setLast(s.computeMACD(), true)
+	s.signal.UpdateLast(s.macd.at(s.macd.bars - 1))
+}
+
+// computeDiff 计算最新K线的均线差值（慢线就绪前为0）
+func (s *MACDState) computeDiff() float64 {
+	if !s.slow.Ready() {
+		return 0
+	}
+	return s.fast.Value() - s.slow.Value()
+}
+
+// computeMACD 计算最新K线的MACD线值
+func (s *MACDState) computeMACD() float64 {
+	k := s.diff.bars - 1
+	if k < s.signalPeriod-1 {
+		return 0
+	}
+	switch {
+	case k >= 2:
+		// 前两天均线差值的平均值
+		return (s.diff.at(k-1) + s.diff.at(k-2)) * 0.5
+	case k >= 1:
+		return s.diff.at(k - 1)
+	default:
+		return s.diff.at(k)
+	}
+}
+
+// Value 获取最新的MACD线、信号线和柱状图值
+func (s *MACDState) Value() (macdLine, signalLine, histogram float64) {
+	macdLine, _ = s.macd.last()
+	histogram, _ = s.diff.last()
+	return macdLine, s.signal.Value(), histogram
+}
+
+// Ready 是否已有足够数据
+func (s *MACDState) Ready() bool {
+	return s.diff.bars >= s.diff.minBars
+}
+
+// Values 获取完整MACD数组（索引0是最新数据），与 CalculateMACD 返回格式一致
+func (s *MACDState) Values() (macdLine, signalLine, histogram []float64) {
+	if !s.Ready() {
+		return nil, nil, nil
+	}
+	return s.macd.series(), s.signal.Values(), s.diff.series()
+}
+
+// Reset 清空状态
+func (s *MACDState) Reset() {
+	s.fast.Reset()
+	s.slow.Reset()
+	s.signal.Reset()
+	s.diff.reset()
+	s.macd.reset()
+}
@@ -0,0 +1,32 @@
+package indicators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMACDStateMatchesBatch(t *testing.T) {
+	prices := testPrices(200)
+	configs := [][3]int{{12, 26, 9}, {5, 13, 4}, {48, 72, 9}}
+	for _, method := range MAMethods() {
+		for _, c := range configs {
+			method, c := method, c
+			t.Run(fmt.Sprintf("%s_%d_%d_%d", method, c[0], c[1], c[2]), func(t *testing.T) {
+				state := NewMACDStateWithMA(c[0], c[1], c[2], method)
+				walkBars(prices, func(p float64, first bool) {
+					if first {
+						state.Append(p)
+					} else {
+						state.UpdateLast(p)
+					}
+				}, func(bar int, history []float64) {
+					wantLine, wantSignal, wantHist := CalculateMACDWithMA(history, c[0], c[1], c[2], method)
+					line, signal, hist := state.Values()
+					assertSeries(t, "MACD", bar, line, wantLine)
+					assertSeries(t, "Signal", bar, signal, wantSignal)
+					assertSeries(t, "Histogram", bar, hist, wantHist)
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package indicators
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	indicators map[string]Indicator
+}
+
+// NewRegistry 创建空的指标注册表
+func NewRegistry() *Registry {
+	return &Registry{indicators: make(map[string]Indicator)}
+}
+
+// Register 注册指标，名称重复时返回错误
+func (r *Registry) Register(ind Indicator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.indicators[ind.Name()]; exists {
+		return fmt.Errorf("指标 %s 已注册", ind.Name())
+	}
+	r.indicators[ind.Name()] = ind
+	return nil
+}
+
+// Get 按名称获取指标
+func (r *Registry) Get(name string) (Indicator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ind, ok := r.indicators[name]
+	return ind, ok
+}
+
+// List 获取所有已注册的指标（按名称排序）
+func (r *Registry) List() []Indicator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Indicator, 0, len(r.indicators))
+	for _, ind := range r.indicators {
+		list = append(list, ind)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// defaultRegistry 默认注册表，内置指标在 init 中注册
+var defaultRegistry = NewRegistry()
+
+// Register 向默认注册表注册指标
+func Register(ind Indicator) error {
+	return defaultRegistry.Register(ind)
+}
+
+// Get 从默认注册表获取指标
+func Get(name string) (Indicator, bool) {
+	return defaultRegistry.Get(name)
+}
+
+// List 获取默认注册表中的所有指标
+func List() []Indicator {
+	return defaultRegistry.List()
+}
+
+// IndicatorInfo 指标描述信息（用于API展示）
+type IndicatorInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Params      []ParamSpec `json:"params"`
+	Outputs     []string    `json:"outputs"`
+	Streaming   bool        `json:"streaming"` // 是否支持增量计算
+}
+
+// Describe 获取指标的描述信息
+func Describe(ind Indicator) IndicatorInfo {
+	_, streaming := ind.(Streamer)
+	return IndicatorInfo{
+		Name:        ind.Name(),
+		Description: ind.Description(),
+		Params:      ind.Params(),
+		Outputs:     ind.Outputs(),
+		Streaming:   streaming,
+	}
+}
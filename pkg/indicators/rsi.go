@@ -5,6 +5,11 @@ package indicators
 // period: 周期
 // 返回RSI数组（索引0是最新数据）
 func CalculateRSI(price []float64, period int) []float64 {
+	return CalculateRSIWithMA(price, period, MAWMA)
+}
+
+// CalculateRSIWithMA 使用指定移动平均方法平滑上涨和下跌计算RSI（经典Wilder RSI为 MASMMA）
+func CalculateRSIWithMA(price []float64, period int, method MAMethod) []float64 {
 	if len(price) < period+1 {
 		return nil
 	}
@@ -35,9 +40,9 @@ func CalculateRSI(price []float64, period int) []float64 {
 		}
 	}
 
-	// 使用WMA计算平均上涨和下跌
-	avgGain := CalculateWMA(gains, period)
-	avgLoss := CalculateWMA(losses, period)
+	// 计算平均上涨和下跌（默认WMA）
+	avgGain := CalculateMA(gains, period, method)
+	avgLoss := CalculateMA(losses, period, method)
 
 	if avgGain == nil || avgLoss == nil {
 		return nil
@@ -0,0 +1,115 @@
+package indicators
+
+// RSIState 增量相对强弱指数（RSI）
+// 使用移动平均平滑上涨和下跌（与 CalculateRSIWithMA 一致），每次更新的开销为O(period)
+type RSIState struct {
+	period    int
+	avgGain   MAState
+	avgLoss   MAState
+	prevPrice float64 // 最新K线之前一根K线的价格
+	lastPrice float64 // 最新K线的价格
+	out       barSeries
+}
+
+// NewRSIState 创建增量RSI（WMA，与 CalculateRSI 一致）
+func NewRSIState(period int) *RSIState {
+	return NewRSIStateWithMA(period, MAWMA)
+}
+
+// NewRSIStateWithMA 使用指定移动平均方法创建增量RSI
+func NewRSIStateWithMA(period int, method MAMethod) *RSIState {
+	if period < 1 {
+		period = 1
+	}
+	return &RSIState{
+		period:  period,
+		avgGain: NewMAState(period, method),
+		avgLoss: NewMAState(period, method),
+		// 批量函数在数据少于 period+1 根时返回nil
+		out: barSeries{minBars: period + 1, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格，返回最新RSI值
+func (s *RSIState) Append(price float64) float64 {
+	gain, loss := 0.0, 0.0
+	if s.out.bars > 0 {
+		gain, loss = splitChange(price - s.lastPrice)
+		s.prevPrice = s.lastPrice
+	}
+	s.lastPrice = price
+
+	s.avgGain.Append(gain)
+	s.avgLoss.Append(loss)
+	v, ok := s.compute()
+	s.out.push(v, ok)
+	return v
+}
+
+// UpdateLast 更新最新K线的价格，返回最新RSI值
+func (s *RSIState) UpdateLast(price float64) float64 {
+	if s.out.bars == 0 {
+		return s.Append(price)
+	}
+
+	gain, loss := 0.0, 0.0
+	if s.out.bars > 1 {
+		gain, loss = splitChange(price - s.prevPrice)
+	}
+	s.lastPrice = price
+
+	s.avgGain.UpdateLast(gain)
+	s.avgLoss.UpdateLast(loss)
+	v, ok := s.compute()
+	s.out.setLast(v, ok)
+	return v
+}
+
+// compute 根据平均上涨和下跌计算RSI
+func (s *RSIState) compute() (float64, bool) {
+	if !s.avgGain.Ready() {
+		return 0, false
+	}
+
+	var rs float64
+	avgLoss := s.avgLoss.Value()
+	if avgLoss == 0 {
+		rs = 100.0
+	} else {
+		rs = s.avgGain.Value() / avgLoss
+	}
+	return 100.0 - (100.0 / (1.0 + rs)), true
+}
+
+// splitChange 将价格变化拆分为上涨和下跌
+func splitChange(change float64) (gain, loss float64) {
+	if change > 0 {
+		return change, 0
+	}
+	return 0, -change
+}
+
+// Value 获取最新RSI值（未就绪时返回0）
+func (s *RSIState) Value() float64 {
+	v, _ := s.out.last()
+	return v
+}
+
+// Ready 是否已有足够数据
+func (s *RSIState) Ready() bool {
+	return s.out.bars >= s.period+1
+}
+
+// Values 获取完整RSI数组（索引0是最新数据），与 CalculateRSI 返回格式一致
+func (s *RSIState) Values() []float64 {
+	return s.out.series()
+}
+
+// Reset 清空状态
+func (s *RSIState) Reset() {
+	s.avgGain.Reset()
+	s.avgLoss.Reset()
+	s.prevPrice = 0
+	s.lastPrice = 0
+	s.out.reset()
+}
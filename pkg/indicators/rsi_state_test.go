@@ -0,0 +1,31 @@
+package indicators
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRSIStateMatchesBatch(t *testing.T) {
+	prices := testPrices(160)
+	for _, method := range MAMethods() {
+		for _, period := range []int{2, 14, 48} {
+			method, period := method, period
+			t.Run(fmt.Sprintf("%s_%d", method, period), func(t *testing.T) {
+				state := NewRSIStateWithMA(period, method)
+				walkBars(prices, func(p float64, first bool) {
+					if first {
+						state.Append(p)
+					} else {
+						state.UpdateLast(p)
+					}
+				}, func(bar int, history []float64) {
+					want := CalculateRSIWithMA(history, period, method)
+					assertSeries(t, "RSI", bar, state.Values(), want)
+					if state.Ready() != (want != nil) {
+						t.Fatalf("第%d根K线: Ready=%v，批量结果nil=%v", bar, state.Ready(), want == nil)
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package indicators
+
+// SMAState 增量简单移动平均（SMA）
+// 使用方式与 WMAState 相同，结果与 CalculateSMA 对同一序列的计算结果一致
+type SMAState struct {
+	period    int
+	invPeriod float64
+	window    priceWindow
+	out       barSeries
+}
+
+// NewSMAState 创建增量SMA
+func NewSMAState(period int) *SMAState {
+	if period < 1 {
+		period = 1
+	}
+	return &SMAState{
+		period:    period,
+		invPeriod: 1.0 / float64(period),
+		window:    priceWindow{size: period},
+		out:       barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格，返回最新SMA值
+func (s *SMAState) Append(price float64) float64 {
+	s.window.push(price)
+	v, ok := s.compute()
+	s.out.push(v, ok)
+	return v
+}
+
+// UpdateLast 更新最新K线的价格，返回最新SMA值
+func (s *SMAState) UpdateLast(price float64) float64 {
+	if s.out.bars == 0 {
+		return s.Append(price)
+	}
+	s.window.setLast(price)
+	v, ok := s.compute()
+	s.out.setLast(v, ok)
+	return v
+}
+
+// compute 计算最新窗口的SMA
+func (s *SMAState) compute() (float64, bool) {
+	if !s.window.full() {
+		return 0, false
+	}
+	sum := 0.0
+	for j := 0; j < s.period; j++ {
+		sum += s.window.get(j)
+	}
+	return sum * s.invPeriod, true
+}
+
+// Value 获取最新SMA值（未就绪时返回0）
+func (s *SMAState) Value() float64 {
+	v, _ := s.out.last()
+	return v
+}
+
+// Ready 是否已有足够数据
+func (s *SMAState) Ready() bool {
+	return s.out.bars >= s.period
+}
+
+// Len 已输入的K线数量
+func (s *SMAState) Len() int {
+	return s.out.bars
+}
+
+// Values 获取完整SMA数组（索引0是最新数据），与 CalculateSMA 返回格式一致
+func (s *SMAState) Values() []float64 {
+	return s.out.series()
+}
+
+// Reset 清空状态
+func (s *SMAState) Reset() {
+	s.window.reset()
+	s.out.reset()
+}
@@ -0,0 +1,112 @@
+package indicators
+
+// barSeries 增量指标的输出序列
+// 按时间顺序（从旧到新）保存输出值，Values 时转换为与批量函数一致的数组（索引0是最新数据）
+type barSeries struct {
+	values  []float64 // 输出值（从旧到新），fill模式下从第一个有效值开始保存
+	bars    int       // 已输入的K线总数
+	minBars int       // 批量函数返回非nil所需的最少K线数
+	fill    bool      // 有效值之前的数据点是否使用最早的有效值填充（与批量函数一致）
+}
+
+// push 追加一根K线的输出值
+func (s *barSeries) push(v float64, valid bool) {
+	s.bars++
+	if valid || !s.fill {
+		s.values = append(s.values, v)
+	}
+}
+
+// setLast 更新最新一根K线的输出值
+func (s *barSeries) setLast(v float64, valid bool) {
+	if len(s.values) == 0 || (!valid && s.fill) {
+		return
+	}
+	s.values[len(s.values)-1] = v
+}
+
+// last 获取最新的有效输出值
+func (s *barSeries) last() (float64, bool) {
+	if len(s.values) == 0 {
+		return 0, false
+	}
+	return s.values[len(s.values)-1], true
+}
+
+// at 获取第k根K线（从旧到新，从0开始）的输出值，不存在时返回0
+func (s *barSeries) at(k int) float64 {
+	idx := k - (s.bars - len(s.values))
+	if idx < 0 || idx >= len(s.values) {
+		return 0
+	}
+	return s.values[idx]
+}
+
+// series 转换为批量函数格式的数组（索引0是最新数据）
+func (s *barSeries) series() []float64 {
+	if s.bars < s.minBars || len(s.values) == 0 {
+		return nil
+	}
+
+	out := make([]float64, s.bars)
+	firstValid := s.bars - len(s.values)
+	for i := 0; i < s.bars; i++ {
+		k := s.bars - 1 - i
+		if k >= firstValid {
+			out[i] = s.values[k-firstValid]
+		} else {
+			// 对于前面的数据点，使用最早的有效值
+			out[i] = s.values[0]
+		}
+	}
+	return out
+}
+
+// reset 清空序列
+func (s *barSeries) reset() {
+	s.values = s.values[:0]
+	s.bars = 0
+}
+
+// priceWindow 最近N个输入值的滑动窗口（从旧到新）
+type priceWindow struct {
+	size   int
+	values []float64
+}
+
+// push 追加一个输入值
+func (w *priceWindow) push(v float64) {
+	w.values = append(w.values, v)
+	// 超过两倍窗口大小时整体前移，保证均摊O(1)
+	if len(w.values) > 2*w.size+1 {
+		n := copy(w.values, w.values[len(w.values)-w.size-1:])
+		w.values = w.values[:n]
+	}
+}
+
+// setLast 更新最新的输入值
+func (w *priceWindow) setLast(v float64) {
+	if len(w.values) > 0 {
+		w.values[len(w.values)-1] = v
+	}
+}
+
+// full 窗口是否已满
+func (w *priceWindow) full() bool {
+	return len(w.values) >= w.size
+}
+
+// get 获取倒数第j个输入值（j=0为最新），与批量函数中 price[i+j] 的含义一致
+func (w *priceWindow) get(j int) float64 {
+	return w.values[len(w.values)-1-j]
+}
+
+// len 当前保存的输入值个数
+func (w *priceWindow) len() int {
+	return len(w.values)
+}
+
+// reset 清空窗口
+func (w *priceWindow) reset() {
+	w.values = w.values[:0]
+}
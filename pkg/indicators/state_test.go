@@ -0,0 +1,66 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// stateEpsilon 增量计算与批量计算结果允许的误差（按数值大小相对放大）
+const stateEpsilon = 1e-9
+
+// testPrices 生成确定性的价格序列（从旧到新），包含趋势、震荡和连续相同价格
+func testPrices(n int) []float64 {
+	prices := make([]float64, n)
+	price := 100.0
+	for i := 0; i < n; i++ {
+		switch {
+		case i%17 == 5:
+			// 与上一根K线价格相同（RSI涨跌均为0）
+		default:
+			price += math.Sin(float64(i)*0.37)*1.8 + math.Cos(float64(i)*0.11)*0.9 + 0.05
+		}
+		prices[i] = price
+	}
+	return prices
+}
+
+// walkBars 逐根K线喂入价格：每根K线先以偏离的价格 Append，再多次 UpdateLast 修改正在形成的K线，
+// 最后一次更新为该K线的最终价格。每次输入后调用 check，history 为对应的批量输入（索引0是最新数据）
+func walkBars(prices []float64, feed func(price float64, first bool), check func(bar int, history []float64)) {
+	history := make([]float64, 0, len(prices))
+	for i, p := range prices {
+		revisions := []float64{p * 0.985, p * 1.012, p + 0.25, p}
+		for j, r := range revisions {
+			feed(r, j == 0)
+			if j == 0 {
+				history = append(history, r)
+			} else {
+				history[len(history)-1] = r
+			}
+			check(i, reverseSeries(history))
+		}
+	}
+}
+
+// reverseSeries 把从旧到新的序列转换为索引0是最新数据的数组
+func reverseSeries(prices []float64) []float64 {
+	out := make([]float64, len(prices))
+	for i, p := range prices {
+		out[len(prices)-1-i] = p
+	}
+	return out
+}
+
+// assertSeries 比较增量结果和批量结果的每个索引
+func assertSeries(t *testing.T, name string, bar int, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) || (got == nil) != (want == nil) {
+		t.Fatalf("%s 第%d根K线: 长度 %d(nil=%v)，期望 %d(nil=%v)", name, bar, len(got), got == nil, len(want), want == nil)
+	}
+	for i := range want {
+		tolerance := stateEpsilon * math.Max(1, math.Abs(want[i]))
+		if math.Abs(got[i]-want[i]) > tolerance {
+			t.Fatalf("%s 第%d根K线 索引%d: %.12f，期望 %.12f", name, bar, i, got[i], want[i])
+		}
+	}
+}
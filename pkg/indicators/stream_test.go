@@ -0,0 +1,39 @@
+package indicators
+
+import "testing"
+
+func TestStreamLast(t *testing.T) {
+	for _, ind := range List() {
+		streamer, ok := ind.(Streamer)
+		if !ok {
+			continue
+		}
+		t.Run(ind.Name(), func(t *testing.T) {
+			params, err := ResolveParams(ind, nil)
+			if err != nil {
+				t.Fatalf("解析默认参数失败: %v", err)
+			}
+			stream := streamer.NewStream(params)
+			if _, ok := stream.(LastValuer); !ok {
+				t.Fatalf("内置增量流应实现 LastValuer")
+			}
+
+			// Last 与完整输出的索引0一致，数据不足时同为nil
+			walkBars(testPrices(120), func(price float64, first bool) {
+				if first {
+					stream.Append(price)
+				} else {
+					stream.UpdateLast(price)
+				}
+			}, func(bar int, history []float64) {
+				got, want := LastValues(stream), Latest(stream.Values())
+				if (got == nil) != (want == nil) {
+					t.Fatalf("第%d根K线: Last %v，完整输出 %v", bar, got, want)
+				}
+				for name, v := range want {
+					assertSeries(t, name, bar, []float64{got[name]}, []float64{v})
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package indicators
+
+import (
+	"testing"
+	"time"
+
+	"github.com/binance_cyan/indicators/pkg/types"
+)
+
+// dailyKlines 生成每天4根6小时K线，第d天的最高价-最低价为ranges[d]
+func dailyKlines(start time.Time, ranges []float64) []types.Kline {
+	var klines []types.Kline
+	for d, r := range ranges {
+		for h := 0; h < 4; h++ {
+			// 最高价和最低价分别出现在当天不同的K线上
+			k := types.Kline{Timestamp: start.Add(time.Duration(d*24+h*6) * time.Hour), Open: 100, Close: 100, High: 100, Low: 100}
+			if h == 1 {
+				k.High = 100 + r/2
+			}
+			if h == 2 {
+				k.Low = 100 - r/2
+			}
+			klines = append(klines, k)
+		}
+	}
+	return klines
+}
+
+func TestCalculateVolatility5Days(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		ranges []float64 // 每个自然天的波动值，最后一天为当前日
+		want   float64
+	}{
+		{name: "不足5天", ranges: []float64{1, 2, 3, 4, 100}, want: 0},
+		{name: "刚好5天", ranges: []float64{1, 2, 3, 4, 5, 100}, want: 3},
+		{name: "只取最近5天", ranges: []float64{50, 1, 2, 3, 4, 5, 100}, want: 3},
+		// 波动为0的自然天不计入
+		{name: "跳过波动为0的天", ranges: []float64{2, 1, 2, 0, 3, 4, 5, 100}, want: 3},
+		{name: "去掉波动为0的天后不足5天", ranges: []float64{1, 2, 0, 4, 5, 100}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateVolatility5Days(dailyKlines(start, tt.ranges)); got != tt.want {
+				t.Fatalf("波动值 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolatilityTracker(t *testing.T) {
+	// 每根K线的结果为当前日之前最近5个波动大于0的自然天的平均值
+	ranges := []float64{7, 3, 0, 5, 2, 9, 4, 6, 1, 8}
+	klines := dailyKlines(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ranges)
+	tracker := NewVolatilityTracker(5)
+	for i, k := range klines {
+		var previous []float64
+		for _, r := range ranges[:i/4] {
+			if r > 0 {
+				previous = append(previous, r)
+			}
+		}
+		want := 0.0
+		if len(previous) >= 5 {
+			for _, r := range previous[len(previous)-5:] {
+				want += r
+			}
+			want /= 5
+		}
+		if got := tracker.Add(k); got != want {
+			t.Fatalf("第%d根K线: 波动值 %v，期望 %v", i, got, want)
+		}
+	}
+}
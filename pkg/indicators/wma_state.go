@@ -0,0 +1,83 @@
+package indicators
+
+// WMAState 增量加权移动平均（WMA）
+// 按时间顺序（从旧到新）输入价格：Append 追加新K线，UpdateLast 更新正在形成的最新K线
+// 每次更新的开销为O(period)，结果与 CalculateWMA 对同一序列的计算结果一致
+type WMAState struct {
+	period      int
+	denominator float64
+	window      priceWindow
+	out         barSeries
+}
+
+// NewWMAState 创建增量WMA
+func NewWMAState(period int) *WMAState {
+	if period < 1 {
+		period = 1
+	}
+	return &WMAState{
+		period:      period,
+		denominator: float64(period * (period + 1) / 2),
+		window:      priceWindow{size: period},
+		out:         barSeries{minBars: period, fill: true},
+	}
+}
+
+// Append 追加一根新K线的价格，返回最新WMA值
+func (s *WMAState) Append(price float64) float64 {
+	s.window.push(price)
+	v, ok := s.compute()
+	s.out.push(v, ok)
+	return v
+}
+
+// UpdateLast 更新最新K线的价格，返回最新WMA值
+func (s *WMAState) UpdateLast(price float64) float64 {
+	if s.out.bars == 0 {
+		return s.Append(price)
+	}
+	s.window.setLast(price)
+	v, ok := s.compute()
+	s.out.setLast(v, ok)
+	return v
+}
+
+// compute 计算最新窗口的WMA
+func (s *WMAState) compute() (float64, bool) {
+	if !s.window.full() {
+		return 0, false
+	}
+	weightedSum := 0.0
+	for j := 0; j < s.period; j++ {
+		weight := float64(s.period - j) // 最新数据权重最大
+		weightedSum += s.window.get(j) * weight
+	}
+	return weightedSum / s.denominator, true
+}
+
+// Value 获取最新WMA值（未就绪时返回0）
+func (s *WMAState) Value() float64 {
+	v, _ := s.out.last()
+	return v
+}
+
+// Ready 是否已有足够数据
+func (s *WMAState) Ready() bool {
+	return s.out.bars >= s.period
+}
+
+// Len 已输入的K线数量
+func (s *WMAState) Len() int {
+	return s.out.bars
+}
+
+// Values 获取完整WMA数组（索引0是最新数据），与 CalculateWMA 返回格式一致
+func (s *WMAState) Values() []float64 {
+	return s.out.series()
+}
+
+// Reset 清空状态
+func (s *WMAState) Reset() {
+	s.window.reset()
+	s.out.reset()
+}
@@ -0,0 +1,58 @@
+package indicators
+
+import "math"
+
+// Zone 计算价格所在的分区号
+// price: 当前价格
+// middle: 中轨价格
+// upper: 上轨价格
+// lower: 下轨价格
+// 返回分区号：-10到+10，0为中轨
+// 分区规则：中轨为0，向上等分10个分区（+1到+10），向下等分10个分区（-1到-10）
+func Zone(price, middle, upper, lower float64) int {
+	if middle == 0 {
+		return 0
+	}
+
+	// 如果价格在中轨，返回0
+	if math.Abs(price-middle) < 0.0001 {
+		return 0
+	}
+
+	// 计算价格相对于中轨的位置
+	if price > middle {
+		// 价格在中轨上方
+		upperRange := upper - middle
+		if upperRange <= 0 {
+			return 10 // 如果上轨等于中轨，返回最大分区
+		}
+		// 计算分区：0到+10
+		// 将上轨到中轨的范围等分为10个分区
+		ratio := (price - middle) / upperRange
+		zone := int(ratio * 10)
+		if zone >= 10 {
+			zone = 10
+		}
+		if zone < 1 {
+			zone = 1
+		}
+		return zone
+	} else {
+		// 价格在中轨下方
+		lowerRange := middle - lower
+		if lowerRange <= 0 {
+			return -10 // 如果下轨等于中轨，返回最小分区
+		}
+		// 计算分区：0到-10
+		// 将中轨到下轨的范围等分为10个分区
+		ratio := (middle - price) / lowerRange
+		zone := -int(ratio * 10)
+		if zone <= -10 {
+			zone = -10
+		}
+		if zone > -1 {
+			zone = -1
+		}
+		return zone
+	}
+}
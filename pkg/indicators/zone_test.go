@@ -0,0 +1,40 @@
+package indicators
+
+import "testing"
+
+func TestZone(t *testing.T) {
+	tests := []struct {
+		name  string
+		price float64
+		want  int
+	}{
+		{name: "中轨", price: 100, want: 0},
+		{name: "中轨附近", price: 100.00005, want: 0},
+		{name: "刚高于中轨", price: 100.5, want: 1},
+		{name: "上方第5区", price: 105.5, want: 5},
+		{name: "上轨", price: 110, want: 10},
+		{name: "高于上轨", price: 130, want: 10},
+		{name: "刚低于中轨", price: 99.5, want: -1},
+		{name: "下方第3区", price: 93, want: -3},
+		{name: "下轨", price: 80, want: -10},
+		{name: "低于下轨", price: 50, want: -10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 上轨距中轨10，下轨距中轨20（上下不对称）
+			if got := Zone(tt.price, 100, 110, 80); got != tt.want {
+				t.Fatalf("分区 %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := Zone(100, 0, 0, 0); got != 0 {
+		t.Fatalf("中轨为0时分区 %d，期望0", got)
+	}
+	if got := Zone(101, 100, 100, 100); got != 10 {
+		t.Fatalf("上轨等于中轨时分区 %d，期望10", got)
+	}
+	if got := Zone(99, 100, 100, 100); got != -10 {
+		t.Fatalf("下轨等于中轨时分区 %d，期望-10", got)
+	}
+}
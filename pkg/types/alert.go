@@ -0,0 +1,158 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 告警规则可引用的指标值
+const (
+	AlertSourcePrice         = "price"          // 收盘价
+	AlertSourceCCI           = "cci"            // CCI，key为周期，如 "48"
+	AlertSourceRSI           = "rsi"            // RSI，key为周期，如 "72"
+	AlertSourceMACDLine      = "macd_line"      // MACD线，key如 "48_72"
+	AlertSourceMACDSignal    = "macd_signal"    // MACD信号线，key如 "48_72"
+	AlertSourceMACDHistogram = "macd_histogram" // MACD柱状图，key如 "48_72"
+	AlertSourceBollZone      = "boll_zone"      // 布林线分区号（-10到+10）
+	AlertSourceEnvZone       = "env_zone"       // 包络线分区号（-10到+10）
+	AlertSourceVolatility    = "volatility"     // 5天平均波动价格值
+	// AlertSourceDivergence 价格与振荡指标的背离，key为 "cci_48"、"rsi"（任一RSI实例）等，为空表示任一振荡指标
+	AlertSourceDivergence = "divergence"
+)
+
+// 告警条件
+const (
+	AlertCondGreater      = ">"
+	AlertCondGreaterEqual = ">="
+	AlertCondLess         = "<"
+	AlertCondLessEqual    = "<="
+	AlertCondCrossAbove   = "cross_above" // 上穿阈值
+	AlertCondCrossBelow   = "cross_below" // 下穿阈值
+
+	// 背离条件（仅用于 divergence，阈值被忽略）
+	AlertCondBullish        = "bullish"         // 底背离（常规或隐藏）
+	AlertCondBearish        = "bearish"         // 顶背离（常规或隐藏）
+	AlertCondRegularBullish = "regular_bullish" // 常规底背离
+	AlertCondRegularBearish = "regular_bearish" // 常规顶背离
+	AlertCondHiddenBullish  = "hidden_bullish"  // 隐藏底背离
+	AlertCondHiddenBearish  = "hidden_bearish"  // 隐藏顶背离
+)
+
+// IsDivergenceCondition 是否为背离条件
+func IsDivergenceCondition(condition string) bool {
+	switch condition {
+	case AlertCondBullish, AlertCondBearish, AlertCondRegularBullish, AlertCondRegularBearish,
+		AlertCondHiddenBullish, AlertCondHiddenBearish:
+		return true
+	}
+	return false
+}
+
+// AlertRule 告警规则
+// 例如 "CCI(48) 上穿 100"：Source=cci, Key=48, Condition=cross_above, Threshold=100
+// 例如 "RSI(72) < 30 持续3根已收盘K线"：Source=rsi, Key=72, Condition=<, Threshold=30, Bars=3, OnClose=true
+type AlertRule struct {
+	ID        int64     `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"` // K线周期，默认1h
+	Name      string    `json:"name"`
+	Source    string    `json:"source"`
+	Key       string    `json:"key"`
+	Condition string    `json:"condition"`
+	Threshold float64   `json:"threshold"`
+	Bars      int       `json:"bars"`     // 条件需连续满足的K线数量，默认1（穿越类条件忽略）
+	OnClose   bool      `json:"on_close"` // 只在已收盘K线上评估（忽略正在形成的K线）
+	Cooldown  int       `json:"cooldown"` // 两次触发的最小间隔（秒）
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize 补全默认值
+func (r *AlertRule) Normalize() {
+	r.Symbol = strings.ToUpper(r.Symbol)
+	if r.Interval == "" {
+		r.Interval = "1h"
+	}
+	if r.Bars < 1 {
+		r.Bars = 1
+	}
+	if r.Name == "" {
+		r.Name = r.Describe()
+	}
+}
+
+// Validate 校验规则
+func (r *AlertRule) Validate() error {
+	if r.Symbol == "" {
+		return fmt.Errorf("symbol不能为空")
+	}
+	if _, err := IntervalToMinutes(r.Interval); err != nil {
+		return err
+	}
+
+	switch r.Source {
+	case AlertSourceCCI, AlertSourceRSI, AlertSourceMACDLine, AlertSourceMACDSignal, AlertSourceMACDHistogram:
+		if r.Key == "" {
+			return fmt.Errorf("指标 %s 需要指定key", r.Source)
+		}
+	case AlertSourcePrice, AlertSourceBollZone, AlertSourceEnvZone, AlertSourceVolatility:
+	case AlertSourceDivergence:
+		if !IsDivergenceCondition(r.Condition) {
+			return fmt.Errorf("背离规则的条件必须为 bullish、bearish、regular_bullish、regular_bearish、hidden_bullish 或 hidden_bearish")
+		}
+		return r.validateCooldown()
+	default:
+		return fmt.Errorf("不支持的指标: %s", r.Source)
+	}
+
+	switch r.Condition {
+	case AlertCondGreater, AlertCondGreaterEqual, AlertCondLess, AlertCondLessEqual, AlertCondCrossAbove, AlertCondCrossBelow:
+	default:
+		return fmt.Errorf("不支持的条件: %s", r.Condition)
+	}
+	return r.validateCooldown()
+}
+
+// validateCooldown 校验冷却时间
+func (r *AlertRule) validateCooldown() error {
+	if r.Cooldown < 0 {
+		return fmt.Errorf("冷却时间不能为负数")
+	}
+	return nil
+}
+
+// Describe 规则的可读描述，如 "cci(48) cross_above 100"
+func (r *AlertRule) Describe() string {
+	source := r.Source
+	if r.Key != "" {
+		source = fmt.Sprintf("%s(%s)", r.Source, r.Key)
+	}
+	if r.Source == AlertSourceDivergence {
+		return fmt.Sprintf("%s %s", source, r.Condition)
+	}
+	desc := fmt.Sprintf("%s %s %v", source, r.Condition, r.Threshold)
+	if r.Bars > 1 {
+		desc += fmt.Sprintf(" 持续%d根", r.Bars)
+	}
+	return desc
+}
+
+// AlertEvent 告警事件（规则触发记录）
+type AlertEvent struct {
+	ID          int64     `json:"id"`
+	RuleID      int64     `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	Symbol      string    `json:"symbol"`
+	Interval    string    `json:"interval"`
+	Source      string    `json:"source"`
+	Key         string    `json:"key"`
+	Condition   string    `json:"condition"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"`    // 触发时的指标值
+	Price       float64   `json:"price"`    // 触发时的价格
+	BarTime     time.Time `json:"bar_time"` // 触发所在K线的开盘时间
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
@@ -1,64 +1,223 @@
 package types
 
-// IndicatorConfig 指标配置
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// IndicatorInstance 指标实例配置
+type IndicatorInstance struct {
+	Type string `json:"type"`           // 指标类型（注册表中的名称），如 cci、macd、rsi、bollinger、envelope
+	Name string `json:"name,omitempty"` // 显示名称
+	// Key 结果key（同类型内唯一），为空时由周期参数生成，如 "48"、"48_72"
+	Key string `json:"key,omitempty"`
+	// Params 参数（周期基于小时），未设置的参数使用注册表中的默认值
+	Params map[string]float64 `json:"params,omitempty"`
+	// MA 移动平均方法（wma、sma、ema、smma、lwma），为空时使用wma（与MQ5一致）
+	MA string `json:"ma,omitempty"`
+}
+
+// IndicatorConfig 指标配置：一个symbol的指标实例列表
+// 同类型的多个实例按列表顺序计算；布林线、包络线的第一个实例用于主看板和分区号
 type IndicatorConfig struct {
-	// CCI配置
-	CCI_Period1 int `json:"cci_period1"` // 默认48
-	CCI_Period2 int `json:"cci_period2"` // 默认72
-	CCI_Period3 int `json:"cci_period3"` // 默认168
-
-	// MACD配置
-	MACD_Fast1   int     `json:"macd_fast1"`   // 默认48
-	MACD_Slow1   int     `json:"macd_slow1"`  // 默认72
-	MACD_Signal1 int     `json:"macd_signal1"` // 默认2
-	MACD_Fast2   int     `json:"macd_fast2"`   // 默认72
-	MACD_Slow2   int     `json:"macd_slow2"`   // 默认168
-	MACD_Signal2 int     `json:"macd_signal2"` // 默认2
-	MACD_N1      float64 `json:"macd_n1"`      // 默认2000
-	MACD_N2      float64 `json:"macd_n2"`      // 默认1000
-
-	// RSI配置
-	RSI_Period1 int `json:"rsi_period1"` // 默认48
-	RSI_Period2 int `json:"rsi_period2"` // 默认72
-
-	// 布林线配置
-	Boll_Period    int     `json:"boll_period"`    // 默认24
-	Boll_Deviation float64 `json:"boll_deviation"` // 默认2.0
-
-	// 包络线配置
-	Env_Period    int     `json:"env_period"`    // 默认24
-	Env_Deviation float64 `json:"env_deviation"` // 默认2.28
+	Indicators []IndicatorInstance `json:"indicators"`
+	// Divergence 背离检测参数，为空时使用默认值
+	Divergence *DivergenceConfig `json:"divergence,omitempty"`
 }
 
-// GetDefaultConfig 获取默认配置
-func GetDefaultConfig() IndicatorConfig {
-	return IndicatorConfig{
-		// CCI
-		CCI_Period1: 48,
-		CCI_Period2: 72,
-		CCI_Period3: 168,
-
-		// MACD
-		MACD_Fast1:   48,
-		MACD_Slow1:   72,
-		MACD_Signal1: 2,
-		MACD_Fast2:   72,
-		MACD_Slow2:   168,
-		MACD_Signal2: 2,
-		MACD_N1:      2000,
-		MACD_N2:      1000,
-
-		// RSI
-		RSI_Period1: 48,
-		RSI_Period2: 72,
-
-		// 布林线
-		Boll_Period:    24,
-		Boll_Deviation: 2.0,
-
-		// 包络线
-		Env_Period:    24,
-		Env_Deviation: 2.28,
+// DivergenceConfig 背离检测配置（以K线根数计，不随K线周期缩放）
+type DivergenceConfig struct {
+	Disabled bool     `json:"disabled,omitempty"`
+	Lookback int      `json:"lookback,omitempty"`  // 摆动点左右各需比较的K线数量，默认5
+	MinRange int      `json:"min_range,omitempty"` // 两个摆动点的最小间隔，默认5
+	MaxRange int      `json:"max_range,omitempty"` // 两个摆动点的最大间隔，默认60
+	Sources  []string `json:"sources,omitempty"`   // 参与检测的振荡指标：cci、rsi、macd（MACD柱），默认全部
+}
+
+// OfType 获取指定类型的所有实例（保持配置顺序）
+func (c IndicatorConfig) OfType(typ string) []IndicatorInstance {
+	var result []IndicatorInstance
+	for _, inst := range c.Indicators {
+		if inst.Type == typ {
+			result = append(result, inst)
+		}
+	}
+	return result
+}
+
+// Clone 深拷贝配置
+func (c IndicatorConfig) Clone() IndicatorConfig {
+	clone := IndicatorConfig{Indicators: make([]IndicatorInstance, len(c.Indicators))}
+	for i, inst := range c.Indicators {
+		if inst.Params != nil {
+			params := make(map[string]float64, len(inst.Params))
+			for k, v := range inst.Params {
+				params[k] = v
+			}
+			inst.Params = params
+		}
+		clone.Indicators[i] = inst
+	}
+	if c.Divergence != nil {
+		divergence := *c.Divergence
+		if c.Divergence.Sources != nil {
+			divergence.Sources = make([]string, len(c.Divergence.Sources))
+			copy(divergence.Sources, c.Divergence.Sources)
+		}
+		clone.Divergence = &divergence
+	}
+	return clone
+}
+
+// UnmarshalJSON 解析配置，兼容旧版固定字段格式（cci_period1、macd_fast1 等）
+func (c *IndicatorConfig) UnmarshalJSON(data []byte) error {
+	legacy, err := IsLegacyIndicatorConfig(data)
+	if err != nil {
+		return err
+	}
+	if legacy {
+		l := defaultLegacyConfig()
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+		c.Indicators = l.instances()
+		// 背离检测参数是实例列表之外的字段，旧版字段与之同时出现时一并保留
+		var extra struct {
+			Divergence *DivergenceConfig `json:"divergence"`
+		}
+		if err := json.Unmarshal(data, &extra); err != nil {
+			return err
+		}
+		c.Divergence = extra.Divergence
+		return nil
+	}
+
+	// 使用别名类型避免递归调用
+	type plain IndicatorConfig
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*c = IndicatorConfig(p)
+	return nil
+}
+
+// legacyFields 旧版固定字段格式的字段名（包括早期版本的 macd_n1、macd_n2）
+var legacyFields = map[string]bool{
+	"cci_period1": true, "cci_period2": true, "cci_period3": true, "cci_ma": true,
+	"macd_fast1": true, "macd_slow1": true, "macd_signal1": true,
+	"macd_fast2": true, "macd_slow2": true, "macd_signal2": true,
+	"macd_n1": true, "macd_n2": true, "macd_ma": true,
+	"rsi_period1": true, "rsi_period2": true, "rsi_ma": true,
+	"boll_period": true, "boll_deviation": true, "boll_ma": true,
+	"env_period": true, "env_deviation": true, "env_ma": true,
+}
+
+// IsLegacyIndicatorConfig 判断JSON是否为旧版固定字段格式（没有 indicators 字段且包含旧版字段）
+// 只包含 divergence 等新字段的对象不是旧版格式
+func IsLegacyIndicatorConfig(data []byte) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false, err
+	}
+	if _, ok := fields["indicators"]; ok {
+		return false, nil
+	}
+	for name := range fields {
+		if legacyFields[name] {
+			return true, nil
+		}
 	}
+	return false, nil
 }
 
+// legacyIndicatorConfig 旧版固定字段配置（indicator_configs 表中的历史数据）
+type legacyIndicatorConfig struct {
+	CCI_Period1 int    `json:"cci_period1"`
+	CCI_Period2 int    `json:"cci_period2"`
+	CCI_Period3 int    `json:"cci_period3"`
+	CCI_MA      string `json:"cci_ma"`
+
+	MACD_Fast1   int    `json:"macd_fast1"`
+	MACD_Slow1   int    `json:"macd_slow1"`
+	MACD_Signal1 int    `json:"macd_signal1"`
+	MACD_Fast2   int    `json:"macd_fast2"`
+	MACD_Slow2   int    `json:"macd_slow2"`
+	MACD_Signal2 int    `json:"macd_signal2"`
+	MACD_MA      string `json:"macd_ma"`
+
+	RSI_Period1 int    `json:"rsi_period1"`
+	RSI_Period2 int    `json:"rsi_period2"`
+	RSI_MA      string `json:"rsi_ma"`
+
+	Boll_Period    int     `json:"boll_period"`
+	Boll_Deviation float64 `json:"boll_deviation"`
+	Boll_MA        string  `json:"boll_ma"`
+
+	Env_Period    int     `json:"env_period"`
+	Env_Deviation float64 `json:"env_deviation"`
+	Env_MA        string  `json:"env_ma"`
+}
+
+// defaultLegacyConfig 旧版默认配置（旧数据中缺少的字段沿用这些值）
+func defaultLegacyConfig() legacyIndicatorConfig {
+	return legacyIndicatorConfig{
+		CCI_Period1: 48, CCI_Period2: 72, CCI_Period3: 168,
+		MACD_Fast1: 48, MACD_Slow1: 72, MACD_Signal1: 2,
+		MACD_Fast2: 72, MACD_Slow2: 168, MACD_Signal2: 2,
+		RSI_Period1: 48, RSI_Period2: 72,
+		Boll_Period: 24, Boll_Deviation: 2.0,
+		Env_Period: 24, Env_Deviation: 2.28,
+	}
+}
+
+// instances 转换为实例列表（顺序与旧版一致，周期无效的实例被丢弃）
+func (l legacyIndicatorConfig) instances() []IndicatorInstance {
+	var result []IndicatorInstance
+	add := func(typ, ma string, params map[string]float64) {
+		for _, v := range params {
+			if v <= 0 {
+				return
+			}
+		}
+		result = append(result, IndicatorInstance{Type: typ, Params: params, MA: ma})
+	}
+
+	for _, period := range []int{l.CCI_Period1, l.CCI_Period2, l.CCI_Period3} {
+		add("cci", l.CCI_MA, map[string]float64{"period": float64(period)})
+	}
+	for _, p := range [][3]int{
+		{l.MACD_Fast1, l.MACD_Slow1, l.MACD_Signal1},
+		{l.MACD_Fast2, l.MACD_Slow2, l.MACD_Signal2},
+	} {
+		add("macd", l.MACD_MA, map[string]float64{"fast": float64(p[0]), "slow": float64(p[1]), "signal": float64(p[2])})
+	}
+	for _, period := range []int{l.RSI_Period1, l.RSI_Period2} {
+		add("rsi", l.RSI_MA, map[string]float64{"period": float64(period)})
+	}
+	add("bollinger", l.Boll_MA, map[string]float64{"period": float64(l.Boll_Period), "deviation": l.Boll_Deviation})
+	add("envelope", l.Env_MA, map[string]float64{"period": float64(l.Env_Period), "deviation": l.Env_Deviation})
+	return result
+}
+
+// MigrateIndicatorConfigJSON 将旧版配置JSON转换为实例列表格式，已是新格式时 ok 为false
+func MigrateIndicatorConfigJSON(data []byte) (migrated []byte, ok bool, err error) {
+	legacy, err := IsLegacyIndicatorConfig(data)
+	if err != nil || !legacy {
+		return nil, false, err
+	}
+	var config IndicatorConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, false, fmt.Errorf("解析旧版配置失败: %w", err)
+	}
+	migrated, err = json.Marshal(config)
+	if err != nil {
+		return nil, false, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return migrated, true, nil
+}
+
+// GetDefaultConfig 获取默认配置（与MQ5指标的默认参数一致）
+func GetDefaultConfig() IndicatorConfig {
+	return IndicatorConfig{Indicators: defaultLegacyConfig().instances()}
+}
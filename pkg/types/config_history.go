@@ -0,0 +1,53 @@
+package types
+
+import "time"
+
+// 配置变更动作
+const (
+	ConfigActionUpdate   = "update"   // 通过 POST /api/config 保存
+	ConfigActionRollback = "rollback" // 回滚到历史版本
+)
+
+// ConfigVersion 指标配置的历史版本（indicator_config_history 表中的一行）
+type ConfigVersion struct {
+	ID           int64           `json:"id"`
+	Symbol       string          `json:"symbol"`
+	Version      int             `json:"version"` // 同一symbol内从1递增
+	Config       IndicatorConfig `json:"config"`
+	Author       string          `json:"author"`
+	Note         string          `json:"note,omitempty"`
+	Action       string          `json:"action"`
+	RollbackFrom int             `json:"rollback_from,omitempty"` // 回滚时恢复的版本号
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// ConfigChangeMeta 配置变更的附加信息
+type ConfigChangeMeta struct {
+	Author       string
+	Note         string
+	Action       string
+	RollbackFrom int
+}
+
+// 指标实例的变更类型
+const (
+	ConfigChangeAdded    = "added"
+	ConfigChangeRemoved  = "removed"
+	ConfigChangeModified = "modified"
+)
+
+// ConfigChangeTypeDivergence 背离检测参数变化的类型（不属于任何指标实例，Key为空）
+const ConfigChangeTypeDivergence = "divergence"
+
+// ConfigChange 两个配置版本之间单个指标实例的差异（按 type + key 对应）
+type ConfigChange struct {
+	Change string             `json:"change"`
+	Type   string             `json:"type"`
+	Key    string             `json:"key"`
+	From   *IndicatorInstance `json:"from,omitempty"`
+	To     *IndicatorInstance `json:"to,omitempty"`
+	Fields []string           `json:"fields,omitempty"` // 发生变化的字段：name、ma、params.<参数名>；背离检测为 disabled、lookback、min_range、max_range、sources
+	// FromDivergence、ToDivergence 背离检测参数变化前后的配置（仅 divergence 类型，为空表示使用默认值）
+	FromDivergence *DivergenceConfig `json:"from_divergence,omitempty"`
+	ToDivergence   *DivergenceConfig `json:"to_divergence,omitempty"`
+}
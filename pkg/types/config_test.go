@@ -0,0 +1,69 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIsLegacyIndicatorConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"旧版字段", `{"cci_period1":24,"macd_fast1":12}`, true},
+		{"早期版本字段", `{"macd_n1":2000}`, true},
+		{"旧版字段和背离参数", `{"rsi_ma":"ema","divergence":{"lookback":3}}`, true},
+		{"实例列表", `{"indicators":[{"type":"cci"}]}`, false},
+		{"实例列表和旧版字段", `{"indicators":[],"cci_period1":24}`, false},
+		{"只有背离参数", `{"divergence":{"disabled":true}}`, false},
+		{"空对象", `{}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsLegacyIndicatorConfig([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("IsLegacyIndicatorConfig(%s) = %v，期望 %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDivergenceOnlyConfig(t *testing.T) {
+	var config IndicatorConfig
+	if err := json.Unmarshal([]byte(`{"divergence":{"lookback":3,"sources":["rsi"]}}`), &config); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if config.Indicators != nil {
+		t.Fatalf("只有背离参数时不应迁移为旧版默认实例: %+v", config.Indicators)
+	}
+	if config.Divergence == nil || config.Divergence.Lookback != 3 || len(config.Divergence.Sources) != 1 {
+		t.Fatalf("背离参数丢失: %+v", config.Divergence)
+	}
+
+	if _, ok, err := MigrateIndicatorConfigJSON([]byte(`{"divergence":{"disabled":true}}`)); err != nil || ok {
+		t.Fatalf("只有背离参数的配置不应迁移: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestUnmarshalLegacyConfig(t *testing.T) {
+	var config IndicatorConfig
+	body := `{"cci_period1":24,"cci_period2":0,"cci_ma":"ema","divergence":{"lookback":4}}`
+	if err := json.Unmarshal([]byte(body), &config); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	ccis := config.OfType("cci")
+	// cci_period2 为0的实例被丢弃，cci_period3 沿用旧版默认值168
+	if len(ccis) != 2 || ccis[0].Params["period"] != 24 || ccis[1].Params["period"] != 168 || ccis[0].MA != "ema" {
+		t.Fatalf("CCI实例不正确: %+v", ccis)
+	}
+	if len(config.OfType("macd")) != 2 || len(config.OfType("rsi")) != 2 {
+		t.Fatalf("缺少的旧版字段应使用默认值: %+v", config.Indicators)
+	}
+	if config.Divergence == nil || config.Divergence.Lookback != 4 {
+		t.Fatalf("背离参数丢失: %+v", config.Divergence)
+	}
+}
@@ -0,0 +1,40 @@
+package types
+
+import "time"
+
+// 策略执行审计记录类型
+const (
+	AuditKindSignal   = "signal"    // 收到非hold信号
+	AuditKindSkip     = "skip"      // 信号无需执行（已持有同向仓位、无持仓可平、数据不足等）
+	AuditKindBlocked  = "blocked"   // 被风控拦截（熔断开关、当日亏损上限）
+	AuditKindOrder    = "order"     // 订单已提交
+	AuditKindError    = "error"     // 查询账户或下单失败、订单被拒绝
+	AuditKindKill     = "kill"      // 熔断开关开启
+	AuditKindResume   = "resume"    // 熔断开关关闭
+	AuditKindDayStart = "day_start" // 当日（UTC）第一次决策时记录的账户起始权益
+)
+
+// ExecutionAudit 策略执行审计记录（每个决策和订单一条）
+type ExecutionAudit struct {
+	ID         int64     `json:"id"`
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	Source     string    `json:"source"`   // 信号来源：策略名称、rule:<规则ID> 或 kill_switch
+	Executor   string    `json:"executor"` // paper、binance
+	Symbol     string    `json:"symbol,omitempty"`
+	Interval   string    `json:"interval,omitempty"`
+	Action     string    `json:"action,omitempty"` // long、short、close
+	Reason     string    `json:"reason,omitempty"` // 信号原因
+	Price      float64   `json:"price,omitempty"`
+	Volatility float64   `json:"volatility,omitempty"` // 5天平均波动价格值
+	Equity     float64   `json:"equity,omitempty"`
+	Position   float64   `json:"position"` // 决策时的持仓数量（空头为负数）
+	Side       string    `json:"side,omitempty"`
+	Quantity   float64   `json:"quantity,omitempty"`
+	ReduceOnly bool      `json:"reduce_only,omitempty"`
+	OrderID    string    `json:"order_id,omitempty"`
+	Status     string    `json:"status,omitempty"`     // 订单状态
+	FilledQty  float64   `json:"filled_qty,omitempty"` // 下单时已成交的数量
+	AvgPrice   float64   `json:"avg_price,omitempty"`  // 成交均价
+	Message    string    `json:"message,omitempty"`    // 决策说明或错误信息
+}
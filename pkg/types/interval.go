@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 // IntervalToMinutes 将K线周期字符串转换为分钟数
@@ -45,6 +46,31 @@ func IntervalToMinutes(interval string) (int, error) {
 	return minutes, nil
 }
 
+// IntervalDuration 将K线周期字符串转换为时长
+// 例如: "1s" -> 1秒, "15m" -> 15分钟, "1M" -> 30天（近似值）
+func IntervalDuration(interval string) (time.Duration, error) {
+	re := regexp.MustCompile(`^(\d+)([smhdwMy])$`)
+	matches := re.FindStringSubmatch(interval)
+	if len(matches) != 3 {
+		return 0, fmt.Errorf("无效的周期格式: %s", interval)
+	}
+
+	value, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, fmt.Errorf("无效的周期值: %s", matches[1])
+	}
+
+	if matches[2] == "s" {
+		return time.Duration(value) * time.Second, nil
+	}
+
+	minutes, err := IntervalToMinutes(interval)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
 // ScalePeriod 根据K线周期缩放周期参数（基于小时）
 // 配置中的周期参数是基于小时的，需要根据实际K线周期进行缩放
 // 例如: 48小时周期，15分钟K线 -> 48 * (60/15) = 192个15分钟周期
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Market 市场类型
+type Market string
+
+const (
+	MarketSpot  Market = "spot"  // 现货
+	MarketUSDM  Market = "usdm"  // U本位合约（USDⓈ-M）
+	MarketCOINM Market = "coinm" // 币本位合约（COIN-M）
+)
+
+// ParseMarket 解析市场类型（不区分大小写，空字符串为现货）
+func ParseMarket(s string) (Market, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "spot":
+		return MarketSpot, nil
+	case "usdm", "usds-m", "um":
+		return MarketUSDM, nil
+	case "coinm", "coin-m", "cm":
+		return MarketCOINM, nil
+	}
+	return "", fmt.Errorf("不支持的市场类型: %s（可选: spot, usdm, coinm）", s)
+}
+
+// IsFutures 是否为合约市场
+func (m Market) IsFutures() bool {
+	return m == MarketUSDM || m == MarketCOINM
+}
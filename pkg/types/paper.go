@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// 订单方向
+const (
+	OrderSideBuy  = "buy"
+	OrderSideSell = "sell"
+)
+
+// 订单类型
+const (
+	OrderTypeMarket = "market"
+	OrderTypeLimit  = "limit"
+	OrderTypeStop   = "stop" // 止损市价单：最新价触及触发价后按市价成交
+)
+
+// 订单状态
+const (
+	OrderStatusNew      = "new"
+	OrderStatusFilled   = "filled"
+	OrderStatusCanceled = "canceled"
+	OrderStatusRejected = "rejected"
+)
+
+// 成交的流动性方向（决定手续费率）
+const (
+	LiquidityMaker = "maker"
+	LiquidityTaker = "taker"
+)
+
+// OrderRequest 下单请求
+type OrderRequest struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // buy、sell
+	Type       string  `json:"type"` // market、limit、stop
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price,omitempty"`       // 限价单价格
+	StopPrice  float64 `json:"stop_price,omitempty"`  // 止损单触发价
+	ReduceOnly bool    `json:"reduce_only,omitempty"` // 只减仓：数量超过持仓时按持仓数量成交
+}
+
+// Normalize 规范化下单请求（交易对大写，方向和类型小写）
+func (r *OrderRequest) Normalize() {
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	r.Side = strings.ToLower(strings.TrimSpace(r.Side))
+	r.Type = strings.ToLower(strings.TrimSpace(r.Type))
+	if r.Type == "" {
+		r.Type = OrderTypeMarket
+	}
+}
+
+// Validate 校验下单请求
+func (r *OrderRequest) Validate() error {
+	if r.Symbol == "" {
+		return fmt.Errorf("symbol不能为空")
+	}
+	if r.Side != OrderSideBuy && r.Side != OrderSideSell {
+		return fmt.Errorf("不支持的订单方向: %s（可选 buy、sell）", r.Side)
+	}
+	if !(r.Quantity > 0) || math.IsInf(r.Quantity, 0) {
+		return fmt.Errorf("数量必须大于0")
+	}
+
+	switch r.Type {
+	case OrderTypeMarket:
+	case OrderTypeLimit:
+		if !(r.Price > 0) {
+			return fmt.Errorf("限价单价格必须大于0")
+		}
+	case OrderTypeStop:
+		if !(r.StopPrice > 0) {
+			return fmt.Errorf("止损单触发价必须大于0")
+		}
+	default:
+		return fmt.Errorf("不支持的订单类型: %s（可选 market、limit、stop）", r.Type)
+	}
+	return nil
+}
+
+// SideSign 买入为1，卖出为-1
+func (r *OrderRequest) SideSign() float64 {
+	if r.Side == OrderSideSell {
+		return -1
+	}
+	return 1
+}
+
+// PaperOrder 模拟交易订单
+type PaperOrder struct {
+	ID int64 `json:"id"`
+	OrderRequest
+	Status    string    `json:"status"`
+	FillPrice float64   `json:"fill_price,omitempty"` // 成交价
+	FilledQty float64   `json:"filled_qty,omitempty"` // 成交数量（只减仓订单可能小于委托数量）
+	Fee       float64   `json:"fee,omitempty"`
+	Reason    string    `json:"reason,omitempty"` // 拒绝原因
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PaperTrade 模拟交易成交记录
+type PaperTrade struct {
+	ID          int64     `json:"id"`
+	OrderID     int64     `json:"order_id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	Fee         float64   `json:"fee"`
+	Liquidity   string    `json:"liquidity"`    // maker、taker
+	RealizedPnL float64   `json:"realized_pnl"` // 本次成交平仓部分的已实现盈亏（不含手续费）
+	Time        time.Time `json:"time"`
+}
+
+// PaperPosition 模拟交易持仓（单向持仓，数量为正表示多头，为负表示空头）
+type PaperPosition struct {
+	Symbol        string    `json:"symbol"`
+	Quantity      float64   `json:"quantity"`
+	EntryPrice    float64   `json:"entry_price"`    // 开仓均价
+	RealizedPnL   float64   `json:"realized_pnl"`   // 累计已实现盈亏（不含手续费）
+	MarkPrice     float64   `json:"mark_price"`     // 最新价格
+	UnrealizedPnL float64   `json:"unrealized_pnl"` // 按最新价格计算的浮动盈亏
+	Margin        float64   `json:"margin"`         // 占用保证金 = |数量| × 最新价格 / 杠杆
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PaperAccount 模拟交易账户（持久化部分）
+type PaperAccount struct {
+	InitialBalance float64   `json:"initial_balance"`
+	Balance        float64   `json:"balance"`      // 钱包余额 = 初始资金 + 已实现盈亏 - 手续费
+	RealizedPnL    float64   `json:"realized_pnl"` // 累计已实现盈亏（不含手续费）
+	Fees           float64   `json:"fees"`         // 累计手续费
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PaperAccountSnapshot 模拟交易账户快照（REST和WebSocket推送）
+type PaperAccountSnapshot struct {
+	PaperAccount
+	Equity          float64         `json:"equity"`           // 权益 = 钱包余额 + 浮动盈亏
+	UnrealizedPnL   float64         `json:"unrealized_pnl"`   // 浮动盈亏合计
+	UsedMargin      float64         `json:"used_margin"`      // 持仓占用保证金合计
+	AvailableMargin float64         `json:"available_margin"` // 可用保证金 = 权益 - 占用保证金
+	Leverage        float64         `json:"leverage"`
+	Positions       []PaperPosition `json:"positions"`
+	OpenOrders      []PaperOrder    `json:"open_orders"`
+	Time            time.Time       `json:"time"`
+}
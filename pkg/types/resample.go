@@ -0,0 +1,105 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// weekOffset 1970-01-01是周四，周K线从周一 00:00 UTC 开始（与Binance一致）
+const weekOffset = 4 * 24 * time.Hour
+
+// CheckResample 校验能否由 base 周期的K线重采样得到 target 周期：
+// target 必须是 base 的整数倍，且不支持按自然月、年划分的周期
+func CheckResample(base, target string) error {
+	baseStep, err := IntervalDuration(base)
+	if err != nil {
+		return err
+	}
+	targetStep, err := IntervalDuration(target)
+	if err != nil {
+		return err
+	}
+	if unit := target[len(target)-1]; unit == 'M' || unit == 'y' {
+		return fmt.Errorf("不支持重采样到 %s（按自然月、年划分）", target)
+	}
+	if targetStep < baseStep || targetStep%baseStep != 0 {
+		return fmt.Errorf("%s 不是 %s 的整数倍，无法重采样", target, base)
+	}
+	return nil
+}
+
+// ResampleKlines 将 base 周期的K线（从旧到新）合并为 target 周期的K线（从旧到新）
+// 分桶按UTC对齐（周K线从周一开始），开头不完整的桶被丢弃，最后一个桶可以是正在形成的K线
+func ResampleKlines(klines []Kline, base, target string) ([]Kline, error) {
+	if err := CheckResample(base, target); err != nil {
+		return nil, err
+	}
+	if base == target {
+		result := make([]Kline, len(klines))
+		copy(result, klines)
+		return result, nil
+	}
+	step, _ := IntervalDuration(target)
+	isWeek := target[len(target)-1] == 'w'
+
+	// 第一根K线不在桶的开头时，第一个桶缺少数据，整个丢弃
+	var partial time.Time
+	if len(klines) > 0 {
+		if start := resampleBucket(klines[0].Timestamp, step, isWeek); !start.Equal(klines[0].Timestamp) {
+			partial = start
+		}
+	}
+
+	var result []Kline
+	for _, k := range klines {
+		start := resampleBucket(k.Timestamp, step, isWeek)
+		if !partial.IsZero() && start.Equal(partial) {
+			continue
+		}
+		if n := len(result); n > 0 && result[n-1].Timestamp.Equal(start) {
+			last := &result[n-1]
+			if k.High > last.High {
+				last.High = k.High
+			}
+			if k.Low < last.Low {
+				last.Low = k.Low
+			}
+			last.Close = k.Close
+			last.Volume += k.Volume
+			continue
+		}
+		result = append(result, Kline{
+			Symbol:    k.Symbol,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			Timestamp: start,
+		})
+	}
+	return result, nil
+}
+
+// ResampleBucket 返回时间所在的 interval 周期K线开盘时间（按UTC对齐，周K线从周一开始）
+func ResampleBucket(t time.Time, interval string) (time.Time, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return resampleBucket(t, step, interval[len(interval)-1] == 'w'), nil
+}
+
+// resampleBucket 返回时间所在的目标周期K线开盘时间
+func resampleBucket(t time.Time, step time.Duration, isWeek bool) time.Time {
+	offset := time.Duration(0)
+	if isWeek {
+		offset = weekOffset
+	}
+	since := time.Duration(t.UnixNano()) - offset
+	rem := since % step
+	if rem < 0 {
+		rem += step
+	}
+	return t.Add(-rem)
+}
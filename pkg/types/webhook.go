@@ -0,0 +1,55 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Webhook事件类型
+const (
+	WebhookEventBarClose   = "bar_close"   // K线收盘时的指标快照
+	WebhookEventZoneChange = "zone_change" // 收盘价所在的布林线/包络线分区变化
+	WebhookEventAlert      = "alert"       // 告警规则触发
+	WebhookEventTest       = "test"        // 测试发送
+)
+
+// WebhookEventTypes 可订阅的事件类型（不含测试事件）
+var WebhookEventTypes = []string{WebhookEventBarClose, WebhookEventZoneChange, WebhookEventAlert}
+
+// ValidateWebhookEventType 校验事件类型
+func ValidateWebhookEventType(eventType string) error {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的webhook事件类型: %s（可选 bar_close、zone_change、alert）", eventType)
+}
+
+// WebhookEvent 推送给webhook的事件（请求体）
+type WebhookEvent struct {
+	ID       string      `json:"id"` // 事件ID，重试时不变，接收方可据此去重
+	Type     string      `json:"type"`
+	Symbol   string      `json:"symbol,omitempty"`
+	Interval string      `json:"interval,omitempty"`
+	Time     time.Time   `json:"time"`
+	Data     interface{} `json:"data"`
+}
+
+// WebhookDelivery 一次webhook投递尝试的记录
+type WebhookDelivery struct {
+	ID         int64     `json:"id"`
+	Endpoint   string    `json:"endpoint"` // 端点名称
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Symbol     string    `json:"symbol"`
+	URL        string    `json:"url"`
+	Attempt    int       `json:"attempt"`     // 第几次尝试（从1开始）
+	StatusCode int       `json:"status_code"` // HTTP状态码，请求失败时为0
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Payload    string    `json:"payload"`            // 请求体
+	Response   string    `json:"response,omitempty"` // 响应体（截断）
+	CreatedAt  time.Time `json:"created_at"`
+}
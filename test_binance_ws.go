@@ -30,7 +30,7 @@ type KLineData struct {
 		Symbol                string `json:"s"` // 交易对
 		Interval              string `json:"i"` // K线周期
 		FirstTradeID          int64  `json:"f"` // 第一笔成交ID
-		LastTradeID           int64  `json:"l"` // 最后一笔成交ID
+		LastTradeID           int64  `json:"L"` // 最后一笔成交ID
 		OpenPrice             string `json:"o"` // 开盘价
 		ClosePrice            string `json:"c"` // 收盘价
 		HighPrice             string `json:"h"` // 最高价
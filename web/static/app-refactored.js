@@ -190,6 +190,7 @@ function createMainPanelSeries(data, gridIndex) {
         );
     }
     
+    series.push(...createDivergenceSeries(data, null, gridIndex, true));
     return series;
 }
 
@@ -271,6 +272,7 @@ function createMACDPanelSeries(data, gridIndex) {
         }
     }
     
+    series.push(...createDivergenceSeries(data, 'macd', gridIndex, false));
     return series;
 }
 
@@ -299,6 +301,7 @@ function createCCIPanelSeries(data, gridIndex) {
         });
     }
     
+    series.push(...createDivergenceSeries(data, 'cci', gridIndex, false));
     return series;
 }
 
@@ -327,6 +330,59 @@ function createRSIPanelSeries(data, gridIndex) {
         });
     }
     
+    series.push(...createDivergenceSeries(data, 'rsi', gridIndex, false));
+    return series;
+}
+
+/**
+ * 创建背离连线系列（source: cci/rsi/macd）
+ * 背离的两个摆动点按时间映射到K线横轴，价格连线画在主图，指标连线画在对应看板
+ */
+function createDivergenceSeries(data, source, gridIndex, onPrice) {
+    const series = [];
+    if (!data.divergences || !data.klines) {
+        return series;
+    }
+    
+    // 横轴从旧到新，后端K线索引0是最新的
+    const n = data.klines.length;
+    const indexByTime = {};
+    data.klines.forEach((k, i) => {
+        indexByTime[new Date(k.time).getTime()] = n - 1 - i;
+    });
+    
+    data.divergences.forEach(d => {
+        if (source && d.source !== source) {
+            return;
+        }
+        const from = indexByTime[new Date(d.from_time).getTime()];
+        const to = indexByTime[new Date(d.to_time).getTime()];
+        if (from === undefined || to === undefined) {
+            return;
+        }
+        const bullish = d.kind.endsWith('bullish');
+        const line = new Array(n).fill(null);
+        line[from] = onPrice ? d.from_price : d.from_value;
+        line[to] = onPrice ? d.to_price : d.to_value;
+        series.push({
+            name: `背离 ${d.source}(${d.key}) ${d.kind}`,
+            type: 'line',
+            xAxisIndex: gridIndex,
+            yAxisIndex: gridIndex,
+            data: line,
+            connectNulls: true,
+            lineStyle: {
+                color: bullish ? '#2ECC71' : '#E74C3C',
+                width: 1.5,
+                type: d.kind.startsWith('hidden') ? 'dotted' : 'solid'
+            },
+            symbol: 'circle',
+            symbolSize: 4,
+            itemStyle: { color: bullish ? '#2ECC71' : '#E74C3C' },
+            z: 10
+        });
+    });
+    
     return series;
 }
 
@@ -338,13 +394,16 @@ function connectWebSocket() {
         ws.close();
     }
     
-    const symbol = document.getElementById('symbol-selector').value.toLowerCase();
+    const symbol = document.getElementById('symbol-selector').value;
     const interval = document.getElementById('interval-selector').value;
-    const wsUrl = `ws://localhost:8080/api/ws?symbol=${symbol}&interval=${interval}`;
     
     updateStatus('🟡 连接中...');
     
-    ws = new WebSocket(wsUrl);
+    // delta模式：快照之后只接收变化的尾部，合并为完整数据后更新图表
+    ws = connectRealtimeStream(symbol, interval, function(data) {
+        updateConnectionStatus(data.connection);
+        updateUnifiedChart(data);
+    });
     
     ws.onopen = function() {
         updateStatus('🟢 已连接');
@@ -354,15 +413,6 @@ function connectWebSocket() {
         }
     };
     
-    ws.onmessage = function(event) {
-        try {
-            const data = JSON.parse(event.data);
-            updateUnifiedChart(data);
-        } catch (error) {
-            console.error('解析WebSocket数据失败:', error, event.data);
-        }
-    };
-    
     ws.onerror = function(error) {
         console.error('WebSocket错误:', error);
         updateStatus('🔴 连接错误');
@@ -381,6 +431,23 @@ function updateStatus(text) {
     document.getElementById('status').textContent = text;
 }
 
+/**
+ * 根据后端行情流状态更新显示（connected / reconnecting / stale）
+ */
+function updateConnectionStatus(connection) {
+    switch (connection) {
+        case 'connected':
+            updateStatus('🟢 已连接');
+            break;
+        case 'reconnecting':
+            updateStatus('🟡 行情源重连中...');
+            break;
+        case 'stale':
+            updateStatus('🟠 行情数据延迟');
+            break;
+    }
+}
+
 /**
  * 更新统一图表
  */
@@ -634,28 +701,61 @@ async function loadConfig(symbol) {
     }
 }
 
+// 获取指定类型的指标实例（保持配置顺序）
+function instancesOfType(config, type) {
+    return ((config && config.indicators) || []).filter(inst => inst.type === type);
+}
+
+// 读取第k个实例的参数，不存在时返回默认值
+function instanceParam(list, k, name, def) {
+    const inst = list[k];
+    return (inst && inst.params && inst.params[name]) || def;
+}
+
+// 用面板输入替换指定类型的前几个实例，保留其显示名称、key和面板之外的实例
+function replaceInstances(config, type, paramsList, ma) {
+    const all = config.indicators || [];
+    const old = all.filter(inst => inst.type === type);
+    const updated = paramsList.map((params, k) => {
+        const prev = old[k] || {};
+        return { ...prev, type, params: { ...(prev.params || {}), ...params }, ma };
+    });
+    config.indicators = all.filter(inst => inst.type !== type).concat(updated, old.slice(paramsList.length));
+}
+
 /**
  * 更新配置输入框
  */
 function updateConfigInputs(config) {
-    document.getElementById('boll-period').value = config.boll_period || 24;
-    document.getElementById('boll-deviation').value = config.boll_deviation || 2.0;
-    document.getElementById('env-period').value = config.env_period || 24;
-    document.getElementById('env-deviation').value = config.env_deviation || 2.28;
-    
-    document.getElementById('macd1-fast').value = config.macd1_fast || 48;
-    document.getElementById('macd1-slow').value = config.macd1_slow || 72;
-    document.getElementById('macd1-signal').value = config.macd1_signal || 2;
-    document.getElementById('macd2-fast').value = config.macd2_fast || 72;
-    document.getElementById('macd2-slow').value = config.macd2_slow || 168;
-    document.getElementById('macd2-signal').value = config.macd2_signal || 2;
-    
-    document.getElementById('cci-period1').value = config.cci_period1 || 48;
-    document.getElementById('cci-period2').value = config.cci_period2 || 72;
-    document.getElementById('cci-period3').value = config.cci_period3 || 168;
-    
-    document.getElementById('rsi-period1').value = config.rsi_period1 || 48;
-    document.getElementById('rsi-period2').value = config.rsi_period2 || 72;
+    const cci = instancesOfType(config, 'cci');
+    const macd = instancesOfType(config, 'macd');
+    const rsi = instancesOfType(config, 'rsi');
+    const boll = instancesOfType(config, 'bollinger');
+    const env = instancesOfType(config, 'envelope');
+
+    document.getElementById('boll-period').value = instanceParam(boll, 0, 'period', 24);
+    document.getElementById('boll-deviation').value = instanceParam(boll, 0, 'deviation', 2.0);
+    document.getElementById('env-period').value = instanceParam(env, 0, 'period', 24);
+    document.getElementById('env-deviation').value = instanceParam(env, 0, 'deviation', 2.28);
+    document.getElementById('boll-ma').value = (boll[0] && boll[0].ma) || 'wma';
+    document.getElementById('env-ma').value = (env[0] && env[0].ma) || 'wma';
+    
+    document.getElementById('macd1-fast').value = instanceParam(macd, 0, 'fast', 48);
+    document.getElementById('macd1-slow').value = instanceParam(macd, 0, 'slow', 72);
+    document.getElementById('macd1-signal').value = instanceParam(macd, 0, 'signal', 2);
+    document.getElementById('macd2-fast').value = instanceParam(macd, 1, 'fast', 72);
+    document.getElementById('macd2-slow').value = instanceParam(macd, 1, 'slow', 168);
+    document.getElementById('macd2-signal').value = instanceParam(macd, 1, 'signal', 2);
+    document.getElementById('macd-ma').value = (macd[0] && macd[0].ma) || 'wma';
+    
+    document.getElementById('cci-period1').value = instanceParam(cci, 0, 'period', 48);
+    document.getElementById('cci-period2').value = instanceParam(cci, 1, 'period', 72);
+    document.getElementById('cci-period3').value = instanceParam(cci, 2, 'period', 168);
+    document.getElementById('cci-ma').value = (cci[0] && cci[0].ma) || 'wma';
+    
+    document.getElementById('rsi-period1').value = instanceParam(rsi, 0, 'period', 48);
+    document.getElementById('rsi-period2').value = instanceParam(rsi, 1, 'period', 72);
+    document.getElementById('rsi-ma').value = (rsi[0] && rsi[0].ma) || 'wma';
 }
 
 /**
@@ -679,27 +779,35 @@ function showConfig(type) {
  */
 async function applyConfig(type) {
     const symbol = document.getElementById('symbol-selector').value;
-    const config = {};
+    if (!currentConfig) {
+        await loadConfig();
+    }
+    const config = JSON.parse(JSON.stringify(currentConfig || { indicators: [] }));
     
+    const value = id => document.getElementById(id).value;
     if (type === 'main') {
-        config.boll_period = parseInt(document.getElementById('boll-period').value);
-        config.boll_deviation = parseFloat(document.getElementById('boll-deviation').value);
-        config.env_period = parseInt(document.getElementById('env-period').value);
-        config.env_deviation = parseFloat(document.getElementById('env-deviation').value);
+        replaceInstances(config, 'bollinger', [
+            { period: parseInt(value('boll-period')), deviation: parseFloat(value('boll-deviation')) },
+        ], value('boll-ma'));
+        replaceInstances(config, 'envelope', [
+            { period: parseInt(value('env-period')), deviation: parseFloat(value('env-deviation')) },
+        ], value('env-ma'));
     } else if (type === 'macd') {
-        config.macd1_fast = parseInt(document.getElementById('macd1-fast').value);
-        config.macd1_slow = parseInt(document.getElementById('macd1-slow').value);
-        config.macd1_signal = parseInt(document.getElementById('macd1-signal').value);
-        config.macd2_fast = parseInt(document.getElementById('macd2-fast').value);
-        config.macd2_slow = parseInt(document.getElementById('macd2-slow').value);
-        config.macd2_signal = parseInt(document.getElementById('macd2-signal').value);
+        replaceInstances(config, 'macd', [
+            { fast: parseInt(value('macd1-fast')), slow: parseInt(value('macd1-slow')), signal: parseInt(value('macd1-signal')) },
+            { fast: parseInt(value('macd2-fast')), slow: parseInt(value('macd2-slow')), signal: parseInt(value('macd2-signal')) },
+        ], value('macd-ma'));
     } else if (type === 'cci') {
-        config.cci_period1 = parseInt(document.getElementById('cci-period1').value);
-        config.cci_period2 = parseInt(document.getElementById('cci-period2').value);
-        config.cci_period3 = parseInt(document.getElementById('cci-period3').value);
+        replaceInstances(config, 'cci', [
+            { period: parseInt(value('cci-period1')) },
+            { period: parseInt(value('cci-period2')) },
+            { period: parseInt(value('cci-period3')) },
+        ], value('cci-ma'));
     } else if (type === 'rsi') {
-        config.rsi_period1 = parseInt(document.getElementById('rsi-period1').value);
-        config.rsi_period2 = parseInt(document.getElementById('rsi-period2').value);
+        replaceInstances(config, 'rsi', [
+            { period: parseInt(value('rsi-period1')) },
+            { period: parseInt(value('rsi-period2')) },
+        ], value('rsi-ma'));
     }
     
     try {
@@ -712,9 +820,9 @@ async function applyConfig(type) {
         });
         
         if (response.ok) {
-            const updatedConfig = await response.json();
-            currentConfig = { ...currentConfig, ...updatedConfig };
-            console.log(`✓ 已更新 ${symbol} 的配置:`, updatedConfig);
+            const result = await response.json();
+            currentConfig = result.config || config;
+            console.log(`✓ 已更新 ${symbol} 的配置:`, currentConfig);
             
             // 关闭配置面板
             document.getElementById(`${type}-config-panel`).style.display = 'none';
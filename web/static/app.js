@@ -552,14 +552,16 @@ function initUnifiedChart() {
 
 // 连接WebSocket
 function connectWebSocket() {
-    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     const symbol = document.getElementById('symbol-selector').value;
     const interval = document.getElementById('interval-selector').value;
-    const wsUrl = `${protocol}//${window.location.host}/api/ws?symbol=${encodeURIComponent(symbol)}&interval=${encodeURIComponent(interval)}`;
     
     updateStatus('🟡 连接中...');
     
-    ws = new WebSocket(wsUrl);
+    // delta模式：快照之后只接收变化的尾部，合并为完整数据后更新图表（需先加载 realtime-stream.js）
+    ws = connectRealtimeStream(symbol, interval, function(data) {
+        console.log('收到数据:', data);
+        updateUnifiedChart(data);
+    });
     
     ws.onopen = function() {
         updateStatus('🟢 已连接');
@@ -569,16 +571,6 @@ function connectWebSocket() {
         }
     };
     
-    ws.onmessage = function(event) {
-        try {
-            const data = JSON.parse(event.data);
-            console.log('收到数据:', data);
-            updateUnifiedChart(data);
-        } catch (error) {
-            console.error('解析WebSocket数据失败:', error, event.data);
-        }
-    };
-    
     ws.onerror = function(error) {
         console.error('WebSocket错误:', error);
         updateStatus('🔴 连接错误');
@@ -1482,26 +1474,59 @@ async function loadConfig(symbol) {
     }
 }
 
+// 获取指定类型的指标实例（保持配置顺序）
+function instancesOfType(config, type) {
+    return ((config && config.indicators) || []).filter(inst => inst.type === type);
+}
+
+// 读取第k个实例的参数，不存在时返回默认值
+function instanceParam(list, k, name, def) {
+    const inst = list[k];
+    return (inst && inst.params && inst.params[name]) || def;
+}
+
+// 用面板输入替换指定类型的前几个实例，保留其显示名称、key和面板之外的实例
+function replaceInstances(config, type, paramsList, ma) {
+    const all = config.indicators || [];
+    const old = all.filter(inst => inst.type === type);
+    const updated = paramsList.map((params, k) => {
+        const prev = old[k] || {};
+        return { ...prev, type, params: { ...(prev.params || {}), ...params }, ma };
+    });
+    config.indicators = all.filter(inst => inst.type !== type).concat(updated, old.slice(paramsList.length));
+}
+
 // 更新配置输入框
 function updateConfigInputs(config) {
-    document.getElementById('boll-period').value = config.boll_period || 24;
-    document.getElementById('boll-deviation').value = config.boll_deviation || 2.0;
-    document.getElementById('env-period').value = config.env_period || 24;
-    document.getElementById('env-deviation').value = config.env_deviation || 2.28;
+    const cci = instancesOfType(config, 'cci');
+    const macd = instancesOfType(config, 'macd');
+    const rsi = instancesOfType(config, 'rsi');
+    const boll = instancesOfType(config, 'bollinger');
+    const env = instancesOfType(config, 'envelope');
+
+    document.getElementById('boll-period').value = instanceParam(boll, 0, 'period', 24);
+    document.getElementById('boll-deviation').value = instanceParam(boll, 0, 'deviation', 2.0);
+    document.getElementById('env-period').value = instanceParam(env, 0, 'period', 24);
+    document.getElementById('env-deviation').value = instanceParam(env, 0, 'deviation', 2.28);
+    document.getElementById('boll-ma').value = (boll[0] && boll[0].ma) || 'wma';
+    document.getElementById('env-ma').value = (env[0] && env[0].ma) || 'wma';
     
-    document.getElementById('macd1-fast').value = config.macd_fast1 || 48;
-    document.getElementById('macd1-slow').value = config.macd_slow1 || 72;
-    document.getElementById('macd1-signal').value = config.macd_signal1 || 2;
-    document.getElementById('macd2-fast').value = config.macd_fast2 || 72;
-    document.getElementById('macd2-slow').value = config.macd_slow2 || 168;
-    document.getElementById('macd2-signal').value = config.macd_signal2 || 2;
+    document.getElementById('macd1-fast').value = instanceParam(macd, 0, 'fast', 48);
+    document.getElementById('macd1-slow').value = instanceParam(macd, 0, 'slow', 72);
+    document.getElementById('macd1-signal').value = instanceParam(macd, 0, 'signal', 2);
+    document.getElementById('macd2-fast').value = instanceParam(macd, 1, 'fast', 72);
+    document.getElementById('macd2-slow').value = instanceParam(macd, 1, 'slow', 168);
+    document.getElementById('macd2-signal').value = instanceParam(macd, 1, 'signal', 2);
+    document.getElementById('macd-ma').value = (macd[0] && macd[0].ma) || 'wma';
     
-    document.getElementById('cci-period1').value = config.cci_period1 || 48;
-    document.getElementById('cci-period2').value = config.cci_period2 || 72;
-    document.getElementById('cci-period3').value = config.cci_period3 || 168;
+    document.getElementById('cci-period1').value = instanceParam(cci, 0, 'period', 48);
+    document.getElementById('cci-period2').value = instanceParam(cci, 1, 'period', 72);
+    document.getElementById('cci-period3').value = instanceParam(cci, 2, 'period', 168);
+    document.getElementById('cci-ma').value = (cci[0] && cci[0].ma) || 'wma';
     
-    document.getElementById('rsi-period1').value = config.rsi_period1 || 48;
-    document.getElementById('rsi-period2').value = config.rsi_period2 || 72;
+    document.getElementById('rsi-period1').value = instanceParam(rsi, 0, 'period', 48);
+    document.getElementById('rsi-period2').value = instanceParam(rsi, 1, 'period', 72);
+    document.getElementById('rsi-ma').value = (rsi[0] && rsi[0].ma) || 'wma';
 }
 
 // 计算价格所在的分区号（前端版本）
@@ -1572,27 +1597,32 @@ async function applyConfig(type) {
         await loadConfig();
     }
     
-    const config = { ...currentConfig };
+    const config = JSON.parse(JSON.stringify(currentConfig || { indicators: [] }));
     
+    const value = id => document.getElementById(id).value;
     if (type === 'main') {
-        config.boll_period = parseInt(document.getElementById('boll-period').value);
-        config.boll_deviation = parseFloat(document.getElementById('boll-deviation').value);
-        config.env_period = parseInt(document.getElementById('env-period').value);
-        config.env_deviation = parseFloat(document.getElementById('env-deviation').value);
+        replaceInstances(config, 'bollinger', [
+            { period: parseInt(value('boll-period')), deviation: parseFloat(value('boll-deviation')) },
+        ], value('boll-ma'));
+        replaceInstances(config, 'envelope', [
+            { period: parseInt(value('env-period')), deviation: parseFloat(value('env-deviation')) },
+        ], value('env-ma'));
     } else if (type === 'macd') {
-        config.macd_fast1 = parseInt(document.getElementById('macd1-fast').value);
-        config.macd_slow1 = parseInt(document.getElementById('macd1-slow').value);
-        config.macd_signal1 = parseInt(document.getElementById('macd1-signal').value);
-        config.macd_fast2 = parseInt(document.getElementById('macd2-fast').value);
-        config.macd_slow2 = parseInt(document.getElementById('macd2-slow').value);
-        config.macd_signal2 = parseInt(document.getElementById('macd2-signal').value);
+        replaceInstances(config, 'macd', [
+            { fast: parseInt(value('macd1-fast')), slow: parseInt(value('macd1-slow')), signal: parseInt(value('macd1-signal')) },
+            { fast: parseInt(value('macd2-fast')), slow: parseInt(value('macd2-slow')), signal: parseInt(value('macd2-signal')) },
+        ], value('macd-ma'));
     } else if (type === 'cci') {
-        config.cci_period1 = parseInt(document.getElementById('cci-period1').value);
-        config.cci_period2 = parseInt(document.getElementById('cci-period2').value);
-        config.cci_period3 = parseInt(document.getElementById('cci-period3').value);
+        replaceInstances(config, 'cci', [
+            { period: parseInt(value('cci-period1')) },
+            { period: parseInt(value('cci-period2')) },
+            { period: parseInt(value('cci-period3')) },
+        ], value('cci-ma'));
     } else if (type === 'rsi') {
-        config.rsi_period1 = parseInt(document.getElementById('rsi-period1').value);
-        config.rsi_period2 = parseInt(document.getElementById('rsi-period2').value);
+        replaceInstances(config, 'rsi', [
+            { period: parseInt(value('rsi-period1')) },
+            { period: parseInt(value('rsi-period2')) },
+        ], value('rsi-ma'));
     }
     
     try {
@@ -0,0 +1,135 @@
+/**
+ * 实时数据流：通过 /api/ws 的订阅协议以 delta 模式接收数据
+ * 每个订阅先收到一次快照，之后只收到变化的尾部（正在形成的K线和新完结的K线），在本地合并为完整数据
+ */
+
+/**
+ * 连接实时数据流，每次收到数据后以合并后的完整数据调用 onData
+ * 返回WebSocket，调用方可设置 onopen/onerror/onclose
+ */
+function connectRealtimeStream(symbol, interval, onData) {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const socket = new WebSocket(`${protocol}//${window.location.host}/api/ws`);
+    let current = null;
+    let seq = 0;
+    let syncing = false;
+
+    // 序号不连续或无法合并时请求重新发送快照，快照到达前忽略增量
+    const resync = () => {
+        current = null;
+        if (!syncing) {
+            syncing = true;
+            socket.send(JSON.stringify({ action: 'resync', symbol, interval }));
+        }
+    };
+
+    socket.addEventListener('open', () => {
+        socket.send(JSON.stringify({ action: 'subscribe', symbol, interval, mode: 'delta' }));
+    });
+
+    socket.addEventListener('message', event => {
+        let msg;
+        try {
+            msg = JSON.parse(event.data);
+        } catch (error) {
+            console.error('解析WebSocket数据失败:', error, event.data);
+            return;
+        }
+
+        switch (msg.type) {
+            case 'snapshot':
+            case 'update':
+                current = msg.data;
+                seq = msg.seq;
+                syncing = false;
+                break;
+            case 'delta':
+                if (syncing) {
+                    return;
+                }
+                if (!current || msg.seq !== seq + 1) {
+                    resync();
+                    return;
+                }
+                current = applyDelta(current, msg.data, msg.length);
+                if (!current) {
+                    resync();
+                    return;
+                }
+                seq = msg.seq;
+                break;
+            case 'error':
+                console.error('WebSocket订阅错误:', msg.error);
+                return;
+            default:
+                return;
+        }
+        onData(current);
+    });
+
+    return socket;
+}
+
+/**
+ * 合并增量推送：tail 中的K线和指标序列只包含最新的几根（索引0为最新），
+ * 覆盖 prev 中相同时间的值、在前面插入新K线，再截断到 length（服务端完整窗口长度）
+ * tail 最旧的K线不在 prev 中时返回null，需要重新获取快照
+ */
+function applyDelta(prev, tail, length) {
+    if (!tail.klines || tail.klines.length === 0) {
+        return null;
+    }
+    const oldest = tail.klines[tail.klines.length - 1].time;
+    const overlap = prev.klines.findIndex(k => k.time === oldest);
+    if (overlap < 0) {
+        return null;
+    }
+    // shift 为新增的K线数量，dropped 为截断到窗口长度时去掉的最旧K线数量
+    const shift = tail.klines.length - 1 - overlap;
+    const dropped = Math.max(0, prev.klines.length + shift - length);
+
+    // head[i] 与 old[i - shift] 为同一根K线的值
+    const merge = (head, old) => {
+        if (!head || !old) {
+            return head || old;
+        }
+        const merged = head.concat(old.slice(Math.max(0, head.length - shift)));
+        return merged.slice(0, Math.max(head.length, old.length + shift - dropped));
+    };
+    const mergeMap = (head, old, fn) => {
+        if (!head || !old) {
+            return head || old;
+        }
+        const out = {};
+        for (const key of Object.keys(head)) {
+            out[key] = fn(head[key], old[key]);
+        }
+        return out;
+    };
+    const mergeBands = (head, old) => {
+        if (!head || !old) {
+            return head || old;
+        }
+        return {
+            ...head,
+            upper: merge(head.upper, old.upper),
+            middle: merge(head.middle, old.middle),
+            lower: merge(head.lower, old.lower),
+        };
+    };
+
+    return {
+        ...tail,
+        klines: merge(tail.klines, prev.klines),
+        cci: mergeMap(tail.cci, prev.cci, merge),
+        rsi: mergeMap(tail.rsi, prev.rsi, merge),
+        macd: mergeMap(tail.macd, prev.macd, (head, old) => old ? {
+            macd_line: merge(head.macd_line, old.macd_line),
+            signal_line: merge(head.signal_line, old.signal_line),
+            histogram: merge(head.histogram, old.histogram),
+        } : head),
+        bollinger: mergeBands(tail.bollinger, prev.bollinger),
+        envelope: mergeBands(tail.envelope, prev.envelope),
+        custom: mergeMap(tail.custom, prev.custom, (head, old) => mergeMap(head, old, merge)),
+    };
+}